@@ -50,11 +50,75 @@ type EnvironmentSpec struct {
 
 	// RemoteCluster flag indicates if the Environment is deployed in a separate cluster to the Development Environment
 	RemoteCluster bool `json:"remoteCluster,omitempty" protobuf:"bytes,12,opt,name=remoteCluster"`
+
+	// PromotionWindows are the recurring periods of time when promotions to the Environment are allowed.
+	// If there are no windows promotions are allowed at any time
+	PromotionWindows []PromotionWindow `json:"promotionWindows,omitempty" protobuf:"bytes,13,rep,name=promotionWindows"`
+
+	// FreezePeriods are the periods of time when promotions to the Environment are not allowed
+	FreezePeriods []FreezePeriod `json:"freezePeriods,omitempty" protobuf:"bytes,14,rep,name=freezePeriods"`
+
+	// PreviewLifecycle configures when a Preview Environment is deleted or scaled down and records its activity
+	PreviewLifecycle *PreviewLifecycle `json:"previewLifecycle,omitempty" protobuf:"bytes,15,opt,name=previewLifecycle"`
+}
+
+// PromotionWindow is a recurring period of time when promotions to an Environment are allowed
+type PromotionWindow struct {
+	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// Schedule is the cron expression of when the window opens such as '0 9 * * MON-FRI'
+	Schedule string `json:"schedule" protobuf:"bytes,2,opt,name=schedule"`
+	// Duration is how long the window stays open
+	Duration metav1.Duration `json:"duration" protobuf:"bytes,3,opt,name=duration"`
+	// Timezone is the IANA time zone of the schedule such as 'Europe/London'. Defaults to UTC
+	Timezone string `json:"timezone,omitempty" protobuf:"bytes,4,opt,name=timezone"`
+}
+
+// FreezePeriod is a period of time when promotions to an Environment are not allowed
+type FreezePeriod struct {
+	Name   string      `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	Start  metav1.Time `json:"start" protobuf:"bytes,2,opt,name=start"`
+	End    metav1.Time `json:"end" protobuf:"bytes,3,opt,name=end"`
+	Reason string      `json:"reason,omitempty" protobuf:"bytes,4,opt,name=reason"`
+}
+
+// PreviewLifecycle is the lifecycle of a Preview Environment
+type PreviewLifecycle struct {
+	// TTL is how long after its creation the Preview Environment is deleted. Zero means it is kept until the Pull Request is closed
+	TTL metav1.Duration `json:"ttl,omitempty" protobuf:"bytes,1,opt,name=ttl"`
+	// IdleTimeout is how long the Preview Environment can go without HTTP traffic or new commits before its deployments are scaled to zero
+	IdleTimeout metav1.Duration `json:"idleTimeout,omitempty" protobuf:"bytes,2,opt,name=idleTimeout"`
+	// MaximumInstances is the maximum number of Preview Environments of the application after which the oldest ones are deleted
+	MaximumInstances int `json:"maximumInstances,omitempty" protobuf:"varint,3,opt,name=maximumInstances"`
+}
+
+// PreviewStatus is the observed activity of a Preview Environment used to apply its lifecycle
+type PreviewStatus struct {
+	// LastCommitTimestamp is when the Preview Environment was last updated with a new commit
+	LastCommitTimestamp *metav1.Time `json:"lastCommitTimestamp,omitempty" protobuf:"bytes,1,opt,name=lastCommitTimestamp"`
+	// LastTrafficTimestamp is when HTTP traffic to the Preview Environment was last observed
+	LastTrafficTimestamp *metav1.Time `json:"lastTrafficTimestamp,omitempty" protobuf:"bytes,2,opt,name=lastTrafficTimestamp"`
+	// ScaledDownTimestamp is when the deployments of the Preview Environment were scaled to zero or nil if they are running
+	ScaledDownTimestamp *metav1.Time `json:"scaledDownTimestamp,omitempty" protobuf:"bytes,3,opt,name=scaledDownTimestamp"`
 }
 
 // EnvironmentStatus is the status for an Environment resource
 type EnvironmentStatus struct {
 	Version string `json:"version,omitempty"`
+	// Applications are the applications deployed in a remote Environment as reported by its agent
+	Applications []EnvironmentApplicationStatus `json:"applications,omitempty" protobuf:"bytes,2,rep,name=applications"`
+	// LastReportTimestamp is when the agent in the remote cluster last reported the status of the Environment
+	LastReportTimestamp *metav1.Time `json:"lastReportTimestamp,omitempty" protobuf:"bytes,3,opt,name=lastReportTimestamp"`
+	// Preview is the status of a Preview Environment
+	Preview *PreviewStatus `json:"preview,omitempty" protobuf:"bytes,4,opt,name=preview"`
+}
+
+// EnvironmentApplicationStatus is the status of an application deployed in an Environment
+type EnvironmentApplicationStatus struct {
+	Name          string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	Version       string `json:"version,omitempty" protobuf:"bytes,2,opt,name=version"`
+	Replicas      int32  `json:"replicas,omitempty" protobuf:"varint,3,opt,name=replicas"`
+	ReadyReplicas int32  `json:"readyReplicas,omitempty" protobuf:"varint,4,opt,name=readyReplicas"`
+	URL           string `json:"url,omitempty" protobuf:"bytes,5,opt,name=url"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -222,6 +286,10 @@ type TeamSettings struct {
 	// VersionStreamRef contains the git ref (tag or branch) in the VersionStreamURL repository to use as the version stream
 	VersionStreamRef string `json:"versionStreamRef,omitempty" protobuf:"bytes,26,opt,name=versionStreamRef"`
 
+	// VersionStreamOverrides the version streams layered on top of the VersionStreamURL such as a team level versions
+	// repository. The first override takes the highest precedence
+	VersionStreamOverrides []VersionStreamOverride `json:"versionStreamOverrides,omitempty" protobuf:"bytes,31,rep,name=versionStreamOverrides"`
+
 	// AppsPrefixes is the list of prefixes for appNames
 	AppsPrefixes     []string          `json:"appPrefixes,omitempty" protobuf:"bytes,27,opt,name=appPrefixes"`
 	DefaultScheduler ResourceReference `json:"defaultScheduler,omitempty" protobuf:"bytes,28,opt,name=defaultScheduler"`
@@ -233,12 +301,26 @@ type TeamSettings struct {
 	Profile string `json:"profile,omitempty" protobuf:"bytes,30,opt,name=profile"`
 }
 
+// VersionStreamOverride a version stream layered on top of the version stream of the team
+type VersionStreamOverride struct {
+	// Name of the override which is used when explaining where versions are resolved from
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// URL the git URL of the override
+	URL string `json:"url" protobuf:"bytes,2,opt,name=url"`
+	// Ref the git ref (tag, branch or SHA) of the override
+	Ref string `json:"ref,omitempty" protobuf:"bytes,3,opt,name=ref"`
+}
+
 // StorageLocation
 type StorageLocation struct {
 	Classifier string `json:"classifier,omitempty" protobuf:"bytes,1,opt,name=classifier"`
 	GitURL     string `json:"gitUrl,omitempty" protobuf:"bytes,2,opt,name=gitUrl"`
 	GitBranch  string `json:"gitBranch,omitempty" protobuf:"bytes,3,opt,name=gitBranch"`
 	BucketURL  string `json:"bucketUrl,omitempty" protobuf:"bytes,4,opt,name=bucketUrl"`
+	// Directory is the local directory (such as a mounted PersistentVolumeClaim) to store files in
+	Directory string `json:"directory,omitempty" protobuf:"bytes,5,opt,name=directory"`
+	// RetentionDays is how many days stored files are kept before being removed by 'jx gc stash'. Zero keeps them forever
+	RetentionDays int `json:"retentionDays,omitempty" protobuf:"varint,6,opt,name=retentionDays"`
 }
 
 // QuickStartLocation
@@ -435,7 +517,7 @@ func (e *EnvironmentSpec) IsLighthouse() bool {
 
 // IsEmpty returns true if the storage location is empty
 func (s *StorageLocation) IsEmpty() bool {
-	return s.GitURL == "" && s.BucketURL == ""
+	return s.GitURL == "" && s.BucketURL == "" && s.Directory == ""
 }
 
 // Description returns the textual description of the storage location
@@ -446,6 +528,9 @@ func (s *StorageLocation) Description() string {
 	if s.BucketURL != "" {
 		return s.BucketURL
 	}
+	if s.Directory != "" {
+		return s.Directory
+	}
 	return "current git repo"
 }
 
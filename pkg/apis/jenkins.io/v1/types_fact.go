@@ -122,4 +122,10 @@ const (
 const (
 	FactTypeCoverage              = "jx.coverage"
 	FactTypeStaticProgramAnalysis = "jx.staticProgramAnalysis"
+	FactTypeApproval              = "jx.approval"
+)
+
+// Recommended statement types for approvals
+const (
+	StatementTypeApproval = "Approval"
 )
@@ -118,6 +118,37 @@ type PromoteActivityStep struct {
 	PullRequest    *PromotePullRequestStep `json:"pullRequest,omitempty" protobuf:"bytes,2,opt,name=pullRequest"`
 	Update         *PromoteUpdateStep      `json:"update,omitempty" protobuf:"bytes,3,opt,name=update"`
 	ApplicationURL string                  `json:"applicationURL,omitempty" protobuf:"bytes,4,opt,name=environment"`
+	Approval       *PromoteApprovalStep    `json:"approval,omitempty" protobuf:"bytes,5,opt,name=approval"`
+	// QueuedUntil is when the promotion is queued until as the Environment is outside of its promotion windows
+	QueuedUntil *metav1.Time `json:"queuedUntil,omitempty" protobuf:"bytes,6,opt,name=queuedUntil"`
+	// OverrideReason is the reason given for promoting outside of the promotion windows of the Environment
+	OverrideReason string `json:"overrideReason,omitempty" protobuf:"bytes,7,opt,name=overrideReason"`
+	// Rollback is true if this step rolls back the Environment to a previous version
+	Rollback bool `json:"rollback,omitempty" protobuf:"bytes,8,opt,name=rollback"`
+	// RollbackFromVersion is the version which was replaced by rolling back
+	RollbackFromVersion string `json:"rollbackFromVersion,omitempty" protobuf:"bytes,9,opt,name=rollbackFromVersion"`
+	// Verification is the step of verifying the health of the application after the promotion
+	Verification *PromoteVerificationStep `json:"verification,omitempty" protobuf:"bytes,10,opt,name=verification"`
+	// Canary is the step of the progressive canary release of the application
+	Canary *PromoteCanaryStep `json:"canary,omitempty" protobuf:"bytes,11,opt,name=canary"`
+}
+
+// PromoteApprovalStep is the step of waiting for a manual approval before promoting to an environment
+type PromoteApprovalStep struct {
+	CoreActivityStep `json:",inline"`
+
+	Decisions []ApprovalDecision `json:"decisions,omitempty" protobuf:"bytes,1,opt,name=decisions"`
+}
+
+// ApprovalDecision is the decision of a user to approve or reject a promotion
+type ApprovalDecision struct {
+	Approver string `json:"approver,omitempty" protobuf:"bytes,1,opt,name=approver"`
+	// Teams the teams of the approver when the decision was made. This is only recorded for auditing as the
+	// membership of the teams is checked again whenever the decision is evaluated
+	Teams     []string     `json:"teams,omitempty" protobuf:"bytes,2,opt,name=teams"`
+	Approved  bool         `json:"approved" protobuf:"varint,3,opt,name=approved"`
+	Comment   string       `json:"comment,omitempty" protobuf:"bytes,4,opt,name=comment"`
+	Timestamp *metav1.Time `json:"timestamp,omitempty" protobuf:"bytes,5,opt,name=timestamp"`
 }
 
 // GitStatus the status of a git commit in terms of CI/CD
@@ -142,6 +173,35 @@ type PromoteUpdateStep struct {
 	Statuses []GitStatus `json:"statuses,omitempty" protobuf:"bytes,1,opt,name=statuses"`
 }
 
+// PromoteVerificationStep is the step of verifying the health of an application after it has been promoted
+type PromoteVerificationStep struct {
+	CoreActivityStep `json:",inline"`
+
+	Checks []VerificationCheck `json:"checks,omitempty" protobuf:"bytes,1,opt,name=checks"`
+	// RollbackVersion is the version the application was rolled back to if the verification failed
+	RollbackVersion string `json:"rollbackVersion,omitempty" protobuf:"bytes,2,opt,name=rollbackVersion"`
+}
+
+// PromoteCanaryStep is the step of progressively releasing an application via a Flagger Canary
+type PromoteCanaryStep struct {
+	CoreActivityStep `json:",inline"`
+
+	Name         string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	Phase        string `json:"phase,omitempty" protobuf:"bytes,2,opt,name=phase"`
+	Weight       int    `json:"weight,omitempty" protobuf:"varint,3,opt,name=weight"`
+	Iterations   int    `json:"iterations,omitempty" protobuf:"varint,4,opt,name=iterations"`
+	FailedChecks int    `json:"failedChecks,omitempty" protobuf:"varint,5,opt,name=failedChecks"`
+	Threshold    int    `json:"threshold,omitempty" protobuf:"varint,6,opt,name=threshold"`
+}
+
+// VerificationCheck is the result of a single health check of a promoted application
+type VerificationCheck struct {
+	Kind    string             `json:"kind,omitempty" protobuf:"bytes,1,opt,name=kind"`
+	Name    string             `json:"name,omitempty" protobuf:"bytes,2,opt,name=name"`
+	Status  ActivityStatusType `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+	Message string             `json:"message,omitempty" protobuf:"bytes,4,opt,name=message"`
+}
+
 // PipelineActivityStatus is the status for an Environment resource
 type PipelineActivityStatus struct {
 	Version string `json:"version,omitempty"  protobuf:"bytes,1,opt,name=version"`
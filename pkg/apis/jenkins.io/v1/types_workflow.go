@@ -45,6 +45,20 @@ type PromoteWorkflowStep struct {
 type WorkflowPreconditions struct {
 	// the names of the environments which need to have promoted before this step can be triggered
 	Environments []string `json:"environments,omitempty" protobuf:"bytes,1,opt,name=environments"`
+	// the manual approval required before this step can be triggered
+	Approval *WorkflowApproval `json:"approval,omitempty" protobuf:"bytes,2,opt,name=approval"`
+}
+
+// WorkflowApproval is a manual approval gate which must be passed before a step can be triggered
+type WorkflowApproval struct {
+	// the user names of the approvers. If no approvers or teams are specified any user can approve
+	Approvers []string `json:"approvers,omitempty" protobuf:"bytes,1,opt,name=approvers"`
+	// the names of the teams whose members can approve
+	Teams []string `json:"teams,omitempty" protobuf:"bytes,2,opt,name=teams"`
+	// the number of different approvers required which defaults to 1
+	MinimumApprovals int `json:"minimumApprovals,omitempty" protobuf:"varint,3,opt,name=minimumApprovals"`
+	// how long to wait for the approval after which the pipeline is aborted. If not specified there is no expiry
+	Expiry *metav1.Duration `json:"expiry,omitempty" protobuf:"bytes,4,opt,name=expiry"`
 }
 
 // WorkflowStatus is the status for an Environment resource
@@ -35,3 +35,8 @@ var AllTypes = []AppType{
 	Controller,
 	PipelineExtension,
 }
+
+const (
+	// UpgradePolicyAnnotation the annotation on the App CRD used to store the upgrade policy of the app
+	UpgradePolicyAnnotation = "jenkins.io/upgrade-policy"
+)
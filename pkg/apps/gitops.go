@@ -2,6 +2,8 @@ package apps
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"os"
 	"path/filepath"
@@ -35,11 +37,33 @@ func (o *GitOpsOptions) AddApp(app string, dir string, version string, repositor
 		Message:    fmt.Sprintf("Add app %s %s", app, version),
 	}
 
+	modifyChartFn := environments.CreateAddRequirementFn(app, alias, version,
+		repository, o.valuesFiles, dir, o.Verbose, o.Helmer)
+	if o.UpgradePolicy != "" {
+		addRequirementFn := modifyChartFn
+		modifyChartFn = func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+			templates map[string]string, envDir string, details *gits.PullRequestDetails) error {
+			err := addRequirementFn(requirements, metadata, values, templates, envDir, details)
+			if err != nil {
+				return err
+			}
+			appDir := filepath.Join(envDir, app)
+			_, appResource, err := findAppResource(appDir)
+			if err != nil {
+				return err
+			}
+			if appResource == nil {
+				log.Logger().Warnf("Unable to set the upgrade policy of %s as there is no App resource in %s", app, appDir)
+				return nil
+			}
+			return setAppResourceAnnotation(appDir, UpgradePolicyAnnotation, string(o.UpgradePolicy))
+		}
+	}
+
 	options := environments.EnvironmentPullRequestOptions{
-		Gitter: o.Gitter,
-		ModifyChartFn: environments.CreateAddRequirementFn(app, alias, version,
-			repository, o.valuesFiles, dir, o.Verbose, o.Helmer),
-		GitProvider: o.GitProvider,
+		Gitter:        o.Gitter,
+		ModifyChartFn: modifyChartFn,
+		GitProvider:   o.GitProvider,
 	}
 
 	info, err := options.Create(o.DevEnv, o.EnvironmentsDir, &details, nil, "", autoMerge)
@@ -96,8 +120,8 @@ func (o *GitOpsOptions) UpgradeApp(app string, version string, repository string
 
 	options := environments.EnvironmentPullRequestOptions{
 		Gitter: o.Gitter,
-		ModifyChartFn: environments.CreateUpgradeRequirementsFn(all, app, alias, version, username, password,
-			o.Helmer, inspectChartFunc, o.Verbose, o.valuesFiles),
+		ModifyChartFn: keepUpgradePolicies(environments.CreateUpgradeRequirementsFn(all, app, alias, version, username,
+			password, o.Helmer, inspectChartFunc, o.Verbose, o.valuesFiles)),
 		GitProvider: o.GitProvider,
 	}
 	_, err = options.Create(o.DevEnv, o.EnvironmentsDir, &details, nil, app, autoMerge)
@@ -107,6 +131,88 @@ func (o *GitOpsOptions) UpgradeApp(app string, version string, repository string
 	return nil
 }
 
+// UpgradeApps upgrades the apps to their target versions (or the latest version if empty) from their repositories with
+// username and password using a single Pull Request. The interrogateChartFn creates the function which asks the
+// configuration questions of each app
+func (o *GitOpsOptions) UpgradeApps(items []*UpgradePlanItem, username string, password string,
+	interrogateChartFn func(item *UpgradePlanItem) func(dir string, existing map[string]interface{}) (*ChartDetails,
+		error), autoMerge bool) error {
+	// use a random string in the branch name to ensure we use a unique git branch and fail to push
+	rand, err := util.RandStringBytesMaskImprSrc(5)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate a random string")
+	}
+	details := gits.PullRequestDetails{
+		BranchName: fmt.Sprintf("upgrade-all-apps-%s", rand),
+		Title:      "Upgrade all apps",
+		Message:    "Upgrade all apps:\n",
+	}
+	plan := &UpgradePlan{
+		GitOps: true,
+		Items:  items,
+	}
+
+	cleanups := make([]func(), 0)
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}()
+	modifyChartFn := func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, envDir string, details *gits.PullRequestDetails) error {
+		for _, d := range requirements.Dependencies {
+			item := plan.Find(d.Name, d.Alias)
+			if item == nil {
+				continue
+			}
+			version := item.TargetVersion
+			interrogateChartFunc := interrogateChartFn(item)
+			err := helm.InspectChart(d.Name, version, d.Repository, username, password, o.Helmer,
+				func(chartDir string) error {
+					if version == "" {
+						// Upgrade to the latest version
+						_, chartVersion, err := helm.LoadChartNameAndVersion(filepath.Join(chartDir, helm.ChartFileName))
+						if err != nil {
+							return errors.Wrapf(err, "error loading chart from %s", chartDir)
+						}
+						version = chartVersion
+					}
+					// each app has its own values
+					o.valuesFiles.Items = make([]string, 0)
+					chartDetails, err := interrogateChartFunc(chartDir, values)
+					cleanups = append(cleanups, chartDetails.Cleanup)
+					if err != nil {
+						return errors.Wrapf(err, "asking questions for %s", chartDir)
+					}
+					err = environments.CreateNestedRequirementDir(envDir, d.Name, chartDir, version, d.Repository,
+						o.Verbose, o.valuesFiles, o.Helmer)
+					if err != nil {
+						return errors.Wrapf(err, "creating nested app dir in chart dir %s", chartDir)
+					}
+					return nil
+				})
+			if err != nil {
+				return errors.Wrapf(err, "inspecting chart %s", d.Name)
+			}
+			details.Message = fmt.Sprintf("%s\n* %s from %s to %s", details.Message, d.Name, d.Version, version)
+			d.Version = version
+		}
+		return nil
+	}
+
+	options := environments.EnvironmentPullRequestOptions{
+		Gitter:        o.Gitter,
+		ModifyChartFn: keepUpgradePolicies(modifyChartFn),
+		GitProvider:   o.GitProvider,
+	}
+	info, err := options.Create(o.DevEnv, o.EnvironmentsDir, &details, nil, "", autoMerge)
+	if err != nil {
+		return errors.Wrapf(err, "creating pr to upgrade %d apps", len(items))
+	}
+	log.Logger().Infof("Upgrading %d apps via Pull Request %s", len(items), info.PullRequest.URL)
+	return nil
+}
+
 // DeleteApp deletes the app with alias
 func (o *GitOpsOptions) DeleteApp(app string, alias string, autoMerge bool) error {
 
@@ -162,34 +268,11 @@ func (o *GitOpsOptions) DeleteApp(app string, alias string, autoMerge bool) erro
 
 // GetApps retrieves all the apps information for the given appNames from the repository and / or the CRD API
 func (o *GitOpsOptions) GetApps(appNames map[string]bool, expandFn func([]string) (*v1.AppList, error)) (*v1.AppList, error) {
-	dir, _, _, _, err := gits.ForkAndPullRepo(o.DevEnv.Spec.Source.URL, o.EnvironmentsDir, o.DevEnv.Spec.Source.Ref, "master", o.GitProvider, o.Gitter, "")
-	if err != nil {
-		return nil, errors.Wrapf(err, "couldn't pull the environment repository from %s", o.DevEnv.Name)
-	}
-
-	envDir := filepath.Join(dir, helm.DefaultEnvironmentChartDir)
-	if err != nil {
-		return nil, err
-	}
-	exists, err := util.DirExists(envDir)
+	envDir, reqs, err := o.loadEnvironmentRequirements()
 	if err != nil {
 		return nil, err
 	}
 
-	if !exists {
-		envDir = dir
-	}
-
-	requirementsFile, err := ioutil.ReadFile(filepath.Join(envDir, helm.RequirementsFileName))
-	if err != nil {
-		return nil, errors.Wrap(err, "couldn't read the environment's requirements.yaml file")
-	}
-	reqs := helm.Requirements{}
-	err = yaml.Unmarshal(requirementsFile, &reqs)
-	if err != nil {
-		return nil, errors.Wrap(err, "couldn't unmarshal the environment's requirements.yaml file")
-	}
-
 	appsList := v1.AppList{}
 	for _, d := range reqs.Dependencies {
 		if appNames[d.Name] == true || len(appNames) == 0 {
@@ -222,3 +305,270 @@ func (o *GitOpsOptions) GetApps(appNames map[string]bool, expandFn func([]string
 	}
 	return &appsList, nil
 }
+
+// loadEnvironmentRequirements pulls the environment repository returning the directory of the environment chart and
+// its requirements
+func (o *GitOpsOptions) loadEnvironmentRequirements() (string, *helm.Requirements, error) {
+	dir, _, _, _, err := gits.ForkAndPullRepo(o.DevEnv.Spec.Source.URL, o.EnvironmentsDir, o.DevEnv.Spec.Source.Ref, "master", o.GitProvider, o.Gitter, "")
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "couldn't pull the environment repository from %s", o.DevEnv.Name)
+	}
+
+	envDir := filepath.Join(dir, helm.DefaultEnvironmentChartDir)
+	exists, err := util.DirExists(envDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !exists {
+		envDir = dir
+	}
+
+	requirementsFile, err := ioutil.ReadFile(filepath.Join(envDir, helm.RequirementsFileName))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "couldn't read the environment's requirements.yaml file")
+	}
+	reqs := helm.Requirements{}
+	err = yaml.Unmarshal(requirementsFile, &reqs)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "couldn't unmarshal the environment's requirements.yaml file")
+	}
+	return envDir, &reqs, nil
+}
+
+// createUpgradePlan creates the plan for upgrading the apps with the names (or all apps if empty) in the environment
+// repository. The upgrade policy of each app is read from its App resource in the environment repository
+func (o *GitOpsOptions) createUpgradePlan(appNames []string, defaultPolicy UpgradePolicy) (*UpgradePlan, error) {
+	envDir, reqs, err := o.loadEnvironmentRequirements()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, name := range appNames {
+		names[name] = true
+	}
+	helmOpts := HelmOpsOptions{
+		InstallOptions: o.InstallOptions,
+	}
+	plan := &UpgradePlan{
+		GitOps: true,
+	}
+	for _, d := range reqs.Dependencies {
+		//Make sure we ignore the jenkins-x-platform requirement
+		if d.Name == "jenkins-x-platform" || (len(names) > 0 && !names[d.Name]) {
+			continue
+		}
+		_, app, err := findAppResource(filepath.Join(envDir, d.Name))
+		if err != nil {
+			return nil, err
+		}
+		policy, err := GetUpgradePolicy(app, defaultPolicy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the upgrade policy of %s", d.Name)
+		}
+		item := &UpgradePlanItem{
+			Name:           d.Name,
+			Alias:          d.Alias,
+			Repository:     d.Repository,
+			Policy:         policy,
+			CurrentVersion: d.Version,
+		}
+		deployed, err := helmOpts.getAppsFromCRDAPI([]string{d.Name})
+		if err != nil {
+			log.Logger().Warnf("Unable to find the deployed version of %s: %s", d.Name, err)
+		} else if len(deployed.Items) > 0 {
+			item.DeployedVersion = deployed.Items[0].Labels[helm.LabelAppVersion]
+		}
+		plan.Items = append(plan.Items, item)
+	}
+	return plan, nil
+}
+
+// keepUpgradePolicies wraps the modifyChartFn so that the upgrade policies on the App resources of the apps in the
+// environment are kept when their directories are recreated from newer charts
+func keepUpgradePolicies(modifyChartFn environments.ModifyChartFn) environments.ModifyChartFn {
+	return func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
+		templates map[string]string, envDir string, details *gits.PullRequestDetails) error {
+		policies := make(map[string]string)
+		for _, d := range requirements.Dependencies {
+			_, app, err := findAppResource(filepath.Join(envDir, d.Name))
+			if err != nil {
+				return err
+			}
+			if app != nil && app.Annotations[UpgradePolicyAnnotation] != "" {
+				policies[d.Name] = app.Annotations[UpgradePolicyAnnotation]
+			}
+		}
+		err := modifyChartFn(requirements, metadata, values, templates, envDir, details)
+		if err != nil {
+			return err
+		}
+		for name, policy := range policies {
+			err = setAppResourceAnnotation(filepath.Join(envDir, name), UpgradePolicyAnnotation, policy)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// findAppResource returns the file and the resource of Kind: App in the templates of the app directory in an
+// environment or nil if there is none
+func findAppResource(appDir string) (string, *v1.App, error) {
+	templatesDir := filepath.Join(appDir, "templates")
+	exists, err := util.DirExists(templatesDir)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "there was a problem checking if %s exists", templatesDir)
+	}
+	if !exists {
+		return "", nil, nil
+	}
+	files, err := ioutil.ReadDir(templatesDir)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "unable to list files in %s", templatesDir)
+	}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(templatesDir, f.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "reading %s", path)
+		}
+		app := &v1.App{}
+		err = yaml.Unmarshal(data, app)
+		// lets ignore any templates which are not valid YAML until they are rendered
+		if err == nil && app.Kind == "App" {
+			return path, app, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// setAppResourceAnnotation sets the annotation on the resource of Kind: App in the templates of the app directory in
+// an environment. Only the annotation is changed so the rest of the template is left as it is
+func setAppResourceAnnotation(appDir string, key string, value string) error {
+	path, app, err := findAppResource(appDir)
+	if err != nil {
+		return err
+	}
+	if app == nil {
+		return errors.Errorf("no resource of Kind: App found in %s", filepath.Join(appDir, "templates"))
+	}
+	if app.Annotations[key] == value {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", path)
+	}
+	text, err := SetAnnotationInYAML(string(data), key, value)
+	if err != nil {
+		return errors.Wrapf(err, "setting the annotation %s in %s", key, path)
+	}
+	err = ioutil.WriteFile(path, []byte(text), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "saving %s", path)
+	}
+	return nil
+}
+
+// SetAnnotationInYAML sets the annotation in the metadata of the YAML resource replacing the line of the annotation
+// if there is one, otherwise adding the line to the annotations. The other lines are left as they are so that
+// comments and templates are kept
+func SetAnnotationInYAML(text string, key string, value string) (string, error) {
+	lines := strings.Split(text, "\n")
+	annotationLine := func(indent string) string {
+		return indent + key + ": " + strconv.Quote(value)
+	}
+	metadata := -1
+	for i, line := range lines {
+		if strings.TrimRight(line, " ") == "metadata:" {
+			metadata = i
+			break
+		}
+	}
+	if metadata < 0 {
+		return "", errors.New("no metadata found")
+	}
+
+	// lets find the annotations in the fields of the metadata
+	fieldIndent := ""
+	annotations := -1
+	end := len(lines)
+	for i := metadata + 1; i < len(lines); i++ {
+		indent, trimmed := splitIndent(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent == "" {
+			end = i
+			break
+		}
+		if fieldIndent == "" {
+			fieldIndent = indent
+		}
+		if indent != fieldIndent || !strings.HasPrefix(trimmed, "annotations:") {
+			continue
+		}
+		switch strings.TrimSpace(strings.TrimPrefix(trimmed, "annotations:")) {
+		case "":
+			annotations = i
+		case "{}":
+			lines[i] = indent + "annotations:"
+			annotations = i
+		default:
+			return "", errors.Errorf("unsupported annotations on line %d: %s", i+1, trimmed)
+		}
+	}
+	if fieldIndent == "" {
+		fieldIndent = "  "
+	}
+	if annotations < 0 {
+		lines = insertLines(lines, metadata+1, fieldIndent+"annotations:", annotationLine(fieldIndent+fieldIndent))
+		return strings.Join(lines, "\n"), nil
+	}
+
+	annotationIndent := ""
+	insertAt := annotations + 1
+	for i := annotations + 1; i < end; i++ {
+		indent, trimmed := splitIndent(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if len(indent) <= len(fieldIndent) {
+			break
+		}
+		if annotationIndent == "" {
+			annotationIndent = indent
+		}
+		insertAt = i + 1
+		if indent != annotationIndent {
+			continue
+		}
+		name := strings.Trim(strings.SplitN(trimmed, ":", 2)[0], `"'`)
+		if name == key {
+			lines[i] = annotationLine(indent)
+			return strings.Join(lines, "\n"), nil
+		}
+	}
+	if annotationIndent == "" {
+		annotationIndent = fieldIndent + fieldIndent
+	}
+	lines = insertLines(lines, insertAt, annotationLine(annotationIndent))
+	return strings.Join(lines, "\n"), nil
+}
+
+func splitIndent(line string) (string, string) {
+	trimmed := strings.TrimLeft(line, " ")
+	return line[:len(line)-len(trimmed)], trimmed
+}
+
+func insertLines(lines []string, idx int, inserted ...string) []string {
+	answer := append([]string{}, lines[:idx]...)
+	answer = append(answer, inserted...)
+	return append(answer, lines[idx:]...)
+}
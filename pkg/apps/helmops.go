@@ -50,6 +50,9 @@ func (o *HelmOpsOptions) AddApp(app string, chart string, name string, version s
 		return errors.Wrapf(err, "attaching values.yaml to %s", appCRDName)
 	}
 	appObj.Labels[helm.LabelReleaseName] = releaseName
+	if o.UpgradePolicy != "" {
+		appObj.Annotations[UpgradePolicyAnnotation] = string(o.UpgradePolicy)
+	}
 	err = addApp(create, o.JxClient, appObj)
 	if err != nil {
 		return errors.Wrapf(err, "creating the app %s in the Apps CRD", appCRDName)
@@ -64,70 +67,76 @@ func (o *HelmOpsOptions) DeleteApp(app string, releaseName string, purge bool) e
 	return o.Helmer.DeleteRelease(o.Namespace, releaseName, purge)
 }
 
-//UpgradeApp upgrades the app with releaseName (or all apps if the app name is empty) to the specified version (
-// or the latest version if the version is empty) using the repository with username and password
-func (o *HelmOpsOptions) UpgradeApp(app string, version string, repository string, username string, password string,
-	releaseName string, alias string, helmUpdate bool) error {
-
-	// TODO implement this!
-	/*var branchNameText string
-	var title string
-	var message string
-	all := true
-
-	if app != "" {
-		all = false
-		if version == "" {
-			version = "latest"
-		}
-		branchNameText = fmt.Sprintf("upgrade-app-%s-%s", app, version)
+//UpgradeApp upgrades the app with releaseName to the version of the chart rooted in dir from the repository with
+// username and password. The values used to configure the chart are stashed on the App CRD
+func (o *HelmOpsOptions) UpgradeApp(app string, chart string, name string, version string, values []byte,
+	repository string, username string, password string, releaseName string, helmUpdate bool) error {
+	err := helm.InstallFromChartOptions(helm.InstallChartOptions{
+		ReleaseName: releaseName,
+		Chart:       app,
+		Version:     version,
+		Ns:          o.Namespace,
+		HelmUpdate:  helmUpdate,
+		ValueFiles:  o.valuesFiles.Items,
+		Repository:  repository,
+		Username:    username,
+		Password:    password,
+		UpgradeOnly: true,
+	}, o.Helmer, o.KubeClient, o.InstallTimeout, o.VaultClient)
+	if err != nil {
+		return errors.Wrapf(err, "failed to upgrade app %s", app)
 	}
+	// Attach the current values.yaml and the new version
+	appCRDName := fmt.Sprintf("%s-%s", releaseName, name)
 
-	branchNameText = fmt.Sprintf("upgrade-all-apps")
-	title = fmt.Sprintf("Upgrade all apps")
-	message = fmt.Sprintf("Upgrade all apps:")
-
-	for _, d := range requirements.Dependencies {
-		upgrade := false
-		// We need to ignore the platform
-		if d.Name == "jenkins-x-platform" {
-			upgrade = false
-		} else if all {
-			upgrade = true
-		} else {
-			if d.Name == app && d.Alias == alias {
-				upgrade = true
+	create, appObj, err := StashValues(values, appCRDName, o.JxClient, o.Namespace, chart, repository)
+	if err != nil {
+		return errors.Wrapf(err, "attaching values.yaml to %s", appCRDName)
+	}
+	appObj.Labels[helm.LabelReleaseName] = releaseName
+	err = addApp(create, o.JxClient, appObj)
+	if err != nil {
+		return errors.Wrapf(err, "updating the app %s in the Apps CRD", appCRDName)
+	}
+	log.Logger().Infof("Successfully upgraded %s to %s", util.ColorInfo(name), util.ColorInfo(version))
+	return nil
+}
 
-			}
+// createUpgradePlan creates the plan for upgrading the apps with the names (or all apps if empty) in the Apps CRD.
+// The upgrade policy of each app is read from its App CRD
+func (o *HelmOpsOptions) createUpgradePlan(appNames []string, defaultPolicy UpgradePolicy) (*UpgradePlan, error) {
+	apps, err := o.getAppsFromCRDAPI(appNames)
+	if err != nil {
+		return nil, err
+	}
+	releases, _, err := o.Helmer.ListReleases(o.Namespace)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the helm releases in %s", o.Namespace)
+	}
+	plan := &UpgradePlan{}
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		name := app.Labels[helm.LabelAppName]
+		if name == "" {
+			continue
 		}
-		if upgrade {
-			upgraded = true
-			if all || version == "" {
-				var err error
-				version, err = helm.GetLatestVersion(d.Name, repository, username, password, o.Helmer)
-				if err != nil {
-					return err
-				}
-				if o.Verbose {
-					log.Logger().Infof("No version specified so using latest version which is %s", util.ColorInfo(version))
-				}
-			}
-			// Do the upgrade
-			oldVersion := d.Version
-			d.Version = version
-			if !all {
-				title = fmt.Sprintf("Upgrade %s to %s", app, version)
-				message = fmt.Sprintf("Upgrade %s from %s to %s", app, oldVersion, version)
-			} else {
-				message = fmt.Sprintf("%s* %s from %s to %s", message, d.Name, oldVersion, version)
-			}
+		policy, err := GetUpgradePolicy(app, defaultPolicy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the upgrade policy of %s", app.Name)
 		}
+		item := &UpgradePlanItem{
+			Name:           name,
+			Repository:     app.Annotations[helm.AnnotationAppRepository],
+			ReleaseName:    app.Labels[helm.LabelReleaseName],
+			Policy:         policy,
+			CurrentVersion: app.Labels[helm.LabelAppVersion],
+		}
+		if release, ok := releases[item.ReleaseName]; ok {
+			item.DeployedVersion = release.ChartVersion
+		}
+		plan.Items = append(plan.Items, item)
 	}
-
-	if !upgraded {
-		log.Logger().Infof("No upgrades available")
-	}*/
-	return nil
+	return plan, nil
 }
 
 func (o *HelmOpsOptions) getAppsFromCRDAPI(appNames []string) (*v1.AppList, error) {
@@ -59,6 +59,7 @@ type InstallOptions struct {
 	VaultClient     vault.Client
 	AutoMerge       bool
 	SecretsScheme   string
+	UpgradePolicy   UpgradePolicy // the upgrade policy recorded on apps when they are added
 
 	valuesFiles *environments.ValuesFiles // internal variable used to track, most be passed in
 }
@@ -151,15 +152,10 @@ func (o *InstallOptions) AddApp(app string, version string, repository string, u
 
 //GetApps gets a list of installed apps
 func (o *InstallOptions) GetApps(appNames []string) (apps *jenkinsv1.AppList, err error) {
-	prefixes := o.getPrefixes()
-	in := make([]string, 0)
+	in := o.appNamesWithPrefixes(appNames)
 	appsMap := make(map[string]bool)
-	for _, prefix := range prefixes {
-		for _, appName := range appNames {
-			completeAppName := fmt.Sprintf("%s%s", prefix, appName)
-			in = append(in, completeAppName)
-			appsMap[completeAppName] = true
-		}
+	for _, completeAppName := range in {
+		appsMap[completeAppName] = true
 	}
 
 	helmOpts := HelmOpsOptions{
@@ -271,9 +267,10 @@ func (o *InstallOptions) UpgradeApp(app string, version string, repository strin
 				return errors.Wrapf(err, "getting App CRD %s", appResource.Name)
 			}
 			var existingValues map[string]interface{}
+			var existingValuesBytes []byte
 			if appResource.Annotations != nil {
 				if encodedValues, ok := appResource.Annotations[ValuesAnnotation]; ok && encodedValues != "" {
-					existingValuesBytes, err := base64.StdEncoding.DecodeString(encodedValues)
+					existingValuesBytes, err = base64.StdEncoding.DecodeString(encodedValues)
 					if err != nil {
 						log.Logger().Warnf("Error decoding base64 encoded string from %s on %s\n%s", ValuesAnnotation,
 							appCrdName, encodedValues)
@@ -292,10 +289,25 @@ func (o *InstallOptions) UpgradeApp(app string, version string, repository strin
 				return errors.Wrapf(err, "asking questions")
 			}
 
+			// Keep the existing answers if there were no questions to ask
+			values := chartDetails.Values
+			if values == nil {
+				values = existingValuesBytes
+				if len(existingValuesBytes) > 0 {
+					// lets upgrade the release with the existing answers ahead of any other values files
+					valuesFile, cleanup, err := AddValuesToChart(appCrdName, existingValuesBytes, o.Verbose)
+					defer cleanup()
+					if err != nil {
+						return errors.Wrapf(err, "writing the existing values of %s", appCrdName)
+					}
+					o.valuesFiles.Items = append([]string{valuesFile}, o.valuesFiles.Items...)
+				}
+			}
 			opts := HelmOpsOptions{
 				InstallOptions: o,
 			}
-			err = opts.UpgradeApp(chartName, version, repository, username, password, releaseName, alias, update)
+			err = opts.UpgradeApp(chartName, dir, chartDetails.Name, chartDetails.Version, values, repository,
+				username, password, releaseName, update)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,254 @@
+package apps
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/environments"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// UpgradeActionUpgrade the app will be upgraded to the target version
+	UpgradeActionUpgrade = "upgrade"
+	// UpgradeActionPinned the app is pinned to its current version
+	UpgradeActionPinned = "pinned"
+	// UpgradeActionHeldBack a newer version of the app exists but its upgrade policy does not allow it
+	UpgradeActionHeldBack = "held back"
+	// UpgradeActionUpToDate the app is already using the newest version
+	UpgradeActionUpToDate = "up to date"
+)
+
+// UpgradePlanItem describes the upgrade of an app. The current version is the version the app should be using
+// (the version in the environment repository when using GitOps or the version in the App CRD when not), the deployed
+// version is the version actually running (the version in the App CRD when using GitOps or the version of the helm
+// release when not). An empty target version means the latest version in the repository
+type UpgradePlanItem struct {
+	Name            string        `json:"name"`
+	Alias           string        `json:"alias,omitempty"`
+	Repository      string        `json:"repository,omitempty"`
+	ReleaseName     string        `json:"releaseName,omitempty"`
+	Policy          UpgradePolicy `json:"policy"`
+	CurrentVersion  string        `json:"currentVersion"`
+	DeployedVersion string        `json:"deployedVersion,omitempty"`
+	LatestVersion   string        `json:"latestVersion,omitempty"`
+	TargetVersion   string        `json:"targetVersion"`
+}
+
+// UpgradePlan describes the upgrades of a set of apps
+type UpgradePlan struct {
+	GitOps bool               `json:"gitOps"`
+	Items  []*UpgradePlanItem `json:"items"`
+}
+
+// Upgrade returns true if the app will be upgraded
+func (i *UpgradePlanItem) Upgrade() bool {
+	return i.Policy != UpgradePolicyPinned && i.TargetVersion != i.CurrentVersion
+}
+
+// Drifted returns true if the deployed version of the app is not the version it should be using
+func (i *UpgradePlanItem) Drifted() bool {
+	return i.DeployedVersion != "" && i.DeployedVersion != i.CurrentVersion
+}
+
+// Action returns a description of what will happen to the app
+func (i *UpgradePlanItem) Action() string {
+	switch {
+	case i.Policy == UpgradePolicyPinned:
+		return UpgradeActionPinned
+	case i.Upgrade():
+		return UpgradeActionUpgrade
+	case i.LatestVersion != "" && i.LatestVersion != i.CurrentVersion:
+		return UpgradeActionHeldBack
+	default:
+		return UpgradeActionUpToDate
+	}
+}
+
+// Upgrades returns the items of the plan which will be upgraded
+func (p *UpgradePlan) Upgrades() []*UpgradePlanItem {
+	answer := []*UpgradePlanItem{}
+	for _, i := range p.Items {
+		if i.Upgrade() {
+			answer = append(answer, i)
+		}
+	}
+	return answer
+}
+
+// Find returns the item for the app with the alias or nil if there is none
+func (p *UpgradePlan) Find(name string, alias string) *UpgradePlanItem {
+	for _, i := range p.Items {
+		if i.Name == name && i.Alias == alias {
+			return i
+		}
+	}
+	return nil
+}
+
+// CreateUpgradePlan creates the plan for upgrading the apps (or all apps if empty) using the upgrade policy of each
+// app, or the default policy for apps without one, and the versions available in the repositories of the apps which
+// are accessed with username and password. GitOps or HelmOps will be automatically chosen based on the o.GitOps flag
+func (o *InstallOptions) CreateUpgradePlan(appNames []string, defaultPolicy UpgradePolicy, username string,
+	password string, helmUpdate bool) (*UpgradePlan, error) {
+	var plan *UpgradePlan
+	var err error
+	names := o.appNamesWithPrefixes(appNames)
+	if o.GitOps {
+		opts := GitOpsOptions{
+			InstallOptions: o,
+		}
+		plan, err = opts.createUpgradePlan(names, defaultPolicy)
+	} else {
+		opts := HelmOpsOptions{
+			InstallOptions: o,
+		}
+		plan, err = opts.createUpgradePlan(names, defaultPolicy)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the upgrade plan")
+	}
+
+	repoNames := map[string]string{}
+	repoCredentials := map[string][]string{}
+	for _, item := range plan.Items {
+		if item.Policy == UpgradePolicyPinned || item.Repository == "" {
+			continue
+		}
+		if _, ok := repoNames[item.Repository]; ok {
+			continue
+		}
+		repoUsername, repoPassword, err := helm.DecorateWithCredentials(item.Repository, username, password,
+			o.VaultClient, o.In, o.Out, o.Err)
+		if err != nil {
+			return nil, errors.Wrapf(err, "locating credentials for %s", item.Repository)
+		}
+		repoName, err := helm.AddHelmRepoIfMissing(item.Repository, "", repoUsername, repoPassword, o.Helmer,
+			o.VaultClient, o.In, o.Out, o.Err)
+		if err != nil {
+			return nil, errors.Wrapf(err, "adding helm repo %s", item.Repository)
+		}
+		repoNames[item.Repository] = repoName
+		repoCredentials[item.Repository] = []string{repoUsername, repoPassword}
+	}
+	if helmUpdate && len(repoNames) > 0 {
+		err = o.Helmer.UpdateRepo()
+		if err != nil {
+			return nil, errors.Wrap(err, "updating the helm repositories")
+		}
+	}
+
+	for _, item := range plan.Items {
+		if item.Policy == UpgradePolicyPinned {
+			item.TargetVersion = item.CurrentVersion
+			continue
+		}
+		versions, err := o.chartVersions(item.Name, repoNames[item.Repository])
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding the versions of %s", item.Name)
+		}
+		if len(versions) == 0 && item.Policy == UpgradePolicyLatest {
+			// the repository cannot be searched so lets fetch the latest release of the chart to find its version
+			credentials := repoCredentials[item.Repository]
+			if len(credentials) == 0 {
+				credentials = []string{username, password}
+			}
+			latest, err := o.latestChartVersion(item.Name, item.Repository, credentials[0], credentials[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "finding the latest version of %s", item.Name)
+			}
+			if latest != "" {
+				versions = append(versions, latest)
+			}
+		}
+		if len(versions) == 0 {
+			log.Logger().Warnf("Unable to find the versions of %s in %s so it will not be upgraded with the %s policy",
+				item.Name, item.Repository, util.ColorInfo(string(item.Policy)))
+			item.TargetVersion = item.CurrentVersion
+			continue
+		}
+		item.LatestVersion = LatestVersion(versions)
+		item.TargetVersion = item.Policy.TargetVersion(item.CurrentVersion, versions)
+	}
+	return plan, nil
+}
+
+// UpgradeApps upgrades the apps of the plan which are not up to date to their target versions from their repositories
+// with username and password. When using GitOps all the upgrades are made in a single Pull Request.
+// GitOps or HelmOps will be automatically chosen based on the o.GitOps flag
+func (o *InstallOptions) UpgradeApps(plan *UpgradePlan, username string, password string, update bool,
+	askExisting bool) error {
+	items := plan.Upgrades()
+	if len(items) == 0 {
+		log.Logger().Infof("No upgrades available")
+		return nil
+	}
+	if o.GitOps {
+		o.valuesFiles = &environments.ValuesFiles{
+			Items: make([]string, 0),
+		}
+		opts := GitOpsOptions{
+			InstallOptions: o,
+		}
+		return opts.UpgradeApps(items, username, password,
+			func(item *UpgradePlanItem) func(dir string, existing map[string]interface{}) (*ChartDetails, error) {
+				return o.createInterrogateChartFn(item.TargetVersion, item.Name, item.Repository, username, password,
+					item.Alias, askExisting)
+			}, o.AutoMerge)
+	}
+	for _, item := range items {
+		err := o.UpgradeApp(item.Name, item.TargetVersion, item.Repository, username, password, item.ReleaseName,
+			item.Alias, update, askExisting)
+		if err != nil {
+			return errors.Wrapf(err, "upgrading app %s", item.Name)
+		}
+	}
+	return nil
+}
+
+// chartVersions returns the versions of the chart in the helm repository with the name or in any repository if the
+// name is empty
+func (o *InstallOptions) chartVersions(chartName string, repoName string) ([]string, error) {
+	filter := chartName
+	if repoName != "" {
+		filter = fmt.Sprintf("%s/%s", repoName, chartName)
+	}
+	charts, err := o.Helmer.SearchCharts(filter, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "searching charts for %s", filter)
+	}
+	answer := []string{}
+	for _, chart := range charts {
+		if chart.Name == filter || (repoName == "" && strings.HasSuffix(chart.Name, "/"+chartName)) {
+			answer = append(answer, chart.ChartVersion)
+		}
+	}
+	return answer, nil
+}
+
+// latestChartVersion returns the version of the latest release of the chart in the repository by fetching it
+func (o *InstallOptions) latestChartVersion(chartName string, repository string, username string,
+	password string) (string, error) {
+	answer := ""
+	err := helm.InspectChart(chartName, "", repository, username, password, o.Helmer, func(dir string) error {
+		_, version, err := helm.LoadChartNameAndVersion(filepath.Join(dir, helm.ChartFileName))
+		answer = version
+		return err
+	})
+	return answer, err
+}
+
+// appNamesWithPrefixes returns the names of the apps with each of the app prefixes of the team
+func (o *InstallOptions) appNamesWithPrefixes(appNames []string) []string {
+	answer := make([]string, 0)
+	for _, prefix := range o.getPrefixes() {
+		for _, appName := range appNames {
+			answer = append(answer, fmt.Sprintf("%s%s", prefix, appName))
+		}
+	}
+	return answer
+}
@@ -0,0 +1,119 @@
+package apps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+)
+
+// UpgradePolicy defines which newer versions of an app may be used when upgrading it
+type UpgradePolicy string
+
+const (
+	// UpgradePolicyPinned the app is never upgraded by bulk upgrades
+	UpgradePolicyPinned UpgradePolicy = "pinned"
+	// UpgradePolicyPatch the app is only upgraded to newer patch releases of its current major and minor version
+	UpgradePolicyPatch UpgradePolicy = "patch"
+	// UpgradePolicyMinor the app is only upgraded to newer minor and patch releases of its current major version
+	UpgradePolicyMinor UpgradePolicy = "minor"
+	// UpgradePolicyLatest the app is upgraded to the latest release
+	UpgradePolicyLatest UpgradePolicy = "latest"
+)
+
+// UpgradePolicies the valid upgrade policies
+var UpgradePolicies = []string{
+	string(UpgradePolicyPinned),
+	string(UpgradePolicyPatch),
+	string(UpgradePolicyMinor),
+	string(UpgradePolicyLatest),
+}
+
+// ParseUpgradePolicy parses the text into an upgrade policy. An empty text is the latest policy
+func ParseUpgradePolicy(text string) (UpgradePolicy, error) {
+	if text == "" {
+		return UpgradePolicyLatest, nil
+	}
+	for _, p := range UpgradePolicies {
+		if strings.EqualFold(text, p) {
+			return UpgradePolicy(p), nil
+		}
+	}
+	return "", fmt.Errorf("invalid upgrade policy %s. Must be one of: %s", text, strings.Join(UpgradePolicies, ", "))
+}
+
+// GetUpgradePolicy returns the upgrade policy in the annotations of the app or the default policy if it has none
+func GetUpgradePolicy(app *v1.App, defaultPolicy UpgradePolicy) (UpgradePolicy, error) {
+	if app == nil || app.Annotations[UpgradePolicyAnnotation] == "" {
+		return defaultPolicy, nil
+	}
+	return ParseUpgradePolicy(app.Annotations[UpgradePolicyAnnotation])
+}
+
+// Allows returns true if the policy allows upgrading from the current version to the version
+func (p UpgradePolicy) Allows(current semver.Version, version semver.Version) bool {
+	if !version.GT(current) {
+		return false
+	}
+	switch p {
+	case UpgradePolicyPatch:
+		return version.Major == current.Major && version.Minor == current.Minor
+	case UpgradePolicyMinor:
+		return version.Major == current.Major
+	case UpgradePolicyLatest:
+		return true
+	default:
+		return false
+	}
+}
+
+// TargetVersion returns the newest of the versions the policy allows upgrading the current version to or the current
+// version if there is none. Pre-release versions are ignored unless the current version is a pre-release.
+// If the current version is not a semantic version only the latest policy upgrades it
+func (p UpgradePolicy) TargetVersion(current string, versions []string) string {
+	if p == UpgradePolicyPinned {
+		return current
+	}
+	currentVersion, err := semver.ParseTolerant(current)
+	if err != nil {
+		if p == UpgradePolicyLatest {
+			latest := LatestVersion(versions)
+			if latest != "" {
+				return latest
+			}
+		}
+		return current
+	}
+	answer := current
+	best := currentVersion
+	for _, text := range versions {
+		v, err := semver.ParseTolerant(text)
+		if err != nil || (len(v.Pre) > 0 && len(currentVersion.Pre) == 0) {
+			continue
+		}
+		if p.Allows(currentVersion, v) && v.GT(best) {
+			best = v
+			answer = text
+		}
+	}
+	return answer
+}
+
+// LatestVersion returns the newest of the versions ignoring pre-releases and any versions which are not semantic
+// versions, or an empty string if there is none
+func LatestVersion(versions []string) string {
+	answer := ""
+	var best *semver.Version
+	for _, text := range versions {
+		v, err := semver.ParseTolerant(text)
+		if err != nil || len(v.Pre) > 0 {
+			continue
+		}
+		if best == nil || v.GT(*best) {
+			best = &v
+			answer = text
+		}
+	}
+	return answer
+}
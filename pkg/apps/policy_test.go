@@ -0,0 +1,162 @@
+package apps_test
+
+import (
+	"testing"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/apps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseUpgradePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := apps.ParseUpgradePolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, apps.UpgradePolicyLatest, policy)
+
+	policy, err = apps.ParseUpgradePolicy("Minor")
+	assert.NoError(t, err)
+	assert.Equal(t, apps.UpgradePolicyMinor, policy)
+
+	_, err = apps.ParseUpgradePolicy("major")
+	assert.Error(t, err)
+}
+
+func TestGetUpgradePolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := apps.GetUpgradePolicy(nil, apps.UpgradePolicyPatch)
+	assert.NoError(t, err)
+	assert.Equal(t, apps.UpgradePolicyPatch, policy)
+
+	app := &v1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "jx-app-jacoco",
+			Annotations: map[string]string{
+				apps.UpgradePolicyAnnotation: "pinned",
+			},
+		},
+	}
+	policy, err = apps.GetUpgradePolicy(app, apps.UpgradePolicyPatch)
+	assert.NoError(t, err)
+	assert.Equal(t, apps.UpgradePolicyPinned, policy)
+
+	app.Annotations[apps.UpgradePolicyAnnotation] = "sometimes"
+	_, err = apps.GetUpgradePolicy(app, apps.UpgradePolicyPatch)
+	assert.Error(t, err)
+}
+
+func TestUpgradePolicyTargetVersion(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"1.2.3", "1.2.5", "1.2.4", "1.3.0", "1.4.1", "2.0.0", "2.1.0-rc.1", "v2.0.1", "nightly"}
+	testCases := []struct {
+		policy   apps.UpgradePolicy
+		current  string
+		expected string
+	}{
+		{apps.UpgradePolicyPinned, "1.2.3", "1.2.3"},
+		{apps.UpgradePolicyPatch, "1.2.3", "1.2.5"},
+		{apps.UpgradePolicyMinor, "1.2.3", "1.4.1"},
+		{apps.UpgradePolicyLatest, "1.2.3", "v2.0.1"},
+		{apps.UpgradePolicyPatch, "1.4.1", "1.4.1"},
+		{apps.UpgradePolicyLatest, "3.0.0", "3.0.0"},
+		{apps.UpgradePolicyLatest, "2.1.0-alpha.1", "2.1.0-rc.1"},
+		{apps.UpgradePolicyMinor, "nightly", "nightly"},
+		{apps.UpgradePolicyLatest, "nightly", "v2.0.1"},
+	}
+	for _, tc := range testCases {
+		actual := tc.policy.TargetVersion(tc.current, versions)
+		assert.Equal(t, tc.expected, actual, "upgrading %s with the %s policy", tc.current, string(tc.policy))
+	}
+}
+
+func TestUpgradePlanItemAction(t *testing.T) {
+	t.Parallel()
+
+	item := &apps.UpgradePlanItem{
+		Name:            "jx-app-jacoco",
+		Policy:          apps.UpgradePolicyPatch,
+		CurrentVersion:  "1.2.3",
+		DeployedVersion: "1.2.2",
+		LatestVersion:   "2.0.0",
+		TargetVersion:   "1.2.5",
+	}
+	assert.True(t, item.Upgrade())
+	assert.True(t, item.Drifted())
+	assert.Equal(t, apps.UpgradeActionUpgrade, item.Action())
+
+	item.TargetVersion = item.CurrentVersion
+	assert.False(t, item.Upgrade())
+	assert.Equal(t, apps.UpgradeActionHeldBack, item.Action())
+
+	item.LatestVersion = item.CurrentVersion
+	item.DeployedVersion = item.CurrentVersion
+	assert.False(t, item.Drifted())
+	assert.Equal(t, apps.UpgradeActionUpToDate, item.Action())
+
+	item.Policy = apps.UpgradePolicyPinned
+	item.TargetVersion = ""
+	assert.False(t, item.Upgrade())
+	assert.Equal(t, apps.UpgradeActionPinned, item.Action())
+
+	plan := &apps.UpgradePlan{
+		Items: []*apps.UpgradePlanItem{
+			item,
+			{
+				Name:           "jx-app-sonar",
+				Policy:         apps.UpgradePolicyLatest,
+				CurrentVersion: "0.1.0",
+			},
+		},
+	}
+	upgrades := plan.Upgrades()
+	assert.Len(t, upgrades, 1)
+	assert.Equal(t, "jx-app-sonar", upgrades[0].Name)
+	assert.Equal(t, upgrades[0], plan.Find("jx-app-sonar", ""))
+	assert.Nil(t, plan.Find("jx-app-sonar", "other"))
+}
+
+func TestSetAnnotationInYAML(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name: "without annotations",
+			text: "apiVersion: jenkins.io/v1\nkind: App\nmetadata:\n  # the app\n  name: {{ .Release.Name }}\nspec: {}\n",
+			expected: "apiVersion: jenkins.io/v1\nkind: App\nmetadata:\n  annotations:\n    jenkins.io/upgrade-policy: \"patch\"\n" +
+				"  # the app\n  name: {{ .Release.Name }}\nspec: {}\n",
+		},
+		{
+			name: "with other annotations",
+			text: "metadata:\n  annotations:\n    jenkins.io/chart-description: \"cheese\"\n  name: cheese\n",
+			expected: "metadata:\n  annotations:\n    jenkins.io/chart-description: \"cheese\"\n" +
+				"    jenkins.io/upgrade-policy: \"patch\"\n  name: cheese\n",
+		},
+		{
+			name:     "with the annotation",
+			text:     "metadata:\n  annotations:\n    jenkins.io/upgrade-policy: latest # keep\n    other: value\n  name: cheese\n",
+			expected: "metadata:\n  annotations:\n    jenkins.io/upgrade-policy: \"patch\"\n    other: value\n  name: cheese\n",
+		},
+		{
+			name:     "with empty annotations",
+			text:     "metadata:\n  annotations: {}\n  name: cheese\n",
+			expected: "metadata:\n  annotations:\n    jenkins.io/upgrade-policy: \"patch\"\n  name: cheese\n",
+		},
+	}
+	for _, tc := range testCases {
+		actual, err := apps.SetAnnotationInYAML(tc.text, apps.UpgradePolicyAnnotation, "patch")
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, actual, tc.name)
+	}
+
+	_, err := apps.SetAnnotationInYAML("kind: App\n", apps.UpgradePolicyAnnotation, "patch")
+	assert.Error(t, err, "should reject a resource without metadata")
+}
@@ -13,4 +13,6 @@ const (
 	GitAuthConfigFile = "gitAuth.yaml"
 	// ChartmuseumAuthConfigFile config file for chartmusuem auth credentials
 	ChartmuseumAuthConfigFile = "chartmuseumAuth.yaml"
+	// DockerAuthConfigFile config file for docker registry auth credentials
+	DockerAuthConfigFile = "dockerAuth.yaml"
 )
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// mergeAuthConfigs performs a three way merge of the local changes (ours) and the remote changes (theirs) made to the
+// config since it was loaded (base). Changes to different servers or different users of a server are merged, if the
+// same server or user has been changed differently on both sides a ConflictError is returned
+func mergeAuthConfigs(secretName string, base *AuthConfig, ours *AuthConfig, theirs *AuthConfig) (*AuthConfig, error) {
+	answer := &AuthConfig{
+		DefaultUsername:  mergeString(base.DefaultUsername, ours.DefaultUsername, theirs.DefaultUsername),
+		CurrentServer:    mergeString(base.CurrentServer, ours.CurrentServer, theirs.CurrentServer),
+		PipeLineUsername: mergeString(base.PipeLineUsername, ours.PipeLineUsername, theirs.PipeLineUsername),
+		PipeLineServer:   mergeString(base.PipeLineServer, ours.PipeLineServer, theirs.PipeLineServer),
+	}
+
+	urls := []string{}
+	for _, servers := range [][]*AuthServer{theirs.Servers, ours.Servers} {
+		for _, s := range servers {
+			if util.StringArrayIndex(urls, s.URL) < 0 {
+				urls = append(urls, s.URL)
+			}
+		}
+	}
+	for _, url := range urls {
+		b := base.GetServer(url)
+		o := ours.GetServer(url)
+		t := theirs.GetServer(url)
+		server, err := mergeServers(secretName, b, o, t)
+		if err != nil {
+			return nil, err
+		}
+		if server != nil {
+			answer.Servers = append(answer.Servers, server)
+		}
+	}
+	return copyAuthConfig(answer)
+}
+
+func mergeServers(secretName string, base *AuthServer, ours *AuthServer, theirs *AuthServer) (*AuthServer, error) {
+	if sameYaml(ours, base) {
+		return theirs, nil
+	}
+	if sameYaml(theirs, base) || sameYaml(ours, theirs) {
+		return ours, nil
+	}
+	serverURL := ""
+	if ours != nil {
+		serverURL = ours.URL
+	} else if theirs != nil {
+		serverURL = theirs.URL
+	}
+	conflict := &ConflictError{
+		SecretName: secretName,
+		ServerURL:  serverURL,
+	}
+	// one side deleted the server while the other modified it
+	if ours == nil || theirs == nil {
+		return nil, conflict
+	}
+	if base == nil {
+		base = &AuthServer{}
+	}
+	answer := &AuthServer{
+		URL: ours.URL,
+	}
+	var ok bool
+	fields := []struct {
+		dest               *string
+		base, ours, theirs string
+	}{
+		{&answer.Name, base.Name, ours.Name, theirs.Name},
+		{&answer.Kind, base.Kind, ours.Kind, theirs.Kind},
+		{&answer.CurrentUser, base.CurrentUser, ours.CurrentUser, theirs.CurrentUser},
+	}
+	for _, f := range fields {
+		*f.dest, ok = mergeStringStrict(f.base, f.ours, f.theirs)
+		if !ok {
+			return nil, conflict
+		}
+	}
+
+	usernames := []string{}
+	for _, users := range [][]*UserAuth{theirs.Users, ours.Users} {
+		for _, u := range users {
+			if util.StringArrayIndex(usernames, u.Username) < 0 {
+				usernames = append(usernames, u.Username)
+			}
+		}
+	}
+	for _, username := range usernames {
+		b := findUser(base.Users, username)
+		o := findUser(ours.Users, username)
+		t := findUser(theirs.Users, username)
+		var user *UserAuth
+		switch {
+		case sameYaml(o, b):
+			user = t
+		case sameYaml(t, b) || sameYaml(o, t):
+			user = o
+		default:
+			conflict.Username = username
+			return nil, conflict
+		}
+		if user != nil {
+			answer.Users = append(answer.Users, user)
+		}
+	}
+	return answer, nil
+}
+
+// mergeString returns our value if we changed it otherwise their value
+func mergeString(base string, ours string, theirs string) string {
+	if ours != base {
+		return ours
+	}
+	return theirs
+}
+
+// mergeStringStrict returns the merged value or false if both sides changed the value differently
+func mergeStringStrict(base string, ours string, theirs string) (string, bool) {
+	if ours == base || ours == theirs {
+		return theirs, true
+	}
+	if theirs == base {
+		return ours, true
+	}
+	return "", false
+}
+
+func findUser(users []*UserAuth, username string) *UserAuth {
+	for _, u := range users {
+		if u.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+// sameYaml returns true if both values marshal to the same YAML
+func sameYaml(a interface{}, b interface{}) bool {
+	d1, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	d2, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(d1) == string(d2)
+}
+
+// copyAuthConfig creates a deep copy of the config
+func copyAuthConfig(config *AuthConfig) (*AuthConfig, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling auth config")
+	}
+	answer := &AuthConfig{}
+	err = yaml.Unmarshal(data, answer)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling auth config")
+	}
+	return answer, nil
+}
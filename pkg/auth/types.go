@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/jenkins-x/jx/pkg/vault"
 )
 
@@ -18,6 +20,16 @@ type UserAuth struct {
 	ApiToken    string `json:"apitoken"`
 	BearerToken string `json:"bearertoken"`
 	Password    string `json:"password,omitempty"`
+
+	// TokenName the name of the API token on the server which is used to revoke it once it has been rotated
+	TokenName string `json:"tokenname,omitempty"`
+	// RefreshToken the token used to obtain a new API token for servers which issue refresh tokens
+	RefreshToken string `json:"refreshtoken,omitempty"`
+
+	// Created when the token was created or last rotated
+	Created *time.Time `json:"created,omitempty"`
+	// Expires when the token expires and so needs to be rotated
+	Expires *time.Time `json:"expires,omitempty"`
 }
 
 type AuthConfig struct {
@@ -44,6 +56,10 @@ type FileAuthConfigSaver struct {
 type VaultAuthConfigSaver struct {
 	vaultClient vault.Client
 	secretName  string
+	cacheTTL    time.Duration
+	now         func() time.Time
+	// base the snapshot of the config when it was last loaded or saved which is used to detect local changes
+	base *vaultAuthSnapshot
 }
 
 // MemoryAuthConfigSaver uses memory
@@ -3,6 +3,7 @@ package auth
 import (
 	"os"
 	"strings"
+	"time"
 )
 
 const (
@@ -59,3 +60,25 @@ func CreateAuthUserFromEnvironment(prefix string) UserAuth {
 func (a *UserAuth) IsInvalid() bool {
 	return a.BearerToken == "" && (a.ApiToken == "" || a.Username == "")
 }
+
+// SetTokenCreated records that the token was created at the given time along with its expiry if the time to live is
+// positive. A zero time to live clears any previous expiry
+func (a *UserAuth) SetTokenCreated(now time.Time, ttl time.Duration) {
+	created := now
+	a.Created = &created
+	a.Expires = nil
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		a.Expires = &expires
+	}
+}
+
+// IsExpired returns true if the token has an expiry time which is before the given time
+func (a *UserAuth) IsExpired(now time.Time) bool {
+	return a.Expires != nil && a.Expires.Before(now)
+}
+
+// ExpiresWithin returns true if the token has an expiry time within the given duration of the given time
+func (a *UserAuth) ExpiresWithin(now time.Time, duration time.Duration) bool {
+	return a.Expires != nil && a.Expires.Before(now.Add(duration))
+}
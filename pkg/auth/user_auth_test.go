@@ -2,6 +2,7 @@ package auth_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -169,3 +170,23 @@ func TestIsInvalid(t *testing.T) {
 		})
 	}
 }
+
+func TestUserAuthTokenExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2019, time.July, 1, 12, 0, 0, 0, time.UTC)
+	user := &auth.UserAuth{Username: "test", ApiToken: "test"}
+	assert.False(t, user.IsExpired(now), "a token without an expiry should never expire")
+	assert.False(t, user.ExpiresWithin(now, 24*time.Hour), "a token without an expiry should never expire")
+
+	user.SetTokenCreated(now, 30*24*time.Hour)
+	assert.Equal(t, now, *user.Created)
+	assert.Equal(t, now.Add(30*24*time.Hour), *user.Expires)
+	assert.False(t, user.IsExpired(now))
+	assert.False(t, user.ExpiresWithin(now, 7*24*time.Hour))
+	assert.True(t, user.ExpiresWithin(now, 31*24*time.Hour))
+	assert.True(t, user.IsExpired(now.Add(31*24*time.Hour)))
+
+	user.SetTokenCreated(now, 0)
+	assert.Nil(t, user.Expires, "a zero time to live should clear the expiry")
+}
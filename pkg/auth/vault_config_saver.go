@@ -1,52 +1,521 @@
 package auth
 
 import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/jenkins-x/jx/pkg/vault"
 	"github.com/pkg/errors"
 
 	"sigs.k8s.io/yaml"
 )
 
-// LoadConfig loads the config from the vault
+const (
+	// DefaultVaultAuthConfigCacheTTL how long a loaded auth config is cached in process before being reloaded from vault
+	DefaultVaultAuthConfigCacheTTL = 30 * time.Second
+
+	vaultYamlKey       = "yaml"
+	vaultServersFolder = "servers"
+
+	// vaultAuthConfigFolder the folder of the auth configs stored with separate server entries. Older binaries read
+	// the auth config from the legacy path so this format is stored elsewhere to stop them reading an index without
+	// any servers and then overwriting it
+	vaultAuthConfigFolder = "v2"
+
+	// maxSaveAttempts how many times we retry merging and saving when another process updates vault concurrently
+	maxSaveAttempts = 5
+)
+
+var (
+	vaultAuthConfigCache = map[vaultAuthConfigKey]*vaultAuthCacheEntry{}
+	vaultAuthConfigLocks = map[vaultAuthConfigKey]*sync.Mutex{}
+	vaultAuthConfigMutex sync.Mutex
+)
+
+// vaultAuthConfigKey identifies an auth config in the vault of a client so that different vaults never share cached
+// configs. The client is nil if it cannot be compared in which case the config is not cached
+type vaultAuthConfigKey struct {
+	client     vault.Client
+	secretName string
+}
+
+// ConflictError is returned when saving an auth config to vault if the same user or server has been modified both
+// locally and by another process since the config was loaded
+type ConflictError struct {
+	SecretName string
+	ServerURL  string
+	Username   string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Username != "" {
+		return fmt.Sprintf("the auth config %q has been concurrently modified for user %s on server %s", e.SecretName, e.Username, e.ServerURL)
+	}
+	return fmt.Sprintf("the auth config %q has been concurrently modified for server %s", e.SecretName, e.ServerURL)
+}
+
+// IsConflictError returns true if the error is a ConflictError
+func IsConflictError(err error) bool {
+	_, ok := errors.Cause(err).(*ConflictError)
+	return ok
+}
+
+// vaultAuthIndex the index entry of an auth config in vault. Each server is stored in its own immutable entry which
+// is referenced by the index, so a server is only visible once the index referencing it has been written
+type vaultAuthIndex struct {
+	AuthConfig
+	ServerRefs []vaultAuthServerRef `json:"serverRefs,omitempty"`
+}
+
+// vaultAuthServerRef references the entry storing a server
+type vaultAuthServerRef struct {
+	Key string `json:"key"`
+	ID  string `json:"id"`
+}
+
+// vaultAuthSnapshot the state of an auth config in vault along with the version of its index
+type vaultAuthSnapshot struct {
+	config       AuthConfig
+	indexVersion int
+	serverRefs   map[string]string
+	legacy       bool
+}
+
+type vaultAuthCacheEntry struct {
+	snapshot *vaultAuthSnapshot
+	loaded   time.Time
+}
+
+// LoadConfig loads the config from the vault, using the in process cache if it has not expired
 func (v *VaultAuthConfigSaver) LoadConfig() (*AuthConfig, error) {
-	data, err := v.vaultClient.ReadYaml(vault.AuthSecretPath(v.secretName))
+	snapshot := v.cachedSnapshot()
+	if snapshot == nil {
+		var err error
+		snapshot, err = v.readSnapshot()
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading the auth config %q from vault", v.secretName)
+		}
+		v.cacheSnapshot(snapshot)
+	}
+	v.base = snapshot
+	return copyAuthConfig(&snapshot.config)
+}
+
+// SaveConfig saves the config to the vault. Any changes made by other processes since the config was loaded are
+// merged in and the given config is updated with the result. If the same server or user was modified both locally
+// and remotely a ConflictError is returned. If the config was not loaded first the local changes replace the remote
+// servers and users they modify
+func (v *VaultAuthConfigSaver) SaveConfig(config *AuthConfig) error {
+	lock := vaultAuthConfigLock(v.cacheKey())
+	lock.Lock()
+	defer lock.Unlock()
+
+	base := v.base
+	var err error
+	for i := 0; i < maxSaveAttempts; i++ {
+		var remote *vaultAuthSnapshot
+		remote, err = v.readSnapshot()
+		if err != nil {
+			return errors.Wrapf(err, "reading the auth config %q from vault", v.secretName)
+		}
+		if base == nil {
+			base = remote
+		}
+		var merged *AuthConfig
+		merged, err = mergeAuthConfigs(v.secretName, &base.config, config, &remote.config)
+		if err != nil {
+			return err
+		}
+		var saved *vaultAuthSnapshot
+		saved, err = v.writeSnapshot(remote, merged)
+		if err == nil {
+			v.base = saved
+			v.cacheSnapshot(saved)
+			*config = *merged
+			return nil
+		}
+		if !vault.IsCASError(err) {
+			return err
+		}
+	}
+	return errors.Wrapf(err, "saving the auth config %q in vault after %d attempts", v.secretName, maxSaveAttempts)
+}
+
+// readSnapshot reads the current state of the auth config from vault falling back to the legacy single entry. If a
+// server entry is deleted by a concurrent save while reading, the index is read again
+func (v *VaultAuthConfigSaver) readSnapshot() (*vaultAuthSnapshot, error) {
+	var err error
+	for i := 0; i < maxSaveAttempts; i++ {
+		var snapshot *vaultAuthSnapshot
+		snapshot, err = v.readIndexSnapshot()
+		if err == nil {
+			return snapshot, nil
+		}
+		if !isMissingServerEntry(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// readIndexSnapshot reads the index and the server entries it references
+func (v *VaultAuthConfigSaver) readIndexSnapshot() (*vaultAuthSnapshot, error) {
+	client, err := v.versionedClient()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &vaultAuthSnapshot{
+		serverRefs: map[string]string{},
+	}
+	data, version, err := client.ReadVersion(v.indexPath())
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s from vault", v.indexPath())
+	}
+	snapshot.indexVersion = version
+	if data == nil {
+		return v.readLegacySnapshot(snapshot)
+	}
+	index := vaultAuthIndex{}
+	err = decodeVaultYaml(v.indexPath(), data, &index)
 	if err != nil {
-		return nil, errors.Wrapf(err, "loading the auth config %q from vault", v.secretName)
+		return nil, err
 	}
+	snapshot.config = index.AuthConfig
+	snapshot.config.Servers = nil
+	for _, ref := range index.ServerRefs {
+		path := v.serverPath(ref.Key, ref.ID)
+		data, _, err := client.ReadVersion(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s from vault", path)
+		}
+		if data == nil {
+			return nil, &missingServerEntryError{path: path}
+		}
+		server := &AuthServer{}
+		err = decodeVaultYaml(path, data, server)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.config.Servers = append(snapshot.config.Servers, server)
+		snapshot.serverRefs[ref.Key] = ref.ID
+	}
+	return snapshot, nil
+}
 
-	var config AuthConfig
-	if data == "" {
-		return &config, nil
+// readLegacySnapshot reads the auth config from the single entry used by older binaries
+func (v *VaultAuthConfigSaver) readLegacySnapshot(snapshot *vaultAuthSnapshot) (*vaultAuthSnapshot, error) {
+	path := v.legacyPath()
+	exists, err := v.exists(path)
+	if err != nil || !exists {
+		return snapshot, err
+	}
+	data, err := v.vaultClient.Read(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s from vault", path)
 	}
+	if data == nil {
+		return snapshot, nil
+	}
+	err = decodeVaultYaml(path, data, &snapshot.config)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.legacy = true
+	return snapshot, nil
+}
 
-	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
-		return nil, errors.Wrapf(err, "unmarshalling auth config %q", v.secretName)
+// writeSnapshot writes new entries for the servers which differ from the remote snapshot and then writes the index
+// referencing them if the index has not been modified since it was read. Entries which are no longer referenced are
+// deleted once the index has been written, or straight away if the index has been modified
+func (v *VaultAuthConfigSaver) writeSnapshot(remote *vaultAuthSnapshot, config *AuthConfig) (*vaultAuthSnapshot, error) {
+	client, err := v.versionedClient()
+	if err != nil {
+		return nil, err
+	}
+	saved := &vaultAuthSnapshot{
+		serverRefs: map[string]string{},
+	}
+	remoteServers := map[string]*AuthServer{}
+	for _, s := range remote.config.Servers {
+		remoteServers[serverKey(s.URL)] = s
+	}
+	index := vaultAuthIndex{
+		AuthConfig: *config,
+	}
+	index.Servers = nil
+	written := []string{}
+	for _, s := range config.Servers {
+		key := serverKey(s.URL)
+		id := remote.serverRefs[key]
+		if id == "" || !sameYaml(remoteServers[key], s) {
+			id, err = util.RandStringBytesMaskImprSrc(10)
+			if err != nil {
+				return nil, errors.Wrap(err, "generating the id of a server entry")
+			}
+			id = strings.ToLower(id)
+			err = v.writeEntry(v.serverPath(key, id), s)
+			if err != nil {
+				v.deleteEntries(client, written)
+				return nil, err
+			}
+			written = append(written, v.serverPath(key, id))
+		}
+		index.ServerRefs = append(index.ServerRefs, vaultAuthServerRef{Key: key, ID: id})
+		saved.serverRefs[key] = id
+	}
+
+	saved.indexVersion = remote.indexVersion
+	if remote.legacy || remote.indexVersion == 0 || len(written) > 0 || !sameYaml(&remote.config, config) {
+		data, err := encodeVaultYaml(v.indexPath(), &index)
+		if err != nil {
+			v.deleteEntries(client, written)
+			return nil, err
+		}
+		saved.indexVersion, err = client.WriteCAS(v.indexPath(), data, remote.indexVersion)
+		if err != nil {
+			v.deleteEntries(client, written)
+			if vault.IsCASError(err) {
+				return nil, err
+			}
+			return nil, errors.Wrapf(err, "saving %s in vault", v.indexPath())
+		}
+	}
+
+	unused := []string{}
+	for key, id := range remote.serverRefs {
+		if saved.serverRefs[key] != id {
+			unused = append(unused, v.serverPath(key, id))
+		}
+	}
+	v.deleteEntries(client, unused)
+
+	copied, err := copyAuthConfig(config)
+	if err != nil {
+		return nil, err
 	}
-	return &config, nil
+	saved.config = *copied
+	return saved, nil
 }
 
-// SaveConfig saves the config to the vault
-func (v *VaultAuthConfigSaver) SaveConfig(config *AuthConfig) error {
-	data, err := yaml.Marshal(config)
+// deleteEntries deletes the entries which are no longer referenced by the index. Failures are only logged as the
+// entries are not visible to readers
+func (v *VaultAuthConfigSaver) deleteEntries(client vault.VersionedClient, paths []string) {
+	for _, path := range paths {
+		err := client.Delete(path)
+		if err != nil {
+			log.Logger().Debugf("failed to delete the unused entry %s from vault: %s", path, err)
+		}
+	}
+}
+
+// writeEntry writes the value as YAML
+func (v *VaultAuthConfigSaver) writeEntry(path string, value interface{}) error {
+	data, err := encodeVaultYaml(path, value)
 	if err != nil {
-		return errors.Wrap(err, "marshaling auth config")
+		return err
 	}
-	if _, err := v.vaultClient.WriteYaml(vault.AuthSecretPath(v.secretName), string(data)); err != nil {
-		return errors.Wrapf(err, "saving auth config %q in vault", v.secretName)
+	_, err = v.vaultClient.Write(path, data)
+	if err != nil {
+		return errors.Wrapf(err, "saving %s in vault", path)
+	}
+	return nil
+}
+
+// versionedClient returns the vault client used for check-and-set writes. Clients which do not support versions
+// fall back to plain reads and writes
+func (v *VaultAuthConfigSaver) versionedClient() (vault.VersionedClient, error) {
+	client, ok := v.vaultClient.(vault.VersionedClient)
+	if !ok {
+		return &unversionedClient{Client: v.vaultClient}, nil
+	}
+	return client, nil
+}
+
+// unversionedClient adapts a Client without versions to a VersionedClient. Writes always succeed so concurrent saves
+// are not detected, and entries are never deleted
+type unversionedClient struct {
+	vault.Client
+}
+
+// ReadVersion reads the secret giving it version 1 if it exists
+func (c *unversionedClient) ReadVersion(secretName string) (map[string]interface{}, int, error) {
+	exists, err := vaultSecretExists(c.Client, secretName)
+	if err != nil || !exists {
+		return nil, 0, err
+	}
+	data, err := c.Read(secretName)
+	if err != nil || data == nil {
+		return nil, 0, err
+	}
+	return data, 1, nil
+}
+
+// WriteCAS writes the secret whatever its current version
+func (c *unversionedClient) WriteCAS(secretName string, data map[string]interface{}, version int) (int, error) {
+	_, err := c.Write(secretName, data)
+	if err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
+
+// Delete does nothing as the client cannot delete secrets
+func (c *unversionedClient) Delete(secretName string) error {
+	return nil
+}
+
+// exists returns true if the secret exists in vault
+func (v *VaultAuthConfigSaver) exists(path string) (bool, error) {
+	return vaultSecretExists(v.vaultClient, path)
+}
+
+// vaultSecretExists returns true if the secret exists in vault
+func vaultSecretExists(client vault.Client, path string) (bool, error) {
+	parent := ""
+	name := path
+	idx := strings.LastIndex(path, "/")
+	if idx >= 0 {
+		parent = path[0:idx]
+		name = path[idx+1:]
+	}
+	names, err := client.List(parent)
+	if err != nil {
+		return false, errors.Wrapf(err, "listing %s in vault", parent)
+	}
+	return util.StringArrayIndex(names, name) >= 0, nil
+}
+
+func (v *VaultAuthConfigSaver) indexPath() string {
+	return vault.AuthSecretPath(vaultAuthConfigFolder + "/" + v.secretName)
+}
+
+func (v *VaultAuthConfigSaver) legacyPath() string {
+	return vault.AuthSecretPath(v.secretName)
+}
+
+func (v *VaultAuthConfigSaver) serverPath(key string, id string) string {
+	return strings.Join([]string{v.indexPath(), vaultServersFolder, key, id}, "/")
+}
+
+// cacheKey returns the key of the config in the in process cache
+func (v *VaultAuthConfigSaver) cacheKey() vaultAuthConfigKey {
+	key := vaultAuthConfigKey{secretName: v.secretName}
+	if v.vaultClient != nil && reflect.TypeOf(v.vaultClient).Comparable() {
+		key.client = v.vaultClient
+	}
+	return key
+}
+
+func (v *VaultAuthConfigSaver) cachedSnapshot() *vaultAuthSnapshot {
+	key := v.cacheKey()
+	if v.cacheTTL <= 0 || key.client == nil {
+		return nil
+	}
+	vaultAuthConfigMutex.Lock()
+	defer vaultAuthConfigMutex.Unlock()
+	entry := vaultAuthConfigCache[key]
+	if entry == nil || v.now().Sub(entry.loaded) > v.cacheTTL {
+		return nil
+	}
+	return entry.snapshot
+}
+
+func (v *VaultAuthConfigSaver) cacheSnapshot(snapshot *vaultAuthSnapshot) {
+	key := v.cacheKey()
+	if v.cacheTTL <= 0 || key.client == nil {
+		return
+	}
+	vaultAuthConfigMutex.Lock()
+	defer vaultAuthConfigMutex.Unlock()
+	vaultAuthConfigCache[key] = &vaultAuthCacheEntry{
+		snapshot: snapshot,
+		loaded:   v.now(),
+	}
+}
+
+// vaultAuthConfigLock returns the lock used to serialise saves of the given config within this process
+func vaultAuthConfigLock(key vaultAuthConfigKey) *sync.Mutex {
+	vaultAuthConfigMutex.Lock()
+	defer vaultAuthConfigMutex.Unlock()
+	lock := vaultAuthConfigLocks[key]
+	if lock == nil {
+		lock = &sync.Mutex{}
+		vaultAuthConfigLocks[key] = lock
+	}
+	return lock
+}
+
+type missingServerEntryError struct {
+	path string
+}
+
+func (e *missingServerEntryError) Error() string {
+	return fmt.Sprintf("the server entry %s is missing", e.path)
+}
+
+func isMissingServerEntry(err error) bool {
+	_, ok := errors.Cause(err).(*missingServerEntryError)
+	return ok
+}
+
+// encodeVaultYaml encodes the value as base64 encoded YAML
+func encodeVaultYaml(path string, value interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshalling %s", path)
+	}
+	return map[string]interface{}{
+		vaultYamlKey: base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// decodeVaultYaml decodes the base64 encoded YAML stored in the data into the value
+func decodeVaultYaml(path string, data map[string]interface{}, value interface{}) error {
+	text, ok := data[vaultYamlKey].(string)
+	if !ok {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return errors.Wrapf(err, "decoding the data stored at %s", path)
+	}
+	err = yaml.Unmarshal(decoded, value)
+	if err != nil {
+		return errors.Wrapf(err, "unmarshalling the data stored at %s", path)
 	}
 	return nil
 }
 
+func serverKey(url string) string {
+	return naming.ToValidName(url)
+}
+
 // NewVaultAuthConfigService creates a new ConfigService that saves it config to a Vault
 func NewVaultAuthConfigService(secretName string, vaultClient vault.Client) ConfigService {
-	saver := newVaultAuthConfigSaver(secretName, vaultClient)
+	return NewCachingVaultAuthConfigService(secretName, vaultClient, DefaultVaultAuthConfigCacheTTL)
+}
+
+// NewCachingVaultAuthConfigService creates a new ConfigService that saves it config to a Vault caching the loaded
+// config in process for the given time to live. The cache is shared by the services using the same vault client. A
+// zero time to live disables caching
+func NewCachingVaultAuthConfigService(secretName string, vaultClient vault.Client, cacheTTL time.Duration) ConfigService {
+	saver := newVaultAuthConfigSaver(secretName, vaultClient, cacheTTL)
 	return NewAuthConfigService(&saver)
 }
 
 // newVaultAuthConfigSaver creates a ConfigSaver that saves the Configs under a specified secretname in a vault
-func newVaultAuthConfigSaver(secretName string, vaultClient vault.Client) VaultAuthConfigSaver {
+func newVaultAuthConfigSaver(secretName string, vaultClient vault.Client, cacheTTL time.Duration) VaultAuthConfigSaver {
 	return VaultAuthConfigSaver{
 		secretName:  secretName,
 		vaultClient: vaultClient,
+		cacheTTL:    cacheTTL,
+		now:         time.Now,
 	}
 }
@@ -0,0 +1,264 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/vault"
+	"github.com/jenkins-x/jx/pkg/vault/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	githubURL = "https://github.com"
+	gitlabURL = "https://gitlab.com"
+)
+
+func TestVaultAuthConfigSaverStoresServersSeparately(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := fake.NewFakeVaultClient()
+	svc := auth.NewCachingVaultAuthConfigService("separateAuth.yaml", vaultClient, 0)
+	_, err := svc.LoadConfig()
+	require.NoError(t, err)
+
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(gitlabURL, &auth.UserAuth{Username: user2, ApiToken: "token2"})
+	require.NoError(t, err)
+
+	names, err := vaultClient.List("auth/v2/separateAuth.yaml/servers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https-github-com/", "https-gitlab-com/"}, names)
+
+	other := auth.NewCachingVaultAuthConfigService("separateAuth.yaml", vaultClient, 0)
+	config, err := other.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(config.Servers))
+	assert.Equal(t, "token1", config.FindUserAuth(githubURL, user1).ApiToken)
+	assert.Equal(t, "token2", config.FindUserAuth(gitlabURL, user2).ApiToken)
+	assert.Equal(t, gitlabURL, config.CurrentServer)
+}
+
+func TestVaultAuthConfigSaverMergesConcurrentChanges(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := fake.NewFakeVaultClient()
+	cli := auth.NewCachingVaultAuthConfigService("mergeAuth.yaml", vaultClient, 0)
+	_, err := cli.LoadConfig()
+	require.NoError(t, err)
+	err = cli.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+
+	controller := auth.NewCachingVaultAuthConfigService("mergeAuth.yaml", vaultClient, 0)
+	_, err = controller.LoadConfig()
+	require.NoError(t, err)
+
+	// both processes update different users and servers concurrently
+	err = cli.SaveUserAuth(githubURL, &auth.UserAuth{Username: user2, ApiToken: "token2"})
+	require.NoError(t, err)
+	err = controller.SaveUserAuth(gitlabURL, &auth.UserAuth{Username: user1, ApiToken: "token3"})
+	require.NoError(t, err)
+
+	config := controller.Config()
+	assert.Equal(t, "token1", config.FindUserAuth(githubURL, user1).ApiToken)
+	assert.Equal(t, "token2", config.FindUserAuth(githubURL, user2).ApiToken, "the concurrent change should have been merged")
+	assert.Equal(t, "token3", config.FindUserAuth(gitlabURL, user1).ApiToken)
+
+	reloaded := auth.NewCachingVaultAuthConfigService("mergeAuth.yaml", vaultClient, 0)
+	config, err = reloaded.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(config.Servers))
+	assert.Equal(t, 2, len(config.GetServer(githubURL).Users))
+}
+
+func TestVaultAuthConfigSaverDetectsConflicts(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := fake.NewFakeVaultClient()
+	first := auth.NewCachingVaultAuthConfigService("conflictAuth.yaml", vaultClient, 0)
+	_, err := first.LoadConfig()
+	require.NoError(t, err)
+	err = first.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+
+	second := auth.NewCachingVaultAuthConfigService("conflictAuth.yaml", vaultClient, 0)
+	_, err = second.LoadConfig()
+	require.NoError(t, err)
+
+	err = first.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "first"})
+	require.NoError(t, err)
+	err = second.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "second"})
+	require.Error(t, err)
+	assert.True(t, auth.IsConflictError(err), "expected a conflict error but got %s", err)
+}
+
+func TestVaultAuthConfigSaverCachesConfig(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewFakeVaultClient()
+	vaultClient := &fakeClient
+	svc := auth.NewCachingVaultAuthConfigService("cachedAuth.yaml", vaultClient, time.Hour)
+	_, err := svc.LoadConfig()
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+
+	// lets remove the data from vault, the cached copy should still be used
+	for k := range vaultClient.Data {
+		delete(vaultClient.Data, k)
+	}
+	cached := auth.NewCachingVaultAuthConfigService("cachedAuth.yaml", vaultClient, time.Hour)
+	config, err := cached.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "token1", config.FindUserAuth(githubURL, user1).ApiToken)
+
+	uncached := auth.NewCachingVaultAuthConfigService("cachedAuth.yaml", vaultClient, 0)
+	config, err = uncached.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(config.Servers))
+
+	otherClient := fake.NewFakeVaultClient()
+	otherVault := auth.NewCachingVaultAuthConfigService("cachedAuth.yaml", &otherClient, time.Hour)
+	config, err = otherVault.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(config.Servers), "the config cached for another vault client should not be used")
+}
+
+// unversionedVaultClient only supports the methods of a vault.Client
+type unversionedVaultClient struct {
+	vault.Client
+}
+
+func TestVaultAuthConfigSaverWithUnversionedClient(t *testing.T) {
+	t.Parallel()
+
+	fakeClient := fake.NewFakeVaultClient()
+	vaultClient := unversionedVaultClient{Client: &fakeClient}
+	svc := auth.NewCachingVaultAuthConfigService("unversionedAuth.yaml", vaultClient, 0)
+	_, err := svc.LoadConfig()
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user2, ApiToken: "token2"})
+	require.NoError(t, err)
+
+	reloaded := auth.NewCachingVaultAuthConfigService("unversionedAuth.yaml", vaultClient, 0)
+	config, err := reloaded.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "token1", config.FindUserAuth(githubURL, user1).ApiToken)
+	assert.Equal(t, "token2", config.FindUserAuth(githubURL, user2).ApiToken)
+}
+
+func TestVaultAuthConfigSaverLoadsLegacyConfig(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := fake.NewFakeVaultClient()
+	_, err := vaultClient.WriteYaml("auth/legacyAuth.yaml", `servers:
+- url: https://github.com
+  users:
+  - username: someone
+    apitoken: legacy
+currentserver: https://github.com
+`)
+	require.NoError(t, err)
+
+	svc := auth.NewCachingVaultAuthConfigService("legacyAuth.yaml", vaultClient, 0)
+	config, err := svc.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", config.FindUserAuth(githubURL, user1).ApiToken)
+
+	err = svc.SaveUserAuth(gitlabURL, &auth.UserAuth{Username: user2, ApiToken: "token2"})
+	require.NoError(t, err)
+
+	reloaded := auth.NewCachingVaultAuthConfigService("legacyAuth.yaml", vaultClient, 0)
+	config, err = reloaded.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", config.FindUserAuth(githubURL, user1).ApiToken)
+	assert.Equal(t, "token2", config.FindUserAuth(gitlabURL, user2).ApiToken)
+
+	// the legacy entry read by older binaries should be left alone
+	assert.Equal(t, 1, vaultClient.Versions["auth/legacyAuth.yaml"])
+}
+
+func TestVaultAuthConfigSaverDeletesRemovedServers(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := fake.NewFakeVaultClient()
+	svc := auth.NewCachingVaultAuthConfigService("deleteAuth.yaml", vaultClient, 0)
+	_, err := svc.LoadConfig()
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(gitlabURL, &auth.UserAuth{Username: user2, ApiToken: "token2"})
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(gitlabURL, &auth.UserAuth{Username: user2, ApiToken: "token3"})
+	require.NoError(t, err)
+
+	config := svc.Config()
+	config.Servers = config.Servers[1:]
+	err = svc.SaveConfig()
+	require.NoError(t, err)
+
+	names, err := vaultClient.List("auth/v2/deleteAuth.yaml/servers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https-gitlab-com/"}, names)
+	ids, err := vaultClient.List("auth/v2/deleteAuth.yaml/servers/https-gitlab-com")
+	require.NoError(t, err)
+	assert.Len(t, ids, 1, "superseded server entries should be deleted")
+
+	reloaded := auth.NewCachingVaultAuthConfigService("deleteAuth.yaml", vaultClient, 0)
+	config, err = reloaded.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(config.Servers))
+	assert.Equal(t, "token3", config.FindUserAuth(gitlabURL, user2).ApiToken)
+}
+
+func TestVaultAuthConfigSaverSaveWithoutLoad(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := fake.NewFakeVaultClient()
+	svc := auth.NewCachingVaultAuthConfigService("unloadedAuth.yaml", vaultClient, 0)
+	_, err := svc.LoadConfig()
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.NoError(t, err)
+
+	unloaded := auth.NewCachingVaultAuthConfigService("unloadedAuth.yaml", vaultClient, 0)
+	unloaded.Config()
+	err = unloaded.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token2"})
+	require.NoError(t, err, "saving without loading should not report a conflict")
+
+	reloaded := auth.NewCachingVaultAuthConfigService("unloadedAuth.yaml", vaultClient, 0)
+	config, err := reloaded.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "token2", config.FindUserAuth(githubURL, user1).ApiToken)
+}
+
+// conflictingVaultClient modifies the index before every check-and-set write
+type conflictingVaultClient struct {
+	fake.FakeVaultClient
+}
+
+func (c conflictingVaultClient) WriteCAS(secretName string, data map[string]interface{}, version int) (int, error) {
+	c.Versions[secretName]++
+	return c.FakeVaultClient.WriteCAS(secretName, data, version)
+}
+
+func TestVaultAuthConfigSaverLeavesNoPartialWrites(t *testing.T) {
+	t.Parallel()
+
+	vaultClient := conflictingVaultClient{FakeVaultClient: fake.NewFakeVaultClient()}
+	svc := auth.NewCachingVaultAuthConfigService("partialAuth.yaml", vaultClient, 0)
+	_, err := svc.LoadConfig()
+	require.NoError(t, err)
+	err = svc.SaveUserAuth(githubURL, &auth.UserAuth{Username: user1, ApiToken: "token1"})
+	require.Error(t, err)
+
+	names, err := vaultClient.List("auth/v2/partialAuth.yaml/servers/https-github-com")
+	require.NoError(t, err)
+	assert.Empty(t, names, "the server entries of a failed save should be deleted")
+	assert.NotContains(t, vaultClient.Data, "auth/v2/partialAuth.yaml")
+}
@@ -2,6 +2,7 @@ package add
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
@@ -28,18 +29,19 @@ type AddAppOptions struct {
 	GitOps bool
 	DevEnv *jenkinsv1.Environment
 
-	Repo        string
-	Username    string
-	Password    string
-	Alias       string
-	Prefixes    []string
-	Namespace   string
-	Version     string
-	ReleaseName string
-	SetValues   []string
-	ValuesFiles []string
-	HelmUpdate  bool
-	AutoMerge   bool
+	Repo          string
+	Username      string
+	Password      string
+	Alias         string
+	Prefixes      []string
+	Namespace     string
+	Version       string
+	ReleaseName   string
+	SetValues     []string
+	ValuesFiles   []string
+	HelmUpdate    bool
+	AutoMerge     bool
+	UpgradePolicy string
 }
 
 const (
@@ -48,6 +50,7 @@ const (
 	optionSet        = "set"
 	optionAlias      = "alias"
 	optionNamespace  = "namespace"
+	optionPolicy     = "upgrade-policy"
 )
 
 var (
@@ -59,7 +62,10 @@ var (
 		jx add app jx-app-jacoco
 
 		# Add an app from a local path
-		jx add app .`)
+		jx add app .
+
+		# Add an app which is only upgraded to newer patch releases when upgrading all apps
+		jx add app jx-app-jacoco --upgrade-policy patch`)
 )
 
 // NewCmdAddApp creates a command object for the "create" command
@@ -109,6 +115,8 @@ func (o *AddAppOptions) addFlags(cmd *cobra.Command, defaultNamespace string) {
 	cmd.Flags().StringArrayVarP(&o.SetValues, optionSet, "s", []string{},
 		"The chart set values (can specify multiple or separate values with commas: key1=val1,key2=val2) (available when NOT using GitOps for your dev environment)")
 	cmd.Flags().BoolVarP(&o.AutoMerge, "auto-merge", "", false, "Automatically merge GitOps pull requests that pass CI")
+	cmd.Flags().StringVarP(&o.UpgradePolicy, optionPolicy, "", "",
+		"The upgrade policy used when upgrading all apps. One of: "+strings.Join(apps.UpgradePolicies, ", "))
 }
 
 // Run implements this command
@@ -149,6 +157,12 @@ func (o *AddAppOptions) Run() error {
 		JxClient:       jxClient,
 		InstallTimeout: opts.DefaultInstallTimeout,
 	}
+	if o.UpgradePolicy != "" {
+		installOpts.UpgradePolicy, err = apps.ParseUpgradePolicy(o.UpgradePolicy)
+		if err != nil {
+			return util.InvalidOptionError(optionPolicy, o.UpgradePolicy, err)
+		}
+	}
 
 	if o.GitOps {
 		msg := "unable to specify --%s when using GitOps for your dev environment"
@@ -0,0 +1,289 @@
+package approve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	optionEnvironment = "env"
+)
+
+// ApproveOptions the options for the approve and reject commands
+type ApproveOptions struct {
+	*opts.CommonOptions
+
+	Approved    bool
+	Environment string
+	Comment     string
+}
+
+var (
+	approveLong = templates.LongDesc(`
+		Approves the promotion of a PipelineActivity to an Environment which is waiting for manual approval.
+
+		Manual approval gates are configured on the promote steps of a Workflow via the approval precondition. Only
+		the configured approvers or members of the configured teams can approve a promotion.
+
+		The decision is made as the user authenticated with the git server of the team.
+`)
+
+	approveExample = templates.Examples(`
+		# pick a pipeline which is waiting for approval and approve it
+		jx approve
+
+		# approve the promotion of a pipeline to production
+		jx approve jstrachan-myapp-master-3 --env production -m "looks good"
+`)
+
+	rejectLong = templates.LongDesc(`
+		Rejects the promotion of a PipelineActivity to an Environment which is waiting for manual approval.
+
+		Rejecting a promotion aborts the Workflow of the PipelineActivity.
+`)
+
+	rejectExample = templates.Examples(`
+		# pick a pipeline which is waiting for approval and reject it
+		jx reject
+
+		# reject the promotion of a pipeline to production
+		jx reject jstrachan-myapp-master-3 --env production -m "failed the smoke tests"
+`)
+)
+
+// NewCmdApprove creates the command to approve a promotion
+func NewCmdApprove(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ApproveOptions{
+		CommonOptions: commonOpts,
+		Approved:      true,
+	}
+	cmd := &cobra.Command{
+		Use:     "approve [activity]",
+		Short:   "Approves the promotion of a pipeline to an environment",
+		Long:    approveLong,
+		Example: approveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+// NewCmdReject creates the command to reject a promotion
+func NewCmdReject(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ApproveOptions{
+		CommonOptions: commonOpts,
+		Approved:      false,
+	}
+	cmd := &cobra.Command{
+		Use:     "reject [activity]",
+		Short:   "Rejects the promotion of a pipeline to an environment",
+		Long:    rejectLong,
+		Example: rejectExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+func (o *ApproveOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Environment, optionEnvironment, "e", "", "The Environment the promotion is waiting for approval to")
+	cmd.Flags().StringVarP(&o.Comment, "comment", "m", "", "A comment to record with the decision")
+}
+
+// Run implements this command
+func (o *ApproveOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	user, err := o.authenticatedUser()
+	if err != nil {
+		return err
+	}
+
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	activity, err := o.pickActivity(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	envName, err := o.pickEnvironment(activity)
+	if err != nil {
+		return err
+	}
+
+	userTeams, err := workflow.GetUserTeams(jxClient, ns)
+	if err != nil {
+		return err
+	}
+	teams := userTeams[user]
+	approval, err := findApproval(jxClient, ns, activity, envName)
+	if err != nil {
+		return err
+	}
+	if !workflow.CanApprove(approval, user, teams) {
+		return fmt.Errorf("user %s is not allowed to approve the promotion of %s to %s", user, activity.Name, envName)
+	}
+
+	decision := v1.ApprovalDecision{
+		Approver:  user,
+		Teams:     teams,
+		Approved:  o.Approved,
+		Comment:   o.Comment,
+		Timestamp: &metav1.Time{Time: time.Now()},
+	}
+	err = workflow.RecordApprovalDecision(activity, envName, decision)
+	if err != nil {
+		return err
+	}
+	name := activity.Name
+	activity, err = activities.PatchUpdate(activity)
+	if err != nil {
+		return errors.Wrapf(err, "updating PipelineActivity %s", name)
+	}
+	_, err = workflow.RecordApprovalFact(jxClient, ns, activity, envName, decision)
+	if err != nil {
+		return err
+	}
+
+	if o.Approved {
+		log.Logger().Infof("Approved the promotion of %s to %s", util.ColorInfo(activity.Name), util.ColorInfo(envName))
+	} else {
+		log.Logger().Infof("Rejected the promotion of %s to %s", util.ColorInfo(activity.Name), util.ColorInfo(envName))
+	}
+	return nil
+}
+
+// pickActivity returns the activity from the arguments or lets the user pick one of the activities waiting for approval
+func (o *ApproveOptions) pickActivity(jxClient versioned.Interface, ns string) (*v1.PipelineActivity, error) {
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	if len(o.Args) > 0 {
+		name := o.Args[0]
+		activity, err := activities.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting PipelineActivity %s", name)
+		}
+		return activity, nil
+	}
+	list, err := activities.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing PipelineActivities")
+	}
+	waiting := map[string]*v1.PipelineActivity{}
+	names := []string{}
+	for i := range list.Items {
+		activity := &list.Items[i]
+		envs := workflow.WaitingForApprovalEnvironments(activity)
+		if len(envs) == 0 || (o.Environment != "" && util.StringArrayIndex(envs, o.Environment) < 0) {
+			continue
+		}
+		waiting[activity.Name] = activity
+		names = append(names, activity.Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("there are no pipelines waiting for approval")
+	}
+	sort.Strings(names)
+	if len(names) == 1 {
+		return waiting[names[0]], nil
+	}
+	if o.BatchMode {
+		return nil, fmt.Errorf("there are %d pipelines waiting for approval so please specify the activity: %s", len(names), strings.Join(names, ", "))
+	}
+	name, err := util.PickName(names, "Pick the pipeline waiting for approval", "", o.In, o.Out, o.Err)
+	if err != nil {
+		return nil, err
+	}
+	return waiting[name], nil
+}
+
+// pickEnvironment returns the environment to approve the promotion to
+func (o *ApproveOptions) pickEnvironment(activity *v1.PipelineActivity) (string, error) {
+	envs := workflow.WaitingForApprovalEnvironments(activity)
+	if o.Environment != "" {
+		if util.StringArrayIndex(envs, o.Environment) < 0 {
+			return "", fmt.Errorf("the PipelineActivity %s is not waiting for approval to promote to %s", activity.Name, o.Environment)
+		}
+		return o.Environment, nil
+	}
+	switch len(envs) {
+	case 0:
+		return "", fmt.Errorf("the PipelineActivity %s is not waiting for approval", activity.Name)
+	case 1:
+		return envs[0], nil
+	}
+	if o.BatchMode {
+		return "", util.MissingOptionWithOptions(optionEnvironment, envs)
+	}
+	return util.PickName(envs, "Pick the environment to promote to", "", o.In, o.Out, o.Err)
+}
+
+// findApproval returns the approval precondition of the promote step to the environment in the workflow of the activity
+func findApproval(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, envName string) (*v1.WorkflowApproval, error) {
+	name := activity.Spec.Workflow
+	if name == "" {
+		return nil, fmt.Errorf("the PipelineActivity %s has no Workflow", activity.Name)
+	}
+	flow, err := jxClient.JenkinsV1().Workflows(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting Workflow %s", name)
+	}
+	for _, step := range flow.Spec.Steps {
+		if step.Promote != nil && step.Promote.Environment == envName {
+			return step.Preconditions.Approval, nil
+		}
+	}
+	return nil, fmt.Errorf("the Workflow %s has no promote step for Environment %s", name, envName)
+}
+
+// authenticatedUser returns the name of the user authenticated with the git server of the team. The name is not
+// taken from a flag or the local environment so that users can only make decisions as themselves
+func (o *ApproveOptions) authenticatedUser() (string, error) {
+	if o.InCluster() {
+		return "", fmt.Errorf("promotions can only be approved or rejected by users and not from inside the cluster")
+	}
+	devEnv, _, err := o.DevEnvAndTeamSettings()
+	if err != nil {
+		return "", errors.Wrap(err, "getting the development Environment")
+	}
+	gitURL := devEnv.Spec.Source.URL
+	if gitURL == "" {
+		return "", fmt.Errorf("the development Environment %s has no git repository to authenticate the user with", devEnv.Name)
+	}
+	provider, _, err := o.CreateGitProviderForURLWithoutKind(gitURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating the git provider for %s", gitURL)
+	}
+	user := provider.CurrentUsername()
+	if user == "" {
+		return "", fmt.Errorf("no git user is authenticated with %s", provider.ServerURL())
+	}
+	// lets check the credentials of the user are accepted by the git server
+	if provider.UserInfo(user) == nil {
+		return "", fmt.Errorf("failed to authenticate the git user %s with %s", user, provider.ServerURL())
+	}
+	return user, nil
+}
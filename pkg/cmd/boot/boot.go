@@ -7,6 +7,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/cmd/namespace"
 	"github.com/jenkins-x/jx/pkg/cmd/step/create"
@@ -152,7 +153,7 @@ func (o *BootOptions) Run() error {
 
 		if o.GitURL == "" && o.GitRef == "" {
 			// If the GitURL is not overridden and the GitRef is set to it's default value then look up the version number
-			resolver, err := o.CreateVersionResolver(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+			resolver, err := o.CreateVersionResolverWithOverrides(requirements.VersionStream.URL, requirements.VersionStream.Ref, requirements.VersionStream.Overrides)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create version resolver")
 			}
@@ -261,7 +262,7 @@ func (o *BootOptions) Run() error {
 		"BASE_CONFIG_REF": gitRef,
 	}
 
-	so.VersionResolver, err = o.CreateVersionResolver(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+	so.VersionResolver, err = o.CreateVersionResolverWithOverrides(requirements.VersionStream.URL, requirements.VersionStream.Ref, requirements.VersionStream.Overrides)
 	if err != nil {
 		return errors.Wrapf(err, "there was a problem creating a version resolver from versions stream repository %s and ref %s", requirements.VersionStream.URL, requirements.VersionStream.Ref)
 	}
@@ -278,6 +279,11 @@ func (o *BootOptions) Run() error {
 		return errors.Wrapf(err, "failed to interpret pipeline file %s", pipelineFile)
 	}
 
+	err = o.UpdateVersionStreamOverrides(requirements)
+	if err != nil {
+		return err
+	}
+
 	// if we can find the deploy namespace lets switch kubernetes context to it so the user can use `jx` commands immediately
 	if ns != "" {
 		no := &namespace.NamespaceOptions{}
@@ -289,6 +295,35 @@ func (o *BootOptions) Run() error {
 	return nil
 }
 
+// UpdateVersionStreamOverrides copies the version stream overrides of the requirements onto the team settings of the
+// dev Environment so that pipelines layer the same overrides on top of the version stream
+func (o *BootOptions) UpdateVersionStreamOverrides(requirements *config.RequirementsConfig) error {
+	var overrides []v1.VersionStreamOverride
+	for _, override := range requirements.VersionStream.Overrides {
+		exists, err := util.DirExists(override.URL)
+		if err != nil {
+			return err
+		}
+		if exists {
+			log.Logger().Warnf("The version stream override %s is a local directory so it is not used by pipelines", util.ColorWarning(override.Name))
+			continue
+		}
+		overrides = append(overrides, v1.VersionStreamOverride{
+			Name: override.Name,
+			URL:  override.URL,
+			Ref:  override.Ref,
+		})
+	}
+	err := o.ModifyDevEnvironment(func(env *v1.Environment) error {
+		env.Spec.TeamSettings.VersionStreamOverrides = overrides
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to update the version stream overrides of the dev Environment")
+	}
+	return nil
+}
+
 func (o *BootOptions) defaultVersionStream(requirements *config.RequirementsConfig) {
 	if requirements.VersionStream.URL == "" && requirements.VersionStream.Ref == "" {
 		requirements.VersionStream.URL = o.VersionStreamURL
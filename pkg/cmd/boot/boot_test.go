@@ -3,7 +3,9 @@ package boot_test
 import (
 	"testing"
 
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/cmd/boot"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,3 +25,27 @@ func TestFindBootNamespace(t *testing.T) {
 
 	assert.Equal(t, "jx", ns, "FindBootNamespace")
 }
+
+func TestUpdateVersionStreamOverrides(t *testing.T) {
+	t.Parallel()
+
+	env := &v1.Environment{}
+	o := &boot.BootOptions{
+		CommonOptions: &opts.CommonOptions{},
+	}
+	o.ModifyDevEnvironmentFn = func(callback func(env *v1.Environment) error) error {
+		return callback(env)
+	}
+	requirements := &config.RequirementsConfig{}
+	requirements.VersionStream.Overrides = []config.VersionStreamOverride{
+		{Name: "team", URL: "https://github.com/myorg/team-versions.git", Ref: "master"},
+		{Name: "local", URL: "test_data"},
+	}
+
+	err := o.UpdateVersionStreamOverrides(requirements)
+
+	require.NoError(t, err)
+	assert.Equal(t, []v1.VersionStreamOverride{
+		{Name: "team", URL: "https://github.com/myorg/team-versions.git", Ref: "master"},
+	}, env.Spec.TeamSettings.VersionStreamOverrides, "should copy the git overrides onto the dev Environment")
+}
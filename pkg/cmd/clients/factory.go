@@ -664,6 +664,13 @@ func (f *factory) CreateHelm(verbose bool,
 	} else if noTiller {
 		featureFlag = "no-tiller-server"
 	}
+	if helmBinary == helm.Helm3Binary {
+		// helm 3 has no Tiller so it neither needs a local tiller nor the template mode
+		if verbose {
+			log.Logger().Debugf("Using helmBinary %s with feature flag: %s", util.ColorInfo(helmBinary), util.ColorInfo("helm3"))
+		}
+		return helm.NewHelm3CLI(helmBinary, "", verbose)
+	}
 	if verbose {
 		log.Logger().Debugf("Using helmBinary %s with feature flag: %s", util.ColorInfo(helmBinary), util.ColorInfo(featureFlag))
 	}
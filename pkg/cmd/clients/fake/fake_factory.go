@@ -186,7 +186,7 @@ func (f *FakeFactory) AuthMergePipelineSecrets(config *auth.AuthConfig, secrets
 func (f *FakeFactory) CreateAuthConfigService(configName string, namespace string) (auth.ConfigService, error) {
 	if f.SecretsLocation() == secrets.VaultLocationKind {
 		vaultClient, err := f.CreateSystemVaultClient(namespace)
-		authService := auth.NewVaultAuthConfigService(configName, vaultClient)
+		authService := auth.NewCachingVaultAuthConfigService(configName, vaultClient, 0)
 		return authService, err
 	}
 	return auth.NewFileAuthConfigService(configName)
@@ -102,7 +102,10 @@ func (o *ComplianceResultsOptions) Run() error {
 				return !results.Skipped(tc)
 			}, testResults)
 		sort.Sort(StatusSortedTestCases(testResults))
-		o.printResults(testResults)
+		err = o.printResults(testResults)
+		if err != nil {
+			return err
+		}
 
 		err = <-ec
 		if err != nil {
@@ -142,7 +145,7 @@ func (s StatusSortedTestCases) Less(i, j int) bool {
 }
 func (s StatusSortedTestCases) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-func (o *ComplianceResultsOptions) printResults(junitResults []reporters.JUnitTestCase) {
+func (o *ComplianceResultsOptions) printResults(junitResults []reporters.JUnitTestCase) error {
 	table := o.CreateTable()
 	table.SetColumnAlign(1, util.ALIGN_LEFT)
 	table.SetColumnAlign(2, util.ALIGN_LEFT)
@@ -150,7 +153,7 @@ func (o *ComplianceResultsOptions) printResults(junitResults []reporters.JUnitTe
 	for _, t := range junitResults {
 		table.AddRow(status(t), t.Name, t.ClassName)
 	}
-	table.Render()
+	return table.Render()
 }
 
 func status(junitResult reporters.JUnitTestCase) string {
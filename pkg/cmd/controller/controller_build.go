@@ -1021,7 +1021,7 @@ func (o *ControllerBuildOptions) generateBuildLogURL(podInterface typedcorev1.Po
 		buildNumber = "1"
 	}
 
-	pathDir := filepath.Join("jenkins-x", "logs", owner, repository, branch)
+	pathDir := filepath.Join(collector.ClassifierPath("logs"), owner, repository, branch)
 	fileName := filepath.Join(pathDir, buildNumber+".log")
 
 	var clientErrs []error
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/step/verify"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// ControllerDriftOptions the options for the drift controller
+type ControllerDriftOptions struct {
+	ControllerOptions
+
+	Period       time.Duration
+	Issue        bool
+	Environments []string
+}
+
+var (
+	controllerDriftLong = templates.LongDesc(`
+		Runs the controller which periodically checks that the resources in the namespaces of the permanent
+		Environments match the charts in their git repositories, reporting any drift.
+`)
+
+	controllerDriftExample = templates.Examples(`
+		# check the environments for drift every hour opening an issue on the git repository of any which have drifted
+		jx controller drift --period 1h --issue
+	`)
+)
+
+// NewCmdControllerDrift creates the command for: jx controller drift
+func NewCmdControllerDrift(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &ControllerDriftOptions{
+		ControllerOptions: ControllerOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "drift",
+		Short:   "Runs the controller which periodically detects the drift of the Environments from their git repositories",
+		Long:    controllerDriftLong,
+		Example: controllerDriftExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().DurationVarP(&options.Period, "period", "p", 30*time.Minute, "The period between checks for drift")
+	cmd.Flags().BoolVarP(&options.Issue, "issue", "", false, "Opens an issue on the git repository of each Environment which has drifted, or comments on the open one")
+	cmd.Flags().StringArrayVarP(&options.Environments, "environment", "e", nil, "The names of the Environments to check for drift. Defaults to all the permanent Environments")
+	return cmd
+}
+
+// Run implements this command
+func (o *ControllerDriftOptions) Run() error {
+	verifyOptions := &verify.StepVerifyEnvironmentsOptions{
+		StepVerifyOptions: verify.StepVerifyOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: o.CommonOptions,
+			},
+		},
+		Drift:        true,
+		DriftIssue:   o.Issue,
+		Environments: o.Environments,
+	}
+	log.Logger().Infof("Checking the environments for drift every %s", util.ColorInfo(o.Period))
+	for {
+		err := verifyOptions.Run()
+		if err != nil {
+			log.Logger().Warnf("Failed to check the environments for drift: %s", err)
+		}
+		time.Sleep(o.Period)
+	}
+}
@@ -67,17 +67,32 @@ type ControllerEnvironmentOptions struct {
 	Branch                string
 	PushRef               string
 	Labels                map[string]string
+	Remote                bool
+	Environment           string
+	ReportURL             string
+	ReportGit             bool
+	ReportSecret          string
+	ReportPeriod          time.Duration
 
 	StepCreateTaskOptions create.StepCreateTaskOptions
 	secret                []byte
+	lastCommitStatus      string
 }
 
 var (
-	controllerEnvironmentsLong = templates.LongDesc(`A controller which takes a webhook and updates the environment via GitOps for remote clusters`)
+	controllerEnvironmentsLong = templates.LongDesc(`A controller which takes a webhook and updates the environment via GitOps for remote clusters
+
+		With --remote the controller runs as a lightweight agent in the remote cluster which reports the status of the
+		applications in the environment back to the development cluster. The report is either posted to the
+		'jx controller remote-status' service in the development cluster signed with the key of the environment in the Secret ` + remoteEnvironmentHmacSecret + `
+		or set as a commit status on the deployed revision of the git repository of the environment.`)
 
 	controllerEnvironmentsExample = templates.Examples(`
 			# run the environment controller
 			jx controller environment
+
+			# run the agent reporting the status of the production environment to the development cluster
+			jx controller environment --remote --environment production --report-url https://remote-status.jx.example.com/status
 		`)
 )
 
@@ -114,6 +129,12 @@ func NewCmdControllerEnvironment(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd.Flags().StringVarP(&options.GitRepo, "repo", "", "", "The git repository name. If not specified defaults to $REPO")
 	cmd.Flags().StringVarP(&options.WebHookURL, "webhook-url", "w", "", "The external WebHook URL of this controller to register with the git provider. If not specified defaults to $WEBHOOK_URL")
 	cmd.Flags().StringVarP(&options.PushRef, "push-ref", "", "refs/heads/master", "The git ref passed from the WebHook which should trigger a new deploy pipeline to trigger. Defaults to only webhooks from the master branch")
+	cmd.Flags().BoolVarP(&options.Remote, "remote", "", false, "Runs as an agent in the remote cluster which reports the status of the environment back to the development cluster")
+	cmd.Flags().StringVarP(&options.Environment, "environment", "e", "", "The name of the Environment in the development cluster. If not specified defaults to $ENVIRONMENT")
+	cmd.Flags().StringVarP(&options.ReportURL, "report-url", "", "", "The URL of the remote status service in the development cluster to report the status to. If not specified defaults to $REPORT_URL")
+	cmd.Flags().BoolVarP(&options.ReportGit, "report-git", "", false, "Reports the status as a commit status on the deployed revision of the git repository of the environment")
+	cmd.Flags().StringVarP(&options.ReportSecret, "report-secret", "", remoteEnvironmentHmacSecret, "The Secret containing the key of the environment used to sign the reports")
+	cmd.Flags().DurationVarP(&options.ReportPeriod, "report-period", "", time.Minute, "The period between reports of the status of the environment")
 
 	so := &options.StepCreateTaskOptions
 	so.CommonOptions = commonOpts
@@ -126,6 +147,10 @@ func NewCmdControllerEnvironment(commonOpts *opts.CommonOptions) *cobra.Command
 func (o *ControllerEnvironmentOptions) Run() error {
 	o.RemoteCluster = true
 
+	if o.Remote {
+		return o.runAgent()
+	}
+
 	if o.Path == "" {
 		return util.MissingOption("path")
 	}
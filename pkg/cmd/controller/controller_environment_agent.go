@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/kube/services"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// remoteEnvironmentHmacSecret the Secret in both the remote and development clusters containing the keys used to
+	// sign the status reports of remote Environments. Each Environment has its own key named after the Environment so
+	// that the agent of one Environment cannot report the status of another
+	remoteEnvironmentHmacSecret = "jx-remote-environment-hmac"
+
+	// remoteEnvironmentHeader the header of a status report containing the name of the Environment whose key signed it
+	remoteEnvironmentHeader = "X-Jx-Environment"
+
+	// remoteEnvironmentStatusContext the context of the commit status of the deployment of a remote Environment
+	remoteEnvironmentStatusContext = "jx/environment"
+)
+
+// runAgent runs the lightweight agent in a remote cluster which reports the status of the applications of the
+// Environment back to the development cluster
+func (o *ControllerEnvironmentOptions) runAgent() error {
+	if o.Environment == "" {
+		o.Environment = os.Getenv("ENVIRONMENT")
+		if o.Environment == "" {
+			return util.MissingOption("environment")
+		}
+	}
+	if o.ReportURL == "" {
+		o.ReportURL = os.Getenv("REPORT_URL")
+	}
+	if o.ReportURL == "" && !o.ReportGit {
+		return fmt.Errorf("please specify where to report the status of the environment with --report-url or --report-git")
+	}
+	if o.SourceURL == "" {
+		o.SourceURL = os.Getenv("SOURCE_URL")
+	}
+	if o.SourceURL != "" {
+		gitInfo, err := gits.ParseGitURL(o.SourceURL)
+		if err != nil {
+			return err
+		}
+		o.GitOwner = gitInfo.Organisation
+		o.GitRepo = gitInfo.Name
+	} else if o.ReportGit {
+		return util.MissingOption("source-url")
+	}
+
+	kubeClient, ns, err := o.KubeClientAndNamespace()
+	if err != nil {
+		return err
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	var key []byte
+	if o.ReportURL != "" {
+		key, err = loadRemoteEnvironmentHmac(kubeClient, ns, o.ReportSecret, o.Environment)
+		if err != nil {
+			return err
+		}
+	}
+	if o.ReportGit && !o.NoGitCredeentialsInit {
+		err = o.InitGitConfigAndUser()
+		if err != nil {
+			return err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(healthPath, http.HandlerFunc(o.health))
+	mux.Handle(readyPath, http.HandlerFunc(o.ready))
+	mux.Handle("/", http.HandlerFunc(o.getIndex))
+	go func() {
+		err := http.ListenAndServe(o.BindAddress+":"+strconv.Itoa(o.Port), mux)
+		if err != nil {
+			log.Logger().Errorf("failed to serve the health checks: %s", err)
+		}
+	}()
+
+	log.Logger().Infof("reporting the status of environment %s in namespace %s every %s", util.ColorInfo(o.Environment), util.ColorInfo(ns), o.ReportPeriod.String())
+	for {
+		err = o.reportEnvironmentStatus(kubeClient, jxClient, ns, key)
+		if err != nil {
+			log.Logger().Warnf("failed to report the status of environment %s: %s", o.Environment, err)
+		}
+		time.Sleep(o.ReportPeriod)
+	}
+}
+
+// reportEnvironmentStatus reports the status of the applications in the namespace and the deployed revision of the
+// Environment to the development cluster and/or as a commit status on the git repository of the Environment
+func (o *ControllerEnvironmentOptions) reportEnvironmentStatus(kubeClient kubernetes.Interface, jxClient versioned.Interface, ns string, key []byte) error {
+	apps, err := kube.GetEnvironmentApplicationStatuses(kubeClient, ns)
+	if err != nil {
+		return err
+	}
+	for i := range apps {
+		apps[i].URL, _ = services.FindServiceURL(kubeClient, ns, apps[i].Name)
+	}
+	report := &kube.RemoteEnvironmentReport{
+		Environment:  o.Environment,
+		Applications: apps,
+		Timestamp:    metav1.Now(),
+	}
+	sha := ""
+	if o.GitOwner != "" && o.GitRepo != "" {
+		activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "listing the pipeline activities in namespace %s", ns)
+		}
+		report.Version, sha = kube.GetDeployedEnvironmentRevision(activities.Items, o.GitOwner, o.GitRepo)
+	}
+
+	if o.ReportURL != "" {
+		err = postRemoteEnvironmentReport(o.ReportURL, key, report)
+		if err != nil {
+			return err
+		}
+	}
+	if o.ReportGit && sha != "" {
+		err = o.updateEnvironmentCommitStatus(sha, apps)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateEnvironmentCommitStatus sets the commit status of the deployed revision of the git repository of the
+// Environment so that promotions can wait for the deployment in the remote cluster
+func (o *ControllerEnvironmentOptions) updateEnvironmentCommitStatus(sha string, apps []v1.EnvironmentApplicationStatus) error {
+	state := "success"
+	ready := kube.CountReadyApplications(apps)
+	if ready < len(apps) {
+		state = "pending"
+	}
+	if o.lastCommitStatus == sha+"/"+state {
+		return nil
+	}
+	provider, err := o.GitProviderForURL(o.SourceURL, "reporting the environment status")
+	if err != nil {
+		return errors.Wrapf(err, "creating the git provider for %s", o.SourceURL)
+	}
+	_, err = provider.UpdateCommitStatus(o.GitOwner, o.GitRepo, sha, &gits.GitRepoStatus{
+		Context:     remoteEnvironmentStatusContext,
+		State:       state,
+		Description: fmt.Sprintf("%d/%d applications ready in environment %s", ready, len(apps), o.Environment),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating the status of commit %s of %s", sha, o.SourceURL)
+	}
+	o.lastCommitStatus = sha + "/" + state
+	return nil
+}
+
+// postRemoteEnvironmentReport sends the report to the development cluster signed with the key
+func postRemoteEnvironmentReport(url string, key []byte, report *kube.RemoteEnvironmentReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshalling the environment report")
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "creating the request to %s", url)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature", PayloadSignature(data, key))
+	req.Header.Set(remoteEnvironmentHeader, report.Environment)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "posting the environment report to %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("posting the environment report to %s returned status %d: %s", url, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// loadRemoteEnvironmentHmac loads the key used to sign the status reports of the remote Environment
+func loadRemoteEnvironmentHmac(kubeClient kubernetes.Interface, ns string, name string, environment string) ([]byte, error) {
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting Secret %s in namespace %s", name, ns)
+	}
+	key := secret.Data[environment]
+	if len(key) == 0 {
+		return nil, fmt.Errorf("the Secret %s in namespace %s has no key for environment %s", name, ns, environment)
+	}
+	return key, nil
+}
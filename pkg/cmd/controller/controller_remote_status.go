@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ControllerRemoteStatusOptions holds the command line arguments
+type ControllerRemoteStatusOptions struct {
+	*opts.CommonOptions
+
+	BindAddress string
+	Path        string
+	Port        int
+	Secret      string
+
+	kubeClient kubernetes.Interface
+	ns         string
+}
+
+var (
+	controllerRemoteStatusLong = templates.LongDesc(`
+		A service in the development cluster which receives the status of the Environments in remote clusters from
+		their agents started with 'jx controller environment --remote'.
+
+		Each report must be signed with the key of its Environment in the Secret ` + remoteEnvironmentHmacSecret + `, which
+		has one key per remote Environment named after the Environment and shared only with the agent of that
+		Environment. The version and applications of each remote Environment are stored in its status so that commands such
+		as 'jx get applications' display the applications in the remote clusters.
+`)
+
+	controllerRemoteStatusExample = templates.Examples(`
+		# receive the status reports of the remote environments
+		jx controller remote-status
+	`)
+)
+
+// NewCmdControllerRemoteStatus creates the command
+func NewCmdControllerRemoteStatus(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := ControllerRemoteStatusOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "remote-status",
+		Short:   "A service which receives the status of the Environments in remote clusters",
+		Long:    controllerRemoteStatusLong,
+		Example: controllerRemoteStatusExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().IntVarP(&options.Port, optionPort, "", 8080, "The TCP port to listen on.")
+	cmd.Flags().StringVarP(&options.BindAddress, optionBind, "", "",
+		"The interface address to bind to (by default, will listen on all interfaces/addresses).")
+	cmd.Flags().StringVarP(&options.Path, "path", "", "/status", "The path to listen on for the status reports")
+	cmd.Flags().StringVarP(&options.Secret, "secret", "", remoteEnvironmentHmacSecret, "The Secret containing the keys of the Environments used to verify the reports")
+	return cmd
+}
+
+// Run will implement this command
+func (o *ControllerRemoteStatusOptions) Run() error {
+	var err error
+	o.kubeClient, o.ns, err = o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(healthPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.Handle(readyPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.Handle(o.Path, http.HandlerFunc(o.handleReport))
+
+	log.Logger().Infof("Remote status controller is now listening on %s for the status of remote environments", util.ColorInfo(o.Path))
+	return http.ListenAndServe(o.BindAddress+":"+strconv.Itoa(o.Port), mux)
+}
+
+// handleReport verifies the signature of a report from the agent of a remote Environment with the key of the
+// Environment and updates the status of the Environment
+func (o *ControllerRemoteStatusOptions) handleReport(w http.ResponseWriter, r *http.Request) {
+	environment := r.Header.Get(remoteEnvironmentHeader)
+	var key []byte
+	if r.Method == http.MethodPost {
+		if environment == "" {
+			responseHTTPError(w, http.StatusBadRequest, "400 Bad Request: Missing "+remoteEnvironmentHeader+" Header")
+			return
+		}
+		// the Secret is loaded for each report so that the keys of new Environments are used without a restart
+		var err error
+		key, err = loadRemoteEnvironmentHmac(o.kubeClient, o.ns, o.Secret, environment)
+		if err != nil {
+			log.Logger().Warnf("rejecting the report of environment %s: %s", environment, err)
+			responseHTTPError(w, http.StatusForbidden, "403 Forbidden: Unknown environment")
+			return
+		}
+	}
+	_, _, data, valid, _ := ValidateWebhook(w, r, key, false)
+	if !valid {
+		return
+	}
+	report := &kube.RemoteEnvironmentReport{}
+	err := json.Unmarshal(data, report)
+	if err != nil || report.Environment == "" {
+		responseHTTPError(w, http.StatusBadRequest, "400 Bad Request: Could not unmarshal the environment report")
+		return
+	}
+	if report.Environment != environment {
+		responseHTTPError(w, http.StatusForbidden, "403 Forbidden: The report is not signed with the key of environment "+report.Environment)
+		return
+	}
+	statusCode, err := o.updateEnvironmentStatus(report)
+	if err != nil {
+		log.Logger().Errorf("failed to update the status of environment %s: %s", report.Environment, err)
+		responseHTTPError(w, statusCode, fmt.Sprintf("%d %s", statusCode, err.Error()))
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+func (o *ControllerRemoteStatusOptions) updateEnvironmentStatus(report *kube.RemoteEnvironmentReport) (int, error) {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	environments := jxClient.JenkinsV1().Environments(ns)
+	env, err := environments.Get(report.Environment, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return http.StatusNotFound, fmt.Errorf("environment %s not found", report.Environment)
+		}
+		return http.StatusInternalServerError, err
+	}
+	if !env.Spec.RemoteCluster {
+		return http.StatusBadRequest, fmt.Errorf("environment %s is not in a remote cluster", report.Environment)
+	}
+	if !kube.ApplyRemoteEnvironmentReport(env, report) {
+		log.Logger().Debugf("ignoring an old report of environment %s", report.Environment)
+		return http.StatusOK, nil
+	}
+	_, err = environments.PatchUpdate(env)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	log.Logger().Infof("updated the status of environment %s to version %s with %d applications", util.ColorInfo(env.Name),
+		util.ColorInfo(env.Status.Version), len(env.Status.Applications))
+	return http.StatusOK, nil
+}
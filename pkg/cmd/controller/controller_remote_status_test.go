@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_mocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHandleRemoteStatusReportRequiresTheKeyOfTheEnvironment(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	productionKey := []byte("production-key")
+	o := &ControllerRemoteStatusOptions{
+		Secret: remoteEnvironmentHmacSecret,
+		kubeClient: kube_mocks.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      remoteEnvironmentHmacSecret,
+				Namespace: ns,
+			},
+			Data: map[string][]byte{
+				"production": productionKey,
+				"staging":    []byte("staging-key"),
+			},
+		}),
+		ns: ns,
+	}
+
+	testCases := []struct {
+		name        string
+		environment string
+		header      string
+		key         []byte
+		status      int
+	}{
+		{name: "missing header", environment: "production", key: productionKey, status: http.StatusBadRequest},
+		{name: "unknown environment", environment: "test", header: "test", key: productionKey, status: http.StatusForbidden},
+		{name: "key of another environment", environment: "staging", header: "staging", key: productionKey, status: http.StatusForbidden},
+		{name: "report of another environment", environment: "staging", header: "production", key: productionKey, status: http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(&kube.RemoteEnvironmentReport{Environment: tc.environment, Timestamp: metav1.Now()})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/status", bytes.NewReader(data))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Hub-Signature", PayloadSignature(data, tc.key))
+			if tc.header != "" {
+				req.Header.Set(remoteEnvironmentHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+
+			o.handleReport(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
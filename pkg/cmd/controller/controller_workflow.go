@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -51,6 +52,20 @@ type ControllerWorkflowOptions struct {
 	PullRequestPollDuration *time.Duration
 	workflowMap             map[string]*v1.Workflow
 	pipelineMap             map[string]*v1.PipelineActivity
+	// lock serialises the processing of the informer events and ticks as they all share the maps above
+	lock sync.Mutex
+	// promotions are the promotions scheduled while processing an event which are run once the lock is released
+	promotions []*pendingPromotion
+	// promoting the keys of the promotions which are scheduled or running so they are not started twice
+	promoting map[string]bool
+}
+
+// pendingPromotion a promotion of a PipelineActivity to an Environment which is run outside of the lock
+type pendingPromotion struct {
+	key      string
+	pipeline string
+	envName  string
+	options  *promote.PromoteOptions
 }
 
 // NewCmdControllerWorkflow creates a command object for the generic "get" action, which
@@ -121,9 +136,12 @@ func (o *ControllerWorkflowOptions) Run() error {
 
 	o.workflowMap = map[string]*v1.Workflow{}
 	o.pipelineMap = map[string]*v1.PipelineActivity{}
+	o.promoting = map[string]bool{}
 
 	if o.NoWatch {
-		return o.updatePipelinesWithoutWatching(jxClient, ns)
+		err = o.updatePipelinesWithoutWatching(jxClient, ns)
+		o.runPromotions(o.takePromotions())
+		return err
 	}
 
 	log.Logger().Infof("Watching for PipelineActivity resources in namespace %s", util.ColorInfo(ns))
@@ -137,13 +155,19 @@ func (o *ControllerWorkflowOptions) Run() error {
 		time.Minute*10,
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				o.onWorkflowObj(obj, jxClient, ns)
+				o.withLock(func() {
+					o.onWorkflowObj(obj, jxClient, ns)
+				})
 			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onWorkflowObj(newObj, jxClient, ns)
+				o.withLock(func() {
+					o.onWorkflowObj(newObj, jxClient, ns)
+				})
 			},
 			DeleteFunc: func(obj interface{}) {
-				o.deleteWorkflowObjb(obj, jxClient, ns)
+				o.withLock(func() {
+					o.deleteWorkflowObjb(obj, jxClient, ns)
+				})
 			},
 		},
 	)
@@ -158,10 +182,14 @@ func (o *ControllerWorkflowOptions) Run() error {
 		time.Minute*10,
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				o.onActivityObj(obj, jxClient, ns)
+				o.withLock(func() {
+					o.onActivityObj(obj, jxClient, ns)
+				})
 			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				o.onActivityObj(newObj, jxClient, ns)
+				o.withLock(func() {
+					o.onActivityObj(newObj, jxClient, ns)
+				})
 			},
 			DeleteFunc: func(obj interface{}) {
 			},
@@ -175,7 +203,19 @@ func (o *ControllerWorkflowOptions) Run() error {
 		for t := range ticker.C {
 			log.Logger().Debugf("Polling to see if any PRs have merged: %v", t)
 			//o.pollGitPipelineStatuses(jxClient, ns)
-			o.ReloadAndPollGitPipelineStatuses(jxClient, ns)
+			o.withLock(func() {
+				o.ReloadAndPollGitPipelineStatuses(jxClient, ns)
+			})
+		}
+	}()
+
+	queueTicker := time.NewTicker(time.Minute)
+	go func() {
+		for t := range queueTicker.C {
+			log.Logger().Debugf("Polling to see if any queued promotions can start: %v", t)
+			o.withLock(func() {
+				o.promoteQueuedActivities(jxClient, ns)
+			})
 		}
 	}()
 
@@ -183,6 +223,56 @@ func (o *ControllerWorkflowOptions) Run() error {
 	select {}
 }
 
+// withLock processes an event while holding the lock so that events from the informers and tickers are not
+// processed concurrently. Any promotions scheduled by the event are run after the lock is released as they clone
+// the environment repository and create a Pull Request which would block all the other events
+func (o *ControllerWorkflowOptions) withLock(fn func()) {
+	promotions := func() []*pendingPromotion {
+		o.lock.Lock()
+		defer o.lock.Unlock()
+		fn()
+		return o.takePromotions()
+	}()
+	o.runPromotions(promotions)
+}
+
+// schedulePromotion schedules the promotion of the PipelineActivity to the Environment to run once the lock is
+// released unless the same promotion is already scheduled or running. It must be called while holding the lock
+func (o *ControllerWorkflowOptions) schedulePromotion(pipeline *v1.PipelineActivity, envName string, po *promote.PromoteOptions) {
+	key := pipeline.Name + "/" + envName
+	if o.promoting[key] {
+		log.Logger().Debugf("The promotion of PipelineActivity %s to Environment %s is already in progress", pipeline.Name, envName)
+		return
+	}
+	o.promoting[key] = true
+	o.promotions = append(o.promotions, &pendingPromotion{
+		key:      key,
+		pipeline: pipeline.Name,
+		envName:  envName,
+		options:  po,
+	})
+}
+
+// takePromotions returns the scheduled promotions and clears them. It must be called while holding the lock
+func (o *ControllerWorkflowOptions) takePromotions() []*pendingPromotion {
+	promotions := o.promotions
+	o.promotions = nil
+	return promotions
+}
+
+// runPromotions runs the given promotions. It must be called without holding the lock
+func (o *ControllerWorkflowOptions) runPromotions(promotions []*pendingPromotion) {
+	for _, p := range promotions {
+		err := p.options.Run()
+		if err != nil {
+			log.Logger().Warnf("Failed to promote PipelineActivity %s to Environment %s: %s", p.pipeline, p.envName, err)
+		}
+		o.lock.Lock()
+		delete(o.promoting, p.key)
+		o.lock.Unlock()
+	}
+}
+
 func (o *ControllerWorkflowOptions) PipelineMap() map[string]*v1.PipelineActivity {
 	return o.pipelineMap
 }
@@ -274,6 +364,7 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 	}
 
 	if workflowName == "" {
+		o.promoteQueued(pipeline, jxClient, ns, activities)
 		o.removePipelineActivityIfNoManual(pipeline, activities)
 		return
 	}
@@ -321,14 +412,11 @@ func (o *ControllerWorkflowOptions) onActivity(pipeline *v1.PipelineActivity, jx
 					if status == nil || status.PullRequest == nil || status.PullRequest.PullRequestURL == "" {
 						allStepsComplete = false
 						// can we generate a PR now?
-						if canExecuteStep(flow, pipeline, &step, promoteStatusMap, envName) {
+						if canExecuteStep(flow, pipeline, &step, promoteStatusMap, envName) && o.isApproved(pipeline, &step, envName, jxClient, ns, activities) &&
+							o.isInPromotionWindow(pipeline, envName, jxClient, ns, activities) {
 							log.Logger().Infof("Creating PR for environment %s from PipelineActivity %s as current status is %#v", envName, pipeline.Name, status)
 							po := o.createPromoteOptions(repoName, envName, pipelineName, build, version)
-
-							err := po.Run()
-							if err != nil {
-								log.Logger().Warnf("Failed to create PullRequest on pipeline %s repo %s version %s with workflow %s: %s", pipeline.Name, repoName, version, workflowName, err)
-							}
+							o.schedulePromotion(pipeline, envName, po)
 						}
 					}
 					if status != nil && status.Status != v1.ActivityStatusTypeSucceeded {
@@ -361,8 +449,11 @@ func (o *ControllerWorkflowOptions) createPromoteOptions(repoName string, envNam
 		HelmRepositoryURL: helm.InClusterHelmRepositoryURL,
 		LocalHelmRepoName: kube.LocalHelmRepoName,
 		Namespace:         o.Namespace,
+		Queue:             true,
 	}
-	po.CommonOptions = o.CommonOptions
+	// the promotion runs outside of the lock so lets not share the lazily created clients with the event processing
+	commonOpts := *o.CommonOptions
+	po.CommonOptions = &commonOpts
 	po.BatchMode = true
 	return po
 }
@@ -690,6 +781,100 @@ func canExecuteStep(workflow *v1.Workflow, activity *v1.PipelineActivity, step *
 	return true
 }
 
+// isApproved returns true if the step does not require approval or has been approved. Otherwise the PipelineActivity
+// is updated to show it is waiting for approval or is aborted if the promotion was rejected or the approval expired
+func (o *ControllerWorkflowOptions) isApproved(pipeline *v1.PipelineActivity, step *v1.WorkflowStep, envName string, jxClient versioned.Interface, ns string, activities typev1.PipelineActivityInterface) bool {
+	approval := step.Preconditions.Approval
+	var userTeams map[string][]string
+	if approval != nil && len(approval.Teams) > 0 {
+		var err error
+		userTeams, err = workflow.GetUserTeams(jxClient, ns)
+		if err != nil {
+			log.Logger().Warnf("Failed to find the teams of the approvers of PipelineActivity %s: %s", pipeline.Name, err)
+			return false
+		}
+	}
+	state, modified := workflow.UpdateApproval(pipeline, envName, approval, userTeams, time.Now())
+	if modified {
+		switch state {
+		case workflow.ApprovalStatePending:
+			log.Logger().Infof("PipelineActivity %s is waiting for approval to promote to Environment %s", pipeline.Name, envName)
+		case workflow.ApprovalStateRejected, workflow.ApprovalStateExpired:
+			log.Logger().Infof("Aborting PipelineActivity %s as the approval to promote to Environment %s is %s", pipeline.Name, envName, string(state))
+		}
+		_, err := activities.PatchUpdate(pipeline)
+		if err != nil {
+			log.Logger().Warnf("Failed to update the approval of PipelineActivity %s: %s", pipeline.Name, err)
+			return false
+		}
+	}
+	return state == workflow.ApprovalStateApproved || state == workflow.ApprovalStateNotRequired
+}
+
+// isInPromotionWindow returns true if promotions to the Environment are currently allowed. Otherwise the promote step
+// of the PipelineActivity is queued until promotions to the Environment are next allowed
+func (o *ControllerWorkflowOptions) isInPromotionWindow(pipeline *v1.PipelineActivity, envName string, jxClient versioned.Interface, ns string, activities typev1.PipelineActivityInterface) bool {
+	env, err := jxClient.JenkinsV1().Environments(ns).Get(envName, metav1.GetOptions{})
+	if err != nil {
+		log.Logger().Warnf("Failed to find Environment %s: %s", envName, err)
+		return false
+	}
+	now := time.Now()
+	status, err := kube.GetPromotionWindowStatus(env, now)
+	if err != nil {
+		log.Logger().Warnf("Failed to check the promotion windows of Environment %s: %s", envName, err)
+		return false
+	}
+	if status.Allowed {
+		return true
+	}
+	promote, added := workflow.GetOrCreatePromoteStep(pipeline, envName, now)
+	if kube.QueuePromoteStep(promote, status) || added {
+		log.Logger().Infof("Queueing the promotion of PipelineActivity %s to Environment %s as %s", pipeline.Name, envName, status.Reason)
+		_, err := activities.PatchUpdate(pipeline)
+		if err != nil {
+			log.Logger().Warnf("Failed to queue the promotion of PipelineActivity %s: %s", pipeline.Name, err)
+		}
+	}
+	return false
+}
+
+// promoteQueued starts any queued promotions of a PipelineActivity without a Workflow once promotions to their
+// Environment are allowed
+func (o *ControllerWorkflowOptions) promoteQueued(pipeline *v1.PipelineActivity, jxClient versioned.Interface, ns string, activities typev1.PipelineActivityInterface) {
+	for _, step := range pipeline.Spec.Steps {
+		promote := step.Promote
+		if !kube.IsPromoteStepQueued(promote) {
+			continue
+		}
+		envName := promote.Environment
+		if o.isInPromotionWindow(pipeline, envName, jxClient, ns, activities) {
+			log.Logger().Infof("Starting the queued promotion of PipelineActivity %s to Environment %s", pipeline.Name, envName)
+			po := o.createPromoteOptionsFromActivity(pipeline, envName)
+			o.schedulePromotion(pipeline, envName, po)
+		}
+	}
+}
+
+// promoteQueuedActivities processes the PipelineActivity resources with queued promotions which are due to start
+func (o *ControllerWorkflowOptions) promoteQueuedActivities(jxClient versioned.Interface, ns string) {
+	pipelines, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		log.Logger().Warnf("failed to list PipelineActivity resources: %s", err)
+		return
+	}
+	now := time.Now()
+	for i := range pipelines.Items {
+		pipeline := &pipelines.Items[i]
+		for _, step := range pipeline.Spec.Steps {
+			if kube.IsPromoteStepQueued(step.Promote) && !step.Promote.QueuedUntil.After(now) {
+				o.onActivity(pipeline, jxClient, ns)
+				break
+			}
+		}
+	}
+}
+
 // createPromoteStatus returns a map indexed by environment name of all the promotions in this pipeline
 func createPromoteStatus(pipeline *v1.PipelineActivity) map[string]*v1.PromoteActivityStep {
 	answer := map[string]*v1.PromoteActivityStep{}
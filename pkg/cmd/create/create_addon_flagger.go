@@ -30,6 +30,8 @@ const (
 	defaultFlaggerRepo                  = "https://flagger.app"
 	optionGrafanaChart                  = "grafana-chart"
 	optionGrafanaVersion                = "grafana-version"
+	optionLoadTesterChart               = "loadtester-chart"
+	optionLoadTesterVersion             = "loadtester-version"
 	defaultFlaggerProductionEnvironment = "production"
 	defaultIstioGateway                 = "jx-gateway"
 )
@@ -50,6 +52,8 @@ type CreateAddonFlaggerOptions struct {
 	Chart                 string
 	GrafanaChart          string
 	GrafanaVersion        string
+	LoadTesterChart       string
+	LoadTesterVersion     string
 	ProductionEnvironment string
 	IstioGateway          string
 }
@@ -79,6 +83,8 @@ func NewCmdCreateAddonFlagger(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Chart, optionChart, "c", kube.ChartFlagger, "The name of the chart to use")
 	cmd.Flags().StringVarP(&options.GrafanaChart, optionGrafanaChart, "", kube.ChartFlaggerGrafana, "The name of the Flagger Grafana chart to use")
 	cmd.Flags().StringVarP(&options.GrafanaVersion, optionGrafanaVersion, "", "", "The version of the Flagger Grafana chart")
+	cmd.Flags().StringVarP(&options.LoadTesterChart, optionLoadTesterChart, "", kube.ChartFlaggerLoadTester, "The name of the Flagger load tester chart used to abort canary releases. Set to empty to not install it")
+	cmd.Flags().StringVarP(&options.LoadTesterVersion, optionLoadTesterVersion, "", "", "The version of the Flagger load tester chart")
 	cmd.Flags().StringVarP(&options.ProductionEnvironment, "environment", "e", defaultFlaggerProductionEnvironment, "The name of the production environment where Istio will be enabled")
 	cmd.Flags().StringVarP(&options.IstioGateway, "istio-gateway", "", defaultIstioGateway, "The name of the Istio Gateway that will be created if it does not exist")
 	return cmd
@@ -129,6 +135,19 @@ func (o *CreateAddonFlaggerOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "Flagger Grafana deployment failed")
 	}
+	if o.LoadTesterChart != "" {
+		helmOptions = helm.InstallChartOptions{
+			Chart:       o.LoadTesterChart,
+			ReleaseName: o.ReleaseName + "-loadtester",
+			Version:     o.LoadTesterVersion,
+			Ns:          o.Namespace,
+			SetValues:   values,
+		}
+		err = o.InstallChartWithOptions(helmOptions)
+		if err != nil {
+			return errors.Wrap(err, "Flagger load tester deployment failed")
+		}
+	}
 
 	// Enable Istio in production namespace
 	if o.ProductionEnvironment != "" {
@@ -2,6 +2,7 @@ package create
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/kube/naming"
@@ -36,11 +37,13 @@ var (
 type CreateChatTokenOptions struct {
 	CreateOptions
 
-	ServerFlags opts.ServerFlags
-	Username    string
-	Password    string
-	ApiToken    string
-	Timeout     string
+	ServerFlags  opts.ServerFlags
+	Username     string
+	Password     string
+	ApiToken     string
+	RefreshToken string
+	Timeout      string
+	Expires      time.Duration
 }
 
 // NewCmdCreateChatToken creates a command
@@ -66,7 +69,9 @@ func NewCmdCreateChatToken(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 	options.ServerFlags.AddGitServerFlags(cmd)
 	cmd.Flags().StringVarP(&options.ApiToken, "api-token", "t", "", "The API Token for the user")
+	cmd.Flags().StringVarP(&options.RefreshToken, "refresh-token", "", "", "The refresh token of a Slack app with token rotation enabled which is used by 'jx step rotate secrets' to refresh the API token")
 	cmd.Flags().StringVarP(&options.Timeout, "timeout", "", "", "The timeout if using browser automation to generate the API token (by passing username and password)")
+	cmd.Flags().DurationVarP(&options.Expires, "expires", "", 0, "How long until the API token expires and should be rotated via 'jx step rotate secrets'. If not specified the token is assumed to never expire")
 
 	return cmd
 }
@@ -98,6 +103,9 @@ func (o *CreateChatTokenOptions) Run() error {
 	if o.ApiToken != "" {
 		userAuth.ApiToken = o.ApiToken
 	}
+	if o.RefreshToken != "" {
+		userAuth.RefreshToken = o.RefreshToken
+	}
 
 	tokenUrl := chats.ProviderAccessTokenURL(server.Kind, server.URL)
 
@@ -120,6 +128,7 @@ func (o *CreateChatTokenOptions) Run() error {
 		}
 	}
 
+	userAuth.SetTokenCreated(time.Now(), o.Expires)
 	config.CurrentServer = server.URL
 	err = authConfigSvc.SaveConfig()
 	if err != nil {
@@ -3,7 +3,11 @@ package create
 import (
 	b64 "encoding/base64"
 	"encoding/json"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/kube"
 
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
@@ -42,10 +46,12 @@ var (
 type CreateDockerAuthOptions struct {
 	CreateOptions
 
-	Host   string
-	User   string
-	Secret string
-	Email  string
+	Host     string
+	User     string
+	Secret   string
+	Email    string
+	Password string
+	Expires  time.Duration
 }
 
 // NewCmdCreateDockerAuth creates a command object for the "create" command
@@ -73,6 +79,8 @@ func NewCmdCreateDockerAuth(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.User, username, "u", "", "The user to associate auth component of config.json")
 	cmd.Flags().StringVarP(&options.Secret, "secret", "s", "", "The secret to associate auth component of config.json")
 	cmd.Flags().StringVarP(&options.Email, "email", "e", "", "The email to associate auth component of config.json")
+	cmd.Flags().StringVarP(&options.Password, "password", "", "", "The password of the user, saved so that Docker Hub access tokens can be rotated via 'jx step rotate secrets'")
+	cmd.Flags().DurationVarP(&options.Expires, "expires", "", 0, "How long until the secret expires and should be rotated via 'jx step rotate secrets'. If not specified the secret is assumed to never expire")
 	return cmd
 }
 
@@ -103,7 +111,7 @@ func (o *CreateDockerAuthOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	secretFromConfig, err := kubeClient.CoreV1().Secrets(currentNs).Get("jenkins-docker-cfg", metav1.GetOptions{})
+	secretFromConfig, err := kubeClient.CoreV1().Secrets(currentNs).Get(kube.SecretJenkinsDockerConfig, metav1.GetOptions{})
 	if err != nil {
 		return nil
 	}
@@ -134,6 +142,29 @@ func (o *CreateDockerAuthOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	kubeClient.CoreV1().Secrets(currentNs).Update(secretFromConfig)
-	return nil
+	_, err = kubeClient.CoreV1().Secrets(currentNs).Update(secretFromConfig)
+	if err != nil {
+		return err
+	}
+	return o.saveUserAuth()
+}
+
+// saveUserAuth records the registry credentials along with when they expire so they can be rotated
+func (o *CreateDockerAuthOptions) saveUserAuth() error {
+	authConfigSvc, err := o.AuthConfigService(auth.DockerAuthConfigFile)
+	if err != nil {
+		return err
+	}
+	config, err := authConfigSvc.LoadConfig()
+	if err != nil {
+		return err
+	}
+	config.GetOrCreateServerName(o.Host, o.Host, kube.ValueKindDocker)
+	userAuth := config.GetOrCreateUserAuth(o.Host, o.User)
+	userAuth.ApiToken = o.Secret
+	if o.Password != "" {
+		userAuth.Password = o.Password
+	}
+	userAuth.SetTokenCreated(time.Now(), o.Expires)
+	return authConfigSvc.SaveConfig()
 }
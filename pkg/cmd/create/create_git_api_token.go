@@ -48,6 +48,7 @@ type CreateGitTokenOptions struct {
 	Password    string
 	ApiToken    string
 	Timeout     string
+	Expires     time.Duration
 }
 
 // NewCmdCreateGitToken creates a command
@@ -73,8 +74,9 @@ func NewCmdCreateGitToken(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 	options.ServerFlags.AddGitServerFlags(cmd)
 	cmd.Flags().StringVarP(&options.ApiToken, "api-token", "t", "", "The API Token for the user")
-	cmd.Flags().StringVarP(&options.Password, "password", "p", "", "The User password to try automatically create a new API Token")
+	cmd.Flags().StringVarP(&options.Password, "password", "p", "", "The User password to try automatically create a new API Token. It is saved so that the token can be rotated via 'jx step rotate secrets'")
 	cmd.Flags().StringVarP(&options.Timeout, "timeout", "", "", "The timeout if using browser automation to generate the API token (by passing username and password)")
+	cmd.Flags().DurationVarP(&options.Expires, "expires", "", 0, "How long until the API token expires and should be rotated via 'jx step rotate secrets'. If not specified the token is assumed to never expire")
 
 	return cmd
 }
@@ -138,6 +140,10 @@ func (o *CreateGitTokenOptions) Run() error {
 		}
 	}
 
+	if o.Password != "" {
+		userAuth.Password = o.Password
+	}
+	userAuth.SetTokenCreated(time.Now(), o.Expires)
 	config.CurrentServer = server.URL
 	err = authConfigSvc.SaveConfig()
 	if err != nil {
@@ -3,6 +3,7 @@ package deletecmd
 import (
 	"github.com/jenkins-x/jx/pkg/cmd/create"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/pkg/errors"
 
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
@@ -70,5 +71,9 @@ func (o *DeleteAddonFlaggerOptions) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to delete Flagger Grafana chart")
 	}
+	err = o.Helm().DeleteRelease(o.Namespace, o.ReleaseName+"-loadtester", o.Purge)
+	if err != nil {
+		log.Logger().Warnf("Failed to delete Flagger load tester chart: %s", err)
+	}
 	return err
 }
@@ -109,31 +109,3 @@ func (o *DeletePreviewOptions) Run() error {
 	}
 	return o.DeletePreview(o.Name)
 }
-
-func (o *DeletePreviewOptions) DeletePreview(name string) error {
-	jxClient, ns, err := o.JXClient()
-	if err != nil {
-		return err
-	}
-
-	environment, err := kube.GetEnvironment(jxClient, ns, name)
-	if err != nil {
-		return err
-	}
-	releaseName := kube.GetPreviewEnvironmentReleaseName(environment)
-	if len(releaseName) > 0 {
-		log.Logger().Infof("Deleting helm release: %s", util.ColorInfo(releaseName))
-		err = o.Helm().DeleteRelease(ns, releaseName, true)
-		if err != nil {
-			return err
-		}
-	}
-
-	log.Logger().Infof("Deleting preview environment: %s", util.ColorInfo(name))
-	deleteOptions := &DeleteEnvOptions{
-		CommonOptions:   o.CommonOptions,
-		DeleteNamespace: true,
-	}
-	deleteOptions.Args = []string{name}
-	return deleteOptions.Run()
-}
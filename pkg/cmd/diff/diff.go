@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/spf13/cobra"
+)
+
+// DiffOptions contains the CLI options
+type DiffOptions struct {
+	*opts.CommonOptions
+}
+
+var (
+	diffLong = templates.LongDesc(`
+		Display the differences between resources
+
+`)
+
+	diffExample = templates.Examples(`
+		# Display the application versions which differ between staging and production
+		jx diff env staging production
+	`)
+)
+
+// NewCmdDiff creates the diff command
+func NewCmdDiff(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DiffOptions{
+		commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "diff [flags]",
+		Short:   "Display the differences between resources",
+		Long:    diffLong,
+		Example: diffExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+		SuggestFor: []string{"compare"},
+	}
+
+	cmd.AddCommand(NewCmdDiffEnv(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *DiffOptions) Run() error {
+	return o.Cmd.Help()
+}
@@ -0,0 +1,162 @@
+package diff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/table"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// DiffEnvOptions contains the CLI options
+type DiffEnvOptions struct {
+	*opts.CommonOptions
+
+	Git bool
+	All bool
+}
+
+var (
+	diffEnvLong = templates.LongDesc(`
+		Display the applications whose versions differ between two Environments.
+
+		By default the versions deployed in the namespaces of the Environments are compared. Use --git to compare the
+		versions in the git repositories of the Environments instead, such as for Environments in remote clusters.
+`)
+
+	diffEnvExample = templates.Examples(`
+		# Display the application versions which differ between staging and production
+		jx diff env staging production
+
+		# Compare the versions in the git repositories of the environments as JSON
+		jx diff env staging production --git -o json
+
+		# Display the versions of all the applications in both environments
+		jx diff env staging production --all
+	`)
+)
+
+// NewCmdDiffEnv creates the new command for: jx diff env
+func NewCmdDiffEnv(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &DiffEnvOptions{
+		CommonOptions: commonOpts,
+	}
+	cmd := &cobra.Command{
+		Use:     "environment <from> <to>",
+		Short:   "Display the application versions which differ between two Environments",
+		Aliases: []string{"env", "environments"},
+		Long:    diffEnvLong,
+		Example: diffEnvExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVarP(&options.Git, "git", "", false, "Compare the versions in the git repositories of the Environments rather than the deployed versions")
+	cmd.Flags().BoolVarP(&options.All, "all", "a", false, "Display the applications whose versions are the same too")
+
+	options.AddOutputFlag(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *DiffEnvOptions) Run() error {
+	if len(o.Args) != 2 {
+		return fmt.Errorf("please specify the two environments to compare such as: jx diff env staging production")
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	versions := []map[string]string{}
+	for _, name := range o.Args {
+		env, err := kube.GetEnvironment(jxClient, ns, name)
+		if err != nil {
+			return errors.Wrapf(err, "getting environment %s", name)
+		}
+		var envVersions map[string]string
+		if o.Git {
+			envVersions, err = o.gitAppVersions(env)
+		} else {
+			envVersions, err = o.deployedAppVersions(env)
+		}
+		if err != nil {
+			return err
+		}
+		versions = append(versions, envVersions)
+	}
+
+	diffs := kube.DiffAppVersions(versions[0], versions[1], o.All)
+	if len(diffs) == 0 && !table.IsObjectFormat(o.Output) {
+		log.Logger().Infof("environments %s and %s have the same application versions", util.ColorInfo(o.Args[0]), util.ColorInfo(o.Args[1]))
+		return nil
+	}
+	t := o.CreateTable()
+	t.AddRow("APPLICATION", strings.ToUpper(o.Args[0]), strings.ToUpper(o.Args[1]))
+	for _, diff := range diffs {
+		t.AddRow(diff.Application, diff.Left, diff.Right)
+	}
+	return t.Render()
+}
+
+// deployedAppVersions returns the versions of the applications deployed in the namespace of the Environment
+func (o *DiffEnvOptions) deployedAppVersions(env *v1.Environment) (map[string]string, error) {
+	if env.Spec.RemoteCluster {
+		return nil, fmt.Errorf("environment %s is in a remote cluster so use --git to compare the versions in its git repository", env.Name)
+	}
+	if env.Spec.Namespace == "" {
+		return nil, fmt.Errorf("environment %s has no namespace", env.Name)
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return kube.GetDeployedAppVersions(kubeClient, env.Spec.Namespace)
+}
+
+// gitAppVersions returns the versions of the applications in the requirements of the git repository of the Environment
+func (o *DiffEnvOptions) gitAppVersions(env *v1.Environment) (map[string]string, error) {
+	if env.Spec.Source.URL == "" {
+		return nil, fmt.Errorf("environment %s has no git repository", env.Name)
+	}
+	dir, err := ioutil.TempDir("", "jx-diff-env-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(dir)
+	err = o.Git().ShallowClone(dir, env.Spec.Source.URL, env.Spec.Source.Ref, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cloning %s", env.Spec.Source.URL)
+	}
+	requirements, err := helm.LoadRequirementsFile(filepath.Join(dir, helm.DefaultEnvironmentChartDir, helm.RequirementsFileName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the requirements of environment %s", env.Name)
+	}
+	answer := map[string]string{}
+	for _, dep := range requirements.Dependencies {
+		if dep == nil {
+			continue
+		}
+		name := dep.Alias
+		if name == "" {
+			name = dep.Name
+		}
+		answer[name] = dep.Version
+	}
+	log.Logger().Debugf("found %d applications in the git repository of environment %s", len(answer), util.ColorInfo(env.Name))
+	return answer, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/helm"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/spf13/cobra"
 
@@ -20,6 +21,9 @@ var (
 		Configures the helm binary version used by your team
 
 		This lets you switch between helm and helm3
+
+		As helm3 does not use Tiller switching to helm3 also disables the template and no tiller modes. The existing
+		releases can then be migrated to helm3 via 'jx step helm migrate'
 `)
 
 	editHelmBinExample = templates.Examples(`
@@ -74,7 +78,18 @@ func (o *EditHelmBinOptions) Run() error {
 	callback := func(env *v1.Environment) error {
 		env.Spec.TeamSettings.HelmBinary = arg
 		log.Logger().Infof("Setting the helm binary name to: %s", util.ColorInfo(arg))
+		if arg == helm.Helm3Binary {
+			env.Spec.TeamSettings.NoTiller = false
+			env.Spec.TeamSettings.HelmTemplate = false
+		}
 		return nil
 	}
-	return o.ModifyDevEnvironment(callback)
+	err := o.ModifyDevEnvironment(callback)
+	if err != nil {
+		return err
+	}
+	if arg == helm.Helm3Binary {
+		log.Logger().Infof("To migrate the existing releases to helm3 use: %s", util.ColorInfo("jx step helm migrate"))
+	}
+	return nil
 }
@@ -42,6 +42,9 @@ var (
 		# Configure the tests to be stored in cloud storage (using S3 / GCS / Azure Blobs etc)
 		jx edit storage -c tests --bucket-url s3://myExistingBucketName
 
+		# Configure the logs to be stored in a mounted volume for 30 days
+		jx edit storage -c logs --directory /var/jenkins-x/storage/logs --retention-days 30
+
 		# Creates a new GCS bucket and configures the logs to be stored in it
 		jx edit storage -c logs --bucket myBucketName
 	`)
@@ -86,6 +89,8 @@ func addStorageLocationFlags(cmd *cobra.Command, location *jenkinsv1.StorageLoca
 	cmd.Flags().StringVarP(&location.BucketURL, "bucket-url", "", "", "Specify the cloud storage bucket URL to send each file to. e.g. use 's3://nameOfBucket' on AWS, gs://anotherBucket' on GCP or on Azure 'azblob://thatBucket'")
 	cmd.Flags().StringVarP(&location.GitURL, "git-url", "", "", "Specify the Git URL to of the repository to use for storage")
 	cmd.Flags().StringVarP(&location.GitBranch, "git-branch", "", "gh-pages", "The branch to use to store files in the git repository")
+	cmd.Flags().StringVarP(&location.Directory, "directory", "", "", "Specify the local directory to store files in such as a mounted PersistentVolumeClaim for air gapped clusters")
+	cmd.Flags().IntVarP(&location.RetentionDays, "retention-days", "", 0, "How many days files are kept before being removed by 'jx gc stash'. Zero keeps them forever")
 }
 
 // Run implements the command
@@ -108,7 +113,7 @@ func (o *EditStorageOptions) Run() error {
 
 	currentLocation := settings.StorageLocationOrDefault(classifier)
 
-	if o.StorageLocation.BucketURL == "" && o.StorageLocation.GitURL == "" {
+	if o.StorageLocation.BucketURL == "" && o.StorageLocation.GitURL == "" && o.StorageLocation.Directory == "" {
 		if !o.CreateBucketValues.IsEmpty() {
 			o.StorageLocation.BucketURL, err = o.CreateBucket(&o.CreateBucketValues, settings)
 			if err != nil {
@@ -137,6 +142,11 @@ func (o *EditStorageOptions) Run() error {
 		}
 	}
 
+	if o.StorageLocation.RetentionDays > 0 && o.StorageLocation.GitURL != "" {
+		return util.InvalidOptionf("retention-days", o.StorageLocation.RetentionDays,
+			"files stored in git cannot be garbage collected so use a bucket or directory instead")
+	}
+
 	callback := func(env *v1.Environment) error {
 		env.Spec.TeamSettings.SetStorageLocation(classifier, o.StorageLocation)
 		return nil
@@ -23,6 +23,7 @@ const (
 	* helm
 	* previews
 	* releases
+	* stash
     `
 )
 
@@ -40,6 +41,7 @@ var (
 		jx gc helm
 		jx gc previews
 		jx gc releases
+		jx gc stash
 
 	`)
 )
@@ -70,6 +72,7 @@ func NewCmdGC(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGCHelm(commonOpts))
 	cmd.AddCommand(NewCmdGCPods(commonOpts))
 	cmd.AddCommand(NewCmdGCReleases(commonOpts))
+	cmd.AddCommand(NewCmdGCStash(commonOpts))
 
 	return cmd
 }
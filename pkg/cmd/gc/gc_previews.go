@@ -6,7 +6,6 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/preview"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
-	"github.com/jenkins-x/jx/pkg/cmd/promote"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,12 +13,18 @@ import (
 	"strconv"
 
 	"strings"
+	"time"
 
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/prometheus"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
 )
 
 // GetOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
@@ -29,18 +34,36 @@ type GCPreviewsOptions struct {
 
 	DisableImport bool
 	OutDir        string
+	PrometheusURL string
+	TrafficQuery  string
 }
 
+const (
+	// DefaultPreviewTrafficQuery the default Prometheus query of the number of HTTP requests to a preview environment
+	// in the $NAMESPACE namespace during the $WINDOW range
+	DefaultPreviewTrafficQuery = `sum(increase(nginx_ingress_controller_requests{exported_namespace="$NAMESPACE"}[$WINDOW]))`
+)
+
 var (
 	GCPreviewsLong = templates.LongDesc(`
 		Garbage collect Jenkins X preview environments.  If a pull request is merged or closed the associated preview
 		environment will be deleted.
 
+		Preview environments which have lived longer than the 'ttl' configured in the previewEnvironments section of
+		the jenkins-x.yml of the application are deleted. Preview environments with no new commits for longer than the
+		'idleTimeout' are scaled to zero until the next commit or 'jx preview wake'. If a Prometheus URL is specified
+		then HTTP traffic to the preview environment during the idle timeout also keeps it running.
+
+		The oldest preview environments of an application are deleted when there are more than its 'maximumInstances'.
+
 `)
 
 	GCPreviewsExample = templates.Examples(`
 		jx garbage collect previews
 		jx gc previews
+
+		# only scale down preview environments without HTTP traffic
+		jx gc previews --prometheus-url http://prometheus-server.monitoring
 `)
 )
 
@@ -62,6 +85,8 @@ func NewCmdGCPreviews(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	cmd.Flags().StringVarP(&options.PrometheusURL, "prometheus-url", "", "", "The URL of the Prometheus server used to find the HTTP traffic to idle preview environments")
+	cmd.Flags().StringVarP(&options.TrafficQuery, "traffic-query", "", DefaultPreviewTrafficQuery, "The Prometheus query of the number of HTTP requests to a preview environment. $NAMESPACE and $WINDOW are replaced with the namespace of the preview and its idle timeout")
 	return cmd
 }
 
@@ -83,10 +108,27 @@ func (o *GCPreviewsOptions) Run() error {
 		return nil
 	}
 
+	deleteOpts := deletecmd.DeletePreviewOptions{
+		PreviewOptions: preview.PreviewOptions{
+			PromoteOptions: promote.PromoteOptions{
+				CommonOptions: o.CommonOptions,
+			},
+		},
+	}
 	var previewFound bool
+	previews := []v1.Environment{}
 	for _, e := range envs.Items {
 		if e.Spec.Kind == v1.EnvironmentKindTypePreview {
 			previewFound = true
+			now := time.Now()
+			if kube.IsPreviewExpired(&e, now) {
+				log.Logger().Infof("Deleting preview environment %s as it is older than its ttl of %s", util.ColorInfo(e.Name), e.Spec.PreviewLifecycle.TTL.Duration)
+				err = deleteOpts.DeletePreview(e.Name)
+				if err != nil {
+					return fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
+				}
+				continue
+			}
 			gitInfo, err := gits.ParseGitURL(e.Spec.Source.URL)
 			if err != nil {
 				return err
@@ -120,22 +162,90 @@ func (o *GCPreviewsOptions) Run() error {
 
 			if strings.HasPrefix(lowerState, "clos") || strings.HasPrefix(lowerState, "merged") || strings.HasPrefix(lowerState, "superseded") || strings.HasPrefix(lowerState, "declined") {
 				// lets delete the preview environment
-				deleteOpts := deletecmd.DeletePreviewOptions{
-					PreviewOptions: preview.PreviewOptions{
-						PromoteOptions: promote.PromoteOptions{
-							CommonOptions: o.CommonOptions,
-						},
-					},
-				}
 				err = deleteOpts.DeletePreview(e.Name)
 				if err != nil {
 					return fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
 				}
+				continue
+			}
+
+			previews = append(previews, e)
+			err = o.scaleDownIdlePreview(client, currentNs, &e, now)
+			if err != nil {
+				return err
 			}
 		}
 	}
 	if !previewFound {
 		log.Logger().Debug("no preview environments found")
 	}
+
+	for _, e := range kube.GetPreviewsToEvict(previews) {
+		log.Logger().Infof("Deleting preview environment %s as its application has more than %d preview environments",
+			util.ColorInfo(e.Name), e.Spec.PreviewLifecycle.MaximumInstances)
+		err = deleteOpts.DeletePreview(e.Name)
+		if err != nil {
+			return fmt.Errorf("failed to delete preview environment %s: %v\n", e.Name, err)
+		}
+	}
 	return nil
 }
+
+// scaleDownIdlePreview scales the preview environment to zero if it has had no new commits or HTTP traffic for
+// longer than its idle timeout
+func (o *GCPreviewsOptions) scaleDownIdlePreview(jxClient versioned.Interface, ns string, env *v1.Environment, now time.Time) error {
+	lifecycle := env.Spec.PreviewLifecycle
+	if lifecycle == nil || lifecycle.IdleTimeout.Duration <= 0 || kube.IsPreviewScaledDown(env) {
+		return nil
+	}
+	environments := jxClient.JenkinsV1().Environments(ns)
+	if o.PrometheusURL != "" && env.Spec.Namespace != "" {
+		requests, err := o.previewRequests(env.Spec.Namespace, lifecycle.IdleTimeout.Duration)
+		if err != nil {
+			log.Logger().Warnf("Failed to find the HTTP traffic to preview environment %s: %s", env.Name, err)
+		} else if requests > 0 {
+			lastTraffic := metav1.NewTime(now)
+			kube.GetOrCreatePreviewStatus(env).LastTrafficTimestamp = &lastTraffic
+			_, err = environments.PatchUpdate(env)
+			if err != nil {
+				return errors.Wrapf(err, "updating preview environment %s", env.Name)
+			}
+			return nil
+		}
+	}
+	if !kube.IsPreviewIdle(env, now) {
+		return nil
+	}
+
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Scaling down preview environment %s as it has been idle since %s", util.ColorInfo(env.Name),
+		kube.GetPreviewLastActivity(env).Format(time.RFC3339))
+	err = kube.ScaleDownPreview(kubeClient, env)
+	if err != nil {
+		return err
+	}
+	scaledDown := metav1.NewTime(now)
+	kube.GetOrCreatePreviewStatus(env).ScaledDownTimestamp = &scaledDown
+	_, err = environments.PatchUpdate(env)
+	if err != nil {
+		return errors.Wrapf(err, "updating preview environment %s", env.Name)
+	}
+	return nil
+}
+
+// previewRequests returns the number of HTTP requests to the namespace of a preview environment during the window
+func (o *GCPreviewsOptions) previewRequests(ns string, window time.Duration) (float64, error) {
+	query := strings.NewReplacer("$NAMESPACE", ns, "$WINDOW", fmt.Sprintf("%ds", int64(window.Seconds()))).Replace(o.TrafficQuery)
+	values, err := prometheus.QueryValues(o.PrometheusURL, query)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total, nil
+}
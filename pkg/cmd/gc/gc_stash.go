@@ -0,0 +1,131 @@
+package gc
+
+import (
+	"sort"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/collector"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// GCStashOptions contains the command line flags
+type GCStashOptions struct {
+	*opts.CommonOptions
+
+	Classifiers []string
+}
+
+// StashPruneTarget is the storage location of a classifier to prune along with the cutoff time for its content
+type StashPruneTarget struct {
+	Location v1.StorageLocation
+	Before   time.Time
+}
+
+var (
+	gcStashLong = templates.LongDesc(`
+		Garbage collect the files stored by 'jx step stash' and the build controller using the retention policy
+		of each storage location.
+
+		The retention of a storage location is configured via 'jx edit storage --retention-days'. Storage locations
+		without a retention are kept forever. Each classifier only removes its own files using its own retention, even
+		when several classifiers share the same storage. Git storage cannot be garbage collected.
+`)
+
+	gcStashExample = templates.Examples(`
+		# garbage collect the stashed files of all classifiers
+		jx gc stash
+
+		# only garbage collect the build logs
+		jx gc stash -c logs
+`)
+)
+
+// NewCmdGCStash creates the command
+func NewCmdGCStash(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GCStashOptions{
+		CommonOptions: commonOpts,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "stash",
+		Short:   "garbage collection for stashed files using the retention of each storage location",
+		Long:    gcStashLong,
+		Example: gcStashExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&options.Classifiers, "classifier", "c", nil, "The classifiers to garbage collect. If not specified all classifiers are garbage collected")
+	return cmd
+}
+
+// Run implements this command
+func (o *GCStashOptions) Run() error {
+	settings, err := o.TeamSettings()
+	if err != nil {
+		return err
+	}
+	for _, location := range settings.StorageLocations {
+		if location.GitURL != "" && location.RetentionDays > 0 {
+			log.Logger().Warnf("ignoring the retention of %s as git storage cannot be garbage collected",
+				util.ColorInfo(location.Description()))
+		}
+	}
+	targets := StashPruneTargets(settings.StorageLocations, o.Classifiers, time.Now())
+	if len(targets) == 0 {
+		log.Logger().Infof("no storage locations have a retention configured")
+		return nil
+	}
+	for _, target := range targets {
+		coll, err := collector.NewCollector(target.Location, settings, o.Git())
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the collector for storage settings %s", target.Location.Description())
+		}
+		removed, err := coll.Prune(target.Before)
+		if err != nil {
+			return errors.Wrapf(err, "failed to garbage collect %s", target.Location.Description())
+		}
+		for _, u := range removed {
+			log.Logger().Debugf("removed %s", u)
+		}
+		log.Logger().Infof("removed %d files from %s for classifier %s stored before %s", len(removed),
+			util.ColorInfo(target.Location.Description()), util.ColorInfo(target.Location.Classifier), target.Before.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// StashPruneTargets returns the storage locations of the given classifiers which have a retention configured. Each
+// classifier is pruned separately using its own retention as the files of each classifier are stored under their
+// own path even when several classifiers share the same storage. Git storage is skipped as it cannot be pruned
+func StashPruneTargets(locations []v1.StorageLocation, classifiers []string, now time.Time) []StashPruneTarget {
+	answer := []StashPruneTarget{}
+	for _, location := range locations {
+		if location.IsEmpty() || location.RetentionDays <= 0 || location.GitURL != "" {
+			continue
+		}
+		if location.Classifier == "" {
+			location.Classifier = "default"
+		}
+		if len(classifiers) > 0 && util.StringArrayIndex(classifiers, location.Classifier) < 0 {
+			continue
+		}
+		answer = append(answer, StashPruneTarget{
+			Location: location,
+			Before:   now.Add(-time.Duration(location.RetentionDays) * 24 * time.Hour),
+		})
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Location.Classifier < answer[j].Location.Classifier
+	})
+	return answer
+}
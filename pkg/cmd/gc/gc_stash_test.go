@@ -0,0 +1,49 @@
+package gc_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/gc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStashPruneTargets(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2019, time.October, 1, 12, 0, 0, 0, time.UTC)
+	locations := []v1.StorageLocation{
+		{Classifier: "logs", Directory: "/storage/logs", RetentionDays: 30},
+		{Classifier: "reports", BucketURL: "s3://reports", RetentionDays: 365},
+		{Classifier: "tests", BucketURL: "s3://reports", RetentionDays: 7},
+		{Classifier: "coverage", BucketURL: "s3://coverage", RetentionDays: 10},
+		{Classifier: "default", BucketURL: "s3://coverage"},
+		{Classifier: "other", RetentionDays: 1},
+		{Classifier: "git", GitURL: "https://github.com/acme/storage.git", RetentionDays: 5},
+	}
+
+	targets := gc.StashPruneTargets(locations, nil, now)
+	require.Equal(t, 4, len(targets))
+	assert.Equal(t, "coverage", targets[0].Location.Classifier)
+	assert.Equal(t, now.AddDate(0, 0, -10), targets[0].Before)
+	assert.Equal(t, "logs", targets[1].Location.Classifier)
+	assert.Equal(t, "/storage/logs", targets[1].Location.Directory)
+	assert.Equal(t, now.AddDate(0, 0, -30), targets[1].Before)
+	assert.Equal(t, "reports", targets[2].Location.Classifier)
+	assert.Equal(t, now.AddDate(0, 0, -365), targets[2].Before)
+	assert.Equal(t, "tests", targets[3].Location.Classifier)
+	assert.Equal(t, "s3://reports", targets[3].Location.BucketURL)
+	assert.Equal(t, now.AddDate(0, 0, -7), targets[3].Before, "shared storage should use the retention of each classifier")
+
+	targets = gc.StashPruneTargets(locations, []string{"tests"}, now)
+	require.Equal(t, 1, len(targets))
+	assert.Equal(t, "tests", targets[0].Location.Classifier)
+
+	targets = gc.StashPruneTargets(locations, []string{"default"}, now)
+	assert.Equal(t, 0, len(targets), "classifiers without retention should be kept")
+
+	targets = gc.StashPruneTargets(locations, []string{"git"}, now)
+	assert.Equal(t, 0, len(targets), "git storage cannot be pruned")
+}
@@ -1,14 +1,15 @@
 package get
 
 import (
-	"encoding/json"
 	"fmt"
-	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"io"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/table"
 
 	"github.com/spf13/cobra"
 
-	"github.com/ghodss/yaml"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -45,6 +46,12 @@ var (
 
 		# List all URLs for services in the current namespace
 		jx get url
+
+		# List the pipeline activities as CSV
+		jx get activity -o csv
+
+		# List the names of the environments using a go template
+		jx get env -o 'go-template={{range .Items}}{{.Name}}{{"\n"}}{{end}}'
 	`)
 )
 
@@ -77,14 +84,17 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGetBranchPattern(commonOpts))
 	cmd.AddCommand(NewCmdGetBuild(commonOpts))
 	cmd.AddCommand(NewCmdGetBuildPack(commonOpts))
+	cmd.AddCommand(NewCmdGetCanary(commonOpts))
 	cmd.AddCommand(NewCmdGetChat(commonOpts))
 	cmd.AddCommand(NewCmdGetConfig(commonOpts))
 	cmd.AddCommand(NewCmdGetCVE(commonOpts))
+	cmd.AddCommand(NewCmdGetDependencies(commonOpts))
 	cmd.AddCommand(NewCmdGetDevPod(commonOpts))
 	cmd.AddCommand(NewCmdGetEks(commonOpts))
 	cmd.AddCommand(NewCmdGetEnv(commonOpts))
 	cmd.AddCommand(NewCmdGetGit(commonOpts))
 	cmd.AddCommand(NewCmdGetHelmBin(commonOpts))
+	cmd.AddCommand(NewCmdGetHistory(commonOpts))
 	cmd.AddCommand(NewCmdGetIssue(commonOpts))
 	cmd.AddCommand(NewCmdGetIssues(commonOpts))
 	cmd.AddCommand(NewCmdGetLimits(commonOpts))
@@ -108,9 +118,19 @@ func NewCmdGet(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdGetVaultConfig(commonOpts))
 	cmd.AddCommand(NewCmdGetStream(commonOpts))
 	cmd.AddCommand(NewCmdGetPlugins(commonOpts))
+
+	// lets make sure every get command supports the same output formats
+	addOutputFlags(commonOpts, cmd.Commands())
 	return cmd
 }
 
+func addOutputFlags(commonOpts *opts.CommonOptions, commands []*cobra.Command) {
+	for _, c := range commands {
+		commonOpts.AddOutputFlag(c)
+		addOutputFlags(commonOpts, c.Commands())
+	}
+}
+
 // Run implements this command
 func (o *GetOptions) Run() error {
 	return o.Cmd.Help()
@@ -122,31 +142,31 @@ func outputEmptyListWarning(out io.Writer) error {
 	return err
 }
 
+// AddGetFlags adds the common flags of get commands
 func (o *GetOptions) AddGetFlags(cmd *cobra.Command) {
 	o.Cmd = cmd
-	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "The output format such as 'yaml'")
+	usage := "The output format. One of: " + strings.Join(table.Formats, ", ")
+	cmd.Flags().VarP(&table.FormatFlag{Format: &o.Output}, opts.OptionOutput, "o", usage)
+}
+
+// CreateTable creates a new Table using the output format of the get command, falling back to the
+// output format of the common options for get commands which do not add the get flags
+func (o *GetOptions) CreateTable() table.Table {
+	t := o.CommonOptions.CreateTable()
+	if o.Output != "" {
+		t.Format = o.Output
+	}
+	return t
+}
+
+// isObjectOutput returns true if the resources should be rendered as objects rather than as a table
+func (o *GetOptions) isObjectOutput() bool {
+	return table.IsObjectFormat(o.Output)
 }
 
 // renderResult renders the result in a given output format
 func (o *GetOptions) renderResult(value interface{}, format string) error {
-	switch format {
-	case "json":
-		data, err := json.Marshal(value)
-		if err != nil {
-			return err
-		}
-		_, e := o.Out.Write(data)
-		return e
-	case "yaml":
-		data, err := yaml.Marshal(value)
-		if err != nil {
-			return err
-		}
-		_, e := o.Out.Write(data)
-		return e
-	default:
-		return fmt.Errorf("Unsupported output format: %s", format)
-	}
+	return table.RenderObject(o.Out, value, format)
 }
 
 func formatInt32(n int32) string {
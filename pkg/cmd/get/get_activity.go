@@ -111,9 +111,7 @@ func (o *GetActivityOptions) Run() error {
 	for _, activity := range list.Items {
 		o.addTableRow(&table, &activity)
 	}
-	table.Render()
-
-	return nil
+	return table.Render()
 }
 
 func (o *GetActivityOptions) addTableRow(table *tbl.Table, activity *v1.PipelineActivity) bool {
@@ -187,7 +185,10 @@ func (o *GetActivityOptions) onActivity(table *tbl.Table, obj interface{}, yamlS
 		if old == "" || old != text {
 			yamlSpecMap[name] = text
 			if o.addTableRow(table, activity) {
-				table.Render()
+				err = table.Render()
+				if err != nil {
+					log.Logger().Warnf("Failed to render the activity %s: %s", name, err)
+				}
 				table.Clear()
 			}
 		}
@@ -87,6 +87,5 @@ func (o *GetAddonOptions) Run() error {
 			table.AddRow(release.ReleaseName, addonName, enableText, release.Status, release.ChartVersion)
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
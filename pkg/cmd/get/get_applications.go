@@ -141,8 +141,7 @@ func (o *GetApplicationsOptions) Run() error {
 
 	table := o.generateTable(apps, envApps, kubeClient, kserveClient)
 
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func (o *GetApplicationsOptions) generateTable(apps []string, envApps []EnvApps, kubeClient kubernetes.Interface, kserveClient kserve.Interface) table.Table {
@@ -163,7 +162,11 @@ func (o *GetApplicationsOptions) generateTable(apps []string, envApps []EnvApps,
 					}
 					version = kube.GetVersion(&d.ObjectMeta)
 					url := ""
-					if !o.HideUrl {
+					if ea.Environment.Spec.RemoteCluster {
+						if !o.HideUrl {
+							url = remoteApplicationURL(&ea.Environment, appName)
+						}
+					} else if !o.HideUrl {
 						names := []string{appName}
 						if d.Name != appName {
 							names = append(names, d.Name)
@@ -278,8 +281,12 @@ func (o *GetApplicationsOptions) getAppData(kubeClient kubernetes.Interface) (na
 			namespaces = append(namespaces, ens)
 			if ens != "" && env.Name != kube.LabelValueDevEnvironment {
 				envNames = append(envNames, env.Name)
-				m, err := kube.GetDeployments(kubeClient, ens)
-				if err == nil {
+				m := remoteEnvironmentDeployments(&env)
+				var deployErr error
+				if !env.Spec.RemoteCluster {
+					m, deployErr = kube.GetDeployments(kubeClient, ens)
+				}
+				if deployErr == nil {
 					envApp := EnvApps{
 						Environment: env,
 						Apps:        map[string]v1beta1.Deployment{},
@@ -347,3 +354,38 @@ func (o *GetApplicationsOptions) generateTableHeaders(envApps []EnvApps) table.T
 	t.AddRow(titles...)
 	return t
 }
+
+// remoteEnvironmentDeployments returns the deployments of the applications in a remote Environment from the status
+// reported by its agent as the deployments are not in this cluster
+func remoteEnvironmentDeployments(env *v1.Environment) map[string]v1beta1.Deployment {
+	answer := map[string]v1beta1.Deployment{}
+	for _, app := range env.Status.Applications {
+		replicas := app.Replicas
+		answer[app.Name] = v1beta1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      app.Name,
+				Namespace: env.Spec.Namespace,
+				Labels: map[string]string{
+					"version": app.Version,
+				},
+			},
+			Spec: v1beta1.DeploymentSpec{
+				Replicas: &replicas,
+			},
+			Status: v1beta1.DeploymentStatus{
+				ReadyReplicas: app.ReadyReplicas,
+			},
+		}
+	}
+	return answer
+}
+
+// remoteApplicationURL returns the URL of the application in a remote Environment reported by its agent
+func remoteApplicationURL(env *v1.Environment, appName string) string {
+	for _, app := range env.Status.Applications {
+		if app.Name == appName {
+			return app.URL
+		}
+	}
+	return ""
+}
@@ -29,7 +29,7 @@ type GetAppsOptions struct {
 }
 
 type appsResult struct {
-	AppOutput []appOutput `json:"items"`
+	Items []appOutput `json:"items"`
 }
 
 type appOutput struct {
@@ -172,13 +172,12 @@ func (o *GetAppsOptions) Run() error {
 		return nil
 	}
 
-	if o.Output != "" {
+	if o.isObjectOutput() {
 		appsResult := o.generateTableFormatted(apps)
 		return o.renderResult(appsResult, o.Output)
 	}
 	table := o.generateTable(apps, kubeClient)
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func (o *GetAppsOptions) generateAppStatusOutput(app *v1.App) error {
@@ -204,7 +203,7 @@ func (o *GetAppsOptions) generateTableFormatted(apps *v1.AppList) appsResult {
 				if releaseStatus, ok := releases[name]; ok {
 					status = releaseStatus
 				}
-				results.AppOutput = append(results.AppOutput, appOutput{
+				results.Items = append(results.Items, appOutput{
 					Name:            name,
 					Version:         app.Labels[helm.LabelAppVersion],
 					ChartRepository: app.Annotations[helm.AnnotationAppRepository],
@@ -259,7 +258,7 @@ func (o *GetAppsOptions) printHelmResourcesWithFormat(helmOutputJSON string) err
 	if err != nil {
 		return err
 	}
-	if o.Output == "" {
+	if !o.isObjectOutput() {
 		fmt.Fprintln(o.Out, h.helmInfoStatus.Resources)
 		return nil
 	}
@@ -59,6 +59,5 @@ func (o *GetBranchPatternOptions) Run() error {
 	table := o.CreateTable()
 	table.AddRow("BRANCH PATTERNS")
 	table.AddRow(patterns.DefaultBranchPattern)
-	table.Render()
-	return nil
+	return table.Render()
 }
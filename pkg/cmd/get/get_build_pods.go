@@ -123,6 +123,5 @@ func (o *GetBuildPodsOptions) Run() error {
 			table.AddRow(build.Organisation, build.Repository, build.Branch, build.Build, build.Context, duration, build.Status(), build.FirstStepImage, build.PodName, build.GitURL)
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
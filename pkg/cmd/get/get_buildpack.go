@@ -101,6 +101,5 @@ func (o *GetBuildPackOptions) Run() error {
 	} else {
 		table.AddRow(settings.BuildPackName, settings.BuildPackURL, settings.BuildPackRef)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -0,0 +1,149 @@
+package get
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// GetCanaryOptions containers the CLI options
+type GetCanaryOptions struct {
+	GetOptions
+
+	Environment string
+	Namespace   string
+}
+
+var (
+	getCanaryLong = templates.LongDesc(`
+		Display the Flagger Canary releases of the applications in the permanent Environments.
+
+		The progress of each canary shows the percentage of the traffic routed to the new version and the number of
+		failed checks compared to the threshold after which the canary is rolled back.
+`)
+
+	getCanaryExample = templates.Examples(`
+		# List the canary releases in all the permanent environments
+		jx get canary
+
+		# List the canary releases in production
+		jx get canary --env production
+	`)
+)
+
+// NewCmdGetCanary creates the new command for: jx get canary
+func NewCmdGetCanary(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetCanaryOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "canaries",
+		Short:   "Display the Flagger Canary releases of the applications",
+		Aliases: []string{"canary"},
+		Long:    getCanaryLong,
+		Example: getCanaryExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Environment, "env", "e", "", "The Environment to display the canaries of. Defaults to all the permanent environments")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace to display the canaries of")
+
+	options.AddGetFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *GetCanaryOptions) Run() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	client, err := o.DynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the dynamic client")
+	}
+
+	envNamespaces := map[string]string{}
+	namespaces := []string{}
+	if o.Namespace != "" {
+		namespaces = append(namespaces, o.Namespace)
+	} else {
+		envMap, envNames, err := kube.GetEnvironments(jxClient, ns)
+		if err != nil {
+			return err
+		}
+		for _, name := range envNames {
+			env := envMap[name]
+			if env == nil || env.Spec.Namespace == "" || (o.Environment != "" && name != o.Environment) {
+				continue
+			}
+			if o.Environment == "" && env.Spec.Kind != v1.EnvironmentKindTypePermanent {
+				continue
+			}
+			envNamespaces[env.Spec.Namespace] = name
+			namespaces = append(namespaces, env.Spec.Namespace)
+		}
+		if o.Environment != "" && len(namespaces) == 0 {
+			return fmt.Errorf("could not find an Environment called %s", o.Environment)
+		}
+	}
+
+	canaries := []flagger.Canary{}
+	for _, envNS := range namespaces {
+		installed, err := flagger.IsInstalled(client, envNS)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			log.Logger().Infof("Flagger is not installed. To install it try: %s", util.ColorInfo("jx create addon flagger"))
+			return nil
+		}
+		list, err := flagger.ListCanaries(client, envNS)
+		if err != nil {
+			return err
+		}
+		canaries = append(canaries, list...)
+	}
+
+	if o.isObjectOutput() {
+		return o.renderResult(canaries, o.Output)
+	}
+	if len(canaries) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+	table := o.CreateTable()
+	table.AddRow("NAME", "ENVIRONMENT", "PHASE", "WEIGHT", "FAILED CHECKS", "ITERATIONS", "LAST TRANSITION")
+	for _, canary := range canaries {
+		envName := envNamespaces[canary.Namespace]
+		if envName == "" {
+			envName = canary.Namespace
+		}
+		status := canary.Status
+		lastTransition := ""
+		if !status.LastTransitionTime.IsZero() {
+			lastTransition = status.LastTransitionTime.Format(time.RFC3339)
+		}
+		table.AddRow(canary.Name, envName, string(status.Phase),
+			fmt.Sprintf("%d%%", status.CanaryWeight),
+			fmt.Sprintf("%d/%d", status.FailedChecks, canary.Spec.CanaryAnalysis.Threshold),
+			fmt.Sprintf("%d", status.Iterations),
+			lastTransition)
+	}
+	return table.Render()
+}
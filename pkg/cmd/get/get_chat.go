@@ -88,6 +88,5 @@ func (o *GetChatOptions) Run() error {
 			table.AddRow(s.Name, s.URL)
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -90,6 +90,5 @@ func (o *GetConfigOptions) Run() error {
 			table.AddRow("User Chat", ch.Kind, ch.URL, ch.UserChannel)
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -132,6 +132,5 @@ func (o *GetCVEOptions) Run() error {
 		return fmt.Errorf("error getting vulnerability table for image %s: %v", query.ImageID, err)
 	}
 
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -0,0 +1,221 @@
+package get
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/dependencymatrix"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/table"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// outputFormatDOT renders the dependencies as a graphviz DOT graph
+	outputFormatDOT = "dot"
+)
+
+// GetDependenciesOptions the command line options
+type GetDependenciesOptions struct {
+	GetOptions
+
+	Dirs      []string
+	Direct    bool
+	UpgradeTo string
+}
+
+var (
+	getDependenciesLong = templates.LongDesc(`
+		Displays the repositories which depend on a repository directly or transitively and the versions they depend on, using the dependency matrix of one or more repositories.
+
+		If no repository is specified all the dependencies in the dependency matrix are displayed.
+
+		Use --upgrade-to to display the pull requests which would be created in the downstream repositories by upgrading the repository to a version.
+
+		Use '-o dot' to output a graphviz DOT graph.
+
+`)
+
+	getDependenciesExample = templates.Examples(`
+		# List the dependencies in the dependency matrix of the current directory
+		jx get dependencies
+
+		# List the repositories which depend on a library directly or transitively
+		jx get dependencies myorg/mylib
+
+		# List the repositories which depend on a library directly
+		jx get dependencies myorg/mylib --direct
+
+		# List the pull requests which would be created by upgrading a library to a version
+		jx get dependencies myorg/mylib --upgrade-to 1.2.3
+
+		# Render the dependents of a library using the dependency matrices of several repositories as a DOT graph
+		jx get dependencies myorg/mylib --dir ../app1 --dir ../app2 -o dot | dot -Tpng > dependents.png
+	`)
+)
+
+// NewCmdGetDependencies creates the command
+func NewCmdGetDependencies(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetDependenciesOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "dependencies [repository]",
+		Short:   "Displays the repositories which depend on a repository from the dependency matrix",
+		Long:    getDependenciesLong,
+		Example: getDependenciesExample,
+		Aliases: []string{"dependency", "deps", "dependents"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().VarP(&dependenciesFormatFlag{FormatFlag: table.FormatFlag{Format: &options.Output}}, opts.OptionOutput, "o",
+		"The output format. One of: "+strings.Join(append(table.Formats, outputFormatDOT), ", "))
+	cmd.Flags().StringArrayVarP(&options.Dirs, "dir", "d", []string{"."}, "The directories of the git repositories containing a dependency matrix")
+	cmd.Flags().BoolVarP(&options.Direct, "direct", "", false, "Only display the repositories which depend on the repository directly")
+	cmd.Flags().StringVarP(&options.UpgradeTo, "upgrade-to", "u", "", "Displays the pull requests which would be created by upgrading the repository to this version")
+	return cmd
+}
+
+// dependenciesFormatFlag is an output format flag which also supports the DOT format
+type dependenciesFormatFlag struct {
+	table.FormatFlag
+}
+
+// Set validates and sets the format
+func (f *dependenciesFormatFlag) Set(value string) error {
+	if value == outputFormatDOT {
+		*f.Format = value
+		return nil
+	}
+	return f.FormatFlag.Set(value)
+}
+
+// Run implements this command
+func (o *GetDependenciesOptions) Run() error {
+	graph, err := o.loadDependencyGraph()
+	if err != nil {
+		return err
+	}
+	if len(o.Args) == 0 {
+		if o.UpgradeTo != "" {
+			return util.MissingArgument("repository")
+		}
+		return o.renderGraph(graph)
+	}
+	name := o.Args[0]
+	if o.UpgradeTo != "" {
+		return o.renderImpact(graph, name)
+	}
+	return o.renderDependents(graph, name)
+}
+
+// loadDependencyGraph loads the dependency matrix of each directory into a graph
+func (o *GetDependenciesOptions) loadDependencyGraph() (*dependencymatrix.DependencyGraph, error) {
+	graph := dependencymatrix.NewDependencyGraph()
+	for _, dir := range o.Dirs {
+		gitInfo, err := o.FindGitInfo(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find the git repository of %s", dir)
+		}
+		matrix, err := dependencymatrix.LoadDependencyMatrix(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load the dependency matrix of %s", dir)
+		}
+		if len(matrix.Dependencies) == 0 {
+			log.Logger().Warnf("No dependency matrix found in %s", util.ColorWarning(filepath.Join(dir, dependencymatrix.DependencyMatrixDirName)))
+		}
+		graph.AddMatrix(dependencymatrix.DependencyDetails{
+			Host:  gitInfo.Host,
+			Owner: gitInfo.Organisation,
+			Repo:  gitInfo.Name,
+			URL:   gitInfo.URL,
+		}, matrix)
+	}
+	return graph, nil
+}
+
+func (o *GetDependenciesOptions) renderGraph(graph *dependencymatrix.DependencyGraph) error {
+	if o.Output == outputFormatDOT {
+		return graph.WriteDOT(o.Out)
+	}
+	if o.isObjectOutput() {
+		return o.renderResult(graph, o.Output)
+	}
+	if len(graph.Edges) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+	table := o.CreateTable()
+	table.AddRow("REPOSITORY", "DEPENDENCY", "VERSION")
+	for _, e := range graph.Edges {
+		table.AddRow(e.From, e.To, e.Version)
+	}
+	return table.Render()
+}
+
+func (o *GetDependenciesOptions) renderDependents(graph *dependencymatrix.DependencyGraph, name string) error {
+	dependency, err := graph.FindNode(name)
+	if err != nil {
+		return err
+	}
+	dependents, err := graph.Dependents(name, o.Direct)
+	if err != nil {
+		return err
+	}
+	if o.Output == outputFormatDOT {
+		return dependencymatrix.DependentsGraph(dependency, dependents).WriteDOT(o.Out)
+	}
+	if o.isObjectOutput() {
+		return o.renderResult(dependents, o.Output)
+	}
+	if len(dependents) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+	table := o.CreateTable()
+	table.AddRow("DEPENDENT", "VERSION", "VIA")
+	for _, d := range dependents {
+		via := []string{}
+		for _, p := range d.Path {
+			via = append(via, p.String())
+		}
+		table.AddRow(d.Repository.String(), d.Version, strings.Join(via, " -> "))
+	}
+	return table.Render()
+}
+
+func (o *GetDependenciesOptions) renderImpact(graph *dependencymatrix.DependencyGraph, name string) error {
+	impacts, err := graph.Impact(name, o.UpgradeTo)
+	if err != nil {
+		return err
+	}
+	if o.Output == outputFormatDOT {
+		return dependencymatrix.ImpactGraph(impacts).WriteDOT(o.Out)
+	}
+	if o.isObjectOutput() {
+		return o.renderResult(impacts, o.Output)
+	}
+	if len(impacts) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+	table := o.CreateTable()
+	table.AddRow("REPOSITORY", "UPGRADE", "FROM", "TO", "DEPTH")
+	for _, i := range impacts {
+		toVersion := i.ToVersion
+		if toVersion == "" {
+			toVersion = "next release"
+		}
+		table.AddTypedRow(i.Repository.String(), i.Dependency.String(), i.FromVersion, toVersion, i.Depth)
+	}
+	return table.Render()
+}
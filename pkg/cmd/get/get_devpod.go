@@ -109,6 +109,5 @@ func (o *GetDevPodOptions) Run() error {
 		}
 	}
 
-	table.Render()
-	return nil
+	return table.Render()
 }
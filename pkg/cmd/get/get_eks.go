@@ -11,8 +11,6 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/jenkins-x/jx/pkg/cloud/amazon"
-	"github.com/pkg/errors"
-	"sigs.k8s.io/yaml"
 
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
@@ -117,19 +115,12 @@ func (o *GetEksOptions) Run() error {
 			return err
 		}
 
-		if o.Output == "" {
-			log.Logger().Infof("NAME")
-			log.Logger().Infof(cluster)
-		} else if o.Output == "yaml" {
-			reservations, err := yaml.Marshal(instances.Reservations)
-			if err != nil {
-				return err
-			}
-			log.Logger().Infof(string(reservations))
-		} else {
-			return errors.New("Invalid output format.")
+		if o.isObjectOutput() {
+			return o.renderResult(instances.Reservations, o.Output)
 		}
-
-		return nil
+		table := o.CreateTable()
+		table.AddRow("NAME")
+		table.AddRow(cluster)
+		return table.Render()
 	}
 }
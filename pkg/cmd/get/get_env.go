@@ -95,7 +95,10 @@ func (o *GetEnvOptions) Run() error {
 		table := o.CreateTable()
 		table.AddRow("NAME", "LABEL", "KIND", "NAMESPACE", "SOURCE", "REF", "PR")
 		table.AddRow(e, spec.Label, spec.Namespace, kindString(spec), spec.Source.URL, spec.Source.Ref, spec.PullRequestURL)
-		table.Render()
+		err = table.Render()
+		if err != nil {
+			return err
+		}
 		log.Blank()
 
 		ens := env.Spec.Namespace
@@ -114,7 +117,7 @@ func (o *GetEnvOptions) Run() error {
 				table.AddRow(d.Name, kube.GetVersion(&d.ObjectMeta), replicas,
 					formatInt32(d.Status.ReadyReplicas), formatInt32(d.Status.UpdatedReplicas), formatInt32(d.Status.AvailableReplicas), "")
 			}
-			table.Render()
+			return table.Render()
 		}
 	} else {
 		envs, err := client.JenkinsV1().Environments(ns).List(metav1.ListOptions{})
@@ -129,7 +132,7 @@ func (o *GetEnvOptions) Run() error {
 		environments := o.filterEnvironments(envs.Items)
 		kube.SortEnvironments(environments)
 
-		if o.Output != "" {
+		if o.isObjectOutput() {
 			envs.Items = environments
 			return o.renderResult(envs, o.Output)
 		}
@@ -148,7 +151,7 @@ func (o *GetEnvOptions) Run() error {
 				table.AddRow(env.Name, spec.Label, kindString(spec), string(spec.PromotionStrategy), spec.Namespace, util.Int32ToA(spec.Order), spec.Cluster, spec.Source.URL, spec.Source.Ref, spec.PullRequestURL)
 			}
 		}
-		table.Render()
+		return table.Render()
 	}
 	return nil
 }
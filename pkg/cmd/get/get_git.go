@@ -68,6 +68,5 @@ func (o *GetGitOptions) Run() error {
 		}
 		table.AddRow(s.Name, kind, s.URL)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -0,0 +1,188 @@
+package get
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetHistoryOptions containers the CLI options
+type GetHistoryOptions struct {
+	GetOptions
+
+	Environment string
+	Application string
+	Since       time.Duration
+	SkipGit     bool
+}
+
+// promotionLogFormat separates the commits with %x1e and the fields of each commit with %x1f
+const promotionLogFormat = "--format=%an%x1f%ct%x1f%B%x1e"
+
+var (
+	getHistoryLong = templates.LongDesc(`
+		Display the history of the promotions of applications to an Environment.
+
+		The history is built from the promote steps of the pipeline activities and the commits to the git repository of
+		the Environment, so that promotions made without a pipeline, such as by merging a Pull Request by hand, are
+		included too.
+`)
+
+	getHistoryExample = templates.Examples(`
+		# List the promotions to staging
+		jx get history --env staging
+
+		# List what changed in staging today
+		jx get history --env staging --since 24h
+
+		# List the promotions of an application to production as YAML
+		jx get history --env production --app myapp -o yaml
+	`)
+)
+
+// NewCmdGetHistory creates the new command for: jx get history
+func NewCmdGetHistory(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &GetHistoryOptions{
+		GetOptions: GetOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "Display the history of the promotions to an Environment",
+		Aliases: []string{"promotions"},
+		Long:    getHistoryLong,
+		Example: getHistoryExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Environment, "env", "e", "", "The Environment to display the promotion history of")
+	cmd.Flags().StringVarP(&options.Application, "app", "a", "", "Only display the promotions of this application")
+	cmd.Flags().DurationVarP(&options.Since, "since", "s", 0, "Only display the promotions within this duration such as 24h")
+	cmd.Flags().BoolVarP(&options.SkipGit, "skip-git", "", false, "Do not include the commits to the git repository of the Environment")
+
+	options.AddGetFlags(cmd)
+	return cmd
+}
+
+// Run implements this command
+func (o *GetHistoryOptions) Run() error {
+	if o.Environment == "" {
+		return util.MissingOption("env")
+	}
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	env, err := kube.GetEnvironment(jxClient, ns, o.Environment)
+	if err != nil {
+		return errors.Wrapf(err, "getting environment %s", o.Environment)
+	}
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "listing the pipeline activities in namespace %s", ns)
+	}
+	activityRecords := kube.GetPromotionRecords(activities.Items, env.Name)
+
+	commitRecords := []kube.PromotionRecord{}
+	if !o.SkipGit && env.Spec.Source.URL != "" {
+		commitRecords, err = o.getCommitPromotions(env.Name, env.Spec.Source.URL)
+		if err != nil {
+			return err
+		}
+	}
+
+	records := []kube.PromotionRecord{}
+	for _, record := range kube.MergePromotionHistory(activityRecords, commitRecords) {
+		if o.Application != "" && record.Application != o.Application {
+			continue
+		}
+		if o.Since > 0 && record.Timestamp.Before(time.Now().Add(-o.Since)) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if o.isObjectOutput() {
+		return o.renderResult(records, o.Output)
+	}
+	if len(records) == 0 {
+		return outputEmptyListWarning(o.Out)
+	}
+	table := o.CreateTable()
+	table.AddRow("APPLICATION", "FROM", "TO", "STATUS", "PULL REQUEST", "AUTHOR", "TIME")
+	for _, record := range records {
+		to := record.Version
+		if record.Rollback {
+			to += " (rollback)"
+		}
+		table.AddRow(record.Application, record.FromVersion, to, string(record.Status), record.PullRequestURL,
+			record.Author, record.Timestamp.Format(time.RFC3339))
+	}
+	return table.Render()
+}
+
+// getCommitPromotions returns the promotions in the commits which changed the requirements of the git repository of
+// the Environment
+func (o *GetHistoryOptions) getCommitPromotions(envName string, gitURL string) ([]kube.PromotionRecord, error) {
+	dir, err := ioutil.TempDir("", "jx-env-history-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	log.Logger().Debugf("cloning %s to find the promotions to environment %s", util.ColorInfo(gitURL), util.ColorInfo(envName))
+	err = o.Git().Clone(gitURL, dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cloning %s", gitURL)
+	}
+	cmd := util.Command{
+		Dir:  dir,
+		Name: "git",
+		Args: []string{"log", promotionLogFormat, "--", filepath.Join(helm.DefaultEnvironmentChartDir, helm.RequirementsFileName)},
+	}
+	out, err := cmd.RunWithoutRetry()
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the commits of %s", gitURL)
+	}
+	return parsePromotionLog(envName, out), nil
+}
+
+// parsePromotionLog parses the promotions in the output of git log using the promotionLogFormat
+func parsePromotionLog(envName string, out string) []kube.PromotionRecord {
+	answer := []kube.PromotionRecord{}
+	for _, rawCommit := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(rawCommit), "\x1f", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			log.Logger().Warnf("ignoring commit with invalid timestamp %s", fields[1])
+			continue
+		}
+		record := kube.ParsePromotionCommit(envName, fields[0], time.Unix(seconds, 0), fields[2])
+		if record != nil {
+			answer = append(answer, *record)
+		}
+	}
+	return answer
+}
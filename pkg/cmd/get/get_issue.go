@@ -125,8 +125,7 @@ func (o *GetIssueOptions) Run() error {
 	if !found {
 		table.AddRow(issue.URL, *issue.State, "", "")
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func (o *GetIssueOptions) findRelease(tracker issues.IssueProvider, issue *gits.GitIssue, releases []v1.Release) *v1.Release {
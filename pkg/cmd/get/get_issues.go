@@ -75,8 +75,7 @@ func (o *GetIssuesOptions) Run() error {
 	for _, i := range issues {
 		table.AddRow(i.URL, i.Title)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func (o *GetIssuesOptions) matchesFilter(job *gojenkins.Job) bool {
@@ -11,7 +11,6 @@ import (
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
 
-	"strconv"
 	"time"
 
 	"github.com/jenkins-x/jx/pkg/log"
@@ -106,14 +105,12 @@ func (o *GetLimitsOptions) Run() error {
 					resetLabel = d.String()
 				}
 
-				table.AddRow(s.Name, s.URL, u.Username, strconv.Itoa(r.Resources.Core.Limit), strconv.Itoa(r.Resources.Core.Remaining), resetLabel)
+				table.AddTypedRow(s.Name, s.URL, u.Username, r.Resources.Core.Limit, r.Resources.Core.Remaining, resetLabel)
 			}
 		}
 
 	}
-	table.Render()
-
-	return nil
+	return table.Render()
 }
 
 func (o *GetLimitsOptions) GetLimits(server string, username string, apitoken string) (RateLimits, error) {
@@ -112,7 +112,7 @@ func (o *GetPipelineOptions) Run() error {
 			return outputEmptyListWarning(o.Out)
 		}
 
-		if o.Output != "" {
+		if o.isObjectOutput() {
 			return o.renderResult(jobs, o.Output)
 		}
 
@@ -125,8 +125,7 @@ func (o *GetPipelineOptions) Run() error {
 			}
 			o.dump(jenkins, job.Name, &table)
 		}
-		table.Render()
-		return nil
+		return table.Render()
 	}
 	o.ProwOptions = prow.Options{
 		KubeClient: client,
@@ -145,7 +144,7 @@ func (o *GetPipelineOptions) Run() error {
 		return outputEmptyListWarning(o.Out)
 	}
 
-	if o.Output != "" {
+	if o.isObjectOutput() {
 		return o.renderResult(names, o.Output)
 	}
 
@@ -157,9 +156,7 @@ func (o *GetPipelineOptions) Run() error {
 		}
 		table.AddRow(j, "N/A", "N/A", "N/A", "N/A")
 	}
-	table.Render()
-
-	return nil
+	return table.Render()
 }
 
 func createTable(o *GetPipelineOptions) table.Table {
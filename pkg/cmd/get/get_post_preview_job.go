@@ -77,7 +77,5 @@ func (o *GetPostPreviewJobOptions) Run() error {
 		}
 		table.AddRow(name, image, backoffLimit, strings.Join(commands, " "))
 	}
-	table.Render()
-
-	return nil
+	return table.Render()
 }
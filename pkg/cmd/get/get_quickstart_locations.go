@@ -81,6 +81,5 @@ func (o *GetQuickstartLocationOptions) Run() error {
 		}
 		table.AddRow(location.GitURL, kind, location.Owner, strings.Join(location.Includes, ", "), strings.Join(location.Excludes, ", "))
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -91,6 +91,5 @@ func (o *GetQuickstartsOptions) Run() error {
 			table.AddRow(qs.Name, qs.Owner, qs.Version, qs.Language, qs.DownloadZipURL)
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -90,6 +90,5 @@ func (o *GetReleaseOptions) Run() error {
 	for _, release := range releases {
 		table.AddRow(release.Spec.Name, release.Spec.Version)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -85,7 +85,5 @@ func (o *GetSecretOptions) Run() error {
 	for _, secret := range secrets {
 		table.AddRow(secret)
 	}
-	table.Render()
-
-	return nil
+	return table.Render()
 }
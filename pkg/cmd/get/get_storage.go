@@ -82,6 +82,5 @@ func (o *GetStorageOptions) Run() error {
 			table.AddRow(n, ls.Description())
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -3,7 +3,6 @@ package get
 import (
 	"strings"
 
-	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/jenkins-x/jx/pkg/versionstream"
 
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
@@ -25,12 +24,15 @@ type GetStreamOptions struct {
 	Kind               string
 	VersionsRepository string
 	VersionsGitRef     string
+	Explain            bool
 }
 
 var (
 	getStreamLong = templates.LongDesc(`
 		Displays the version of a chart, package or docker image from the Version Stream
 
+		If the team's version stream has overrides layered on top of it the layer each version is resolved from is displayed. Use --explain to see the version defined by every layer.
+
 		For more information see: [https://jenkins-x.io/architecture/version-stream/](https://jenkins-x.io/architecture/version-stream/)
 
 `)
@@ -41,6 +43,9 @@ var (
 
 		# List the version of a chart
 		jx get stream -k charts jenkins-x/tekton
+
+		# Explain how each layer of the version stream contributes to the version of a chart
+		jx get stream -k charts jenkins-x/tekton --explain
 	`)
 )
 
@@ -66,8 +71,9 @@ func NewCmdGetStream(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "docker", "The kind of version. Possible values: "+strings.Join(versionstream.KindStrings, ", "))
-	cmd.Flags().StringVarP(&options.VersionsRepository, "repo", "r", config.DefaultVersionsURL, "Jenkins X versions Git repo")
+	cmd.Flags().StringVarP(&options.VersionsRepository, "repo", "r", "", "Jenkins X versions Git repo. Defaults to the version stream of the team along with its overrides")
 	cmd.Flags().StringVarP(&options.VersionsGitRef, "versions-ref", "", "", "Jenkins X versions Git repository reference (tag, branch, sha etc)")
+	cmd.Flags().BoolVarP(&options.Explain, "explain", "", false, "Displays the version defined by each layer of the version stream")
 	return cmd
 }
 
@@ -84,12 +90,18 @@ func (o *GetStreamOptions) Run() error {
 	name := args[0]
 
 	kind := versionstream.VersionKind(o.Kind)
+	if o.Explain {
+		err = o.explain(resolver, kind, name)
+		if err != nil {
+			return err
+		}
+	}
 	if kind == versionstream.KindDocker {
 		result, err := resolver.ResolveDockerImage(name)
 		if err != nil {
 			return errors.Wrapf(err, "failed to resolve docker image %s", name)
 		}
-		log.Logger().Infof("resolved image %s to %s", util.ColorInfo(name), util.ColorInfo(result))
+		log.Logger().Infof("resolved image %s to %s%s", util.ColorInfo(name), util.ColorInfo(result), o.layerDescription(resolver, kind, name))
 		return nil
 	}
 
@@ -98,6 +110,45 @@ func (o *GetStreamOptions) Run() error {
 		return errors.Wrapf(err, "failed to resolve %s version of %s", o.Kind, name)
 	}
 
-	log.Logger().Infof("resolved %s %s to version: %s", util.ColorInfo(name), util.ColorInfo(o.Kind), util.ColorInfo(n))
+	log.Logger().Infof("resolved %s %s to version: %s%s", util.ColorInfo(name), util.ColorInfo(o.Kind), util.ColorInfo(n), o.layerDescription(resolver, kind, name))
 	return nil
 }
+
+// explain renders a table of the version defined by each layer of the version stream
+func (o *GetStreamOptions) explain(resolver *versionstream.VersionResolver, kind versionstream.VersionKind, name string) error {
+	resolutions, err := resolver.Explain(kind, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to explain the %s version of %s", o.Kind, name)
+	}
+	table := o.CreateTable()
+	table.AddRow("LAYER", "VERSION", "FILE")
+	for _, r := range resolutions {
+		layer := r.Layer.String()
+		version := r.Description()
+		if r.Found && r.Version.Version != "" {
+			version = r.Version.Version
+		}
+		if r.Selected {
+			layer = util.ColorInfo(layer)
+			version = util.ColorInfo(version)
+		}
+		table.AddRow(layer, version, r.File)
+	}
+	return table.Render()
+}
+
+// layerDescription returns a description of the layer the version is resolved from if the version stream has overrides
+func (o *GetStreamOptions) layerDescription(resolver *versionstream.VersionResolver, kind versionstream.VersionKind, name string) string {
+	if len(resolver.Overrides) == 0 {
+		return ""
+	}
+	if kind == versionstream.KindDocker {
+		// lets strip any tag from the image to find its version file
+		name = strings.Split(name, ":")[0]
+	}
+	_, layer, err := resolver.ResolveStableVersion(kind, name)
+	if err != nil || layer == nil {
+		return ""
+	}
+	return " from layer " + util.ColorInfo(layer.String())
+}
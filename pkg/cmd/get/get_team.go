@@ -86,8 +86,7 @@ See https://jenkins-x.io/getting-started/\n for more detail
 	for _, team := range teams {
 		table.AddRow(team.Name)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func (o *GetTeamOptions) getPendingTeams() error {
@@ -119,7 +118,6 @@ There are no pending Teams yet. Try create one via: jx create team --pending
 		spec := &team.Spec
 		table.AddRow(team.Name, string(team.Status.ProvisionStatus), string(spec.Kind), strings.Join(spec.Members, ", "))
 	}
-	table.Render()
-	return nil
+	return table.Render()
 
 }
@@ -82,6 +82,5 @@ There are no Team roles defined so far!
 		}
 		table.AddRow(name, title, description)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -1,9 +1,14 @@
 package get
 
 import (
+	"time"
+
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/spf13/cobra"
 )
 
@@ -11,10 +16,28 @@ import (
 type GetTokenOptions struct {
 	GetOptions
 
-	Kind string
-	Name string
+	Kind     string
+	Name     string
+	Expiring bool
+	Within   time.Duration
 }
 
+var (
+	getTokenLong = templates.LongDesc(`
+		Display the tokens for different kinds of services
+
+		Use the --expiring flag to list the git, chat and docker registry tokens which have expired or expire soon
+`)
+
+	getTokenExample = templates.Examples(`
+		# List the tokens which have expired or expire within the next week
+		jx get token --expiring
+
+		# List the tokens which have expired or expire within the next 30 days
+		jx get token --expiring --within 720h
+	`)
+)
+
 // NewCmdGetToken creates the command
 func NewCmdGetToken(commonOpts *opts.CommonOptions) *cobra.Command {
 	options := &GetTokenOptions{
@@ -26,6 +49,8 @@ func NewCmdGetToken(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "token",
 		Short:   "Display the tokens for different kinds of services",
+		Long:    getTokenLong,
+		Example: getTokenExample,
 		Aliases: []string{"api-token"},
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
@@ -34,6 +59,8 @@ func NewCmdGetToken(commonOpts *opts.CommonOptions) *cobra.Command {
 			helper.CheckErr(err)
 		},
 	}
+	cmd.Flags().BoolVarP(&options.Expiring, "expiring", "", false, "Lists the git, chat and docker registry tokens which have expired or expire soon")
+	cmd.Flags().DurationVarP(&options.Within, "within", "", 7*24*time.Hour, "The duration from now in which a token expiring is reported when using --expiring")
 	cmd.AddCommand(NewCmdGetTokenAddon(commonOpts))
 	return cmd
 }
@@ -45,9 +72,56 @@ func (o *GetTokenOptions) addFlags(cmd *cobra.Command) {
 
 // Run implements this command
 func (o *GetTokenOptions) Run() error {
+	if o.Expiring {
+		return o.displayExpiringTokens()
+	}
 	return o.Cmd.Help()
 }
 
+// displayExpiringTokens displays the git, chat and docker registry tokens which have expired or expire soon
+func (o *GetTokenOptions) displayExpiringTokens() error {
+	configs := map[string]func() (auth.ConfigService, error){
+		kube.ValueKindGit:  o.CreateGitAuthConfigService,
+		kube.ValueKindChat: o.CreateChatAuthConfigService,
+		kube.ValueKindDocker: func() (auth.ConfigService, error) {
+			return o.AuthConfigService(auth.DockerAuthConfigFile)
+		},
+	}
+	now := time.Now()
+
+	table := o.CreateTable()
+	table.AddRow("TYPE", "KIND", "NAME", "URL", "USERNAME", "CREATED", "EXPIRES")
+	for _, tokenType := range []string{kube.ValueKindGit, kube.ValueKindChat, kube.ValueKindDocker} {
+		authConfigSvc, err := configs[tokenType]()
+		if err == nil && len(authConfigSvc.Config().Servers) == 0 {
+			// some services are created without loading their configuration
+			_, err = authConfigSvc.LoadConfig()
+		}
+		if err != nil {
+			log.Logger().Warnf("failed to load the %s auth configuration: %s", tokenType, err)
+			continue
+		}
+		config := authConfigSvc.Config()
+		for _, s := range config.Servers {
+			for _, u := range s.Users {
+				if !u.ExpiresWithin(now, o.Within) {
+					continue
+				}
+				created := ""
+				if u.Created != nil {
+					created = u.Created.Format(time.RFC3339)
+				}
+				expires := u.Expires.Format(time.RFC3339)
+				if u.IsExpired(now) {
+					expires += " (expired)"
+				}
+				table.AddRow(tokenType, s.Kind, s.Name, s.URL, u.Username, created, expires)
+			}
+		}
+	}
+	return table.Render()
+}
+
 func (o *GetTokenOptions) displayUsersWithTokens(authConfigSvc auth.ConfigService) error {
 	config := authConfigSvc.Config()
 
@@ -77,6 +151,5 @@ func (o *GetTokenOptions) displayUsersWithTokens(authConfigSvc auth.ConfigServic
 			}
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
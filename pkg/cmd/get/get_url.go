@@ -95,6 +95,5 @@ func (o *GetURLOptions) Run() error {
 		}
 		table.AddRow(u.Name, text)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
@@ -93,7 +93,6 @@ There are no Users yet. Try create one via: jx create user
 			table.AddRow(name, spec.Name, spec.Email, spec.URL, strings.Join(roleNames, ", "))
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 
 }
@@ -81,7 +81,5 @@ func (o *GetVaultOptions) Run() error {
 	for _, vault := range vaults {
 		table.AddRow(vault.Name, vault.URL, vault.AuthServiceAccountName)
 	}
-	table.Render()
-
-	return nil
+	return table.Render()
 }
@@ -89,8 +89,7 @@ func (o *GetWorkflowOptions) Run() error {
 	for _, workflow := range workflows.Items {
 		table.AddRow(workflow.Name)
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func (o *GetWorkflowOptions) getWorkflow(name string, jxClient versioned.Interface, ns string) error {
@@ -46,6 +46,7 @@ import (
 	"gopkg.in/AlecAivazis/survey.v1/terminal"
 	gitcfg "gopkg.in/src-d/go-git.v4/config"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -65,6 +66,7 @@ const (
 	OptionNoBrew           = "no-brew"
 	OptionRelease          = "release"
 	OptionServerName       = "name"
+	OptionOutput           = "output"
 	OptionOutputDir        = "output-dir"
 	OptionServerURL        = "url"
 	OptionSkipAuthSecMerge = "skip-auth-secrets-merge"
@@ -118,6 +120,7 @@ type CommonOptions struct {
 	NoBrew                 bool
 	RemoteCluster          bool
 	Out                    terminal.FileWriter
+	Output                 string
 	ServiceAccount         string
 	SkipAuthSecretsMerge   bool
 	Username               string
@@ -175,9 +178,25 @@ func (o *CommonOptions) SetFactory(f clients.Factory) {
 	o.factory = f
 }
 
-// CreateTable creates a new Table
+// CreateTable creates a new Table using the output format
 func (o *CommonOptions) CreateTable() table.Table {
-	return o.factory.CreateTable(o.Out)
+	t := o.factory.CreateTable(o.Out)
+	t.Format = o.Output
+	return t
+}
+
+// AddOutputFlag adds the --output flag used to choose the output format of tables. The -o shorthand is only
+// used if the command does not already use it for another flag
+func (o *CommonOptions) AddOutputFlag(cmd *cobra.Command) {
+	if cmd.Flags().Lookup(OptionOutput) != nil {
+		return
+	}
+	shorthand := "o"
+	if cmd.Flags().ShorthandLookup(shorthand) != nil {
+		shorthand = ""
+	}
+	usage := "The output format. One of: " + strings.Join(table.Formats, ", ")
+	cmd.Flags().VarP(&table.FormatFlag{Format: &o.Output}, OptionOutput, shorthand, usage)
 }
 
 // NotifyProgress by default logs info to the console but a custom callback can be added to send feedback to, say, a web UI
@@ -533,9 +552,31 @@ func (o *CommonOptions) SetFakeGitProvider(provider *gits.FakeProvider) {
 // NewHelm cerates a new helm client from the given list of parameters
 func (o *CommonOptions) NewHelm(verbose bool, helmBinary string, noTiller bool, helmTemplate bool) helm.Helmer {
 	o.helm = o.factory.CreateHelm(o.Verbose, helmBinary, noTiller, helmTemplate)
+	switch h := o.helm.(type) {
+	case *helm.HelmTemplate:
+		h.Client.ChartProvenance = o.chartProvenance
+		h.Client.InstallHelm3 = o.InstallHelm3
+	case *helm.Helm3CLI:
+		h.ChartProvenance = o.chartProvenance
+		h.InstallHelm3 = o.InstallHelm3
+	case *helm.HelmCLI:
+		h.ChartProvenance = o.chartProvenance
+		h.InstallHelm3 = o.InstallHelm3
+	}
 	return o.helm
 }
 
+// chartProvenance returns the provenance policy and keyring of the repository of a chart from the version stream.
+// Only an already configured version resolver is used as creating one needs the dev environment and clones the
+// version stream, so charts are not verified when there is none
+func (o *CommonOptions) chartProvenance(chart string, repo string) (versionstream.ProvenancePolicy, string, error) {
+	if o.versionResolver == nil {
+		log.Logger().Debugf("not verifying the provenance of chart %s as there is no version stream configured", chart)
+		return versionstream.ProvenanceNone, "", nil
+	}
+	return o.versionResolver.ChartProvenance(chart, repo)
+}
+
 // Helm returns or creates the helm client
 func (o *CommonOptions) Helm() helm.Helmer {
 	if o.helm == nil {
@@ -1187,6 +1228,15 @@ func (o *CommonOptions) IstioClient() (istioclient.Interface, error) {
 	return istioclient.NewForConfig(config)
 }
 
+// DynamicClient creates a new dynamic Kubernetes client for custom resources which have no generated client
+func (o *CommonOptions) DynamicClient() (dynamic.Interface, error) {
+	config, err := o.factory.CreateKubeConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
 // IsFlagExplicitlySet checks whether the flag with the specified name is explicitly set by the user.
 // If so, true is returned, false otherwise.
 func (o *CommonOptions) IsFlagExplicitlySet(flagName string) bool {
@@ -7,7 +7,9 @@ import (
 	"path"
 	"testing"
 
+	jxfake "github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/versionstream"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -123,6 +125,36 @@ func Test_JXNamespace(t *testing.T) {
 	}
 }
 
+func Test_chartProvenance_without_dev_environment(t *testing.T) {
+	RegisterMockTestingT(t)
+
+	factory := clientmocks.NewMockFactory()
+	jxClient := jxfake.NewSimpleClientset()
+	When(factory.CreateJXClient()).ThenReturn(jxClient, "jx", nil)
+	o := &CommonOptions{}
+	o.SetFactory(factory)
+
+	policy, keyring, err := o.chartProvenance("jenkins-x/jenkins-x-platform", "")
+	require.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceNone, policy)
+	assert.Equal(t, "", keyring)
+
+	envs, err := jxClient.JenkinsV1().Environments("jx").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, envs.Items, "no dev environment should be created")
+}
+
+func Test_chartProvenance_with_version_resolver(t *testing.T) {
+	o := &CommonOptions{}
+	o.SetVersionResolver(&versionstream.VersionResolver{
+		VersionsDir: path.Join("..", "..", "versionstream", "test_data", "jenkins-x-versions"),
+	})
+
+	policy, _, err := o.chartProvenance("jenkins-x/jenkins-x-platform", "")
+	require.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceRequired, policy)
+}
+
 func Test_GetConfiguration(t *testing.T) {
 	setupTestCommand()
 
@@ -15,6 +15,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/versionstream"
 
 	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/secreturl/kubesecrets"
 	"github.com/jenkins-x/jx/pkg/secreturl/localvault"
 	"github.com/pborman/uuid"
 
@@ -402,6 +403,14 @@ func (o *CommonOptions) InstallChartWithOptionsAndTimeout(options helm.InstallCh
 	if err != nil {
 		return err
 	}
+	if options.VersionsDir == "" && options.VersionResolver == nil && options.VersionsGitURL == "" && options.VersionsGitRef == "" {
+		// lets use the team's version stream along with any overrides
+		options.VersionResolver, err = o.GetVersionResolver()
+		if err != nil {
+			return err
+		}
+		options.VersionsDir = options.VersionResolver.VersionsDir
+	}
 	if options.VersionsDir == "" {
 		options.VersionsDir, err = o.CloneJXVersionsRepo(options.VersionsGitURL, options.VersionsGitRef)
 		if err != nil {
@@ -434,6 +443,16 @@ func (o *CommonOptions) GetSecretURLClient(location secrets.SecretsLocationKind)
 			return o.secretURLClient, errors.Wrapf(err, "getting the file system secrets directory")
 		}
 		o.secretURLClient = localvault.NewFileSystemClient(dir)
+	case secrets.KubeLocationKind:
+		kubeClient, ns, err := o.KubeClientAndDevNamespace()
+		if err != nil {
+			return o.secretURLClient, errors.Wrapf(err, "creating the kube client")
+		}
+		requirements, _, err := config.LoadRequirementsConfig("")
+		if err == nil && requirements.Cluster.SecretsNamespace != "" {
+			ns = requirements.Cluster.SecretsNamespace
+		}
+		o.secretURLClient = kubesecrets.NewKubeSecretsClient(kubeClient, ns)
 	case secrets.AutoLocationKind:
 		location := o.detectSecretsLocation()
 		o.secretURLClient, err = o.GetSecretURLClient(location)
@@ -733,6 +752,9 @@ func (o *CommonOptions) AddChartRepos(dir string, helmBinary string, chartRepos
 	}
 	if chartRepos != nil {
 		for _, url := range chartRepos {
+			if helm.IsOCIChart(url) {
+				continue
+			}
 			if !util.StringMapHasValue(installedChartRepos, url) {
 				_, err = o.AddHelmBinaryRepoIfMissing(url, "", "", "")
 				if err != nil {
@@ -766,7 +788,7 @@ func (o *CommonOptions) AddChartRepos(dir string, helmBinary string, chartRepos
 			}
 			for _, dep := range requirements.Dependencies {
 				repo := dep.Repository
-				if repo != "" && !util.StringMapHasValue(installedChartRepos, repo) && repo != DefaultChartRepo && !strings.HasPrefix(repo, "file:") && !strings.HasPrefix(repo, "alias:") && !strings.HasPrefix(repo, "@") {
+				if repo != "" && !helm.IsOCIChart(repo) && !util.StringMapHasValue(installedChartRepos, repo) && repo != DefaultChartRepo && !strings.HasPrefix(repo, "file:") && !strings.HasPrefix(repo, "alias:") && !strings.HasPrefix(repo, "@") {
 					name, err := o.AddHelmBinaryRepoIfMissing(repo, "", "", "")
 					if err != nil {
 						return errors.Wrapf(err, "failed to add Helm repository '%s'", repo)
@@ -786,6 +808,67 @@ func (o *CommonOptions) AddChartRepos(dir string, helmBinary string, chartRepos
 	return nil
 }
 
+// exportOCIDependencies exports the dependencies of the chart in the directory from OCI registries into the chart as
+// helm 2 cannot build them and points the requirements file at them. The returned function restores the requirements
+// file and removes the exported charts so they are only used while building the dependencies
+func (o *CommonOptions) exportOCIDependencies(dir string, helmBinary string) (func(), error) {
+	noop := func() {}
+	reqfile := filepath.Join(dir, helm.RequirementsFileName)
+	exists, err := util.FileExists(reqfile)
+	if err != nil || !exists {
+		return noop, err
+	}
+	data, err := ioutil.ReadFile(reqfile)
+	if err != nil {
+		return noop, errors.Wrapf(err, "failed to read the Helm requirements file %s", reqfile)
+	}
+	requirements, err := helm.LoadRequirements(data)
+	if err != nil {
+		return noop, errors.Wrap(err, "failed to load the Helm requirements file")
+	}
+	exportDir := filepath.Join(dir, helm.OCIChartsDir)
+	restore := func() {
+		err := ioutil.WriteFile(reqfile, data, util.DefaultWritePermissions)
+		if err != nil {
+			log.Logger().Warnf("failed to restore the Helm requirements file %s: %s", reqfile, err)
+		}
+		err = os.RemoveAll(exportDir)
+		if err != nil {
+			log.Logger().Warnf("failed to remove the exported OCI charts %s: %s", exportDir, err)
+		}
+	}
+	changed := false
+	for _, dep := range requirements.Dependencies {
+		repo := dep.Repository
+		if !helm.IsOCIChart(repo) {
+			continue
+		}
+		if !changed {
+			err = o.InstallHelm3()
+			if err != nil {
+				return noop, errors.Wrap(err, "failed to install helm 3 which is required for OCI registries")
+			}
+		}
+		ociClient := helm.NewOCIClient(helm.OCIBinary(helmBinary), dir)
+		localRepo, err := ociClient.ExportDependency(dir, dep)
+		if err != nil {
+			restore()
+			return noop, errors.Wrapf(err, "failed to export the dependency %s from the OCI registry %s", dep.Name, repo)
+		}
+		dep.Repository = localRepo
+		changed = true
+	}
+	if !changed {
+		return noop, nil
+	}
+	err = helm.SaveFile(reqfile, requirements)
+	if err != nil {
+		restore()
+		return noop, errors.Wrap(err, "failed to save the Helm requirements file")
+	}
+	return restore, nil
+}
+
 // GetInstalledChartRepos retruns the installed chart repositories
 func (o *CommonOptions) GetInstalledChartRepos(helmBinary string) (map[string]string, error) {
 	return o.Helm().ListRepos()
@@ -856,6 +939,11 @@ func (o *CommonOptions) HelmInitDependencyBuild(dir string, chartRepos []string,
 	if err != nil {
 		return helmBin, err
 	}
+	restore, err := o.exportOCIDependencies(dir, helmBin)
+	if err != nil {
+		return helmBin, err
+	}
+	defer restore()
 	// TODO due to this issue: https://github.com/kubernetes/helm/issues/4230
 	// lets stick with helm2 for this step
 	//
@@ -877,10 +965,15 @@ func (o *CommonOptions) HelmInitDependencyBuild(dir string, chartRepos []string,
 
 // HelmInitRecursiveDependencyBuild helm initialises the dependencies recursively
 func (o *CommonOptions) HelmInitRecursiveDependencyBuild(dir string, chartRepos []string, valuesFiles []string) error {
-	_, err := o.HelmInitDependency(dir, chartRepos)
+	helmBin, err := o.HelmInitDependency(dir, chartRepos)
 	if err != nil {
 		return errors.Wrap(err, "initializing Helm")
 	}
+	restore, err := o.exportOCIDependencies(dir, helmBin)
+	if err != nil {
+		return err
+	}
+	defer restore()
 
 	helmBinary := o.Helm().HelmBinary()
 	o.Helm().SetHelmBinary("helm")
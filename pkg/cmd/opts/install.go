@@ -85,6 +85,9 @@ const (
 	CloudEnvSopsConfigFile      = ".sops.yaml"
 	DefaultInstallTimeout       = "6000"
 	DefaultCloudEnvironmentsURL = "https://github.com/jenkins-x/cloud-environments"
+
+	// defaultHelm3Version the version of helm 3 installed if there is none in the version stream
+	defaultHelm3Version = "3.2.4"
 )
 
 // DoInstallMissingDependencies install missing dependencies from the given list
@@ -422,15 +425,27 @@ func (o *CommonOptions) InstallHelm3() error {
 	if err != nil {
 		return err
 	}
-	binary := "helm3"
+	binary := helm.Helm3Binary
 	fileName, flag, err := packages.ShouldInstallBinary(binary)
 	if err != nil || !flag {
 		return err
 	}
 
-	// https://get.helm.sh/helm-v3.0.0-alpha.1-darwin-amd64.tar.gz
-	latestVersion := "v3.0.0-alpha.1"
-	clientURL := fmt.Sprintf("https://get.helm.sh/helm-%v-%s-%s.tar.gz", latestVersion, runtime.GOOS, runtime.GOARCH)
+	latestVersion := defaultHelm3Version
+	resolver, err := o.GetVersionResolver()
+	if err != nil {
+		log.Logger().Warnf("failed to load the version stream, using helm %s: %s", latestVersion, err)
+	} else {
+		stableVersion, err := resolver.StableVersionNumber(versionstream.KindPackage, binary)
+		if err != nil {
+			return errors.Wrapf(err, "resolving the version of %s from the version stream", binary)
+		}
+		if stableVersion != "" {
+			latestVersion = stableVersion
+		}
+	}
+	// https://get.helm.sh/helm-v3.2.4-darwin-amd64.tar.gz
+	clientURL := fmt.Sprintf("https://get.helm.sh/helm-v%s-%s-%s.tar.gz", strings.TrimPrefix(latestVersion, "v"), runtime.GOOS, runtime.GOARCH)
 
 	tmpDir := filepath.Join(binDir, "helm3.tmp")
 	err = os.MkdirAll(tmpDir, util.DefaultWritePermissions)
@@ -1,9 +1,15 @@
 package opts
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/helm"
 	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/pkg/errors"
 
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/table"
@@ -11,15 +17,103 @@ import (
 	"github.com/jenkins-x/jx/pkg/version"
 )
 
-// CreateVersionResolver creates a new VersionResolver service
+// CreateVersionResolver creates a new VersionResolver service. If no repository is specified the version stream
+// and overrides of the team are used
 func (o *CommonOptions) CreateVersionResolver(repo string, gitRef string) (*versionstream.VersionResolver, error) {
+	var overrides []config.VersionStreamOverride
+	if repo == "" {
+		settings, err := o.TeamSettings()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load TeamSettings")
+		}
+		for _, override := range settings.VersionStreamOverrides {
+			overrides = append(overrides, config.VersionStreamOverride{
+				Name: override.Name,
+				URL:  override.URL,
+				Ref:  override.Ref,
+			})
+		}
+	}
+	return o.CreateVersionResolverWithOverrides(repo, gitRef, overrides)
+}
+
+// CreateVersionResolverWithOverrides creates a new VersionResolver service which layers the overrides on top of
+// the version stream. Overrides can be git repositories or local directories
+func (o *CommonOptions) CreateVersionResolverWithOverrides(repo string, gitRef string, overrides []config.VersionStreamOverride) (*versionstream.VersionResolver, error) {
 	versionsDir, err := o.CloneJXVersionsRepo(repo, gitRef)
 	if err != nil {
 		return nil, err
 	}
-	return &versionstream.VersionResolver{
-		VersionsDir: versionsDir,
-	}, nil
+	upstream := &versionstream.Layer{
+		Dir: versionsDir,
+		URL: repo,
+		Ref: o.versionStreamCommit(versionsDir, gitRef),
+	}
+	layers := []*versionstream.Layer{}
+	for _, override := range overrides {
+		layer, err := o.cloneVersionStreamOverride(override)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to clone the version stream override %s", override.Name)
+		}
+		layers = append(layers, layer)
+	}
+	return versionstream.NewLayeredVersionResolver(upstream, layers...), nil
+}
+
+// cloneVersionStreamOverride clones the git repository of the override returning its layer. Local directories are
+// used as they are
+func (o *CommonOptions) cloneVersionStreamOverride(override config.VersionStreamOverride) (*versionstream.Layer, error) {
+	if override.Name == "" {
+		return nil, util.MissingOption("name")
+	}
+	if override.URL == "" {
+		return nil, fmt.Errorf("no URL for version stream override %s", override.Name)
+	}
+	layer := &versionstream.Layer{
+		Name: override.Name,
+		Dir:  override.URL,
+		URL:  override.URL,
+	}
+	exists, err := util.DirExists(override.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		configDir, err := util.ConfigDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "error determining config dir")
+		}
+		layer.Dir = filepath.Join(configDir, "jenkins-x-versions-overrides", override.Name)
+		err = os.RemoveAll(layer.Dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to remove directory %s", layer.Dir)
+		}
+		err = os.MkdirAll(layer.Dir, util.DefaultWritePermissions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create directory %s", layer.Dir)
+		}
+		log.Logger().Debugf("cloning version stream override %s from %s ref %s", override.Name, override.URL, override.Ref)
+		err = o.Git().ShallowClone(layer.Dir, override.URL, override.Ref, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to clone %s", override.URL)
+		}
+	}
+	layer.Ref = o.versionStreamCommit(layer.Dir, override.Ref)
+	return layer, nil
+}
+
+// versionStreamCommit returns the commit SHA of a version stream directory or the given ref if it is not a git repository
+func (o *CommonOptions) versionStreamCommit(dir string, ref string) string {
+	exists, err := util.DirExists(filepath.Join(dir, ".git"))
+	if err != nil || !exists {
+		return ref
+	}
+	sha, err := o.Git().GetLatestCommitSha(dir)
+	if err != nil {
+		log.Logger().Debugf("failed to find the latest commit of %s: %s", dir, err)
+		return ref
+	}
+	return sha
 }
 
 // GetVersionResolver gets a VersionResolver, lazy creating one if required so we can reuse it later
@@ -98,7 +192,11 @@ func (o *CommonOptions) GetPackageVersions(ns string, helmTLS bool) (map[string]
 		log.Logger().Warnf("Failed to get helm version: %s", err)
 	} else {
 		helmBinary, noTiller, helmTemplate, _ := o.TeamHelmBin()
-		if helmBinary == "helm3" || noTiller || helmTemplate {
+		if helmBinary == helm.Helm3Binary {
+			v := helm.ParseHelm3Version(output)
+			table.AddRow("helm client", info(v))
+			packages[helm.Helm3Binary] = v
+		} else if noTiller || helmTemplate {
 			table.AddRow("helm client", info(output))
 		} else {
 			for i, line := range strings.Split(output, "\n") {
@@ -0,0 +1,216 @@
+package preview
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultDependenciesEnvironment the Environment whose versions of the sibling applications are deployed into
+	// preview environments by default
+	DefaultDependenciesEnvironment = "staging"
+
+	// LabelPreviewSeed the label of the seed Jobs of a preview environment
+	LabelPreviewSeed = "jenkins.io/preview-seed"
+
+	dependencyValuesFileName = "dependency-values.yaml"
+)
+
+// ResolvePreviewDependencies returns the chart dependencies of the sibling applications using the versions and
+// repositories in the requirements of the Environment
+func ResolvePreviewDependencies(envRequirements *helm.Requirements, apps []config.PreviewDependencyApplication) ([]*helm.Dependency, error) {
+	answer := []*helm.Dependency{}
+	for _, app := range apps {
+		var found *helm.Dependency
+		if envRequirements != nil {
+			for _, dep := range envRequirements.Dependencies {
+				if dep != nil && (dep.Name == app.Name || dep.Alias == app.Name) {
+					found = dep
+					break
+				}
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("application %s is not in the requirements of the environment", app.Name)
+		}
+		dep := *found
+		if app.Version != "" {
+			dep.Version = app.Version
+		}
+		answer = append(answer, &dep)
+	}
+	return answer, nil
+}
+
+// PreviewDependencyValues returns the values of the sibling applications in the values of the Environment
+func PreviewDependencyValues(envValues map[string]interface{}, deps []*helm.Dependency) map[string]interface{} {
+	answer := map[string]interface{}{}
+	for _, dep := range deps {
+		key := dep.Alias
+		if key == "" {
+			key = dep.Name
+		}
+		if value, ok := envValues[key]; ok {
+			answer[key] = value
+		}
+	}
+	return answer
+}
+
+// CreateSeedJob creates the Job which seeds the data of a preview environment
+func CreateSeedJob(seed config.PreviewSeedJob, ns string) *batchv1.Job {
+	name := naming.ToValidName(seed.Name)
+	envVars := []corev1.EnvVar{}
+	keys := []string{}
+	for k := range seed.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		envVars = append(envVars, corev1.EnvVar{Name: k, Value: seed.Env[k]})
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels: map[string]string{
+				LabelPreviewSeed: name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						LabelPreviewSeed: name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    name,
+							Image:   seed.Image,
+							Command: seed.Command,
+							Args:    seed.Args,
+							Env:     envVars,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// addPreviewDependencies adds the sibling applications at their versions in the git repository of the Environment to
+// the requirements of the preview chart in the directory, returning the values file of the applications if they
+// have values in the Environment
+func (o *PreviewOptions) addPreviewDependencies(dir string, deps *config.PreviewDependenciesConfig) (string, error) {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return "", err
+	}
+	envName := deps.Environment
+	if envName == "" {
+		envName = DefaultDependenciesEnvironment
+	}
+	env, err := kube.GetEnvironment(jxClient, ns, envName)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting environment %s", envName)
+	}
+	if env.Spec.Source.URL == "" {
+		return "", fmt.Errorf("environment %s has no git repository", envName)
+	}
+
+	cloneDir, err := ioutil.TempDir("", "preview-dependencies-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(cloneDir)
+	err = o.Git().ShallowClone(cloneDir, env.Spec.Source.URL, env.Spec.Source.Ref, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "cloning the git repository %s of environment %s", env.Spec.Source.URL, envName)
+	}
+	envDir := filepath.Join(cloneDir, helm.DefaultEnvironmentChartDir)
+	envRequirements, err := helm.LoadRequirementsFile(filepath.Join(envDir, helm.RequirementsFileName))
+	if err != nil {
+		return "", errors.Wrapf(err, "loading the requirements of environment %s", envName)
+	}
+	dependencies, err := ResolvePreviewDependencies(envRequirements, deps.Applications)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolving the dependencies of the preview from environment %s", envName)
+	}
+
+	requirementsFile := filepath.Join(dir, helm.RequirementsFileName)
+	requirements, err := helm.LoadRequirementsFile(requirementsFile)
+	if err != nil {
+		return "", err
+	}
+	for _, dep := range dependencies {
+		log.Logger().Infof("Adding %s version %s from environment %s to the preview", util.ColorInfo(dep.Name), util.ColorInfo(dep.Version), util.ColorInfo(envName))
+		requirements.SetAppVersion(dep.Name, dep.Version, dep.Repository, dep.Alias)
+		if dep.Repository != "" && !strings.HasPrefix(dep.Repository, "file://") {
+			_, err = o.AddHelmBinaryRepoIfMissing(dep.Repository, "", "", "")
+			if err != nil {
+				return "", errors.Wrapf(err, "adding the helm repository %s", dep.Repository)
+			}
+		}
+	}
+	err = helm.SaveFile(requirementsFile, requirements)
+	if err != nil {
+		return "", err
+	}
+	o.Helm().SetCWD(dir)
+	err = o.Helm().BuildDependency()
+	if err != nil {
+		return "", errors.Wrap(err, "building the dependencies of the preview chart")
+	}
+
+	envValues, err := helm.LoadValuesFile(filepath.Join(envDir, helm.ValuesFileName))
+	if err != nil {
+		return "", errors.Wrapf(err, "loading the values of environment %s", envName)
+	}
+	values := PreviewDependencyValues(envValues, dependencies)
+	if len(values) == 0 {
+		return "", nil
+	}
+	valuesFile := filepath.Join(dir, dependencyValuesFileName)
+	err = helm.SaveFile(valuesFile, values)
+	if err != nil {
+		return "", err
+	}
+	return valuesFile, nil
+}
+
+// runSeedJobs runs the seed Jobs of the preview environment waiting for them to complete
+func (o *PreviewOptions) runSeedJobs(kubeClient kubernetes.Interface, ns string, envVars map[string]string) error {
+	if o.Dependencies == nil || len(o.Dependencies.Seeds) == 0 {
+		return nil
+	}
+	createdJobs := []*batchv1.Job{}
+	for _, seed := range o.Dependencies.Seeds {
+		job := o.modifyJob(CreateSeedJob(seed, ns), envVars)
+		log.Logger().Infof("Triggering seed Job %s in namespace %s", util.ColorInfo(job.Name), util.ColorInfo(ns))
+		createdJob, err := o.recreateJob(kubeClient, ns, job)
+		if err != nil {
+			return errors.Wrapf(err, "creating seed Job %s", job.Name)
+		}
+		createdJobs = append(createdJobs, createdJob)
+	}
+	return o.waitForJobsToComplete(kubeClient, createdJobs)
+}
@@ -0,0 +1,69 @@
+package preview_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/cmd/preview"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestResolvePreviewDependencies(t *testing.T) {
+	t.Parallel()
+	envRequirements := &helm.Requirements{
+		Dependencies: []*helm.Dependency{
+			{Name: "exposecontroller", Version: "2.3.89", Repository: "http://chartmuseum.jenkins-x.io"},
+			{Name: "orders", Version: "0.0.12", Repository: "http://jenkins-x-chartmuseum:8080"},
+			{Name: "customers", Version: "1.2.0", Repository: "http://jenkins-x-chartmuseum:8080", Alias: "crm"},
+		},
+	}
+	apps := []config.PreviewDependencyApplication{
+		{Name: "orders"},
+		{Name: "crm", Version: "1.3.0"},
+	}
+
+	deps, err := preview.ResolvePreviewDependencies(envRequirements, apps)
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+	assert.Equal(t, helm.Dependency{Name: "orders", Version: "0.0.12", Repository: "http://jenkins-x-chartmuseum:8080"}, *deps[0])
+	assert.Equal(t, helm.Dependency{Name: "customers", Version: "1.3.0", Repository: "http://jenkins-x-chartmuseum:8080", Alias: "crm"}, *deps[1])
+	assert.Equal(t, "1.2.0", envRequirements.Dependencies[2].Version, "the environment requirements should not be modified")
+
+	values := preview.PreviewDependencyValues(map[string]interface{}{
+		"orders":           map[string]interface{}{"replicaCount": float64(2)},
+		"crm":              map[string]interface{}{"db": "postgres"},
+		"exposecontroller": map[string]interface{}{"enabled": true},
+	}, deps)
+	assert.Equal(t, map[string]interface{}{
+		"orders": map[string]interface{}{"replicaCount": float64(2)},
+		"crm":    map[string]interface{}{"db": "postgres"},
+	}, values)
+
+	_, err = preview.ResolvePreviewDependencies(envRequirements, []config.PreviewDependencyApplication{{Name: "payments"}})
+	assert.Error(t, err)
+}
+
+func TestCreateSeedJob(t *testing.T) {
+	t.Parallel()
+	job := preview.CreateSeedJob(config.PreviewSeedJob{
+		Name:    "restore-db",
+		Image:   "postgres:11",
+		Command: []string{"pg_restore"},
+		Args:    []string{"-d", "orders", "/fixtures/orders.dump"},
+		Env:     map[string]string{"PGUSER": "orders", "PGHOST": "orders-db"},
+	}, "jx-myorg-myapp-pr-1")
+
+	assert.Equal(t, "restore-db", job.Name)
+	assert.Equal(t, "jx-myorg-myapp-pr-1", job.Namespace)
+	assert.Equal(t, "restore-db", job.Labels[preview.LabelPreviewSeed])
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	require.Len(t, podSpec.Containers, 1)
+	container := podSpec.Containers[0]
+	assert.Equal(t, "postgres:11", container.Image)
+	assert.Equal(t, []string{"pg_restore"}, container.Command)
+	assert.Equal(t, []corev1.EnvVar{{Name: "PGHOST", Value: "orders-db"}, {Name: "PGUSER", Value: "orders"}}, container.Env)
+}
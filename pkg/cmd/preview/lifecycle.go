@@ -0,0 +1,118 @@
+package preview
+
+import (
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updatePreviewStatus records a new commit to the preview environment which wakes it up if it was scaled down
+func updatePreviewStatus(env *v1.Environment) {
+	status := kube.GetOrCreatePreviewStatus(env)
+	now := metav1.Now()
+	status.LastCommitTimestamp = &now
+	status.ScaledDownTimestamp = nil
+}
+
+// WakePreview scales the deployments of a preview environment which was scaled down when idle back up
+func (o *PreviewOptions) WakePreview(name string) error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	environments := jxClient.JenkinsV1().Environments(ns)
+	env, err := environments.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "getting preview environment %s", name)
+	}
+	if !kube.IsPreviewEnvironment(env) {
+		return fmt.Errorf("environment %s is not a preview environment", name)
+	}
+	if !kube.IsPreviewScaledDown(env) {
+		log.Logger().Infof("Preview environment %s is already running", util.ColorInfo(name))
+		return nil
+	}
+	err = kube.WakePreview(kubeClient, env)
+	if err != nil {
+		return err
+	}
+	// treat waking on demand as traffic so that the preview is not scaled down again straight away
+	status := kube.GetOrCreatePreviewStatus(env)
+	now := metav1.Now()
+	status.LastTrafficTimestamp = &now
+	status.ScaledDownTimestamp = nil
+	_, err = environments.PatchUpdate(env)
+	if err != nil {
+		return errors.Wrapf(err, "updating preview environment %s", name)
+	}
+	log.Logger().Infof("Woke preview environment %s", util.ColorInfo(name))
+	return nil
+}
+
+// EvictPreviews deletes the oldest preview environments of each application which exceed the maximum number of
+// instances configured in the jenkins-x.yml of the application, never deleting the preview environment to keep
+func (o *PreviewOptions) EvictPreviews(jxClient versioned.Interface, ns string, keep string) error {
+	envs, err := jxClient.JenkinsV1().Environments(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "listing environments in namespace %s", ns)
+	}
+	for _, env := range kube.GetPreviewsToEvict(envs.Items) {
+		if env.Name == keep {
+			continue
+		}
+		log.Logger().Infof("Evicting preview environment %s as its application has more than %d preview environments",
+			util.ColorInfo(env.Name), env.Spec.PreviewLifecycle.MaximumInstances)
+		err = o.DeletePreview(env.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePreview deletes the helm release, Environment and namespace of the preview environment
+func (o *PreviewOptions) DeletePreview(name string) error {
+	jxClient, ns, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	env, err := kube.GetEnvironment(jxClient, ns, name)
+	if err != nil {
+		return err
+	}
+	releaseName := kube.GetPreviewEnvironmentReleaseName(env)
+	if len(releaseName) > 0 {
+		log.Logger().Infof("Deleting helm release: %s", util.ColorInfo(releaseName))
+		err = o.Helm().DeleteRelease(ns, releaseName, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Logger().Infof("Deleting preview environment: %s", util.ColorInfo(name))
+	err = jxClient.JenkinsV1().Environments(ns).Delete(name, &metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Deleted environment %s", util.ColorInfo(name))
+
+	envNs := env.Spec.Namespace
+	if envNs == "" {
+		return fmt.Errorf("No namespace for environment %s", name)
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	return kubeClient.CoreV1().Namespaces().Delete(envNs, &metav1.DeleteOptions{})
+}
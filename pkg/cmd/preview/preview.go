@@ -49,6 +49,14 @@ var (
 	previewLong = templates.LongDesc(`
 		Creates or updates a Preview Environment for the given Pull Request or Branch.
 
+		The previewEnvironments section of the jenkins-x.yml can configure a 'ttl' after which the Preview Environment is deleted,
+		an 'idleTimeout' without HTTP traffic or new commits after which it is scaled to zero until the next commit and the
+		'maximumInstances' of Preview Environments of the application after which the oldest ones are deleted.
+
+		Its 'dependencies' can deploy the versions of sibling applications in the git repository of an Environment
+		(staging by default) with the Preview Environment and run 'seeds' Jobs such as restoring a database fixture
+		before the post preview Jobs.
+
 		For more documentation on Preview Environments see: [https://jenkins-x.io/about/features/#preview-environments](https://jenkins-x.io/about/features/#preview-environments)
 
 `)
@@ -56,6 +64,9 @@ var (
 	previewExample = templates.Examples(`
 		# Create or updates the Preview Environment for the Pull Request
 		jx preview
+
+		# Wake a Preview Environment which was scaled down when idle
+		jx preview wake myorg-myapp-pr-1
 	`)
 )
 
@@ -100,6 +111,7 @@ type PreviewOptions struct {
 	// calculated fields
 	PostPreviewJobTimeoutDuration time.Duration
 	PostPreviewJobPollDuration    time.Duration
+	Dependencies                  *config.PreviewDependenciesConfig
 
 	HelmValuesConfig config.HelmValuesConfig
 }
@@ -138,6 +150,7 @@ func NewCmdPreview(commonOpts *opts.CommonOptions) *cobra.Command {
 	options.HelmValuesConfig.AddExposeControllerValues(cmd, false)
 	options.PromoteOptions.AddPromoteOptions(cmd)
 
+	cmd.AddCommand(NewCmdPreviewWake(commonOpts))
 	return cmd
 }
 
@@ -331,6 +344,14 @@ func (o *PreviewOptions) Run() error {
 		}
 	}
 
+	lifecycle, err := kube.NewPreviewLifecycle(projectConfig.PreviewEnvironments)
+	if err != nil {
+		return err
+	}
+	if projectConfig.PreviewEnvironments != nil {
+		o.Dependencies = projectConfig.PreviewEnvironments.Dependencies
+	}
+
 	environmentsResource := jxClient.JenkinsV1().Environments(ns)
 	env, err := environmentsResource.Get(o.Name, metav1.GetOptions{})
 	if err == nil {
@@ -368,7 +389,7 @@ func (o *PreviewOptions) Run() error {
 			update = true
 		}
 
-		gitSpec := spec.PreviewGitSpec
+		gitSpec := &spec.PreviewGitSpec
 		if gitSpec.BuildStatus != buildStatus {
 			gitSpec.BuildStatus = buildStatus
 			update = true
@@ -405,6 +426,18 @@ func (o *PreviewOptions) Run() error {
 			}
 		}
 
+		// a helm upgrade does not restore the replicas of a preview which was scaled down when idle
+		if kube.IsPreviewScaledDown(env) {
+			log.Logger().Infof("Waking preview environment %s which was scaled down when idle", util.ColorInfo(env.Name))
+			err = kube.WakePreview(kubeClient, env)
+			if err != nil {
+				return errors.Wrapf(err, "waking preview environment %s", env.Name)
+			}
+		}
+		spec.PreviewLifecycle = lifecycle
+		updatePreviewStatus(env)
+		update = true
+
 		if update {
 			env, err = environmentsResource.PatchUpdate(env)
 			if err != nil {
@@ -446,9 +479,11 @@ func (o *PreviewOptions) Run() error {
 					URL:  o.SourceURL,
 					Ref:  o.SourceRef,
 				},
-				PreviewGitSpec: previewGitSpec,
+				PreviewGitSpec:   previewGitSpec,
+				PreviewLifecycle: lifecycle,
 			},
 		}
+		updatePreviewStatus(env)
 		_, err = environmentsResource.Create(env)
 		if err != nil {
 			return fmt.Errorf("Failed to create environment in namespace %s due to: %s", ns, err)
@@ -456,6 +491,11 @@ func (o *PreviewOptions) Run() error {
 		log.Logger().Infof("Created environment %s", util.ColorInfo(env.Name))
 	}
 
+	err = o.EvictPreviews(jxClient, ns, env.Name)
+	if err != nil {
+		log.Logger().Warnf("Failed to evict the oldest preview environments: %s", err)
+	}
+
 	err = kube.EnsureEnvironmentNamespaceSetup(kubeClient, jxClient, env, ns)
 	if err != nil {
 		return err
@@ -503,6 +543,16 @@ func (o *PreviewOptions) Run() error {
 		helmOptions.ValueFiles = append(helmOptions.ValueFiles, defaultValuesFileName)
 	}
 
+	if o.Dependencies != nil && len(o.Dependencies.Applications) > 0 {
+		dependencyValuesFileName, err := o.addPreviewDependencies(dir, o.Dependencies)
+		if err != nil {
+			return err
+		}
+		if dependencyValuesFileName != "" {
+			helmOptions.ValueFiles = append(helmOptions.ValueFiles, dependencyValuesFileName)
+		}
+	}
+
 	err = o.InstallChartWithOptions(helmOptions)
 	if err != nil {
 		return err
@@ -656,7 +706,8 @@ func (o *PreviewOptions) findPreviewURL(kubeClient kubernetes.Interface, kserveC
 	return url, appNames, err
 }
 
-// RunPostPreviewSteps lets run any post-preview steps that are configured for all apps in a team
+// RunPostPreviewSteps lets run any post-preview steps that are configured for all apps in a team after the seed Jobs
+// of the preview
 func (o *PreviewOptions) RunPostPreviewSteps(kubeClient kubernetes.Interface, ns string, url string, pipeline string, build string, application string) error {
 	teamSettings, err := o.TeamSettings()
 	if err != nil {
@@ -687,35 +738,23 @@ func (o *PreviewOptions) RunPostPreviewSteps(kubeClient kubernetes.Interface, ns
 		"JX_BUILD":            build,
 	}
 
+	err = o.runSeedJobs(kubeClient, ns, envVars)
+	if err != nil {
+		return err
+	}
+
 	// Note that post preview jobs need to allow for use cases where no HTTP-based services are published by a pod
 
 	// Post preview jobs should validate input and behave appropriately. Needs a selector to invoke only relevant PPJs?
 
 	jobs := teamSettings.PostPreviewJobs
-	jobResources := kubeClient.BatchV1().Jobs(ns)
 	createdJobs := []*batchv1.Job{}
 	for _, job := range jobs {
 		// TODO lets modify the job name?
 		job2 := o.modifyJob(&job, envVars)
 		log.Logger().Infof("Triggering post preview Job %s in namespace %s", util.ColorInfo(job2.Name), util.ColorInfo(ns))
 
-		gracePeriod := int64(0)
-		propationPolicy := metav1.DeletePropagationForeground
-
-		// lets try delete it if it exists
-		jobResources.Delete(job2.Name, &metav1.DeleteOptions{
-			GracePeriodSeconds: &gracePeriod,
-			PropagationPolicy:  &propationPolicy,
-		})
-
-		// lets wait for the resource to be gone
-		hasJob := func() (bool, error) {
-			job, err := jobResources.Get(job.Name, metav1.GetOptions{})
-			return job == nil || err != nil, nil
-		}
-		o.RetryUntilTrueOrTimeout(time.Minute, time.Second, hasJob)
-
-		createdJob, err := jobResources.Create(job2)
+		createdJob, err := o.recreateJob(kubeClient, ns, job2)
 		if err != nil {
 			return err
 		}
@@ -724,6 +763,28 @@ func (o *PreviewOptions) RunPostPreviewSteps(kubeClient kubernetes.Interface, ns
 	return o.waitForJobsToComplete(kubeClient, createdJobs)
 }
 
+// recreateJob deletes any previous run of the job before creating it
+func (o *PreviewOptions) recreateJob(kubeClient kubernetes.Interface, ns string, job *batchv1.Job) (*batchv1.Job, error) {
+	jobResources := kubeClient.BatchV1().Jobs(ns)
+	gracePeriod := int64(0)
+	propationPolicy := metav1.DeletePropagationForeground
+
+	// lets try delete it if it exists
+	jobResources.Delete(job.Name, &metav1.DeleteOptions{
+		GracePeriodSeconds: &gracePeriod,
+		PropagationPolicy:  &propationPolicy,
+	})
+
+	// lets wait for the resource to be gone
+	hasJob := func() (bool, error) {
+		job, err := jobResources.Get(job.Name, metav1.GetOptions{})
+		return job == nil || err != nil, nil
+	}
+	o.RetryUntilTrueOrTimeout(time.Minute, time.Second, hasJob)
+
+	return jobResources.Create(job)
+}
+
 func (o *PreviewOptions) waitForJobsToComplete(kubeClient kubernetes.Interface, jobs []*batchv1.Job) error {
 	for _, job := range jobs {
 		err := o.waitForJob(kubeClient, job)
@@ -0,0 +1,99 @@
+package preview
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PreviewWakeOptions the options for waking preview environments
+type PreviewWakeOptions struct {
+	PreviewOptions
+}
+
+var (
+	previewWakeLong = templates.LongDesc(`
+		Wakes Preview Environments which were scaled to zero by 'jx gc previews' because they were idle.
+
+		Preview Environments are also woken when a new commit is pushed to their Pull Request.
+`)
+
+	previewWakeExample = templates.Examples(`
+		# Pick the Preview Environments to wake
+		jx preview wake
+
+		# Wake a Preview Environment
+		jx preview wake myorg-myapp-pr-1
+	`)
+)
+
+// NewCmdPreviewWake creates the command for: jx preview wake
+func NewCmdPreviewWake(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &PreviewWakeOptions{
+		PreviewOptions: PreviewOptions{
+			PromoteOptions: promote.PromoteOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "wake [name]",
+		Short:   "Wakes Preview Environments which were scaled down when idle",
+		Long:    previewWakeLong,
+		Example: previewWakeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+// Run implements this command
+func (o *PreviewWakeOptions) Run() error {
+	names := o.Args
+	if len(names) == 0 {
+		if o.BatchMode {
+			return fmt.Errorf("missing preview environment name")
+		}
+		jxClient, ns, err := o.JXClientAndDevNamespace()
+		if err != nil {
+			return err
+		}
+		envs, err := jxClient.JenkinsV1().Environments(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		scaledDown := []string{}
+		for _, env := range envs.Items {
+			if kube.IsPreviewEnvironment(&env) && kube.IsPreviewScaledDown(&env) {
+				scaledDown = append(scaledDown, env.Name)
+			}
+		}
+		if len(scaledDown) == 0 {
+			log.Logger().Infof("No preview environments are scaled down")
+			return nil
+		}
+		names, err = util.PickNames(scaledDown, "Pick preview environments to wake: ", "", o.In, o.Out, o.Err)
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		err := o.WakePreview(name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package promote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	optionCanaryAbort = "canary-abort"
+)
+
+// canaryChart the canary configuration of a version of the chart of an application
+type canaryChart struct {
+	values    *flagger.CanaryValues
+	templated bool
+}
+
+// prepareCanary works out the Flagger Canary of the application from the canary values of its chart if Flagger is
+// installed and the chart enables canary releases so that the promotion can follow the progress of the canary.
+//
+// A generated Canary is not applied here but added to the environment chart by the promotion so that it is only
+// created once the Pull Request merges. If it cannot be checked whether Flagger is installed the promotion carries on
+// without a canary release
+func (o *PromoteOptions) prepareCanary(targetNS string, releaseInfo *ReleaseInfo) error {
+	client, err := o.DynamicClient()
+	if err != nil {
+		log.Logger().Warnf("Failed to create the dynamic client so cannot check for a canary release of %s: %s", o.Application, err)
+		return nil
+	}
+	installed, err := flagger.IsInstalled(client, targetNS)
+	if err != nil {
+		log.Logger().Warnf("Failed to check if Flagger is installed in namespace %s so cannot check for a canary release of %s: %s", targetNS, o.Application, err)
+		return nil
+	}
+	if !installed {
+		return nil
+	}
+
+	// lets look for the deployment before fetching the chart as there is nothing to do on the first release
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "getting kube client")
+	}
+	deployment, err := findApplicationDeployment(kubeClient, targetNS, o.Application, releaseInfo.ReleaseName)
+	if err != nil {
+		return err
+	}
+	if deployment == "" {
+		log.Logger().Infof("No Deployment of %s found in namespace %s so the canary release will start on the next promotion", o.Application, targetNS)
+		return nil
+	}
+
+	chart, err := o.getCanaryChart(releaseInfo)
+	if err != nil {
+		return err
+	}
+	if chart.values == nil || !chart.values.Enabled {
+		return nil
+	}
+
+	name := ""
+	if chart.templated {
+		canaries, err := flagger.ListCanaries(client, targetNS)
+		if err != nil {
+			return err
+		}
+		canary := flagger.FindCanaryForDeployment(canaries, deployment)
+		if canary == nil {
+			return nil
+		}
+		name = canary.Name
+	} else {
+		releaseInfo.canaryResource = flagger.GenerateCanary(deployment, targetNS, chart.values)
+		name = releaseInfo.canaryResource.Name
+	}
+	releaseInfo.Canary = name
+	releaseInfo.CanaryStarted = time.Now()
+	return nil
+}
+
+// getCanaryChart returns the canary configuration of the chart version being promoted. The chart is only fetched
+// once for each version as it is the same for each Environment the version is promoted to
+func (o *PromoteOptions) getCanaryChart(releaseInfo *ReleaseInfo) (*canaryChart, error) {
+	key := releaseInfo.FullAppName + "@" + releaseInfo.Version
+	if chart := o.canaryCharts[key]; chart != nil {
+		return chart, nil
+	}
+	chart := &canaryChart{}
+	err := helm.InspectChart(releaseInfo.FullAppName, releaseInfo.Version, "", "", "", o.Helm(), func(dir string) error {
+		values, err := helm.LoadValuesFile(filepath.Join(dir, helm.ValuesFileName))
+		if err != nil {
+			return err
+		}
+		chart.values, err = flagger.GetCanaryValues(values)
+		if err != nil {
+			return err
+		}
+		chart.templated, err = templatesCanary(filepath.Join(dir, "templates"))
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "inspecting chart %s", releaseInfo.FullAppName)
+	}
+	if o.canaryCharts == nil {
+		o.canaryCharts = map[string]*canaryChart{}
+	}
+	o.canaryCharts[key] = chart
+	return chart, nil
+}
+
+// findApplicationDeployment returns the name of the deployment of the application in the namespace or an empty
+// string if there is none
+func findApplicationDeployment(kubeClient kubernetes.Interface, ns string, app string, releaseName string) (string, error) {
+	list, err := kubeClient.AppsV1().Deployments(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "listing Deployments in namespace %s", ns)
+	}
+	for i := range list.Items {
+		if isApplicationDeployment(&list.Items[i], app, releaseName) {
+			return list.Items[i].Name, nil
+		}
+	}
+	return "", nil
+}
+
+// applyCanary applies the generated Canary to an Environment which has no git repository
+func (o *PromoteOptions) applyCanary(canary *flagger.Canary) error {
+	client, err := o.DynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the dynamic client")
+	}
+	_, err = flagger.ApplyCanary(client, canary)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Applied the Flagger Canary %s in namespace %s", util.ColorInfo(canary.Name), util.ColorInfo(canary.Namespace))
+	return nil
+}
+
+// canaryTemplateFileName returns the name of the template of the generated Canary of the application in the
+// environment chart
+func canaryTemplateFileName(app string) string {
+	return app + "-canary.yaml"
+}
+
+// writeCanaryTemplate adds the generated Canary of the application to the templates of the environment chart in
+// the directory
+func writeCanaryTemplate(dir string, app string, canary *flagger.Canary) error {
+	templatesDir := filepath.Join(dir, "templates")
+	err := os.MkdirAll(templatesDir, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "creating %s", templatesDir)
+	}
+	data, err := yaml.Marshal(canary)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling Canary %s", canary.Name)
+	}
+	fileName := filepath.Join(templatesDir, canaryTemplateFileName(app))
+	err = ioutil.WriteFile(fileName, data, util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "writing %s", fileName)
+	}
+	return nil
+}
+
+// waitForCanary waits for the canary analysis of the promoted version to finish, recording its progress on the
+// promote step of the PipelineActivity
+func (o *PromoteOptions) waitForCanary(ns string, releaseInfo *ReleaseInfo, end time.Time, promoteKey *kube.PromoteStepActivityKey) error {
+	client, err := o.DynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the dynamic client")
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "getting jx client")
+	}
+	name := releaseInfo.Canary
+	log.Logger().Infof("Waiting for the canary analysis of %s in namespace %s", util.ColorInfo(name), util.ColorInfo(ns))
+	lastProgress := ""
+	for {
+		canary, err := flagger.GetCanary(client, ns, name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "getting Canary %s in namespace %s", name, ns)
+			}
+			// the Canary is created when the environment chart is applied after the Pull Request merges
+			if time.Now().After(end) {
+				return fmt.Errorf("timed out waiting for the Canary %s to be created in namespace %s", name, ns)
+			}
+			time.Sleep(*o.PullRequestPollDuration)
+			continue
+		}
+		finished := false
+		err = promoteKey.OnPromote(jxClient, o.Namespace, func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep) error {
+			if ps.Canary == nil {
+				ps.Canary = &v1.PromoteCanaryStep{}
+			}
+			finished = flagger.UpdatePromoteCanaryStep(ps.Canary, canary, releaseInfo.CanaryStarted)
+			if finished && ps.Canary.Status == v1.ActivityStatusTypeFailed {
+				ps.Status = v1.ActivityStatusTypeFailed
+			}
+			return nil
+		})
+		if err != nil {
+			log.Logger().Warnf("Failed to update the PipelineActivity: %s", err)
+			finished = flagger.UpdatePromoteCanaryStep(&v1.PromoteCanaryStep{}, canary, releaseInfo.CanaryStarted)
+		}
+
+		status := canary.Status
+		progress := fmt.Sprintf("%s %d%% %d", status.Phase, status.CanaryWeight, status.FailedChecks)
+		if progress != lastProgress {
+			lastProgress = progress
+			log.Logger().Infof("Canary %s is %s with %d%% of the traffic and %d failed checks", util.ColorInfo(name),
+				util.ColorInfo(status.Phase), status.CanaryWeight, status.FailedChecks)
+		}
+		if finished {
+			if status.Phase == flagger.CanaryPhaseFailed {
+				return fmt.Errorf("the canary analysis of %s failed so it was rolled back: %s", name, status.Message())
+			}
+			log.Logger().Infof("Canary %s succeeded", util.ColorInfo(name))
+			return nil
+		}
+		if time.Now().After(end) {
+			return fmt.Errorf("timed out waiting for the canary analysis of %s", name)
+		}
+		time.Sleep(*o.PullRequestPollDuration)
+	}
+}
+
+// AbortCanary aborts the canary analysis of the application in the namespace so that all the traffic is routed
+// back to the previous version
+func (o *PromoteOptions) AbortCanary(targetNS string) error {
+	client, err := o.DynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the dynamic client")
+	}
+	canaries, err := flagger.ListCanaries(client, targetNS)
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return errors.Wrap(err, "getting kube client")
+	}
+	deployment, err := findApplicationDeployment(kubeClient, targetNS, o.Application, o.ReleaseName)
+	if err != nil {
+		return err
+	}
+	canary := flagger.FindCanaryForDeployment(canaries, deployment)
+	if deployment == "" || canary == nil {
+		return fmt.Errorf("no Canary found for %s in namespace %s", o.Application, targetNS)
+	}
+	err = flagger.AbortCanary(kubeClient, canary)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("Aborted the canary %s in namespace %s", util.ColorInfo(canary.Name), util.ColorInfo(targetNS))
+	return nil
+}
+
+// templatesCanary returns true if the templates of a chart contain a Canary resource
+func templatesCanary(dir string) (bool, error) {
+	exists, err := util.DirExists(dir)
+	if err != nil || !exists {
+		return false, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, errors.Wrapf(err, "reading %s", dir)
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return false, errors.Wrapf(err, "reading %s", f.Name())
+		}
+		if strings.Contains(string(data), "kind: "+flagger.CanaryKind) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -18,6 +18,7 @@ import (
 
 	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/environments"
+	"github.com/jenkins-x/jx/pkg/flagger"
 
 	"k8s.io/helm/pkg/proto/hapi/chart"
 
@@ -38,6 +39,8 @@ import (
 
 const (
 	optionPullRequestPollTime = "pull-request-poll-time"
+	optionOverrideFreeze      = "override-freeze"
+	optionQueue               = "queue"
 
 	GitStatusSuccess = "success"
 )
@@ -70,6 +73,14 @@ type PromoteOptions struct {
 	PullRequestPollTime     string
 	Filter                  string
 	Alias                   string
+	OverrideFreeze          string
+	Queue                   bool
+	// RollbackFromVersion is the version being replaced if this promotion rolls back to a previous version
+	RollbackFromVersion string
+	// Verification the checks of the health of the application after it has been promoted
+	Verification PromoteVerification
+	// CanaryAbort aborts the canary release of the application rather than promoting it
+	CanaryAbort bool
 
 	// calculated fields
 	TimeoutDuration         *time.Duration
@@ -80,6 +91,7 @@ type PromoteOptions struct {
 	releaseResource         *v1.Release
 	ReleaseInfo             *ReleaseInfo
 	prow                    bool
+	canaryCharts            map[string]*canaryChart
 }
 
 type ReleaseInfo struct {
@@ -87,6 +99,20 @@ type ReleaseInfo struct {
 	FullAppName     string
 	Version         string
 	PullRequestInfo *gits.PullRequestInfo
+	// Queued is true if the promotion was queued until promotions to the Environment are allowed
+	Queued bool
+	// Canary is the name of the Flagger Canary which progressively releases the version
+	Canary string
+	// CanaryStarted is when the canary release of the version was started
+	CanaryStarted time.Time
+
+	// canaryResource is the generated Canary which is added to the Environment along with the version
+	canaryResource *flagger.Canary
+}
+
+// IsQueued returns true if the promotion was queued until promotions to the Environment are allowed
+func (r *ReleaseInfo) IsQueued() bool {
+	return r != nil && r.Queued
 }
 
 var (
@@ -112,6 +138,22 @@ var (
 		# To promote a postgres chart using an alias
 		jx promote -f postgres --alias mydb
 
+		# Promote to production outside of its promotion windows or during a freeze period
+		jx promote myapp --version 1.2.3 --env production --override-freeze "urgent security fix"
+
+		# Queue the promotion until the next promotion window of production opens
+		jx promote myapp --version 1.2.3 --env production --queue
+
+		# Verify the application rolls out and its error rate stays low after the promotion
+		# rolling back to the previous good version if it does not
+		jx promote myapp --version 1.2.3 --env production --verify-rollout \
+			--verify-url http://myapp.jx-production.svc.cluster.local/health \
+			--verify-query 'sum(rate(http_requests_total{app="myapp",status=~"5.."}[5m])) < 1' \
+			--prometheus-url http://prometheus-server.monitoring --rollback-on-failure
+
+		# Abort the canary release of the application in production
+		jx promote myapp --env production --canary-abort
+
 		# To create or update a Preview Environment please see the 'jx preview' command
 		jx preview
 	`)
@@ -138,8 +180,19 @@ func NewCmdPromote(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The Namespace to promote to")
 	cmd.Flags().StringVarP(&options.Environment, opts.OptionEnvironment, "e", "", "The Environment to promote to")
 	cmd.Flags().BoolVarP(&options.AllAutomatic, "all-auto", "", false, "Promote to all automatic environments in order")
+	cmd.Flags().BoolVarP(&options.CanaryAbort, optionCanaryAbort, "", false, "Aborts the canary release of the application in the Environment so all the traffic is routed back to the previous version")
 
 	options.AddPromoteOptions(cmd)
+	cmd.Flags().StringVarP(&options.OverrideFreeze, optionOverrideFreeze, "", "", "The reason for promoting outside of the promotion windows or during a freeze period of the Environment")
+	cmd.Flags().BoolVarP(&options.Queue, optionQueue, "", false, "Queues the promotion until the next promotion window of the Environment opens rather than failing")
+	cmd.Flags().BoolVarP(&options.Verification.Rollout, optionVerifyRollout, "", false, "Verifies the deployments of the application roll out after the promotion")
+	cmd.Flags().StringArrayVarP(&options.Verification.URLs, optionVerifyURL, "", nil, "A URL which should return a successful response after the promotion")
+	cmd.Flags().StringArrayVarP(&options.Verification.Queries, optionVerifyQuery, "", nil, "A Prometheus query with a threshold of the form '<promql> <operator> <number>' which should hold after the promotion")
+	cmd.Flags().StringVarP(&options.Verification.PrometheusURL, optionPrometheusURL, "", "", "The URL of the Prometheus server used for the --"+optionVerifyQuery+" checks")
+	cmd.Flags().StringVarP(&options.Verification.Timeout, optionVerifyTimeout, "", "5m", "The timeout for each verification check after the promotion")
+	cmd.Flags().IntVarP(&options.Verification.Samples, optionVerifySamples, "", 3, "The number of consecutive healthy samples each verification check requires to pass")
+	cmd.Flags().StringVarP(&options.Verification.Interval, optionVerifyInterval, "", "10s", "The interval between the samples of each verification check")
+	cmd.Flags().BoolVarP(&options.Verification.RollbackOnFailure, optionRollbackOnFailure, "", false, "Rolls back to the previous good version of the application if the verification fails")
 	return cmd
 }
 
@@ -235,6 +288,9 @@ func (o *PromoteOptions) Run() error {
 		}
 		o.TimeoutDuration = &duration
 	}
+	if o.NoPoll && o.Verification.IsEnabled() {
+		return fmt.Errorf("the promotion can only be verified when polling for its status so the verification options cannot be used with --no-poll")
+	}
 
 	targetNS, env, err := o.GetTargetNamespace(o.Namespace, o.Environment)
 	if err != nil {
@@ -249,6 +305,9 @@ func (o *PromoteOptions) Run() error {
 		o.ReleaseName = releaseName
 	}
 
+	if o.CanaryAbort {
+		return o.AbortCanary(targetNS)
+	}
 	if o.AllAutomatic {
 		return o.PromoteAllAutomatic()
 	}
@@ -270,7 +329,10 @@ func (o *PromoteOptions) Run() error {
 	}
 
 	o.ReleaseInfo = releaseInfo
-	if !o.NoPoll {
+	if releaseInfo.IsQueued() && o.Verification.IsEnabled() {
+		log.Logger().Warnf("The promotion of %s to Environment %s is queued so it will not be verified when it starts", app, o.Environment)
+	}
+	if !o.NoPoll && !releaseInfo.IsQueued() {
 		err = o.WaitForPromotion(targetNS, env, releaseInfo)
 		if err != nil {
 			return err
@@ -304,6 +366,12 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 	}
 	kube.SortEnvironments(environments)
 
+	// automatic promotions are queued until the promotion windows of the environments open
+	queue := o.Queue
+	o.Queue = true
+	defer func() {
+		o.Queue = queue
+	}()
 	for _, env := range environments {
 		kind := env.Spec.Kind
 		if env.Spec.PromotionStrategy == v1.PromotionStrategyTypeAutomatic && kind.IsPermanent() {
@@ -316,7 +384,7 @@ func (o *PromoteOptions) PromoteAllAutomatic() error {
 				return err
 			}
 			o.ReleaseInfo = releaseInfo
-			if !o.NoPoll {
+			if !o.NoPoll && !releaseInfo.IsQueued() {
 				err = o.WaitForPromotion(ns, &env, releaseInfo)
 				if err != nil {
 					return err
@@ -373,6 +441,20 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 		}
 	}
 
+	overrideReason := ""
+	if env != nil {
+		proceed, reason, err := o.checkPromotionWindow(env, releaseInfo)
+		if err != nil || !proceed {
+			return releaseInfo, err
+		}
+		overrideReason = reason
+	}
+
+	err := o.prepareCanary(targetNS, releaseInfo)
+	if err != nil {
+		return releaseInfo, errors.Wrapf(err, "preparing the canary release of %s", app)
+	}
+
 	jxClient, _, err := o.JXClient()
 	if err != nil {
 		return releaseInfo, err
@@ -385,6 +467,7 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 			if err == nil {
 				startPromotePR := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromotePullRequestStep) error {
 					kube.StartPromotionPullRequest(a, s, ps, p)
+					o.markPromoteStep(ps, overrideReason)
 					pr := releaseInfo.PullRequestInfo
 					if pr != nil && pr.PullRequest != nil && p.PullRequestURL == "" {
 						p.PullRequestURL = pr.PullRequest.URL
@@ -421,6 +504,7 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 
 	startPromote := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep, p *v1.PromoteUpdateStep) error {
 		kube.StartPromotionUpdate(a, s, ps, p)
+		o.markPromoteStep(ps, overrideReason)
 		if version != "" && a.Spec.Version == "" {
 			a.Spec.Version = version
 		}
@@ -437,6 +521,10 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 		Wait:        true,
 	}
 	err = o.InstallChartWithOptions(helmOptions)
+	if err == nil && releaseInfo.canaryResource != nil {
+		// without an environment git repository the Canary is applied along with the release
+		err = o.applyCanary(releaseInfo.canaryResource)
+	}
 	if err == nil {
 		err = o.CommentOnIssues(targetNS, env, promoteKey)
 		if err != nil {
@@ -449,6 +537,62 @@ func (o *PromoteOptions) Promote(targetNS string, env *v1.Environment, warnIfAut
 	return releaseInfo, err
 }
 
+// markPromoteStep clears any queued state of the promote step and records why the promotion is happening
+func (o *PromoteOptions) markPromoteStep(ps *v1.PromoteActivityStep, overrideReason string) {
+	ps.QueuedUntil = nil
+	if overrideReason != "" {
+		ps.OverrideReason = overrideReason
+	}
+	if o.RollbackFromVersion != "" {
+		ps.Rollback = true
+		ps.RollbackFromVersion = o.RollbackFromVersion
+	}
+}
+
+// checkPromotionWindow returns true if the promotion to the Environment can proceed now along with the override reason
+// if it is outside of the promotion windows of the Environment. Otherwise the promotion is queued or fails
+func (o *PromoteOptions) checkPromotionWindow(env *v1.Environment, releaseInfo *ReleaseInfo) (bool, string, error) {
+	status, err := kube.GetPromotionWindowStatus(env, time.Now())
+	if err != nil {
+		return false, "", err
+	}
+	if status.Allowed {
+		return true, "", nil
+	}
+	if o.OverrideFreeze != "" {
+		log.Logger().Warnf("Promoting to Environment %s even though %s because: %s", env.Name, status.Reason, o.OverrideFreeze)
+		return true, o.OverrideFreeze, nil
+	}
+	if !o.Queue {
+		return false, "", fmt.Errorf("cannot promote as %s. Use --%s to give a reason for promoting anyway or --%s to queue the promotion",
+			status.Reason, optionOverrideFreeze, optionQueue)
+	}
+	if status.NextAllowed == nil {
+		return false, "", fmt.Errorf("cannot queue the promotion as %s and no promotion window will open", status.Reason)
+	}
+	log.Logger().Infof("Queueing the promotion of %s to Environment %s until %s as %s", util.ColorInfo(o.Application),
+		util.ColorInfo(env.Name), util.ColorInfo(status.NextAllowed.Format(time.RFC3339)), status.Reason)
+
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return false, "", err
+	}
+	version := o.Version
+	queuePromote := func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep) error {
+		kube.QueuePromoteStep(ps, status)
+		if version != "" && a.Spec.Version == "" {
+			a.Spec.Version = version
+		}
+		return nil
+	}
+	err = o.CreatePromoteKey(env).OnPromote(jxClient, o.Namespace, queuePromote)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "queueing the promotion to Environment %s", env.Name)
+	}
+	releaseInfo.Queued = true
+	return false, "", nil
+}
+
 func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo *ReleaseInfo) error {
 	version := o.Version
 	versionName := version
@@ -462,6 +606,13 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 		Title:      "chore: " + app + " to " + versionName,
 		Message:    fmt.Sprintf("chore: Promote %s to version %s", app, versionName),
 	}
+	if o.RollbackFromVersion != "" {
+		details = gits.PullRequestDetails{
+			BranchName: "rollback-" + app + "-" + versionName,
+			Title:      "chore: rollback " + app + " to " + versionName,
+			Message:    fmt.Sprintf("chore: Rollback %s from version %s to version %s", app, o.RollbackFromVersion, versionName),
+		}
+	}
 
 	modifyChartFn := func(requirements *helm.Requirements, metadata *chart.Metadata, values map[string]interface{},
 		templates map[string]string, dir string, details *gits.PullRequestDetails) error {
@@ -473,6 +624,9 @@ func (o *PromoteOptions) PromoteViaPullRequest(env *v1.Environment, releaseInfo
 			}
 		}
 		requirements.SetAppVersion(app, version, o.HelmRepositoryURL, o.Alias)
+		if releaseInfo.canaryResource != nil {
+			return writeCanaryTemplate(dir, app, releaseInfo.canaryResource)
+		}
 		return nil
 	}
 	gitProvider, _, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
@@ -572,6 +726,15 @@ func (o *PromoteOptions) WaitForPromotion(ns string, env *v1.Environment, releas
 			return err
 		}
 	}
+	if releaseInfo.Canary != "" {
+		err := o.waitForCanary(ns, releaseInfo, end, o.CreatePromoteKey(env))
+		if err != nil {
+			return err
+		}
+	}
+	if o.Verification.IsEnabled() {
+		return o.VerifyPromotion(ns, env, releaseInfo)
+	}
 	return nil
 }
 
@@ -938,7 +1101,14 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 		return err
 	}
 
-	releaseName := naming.ToValidNameWithDots(app + "-" + version)
+	releaseNs := ens
+	releaseVersion := version
+	if o.RollbackFromVersion != "" {
+		// the issues affected by a rollback are the ones in the version rolled back from which is only in the dev namespace now
+		releaseNs = o.Namespace
+		releaseVersion = o.RollbackFromVersion
+	}
+	releaseName := naming.ToValidNameWithDots(app + "-" + releaseVersion)
 	jxClient, _, err := o.JXClient()
 	if err != nil {
 		return err
@@ -985,20 +1155,29 @@ func (o *PromoteOptions) CommentOnIssues(targetNS string, environment *v1.Enviro
 		log.Logger().Debugf("Application is available at: %s", util.ColorInfo(url))
 	}
 
-	release, err := jxClient.JenkinsV1().Releases(ens).Get(releaseName, metav1.GetOptions{})
+	release, err := jxClient.JenkinsV1().Releases(releaseNs).Get(releaseName, metav1.GetOptions{})
 	if err == nil && release != nil {
-		o.releaseResource = release
+		if o.RollbackFromVersion == "" {
+			o.releaseResource = release
+		}
 		issues := release.Spec.Issues
 
-		versionMessage := version
+		versionMessage := releaseVersion
 		if release.Spec.ReleaseNotesURL != "" {
-			versionMessage = "[" + version + "](" + release.Spec.ReleaseNotesURL + ")"
+			versionMessage = "[" + releaseVersion + "](" + release.Spec.ReleaseNotesURL + ")"
 		}
 		for _, issue := range issues {
 			if issue.IsClosed() {
-				log.Logger().Infof("Commenting that issue %s is now in %s", util.ColorInfo(issue.URL), util.ColorInfo(envName))
+				if o.RollbackFromVersion != "" {
+					log.Logger().Infof("Commenting that issue %s has been rolled back in %s", util.ColorInfo(issue.URL), util.ColorInfo(envName))
+				} else {
+					log.Logger().Infof("Commenting that issue %s is now in %s", util.ColorInfo(issue.URL), util.ColorInfo(envName))
+				}
 
 				comment := fmt.Sprintf(":white_check_mark: the fix for this issue is now deployed to **%s** in version %s %s", envName, versionMessage, available)
+				if o.RollbackFromVersion != "" {
+					comment = fmt.Sprintf(":warning: the fix for this issue has been rolled back in **%s** from version %s to version %s", envName, versionMessage, version)
+				}
 				id := issue.ID
 				if id != "" {
 					number, err := strconv.Atoi(id)
@@ -0,0 +1,71 @@
+package promote
+
+import (
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Rollback rolls back the application in the Environment from the current version to the target version. The
+// rollback is recorded in a new PipelineActivity of the pipeline which released the application
+func (o *PromoteOptions) Rollback(targetNS string, env *v1.Environment, current string, target *kube.DeployedVersion, history []kube.DeployedVersion) (*ReleaseInfo, error) {
+	log.Logger().Infof("Rolling back %s in Environment %s from version %s to version %s", util.ColorInfo(o.Application),
+		util.ColorInfo(env.Name), util.ColorInfo(current), util.ColorInfo(target.Version))
+
+	o.Version = target.Version
+	o.RollbackFromVersion = current
+	o.ReleaseName = targetNS + "-" + o.Application
+	o.IgnoreLocalFiles = true
+	o.releaseResource = nil
+	err := o.createRollbackActivity(history, target)
+	if err != nil {
+		log.Logger().Warnf("Failed to create a PipelineActivity for the rollback so it will not be recorded: %s", err)
+	}
+	return o.Promote(targetNS, env, false)
+}
+
+// createRollbackActivity creates a new PipelineActivity for the pipeline which released the versions of the
+// application so the rollback is recorded alongside the promotions
+func (o *PromoteOptions) createRollbackActivity(history []kube.DeployedVersion, target *kube.DeployedVersion) error {
+	source := target.Activity
+	if source == nil && len(history) > 0 {
+		source = history[0].Activity
+	}
+	if source == nil || source.Spec.Pipeline == "" {
+		return fmt.Errorf("no PipelineActivity found for %s", o.Application)
+	}
+	if source.Spec.GitURL != "" {
+		gitInfo, err := gits.ParseGitURL(source.Spec.GitURL)
+		if err != nil {
+			log.Logger().Warnf("Failed to parse git URL %s: %s", source.Spec.GitURL, err)
+		} else {
+			o.GitInfo = gitInfo
+		}
+	}
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return err
+	}
+	activities := jxClient.JenkinsV1().PipelineActivities(o.Namespace)
+	list, err := activities.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing PipelineActivities")
+	}
+	pipelines := []*v1.PipelineActivity{}
+	for i := range list.Items {
+		pipelines = append(pipelines, &list.Items[i])
+	}
+	build, _, err := kube.GenerateBuildNumber(activities, pipelines, kube.NewPipelineIDFromString(source.Spec.Pipeline))
+	if err != nil {
+		return errors.Wrapf(err, "creating a PipelineActivity for pipeline %s", source.Spec.Pipeline)
+	}
+	o.Pipeline = source.Spec.Pipeline
+	o.Build = build
+	return nil
+}
@@ -0,0 +1,353 @@
+package promote
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/prometheus"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	optionVerifyRollout     = "verify-rollout"
+	optionVerifyURL         = "verify-url"
+	optionVerifyQuery       = "verify-query"
+	optionPrometheusURL     = "prometheus-url"
+	optionVerifyTimeout     = "verify-timeout"
+	optionVerifySamples     = "verify-samples"
+	optionVerifyInterval    = "verify-interval"
+	optionRollbackOnFailure = "rollback-on-failure"
+
+	// VerificationKindRollout the kind of check that the deployments of the application rolled out
+	VerificationKindRollout = "rollout"
+	// VerificationKindURL the kind of check that a URL returns a successful response
+	VerificationKindURL = "url"
+	// VerificationKindQuery the kind of check that a Prometheus query is within its threshold
+	VerificationKindQuery = "query"
+)
+
+var verifyHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// PromoteVerification the options for verifying the health of an application after it has been promoted
+type PromoteVerification struct {
+	Rollout           bool
+	URLs              []string
+	Queries           []string
+	PrometheusURL     string
+	Timeout           string
+	Samples           int
+	Interval          string
+	RollbackOnFailure bool
+}
+
+// IsEnabled returns true if any verification checks are configured
+func (v *PromoteVerification) IsEnabled() bool {
+	return v.Rollout || len(v.URLs) > 0 || len(v.Queries) > 0
+}
+
+// VerifyPromotion verifies the health of the application after it has been promoted to the Environment, recording
+// the checks in the PipelineActivity. If the verification fails and rollback on failure is enabled then the
+// application is rolled back to the previous good version
+func (o *PromoteOptions) VerifyPromotion(ns string, env *v1.Environment, releaseInfo *ReleaseInfo) error {
+	timeout := 5 * time.Minute
+	if o.Verification.Timeout != "" {
+		duration, err := time.ParseDuration(o.Verification.Timeout)
+		if err != nil {
+			return util.InvalidOptionError(optionVerifyTimeout, o.Verification.Timeout, err)
+		}
+		timeout = duration
+	}
+	interval := 10 * time.Second
+	if o.Verification.Interval != "" {
+		duration, err := time.ParseDuration(o.Verification.Interval)
+		if err != nil {
+			return util.InvalidOptionError(optionVerifyInterval, o.Verification.Interval, err)
+		}
+		interval = duration
+	}
+	samples := o.Verification.Samples
+	if samples < 1 {
+		samples = 1
+	}
+	queries := []*prometheus.Query{}
+	for _, text := range o.Verification.Queries {
+		query, err := prometheus.ParseQuery(text)
+		if err != nil {
+			return util.InvalidOptionError(optionVerifyQuery, text, err)
+		}
+		queries = append(queries, query)
+	}
+	if len(queries) > 0 && o.Verification.PrometheusURL == "" {
+		return util.MissingOption(optionPrometheusURL)
+	}
+
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "getting jx client")
+	}
+	promoteKey := o.CreatePromoteKey(env)
+	started := metav1.Now()
+	err = promoteKey.OnPromote(jxClient, o.Namespace, func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep) error {
+		ps.Verification = &v1.PromoteVerificationStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				StartedTimestamp: &started,
+				Status:           v1.ActivityStatusTypeRunning,
+				Description:      fmt.Sprintf("Verifying %s version %s", o.Application, releaseInfo.Version),
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		log.Logger().Warnf("Failed to update the PipelineActivity: %s", err)
+	}
+
+	log.Logger().Infof("Verifying %s version %s in Environment %s", util.ColorInfo(o.Application), util.ColorInfo(releaseInfo.Version), util.ColorInfo(env.Name))
+	checks := []v1.VerificationCheck{}
+	if o.Verification.Rollout {
+		kubeClient, err := o.KubeClient()
+		if err != nil {
+			return errors.Wrap(err, "getting kube client")
+		}
+		checks = append(checks, o.verifyRollout(kubeClient, ns, releaseInfo, timeout, samples, interval))
+	}
+	for _, u := range o.Verification.URLs {
+		checks = append(checks, verifyURL(u, timeout, samples, interval))
+	}
+	for _, query := range queries {
+		checks = append(checks, verifyPrometheusQuery(o.Verification.PrometheusURL, query, timeout, samples, interval))
+	}
+
+	failed := []string{}
+	for _, check := range checks {
+		if check.Status == v1.ActivityStatusTypeSucceeded {
+			log.Logger().Infof("Verification %s %s succeeded", check.Kind, util.ColorInfo(check.Name))
+		} else {
+			log.Logger().Warnf("Verification %s %s failed: %s", check.Kind, check.Name, check.Message)
+			failed = append(failed, check.Name)
+		}
+	}
+
+	status := v1.ActivityStatusTypeSucceeded
+	if len(failed) > 0 {
+		status = v1.ActivityStatusTypeFailed
+	}
+	err = promoteKey.OnPromote(jxClient, o.Namespace, func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep) error {
+		if ps.Verification == nil {
+			ps.Verification = &v1.PromoteVerificationStep{}
+		}
+		ps.Verification.Checks = checks
+		ps.Verification.Status = status
+		ps.Verification.CompletedTimestamp = &metav1.Time{Time: time.Now()}
+		if status == v1.ActivityStatusTypeFailed {
+			ps.Status = v1.ActivityStatusTypeFailed
+			ps.Verification.Description = fmt.Sprintf("Verification failed: %s", strings.Join(failed, ", "))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Logger().Warnf("Failed to update the PipelineActivity: %s", err)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	verifyErr := fmt.Errorf("verification of %s version %s in Environment %s failed: %s", o.Application, releaseInfo.Version, env.Name, strings.Join(failed, ", "))
+	if !o.Verification.RollbackOnFailure {
+		return verifyErr
+	}
+	err = o.rollbackFailedPromotion(ns, env, releaseInfo, promoteKey)
+	if err != nil {
+		return errors.Wrapf(verifyErr, "failed to roll back: %s", err)
+	}
+	return verifyErr
+}
+
+// rollbackFailedPromotion rolls back the application to the previous good version in the Environment and records
+// the version rolled back to on the verification step of the failed promotion
+func (o *PromoteOptions) rollbackFailedPromotion(ns string, env *v1.Environment, releaseInfo *ReleaseInfo, promoteKey *kube.PromoteStepActivityKey) error {
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return errors.Wrap(err, "getting jx client")
+	}
+	list, err := jxClient.JenkinsV1().PipelineActivities(o.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing PipelineActivities")
+	}
+	history := kube.GetDeployedVersions(list.Items, o.Application, env.Name)
+	target := kube.FindRollbackVersion(history, releaseInfo.Version)
+	if target == nil {
+		return fmt.Errorf("could not find a previous good version of %s in Environment %s", o.Application, env.Name)
+	}
+
+	rollback := *o
+	rollback.Verification = PromoteVerification{}
+	rollbackInfo, err := rollback.Rollback(ns, env, releaseInfo.Version, target, history)
+	if err != nil {
+		return err
+	}
+	err = promoteKey.OnPromote(jxClient, o.Namespace, func(a *v1.PipelineActivity, s *v1.PipelineActivityStep, ps *v1.PromoteActivityStep) error {
+		if ps.Verification == nil {
+			ps.Verification = &v1.PromoteVerificationStep{}
+		}
+		ps.Verification.RollbackVersion = target.Version
+		return nil
+	})
+	if err != nil {
+		log.Logger().Warnf("Failed to update the PipelineActivity: %s", err)
+	}
+	if !rollback.NoPoll && !rollbackInfo.IsQueued() {
+		return rollback.WaitForPromotion(ns, env, rollbackInfo)
+	}
+	return nil
+}
+
+// WaitForHealthySamples calls the check at the interval until it succeeds for the given number of consecutive samples.
+// Any failure resets the count so an application which is only briefly healthy does not pass. Returns the last error
+// if there were not enough consecutive healthy samples before the timeout
+func WaitForHealthySamples(timeout time.Duration, samples int, interval time.Duration, check func() error) error {
+	deadline := time.Now().Add(timeout)
+	healthy := 0
+	for {
+		err := check()
+		if err == nil {
+			healthy++
+			if healthy >= samples {
+				return nil
+			}
+		} else {
+			healthy = 0
+			log.Logger().Infof("Retrying due to: %s", err)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if err != nil {
+				return errors.Wrapf(err, "timed out after %s", timeout.String())
+			}
+			return fmt.Errorf("only %d of %d consecutive samples were healthy after %s", healthy, samples, timeout.String())
+		}
+		time.Sleep(interval)
+	}
+}
+
+// verifyRollout waits for the deployments of the application to finish rolling out and stay rolled out
+func (o *PromoteOptions) verifyRollout(kubeClient kubernetes.Interface, ns string, releaseInfo *ReleaseInfo, timeout time.Duration, samples int, interval time.Duration) v1.VerificationCheck {
+	check := v1.VerificationCheck{
+		Kind: VerificationKindRollout,
+		Name: o.Application,
+	}
+	err := WaitForHealthySamples(timeout, samples, interval, func() error {
+		list, err := kubeClient.AppsV1().Deployments(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "listing Deployments in namespace %s", ns)
+		}
+		found := false
+		for i := range list.Items {
+			d := &list.Items[i]
+			if !isApplicationDeployment(d, o.Application, releaseInfo.ReleaseName) {
+				continue
+			}
+			found = true
+			if !isDeploymentRolledOut(d) {
+				return fmt.Errorf("deployment %s has %d of %d replicas updated and %d available", d.Name, d.Status.UpdatedReplicas, replicas(d), d.Status.AvailableReplicas)
+			}
+		}
+		if !found {
+			return fmt.Errorf("no Deployments found for %s in namespace %s", o.Application, ns)
+		}
+		return nil
+	})
+	if err != nil {
+		check.Status = v1.ActivityStatusTypeFailed
+		check.Message = err.Error()
+		return check
+	}
+	check.Status = v1.ActivityStatusTypeSucceeded
+	return check
+}
+
+// isApplicationDeployment returns true if the deployment is for the application of the release using the app label
+// of the deployment so that the deployments of other applications whose names end with the application name
+// do not match
+func isApplicationDeployment(d *appsv1.Deployment, app string, releaseName string) bool {
+	return kube.GetName(&d.ObjectMeta) == app || (releaseName != "" && d.Name == releaseName)
+}
+
+func isDeploymentRolledOut(d *appsv1.Deployment) bool {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false
+	}
+	expected := replicas(d)
+	return d.Status.UpdatedReplicas == expected && d.Status.ReadyReplicas == expected &&
+		d.Status.AvailableReplicas == expected && d.Status.Replicas == expected
+}
+
+func replicas(d *appsv1.Deployment) int32 {
+	if d.Spec.Replicas == nil {
+		return 1
+	}
+	return *d.Spec.Replicas
+}
+
+// verifyURL waits for the URL to keep returning successful responses
+func verifyURL(u string, timeout time.Duration, samples int, interval time.Duration) v1.VerificationCheck {
+	check := v1.VerificationCheck{
+		Kind: VerificationKindURL,
+		Name: u,
+	}
+	err := WaitForHealthySamples(timeout, samples, interval, func() error {
+		resp, err := verifyHTTPClient.Get(u)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("invalid status code %d", resp.StatusCode)
+		}
+		return nil
+	})
+	if err != nil {
+		check.Status = v1.ActivityStatusTypeFailed
+		check.Message = err.Error()
+		return check
+	}
+	check.Status = v1.ActivityStatusTypeSucceeded
+	return check
+}
+
+// verifyPrometheusQuery waits for all the values of the Prometheus query to stay within its threshold
+func verifyPrometheusQuery(prometheusURL string, query *prometheus.Query, timeout time.Duration, samples int, interval time.Duration) v1.VerificationCheck {
+	check := v1.VerificationCheck{
+		Kind: VerificationKindQuery,
+		Name: query.String(),
+	}
+	err := WaitForHealthySamples(timeout, samples, interval, func() error {
+		values, err := prometheus.QueryValues(prometheusURL, query.Query)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("query %s returned no values", query.Query)
+		}
+		for _, value := range values {
+			if !query.Matches(value) {
+				return fmt.Errorf("value %s is not %s %s", strconv.FormatFloat(value, 'f', -1, 64), query.Operator, strconv.FormatFloat(query.Threshold, 'f', -1, 64))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		check.Status = v1.ActivityStatusTypeFailed
+		check.Message = err.Error()
+		return check
+	}
+	check.Status = v1.ActivityStatusTypeSucceeded
+	return check
+}
@@ -0,0 +1,58 @@
+package promote_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/testhelpers"
+	"github.com/jenkins-x/jx/pkg/gits"
+	helm_test "github.com/jenkins-x/jx/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/pkg/kube"
+	resources_mock "github.com/jenkins-x/jx/pkg/kube/resources/mocks"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestWaitForHealthySamples(t *testing.T) {
+	t.Parallel()
+	results := []error{nil, errors.New("unhealthy"), nil, nil, nil}
+	calls := 0
+	err := promote.WaitForHealthySamples(time.Second, 3, time.Millisecond, func() error {
+		answer := results[calls]
+		calls++
+		return answer
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, calls, "a failed sample should reset the count of healthy samples")
+
+	err = promote.WaitForHealthySamples(30*time.Millisecond, 5, 20*time.Millisecond, func() error {
+		return nil
+	})
+	assert.Error(t, err, "should fail if there is not time for enough healthy samples")
+}
+
+func TestVerificationRequiresPolling(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+	commonOpts := &opts.CommonOptions{}
+	testhelpers.ConfigureTestOptionsWithResources(commonOpts, nil, []runtime.Object{kube.NewPermanentEnvironment("production")},
+		gits.NewGitCLI(), nil, helm_test.NewMockHelmer(), resources_mock.NewMockInstaller())
+	o := &promote.PromoteOptions{
+		CommonOptions: commonOpts,
+		Application:   "myapp",
+		Environment:   "production",
+		Version:       "1.0.0",
+		NoPoll:        true,
+		Verification: promote.PromoteVerification{
+			URLs: []string{"http://myapp.jx-production.svc.cluster.local/health"},
+		},
+	}
+
+	err := o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--no-poll")
+}
@@ -0,0 +1,199 @@
+package rollback
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	optionTo = "to"
+)
+
+// RollbackOptions the options for the rollback command
+type RollbackOptions struct {
+	promote.PromoteOptions
+
+	To string
+}
+
+var (
+	rollbackLong = templates.LongDesc(`
+		Rolls back an application in an Environment to the previous good version.
+
+		The versions deployed to the Environment are found from the promotion history of the PipelineActivity resources.
+		Versions which have already been rolled back from are not considered good. For GitOps environments a
+		Pull Request is created on the environment git repository otherwise the helm release is upgraded directly.
+`)
+
+	rollbackExample = templates.Examples(`
+		# roll back the application to the previous good version in production
+		jx rollback --env production --app myapp
+
+		# roll back the application to a specific version
+		jx rollback --env production --app myapp --to 1.2.3
+`)
+)
+
+// NewCmdRollback creates the command
+func NewCmdRollback(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &RollbackOptions{
+		PromoteOptions: promote.PromoteOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "rollback [application]",
+		Short:   "Rolls back an application in an Environment to the previous good version",
+		Long:    rollbackLong,
+		Example: rollbackExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Environment, opts.OptionEnvironment, "e", "", "The Environment to roll back")
+	cmd.Flags().StringVarP(&options.Application, opts.OptionApplication, "a", "", "The Application to roll back")
+	cmd.Flags().StringVarP(&options.To, optionTo, "", "", "The version to roll back to. Defaults to the previous good version")
+	cmd.Flags().StringVarP(&options.LocalHelmRepoName, "helm-repo-name", "r", kube.LocalHelmRepoName, "The name of the helm repository that contains the app")
+	cmd.Flags().StringVarP(&options.HelmRepositoryURL, "helm-repo-url", "u", helm.InClusterHelmRepositoryURL, "The Helm Repository URL to use for the App")
+	cmd.Flags().StringVarP(&options.Timeout, opts.OptionTimeout, "t", "1h", "The timeout to wait for the rollback to succeed in the underlying Environment")
+	cmd.Flags().StringVarP(&options.PullRequestPollTime, "pull-request-poll-time", "", "20s", "Poll time when waiting for a Pull Request to merge")
+	cmd.Flags().BoolVarP(&options.NoMergePullRequest, "no-merge", "", false, "Disables automatic merge of the rollback Pull Request")
+	cmd.Flags().BoolVarP(&options.NoPoll, "no-poll", "", false, "Disables polling for Pull Request or Pipeline status")
+	cmd.Flags().StringVarP(&options.OverrideFreeze, "override-freeze", "", "", "The reason for rolling back outside of the promotion windows or during a freeze period of the Environment")
+	return cmd
+}
+
+// Run implements this command
+func (o *RollbackOptions) Run() error {
+	app := o.Application
+	if app == "" && len(o.Args) > 0 {
+		app = o.Args[0]
+	}
+	if app == "" {
+		return util.MissingOption(opts.OptionApplication)
+	}
+	o.Application = app
+
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = ns
+
+	if o.Environment == "" {
+		if o.BatchMode {
+			return util.MissingOption(opts.OptionEnvironment)
+		}
+		m, allEnvNames, err := kube.GetOrderedEnvironments(jxClient, ns)
+		if err != nil {
+			return err
+		}
+		names := []string{}
+		for _, n := range allEnvNames {
+			if m[n].Spec.Kind == v1.EnvironmentKindTypePermanent {
+				names = append(names, n)
+			}
+		}
+		o.Environment, err = kube.PickEnvironment(names, "", o.In, o.Out, o.Err)
+		if err != nil {
+			return err
+		}
+	}
+	err = o.parseDurations()
+	if err != nil {
+		return err
+	}
+	targetNS, env, err := o.GetTargetNamespace(o.Namespace, o.Environment)
+	if err != nil {
+		return err
+	}
+
+	activities := jxClient.JenkinsV1().PipelineActivities(ns)
+	o.Activities = activities
+	list, err := activities.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing PipelineActivities")
+	}
+	history := kube.GetDeployedVersions(list.Items, app, env.Name)
+
+	current := ""
+	if len(history) > 0 {
+		current = history[0].Version
+	} else {
+		releases, err := kube.GetOrderedReleases(jxClient, targetNS, app)
+		if err != nil {
+			return errors.Wrapf(err, "listing Releases in namespace %s", targetNS)
+		}
+		for _, release := range releases {
+			if release.Spec.Name == app {
+				current = release.Spec.Version
+				break
+			}
+		}
+	}
+	if current == "" {
+		return fmt.Errorf("could not find the version of %s deployed to Environment %s", app, env.Name)
+	}
+
+	var target *kube.DeployedVersion
+	if o.To != "" {
+		if o.To == current {
+			return fmt.Errorf("version %s of %s is already deployed to Environment %s", current, app, env.Name)
+		}
+		target = &kube.DeployedVersion{Version: o.To}
+		for i := range history {
+			if history[i].Version == o.To {
+				target = &history[i]
+				break
+			}
+		}
+	} else {
+		target = kube.FindRollbackVersion(history, current)
+		if target == nil {
+			return fmt.Errorf("could not find a previous good version of %s in Environment %s. Please specify the version via --%s", app, env.Name, optionTo)
+		}
+	}
+	releaseInfo, err := o.PromoteOptions.Rollback(targetNS, env, current, target, history)
+	if err != nil {
+		return err
+	}
+	o.ReleaseInfo = releaseInfo
+	if !o.NoPoll && !releaseInfo.IsQueued() {
+		return o.WaitForPromotion(targetNS, env, releaseInfo)
+	}
+	return nil
+}
+
+func (o *RollbackOptions) parseDurations() error {
+	if o.PullRequestPollTime != "" {
+		duration, err := time.ParseDuration(o.PullRequestPollTime)
+		if err != nil {
+			return util.InvalidOptionError("pull-request-poll-time", o.PullRequestPollTime, err)
+		}
+		o.PullRequestPollDuration = &duration
+	}
+	if o.Timeout != "" {
+		duration, err := time.ParseDuration(o.Timeout)
+		if err != nil {
+			return util.InvalidOptionError(opts.OptionTimeout, o.Timeout, err)
+		}
+		o.TimeoutDuration = &duration
+	}
+	return nil
+}
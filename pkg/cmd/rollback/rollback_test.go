@@ -0,0 +1,191 @@
+package rollback_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/promote"
+	"github.com/jenkins-x/jx/pkg/cmd/rollback"
+	"github.com/jenkins-x/jx/pkg/cmd/testhelpers"
+	"github.com/jenkins-x/jx/pkg/gits"
+	helm_test "github.com/jenkins-x/jx/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/pkg/kube"
+	resources_mock "github.com/jenkins-x/jx/pkg/kube/resources/mocks"
+	"github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func promotedActivity(build string, version string, completed time.Time, rollbackFrom string) *v1.PipelineActivity {
+	return &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myorg-myapp-master-" + build,
+			Namespace: "jx",
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:      "myorg/myapp/master",
+			Build:         build,
+			Version:       version,
+			GitOwner:      "myorg",
+			GitRepository: "myapp",
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Status:             v1.ActivityStatusTypeSucceeded,
+							CompletedTimestamp: &metav1.Time{Time: completed},
+						},
+						Environment:         "production",
+						Rollback:            rollbackFrom != "",
+						RollbackFromVersion: rollbackFrom,
+					},
+				},
+			},
+		},
+	}
+}
+
+// newRollbackOptions creates the options for rolling back myapp in a production Environment which is frozen so the
+// rollback stops once the target version has been chosen
+func newRollbackOptions(t *testing.T, activities ...*v1.PipelineActivity) *rollback.RollbackOptions {
+	pegomock.RegisterMockTestingT(t)
+	now := time.Now()
+	production := kube.NewPermanentEnvironment("production")
+	production.Spec.PromotionStrategy = v1.PromotionStrategyTypeManual
+	production.Spec.FreezePeriods = []v1.FreezePeriod{
+		{
+			Name:   "release",
+			Start:  metav1.Time{Time: now.Add(-time.Hour)},
+			End:    metav1.Time{Time: now.Add(time.Hour)},
+			Reason: "release in progress",
+		},
+	}
+	jxObjects := []runtime.Object{production}
+	for _, activity := range activities {
+		jxObjects = append(jxObjects, activity)
+	}
+
+	commonOpts := &opts.CommonOptions{}
+	testhelpers.ConfigureTestOptionsWithResources(commonOpts, nil, jxObjects, gits.NewGitCLI(), nil,
+		helm_test.NewMockHelmer(), resources_mock.NewMockInstaller())
+	return &rollback.RollbackOptions{
+		PromoteOptions: promote.PromoteOptions{
+			CommonOptions: commonOpts,
+			Application:   "myapp",
+			Environment:   "production",
+			NoPoll:        true,
+		},
+	}
+}
+
+func TestRollbackToPreviousGoodVersion(t *testing.T) {
+	now := time.Now()
+	o := newRollbackOptions(t,
+		promotedActivity("1", "1.0.0", now.Add(-4*time.Hour), ""),
+		promotedActivity("2", "1.0.1", now.Add(-3*time.Hour), ""),
+		promotedActivity("3", "1.0.2", now.Add(-2*time.Hour), ""),
+		promotedActivity("4", "1.0.1", now.Add(-1*time.Hour), "1.0.2"),
+	)
+
+	err := o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "frozen")
+
+	// 1.0.1 is deployed and 1.0.2 was rolled back from so the previous good version is 1.0.0
+	assert.Equal(t, "1.0.0", o.Version)
+	assert.Equal(t, "1.0.1", o.RollbackFromVersion)
+	assert.Equal(t, "myorg/myapp/master", o.Pipeline)
+}
+
+func TestRollbackToSpecificVersion(t *testing.T) {
+	now := time.Now()
+	o := newRollbackOptions(t,
+		promotedActivity("1", "1.0.0", now.Add(-3*time.Hour), ""),
+		promotedActivity("2", "1.0.1", now.Add(-2*time.Hour), ""),
+		promotedActivity("3", "1.0.2", now.Add(-1*time.Hour), ""),
+	)
+	o.To = "1.0.0"
+
+	err := o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "frozen")
+
+	assert.Equal(t, "1.0.0", o.Version)
+	assert.Equal(t, "1.0.2", o.RollbackFromVersion)
+}
+
+func TestRollbackErrors(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name       string
+		activities []*v1.PipelineActivity
+		app        string
+		env        string
+		to         string
+		expected   string
+	}{
+		{
+			name:     "missing application",
+			env:      "production",
+			expected: "Missing option",
+		},
+		{
+			name:     "missing environment in batch mode",
+			app:      "myapp",
+			expected: "Missing option",
+		},
+		{
+			name:     "unknown environment",
+			app:      "myapp",
+			env:      "unknown",
+			expected: "Invalid option",
+		},
+		{
+			name:     "nothing deployed",
+			app:      "myapp",
+			env:      "production",
+			expected: "could not find the version of myapp deployed to Environment production",
+		},
+		{
+			name: "target version already deployed",
+			activities: []*v1.PipelineActivity{
+				promotedActivity("1", "1.0.0", now.Add(-2*time.Hour), ""),
+				promotedActivity("2", "1.0.1", now.Add(-1*time.Hour), ""),
+			},
+			app:      "myapp",
+			env:      "production",
+			to:       "1.0.1",
+			expected: "version 1.0.1 of myapp is already deployed to Environment production",
+		},
+		{
+			name: "no previous good version",
+			activities: []*v1.PipelineActivity{
+				promotedActivity("1", "1.0.0", now.Add(-3*time.Hour), ""),
+				promotedActivity("2", "1.0.1", now.Add(-2*time.Hour), ""),
+				promotedActivity("3", "1.0.0", now.Add(-1*time.Hour), "1.0.1"),
+			},
+			app:      "myapp",
+			env:      "production",
+			expected: "could not find a previous good version of myapp in Environment production",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := newRollbackOptions(t, tc.activities...)
+			o.Application = tc.app
+			o.Environment = tc.env
+			o.To = tc.to
+
+			err := o.Run()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+			assert.Equal(t, "", o.Version, "no version should be rolled back to")
+		})
+	}
+}
@@ -257,7 +257,10 @@ func (o *ScanClusterOptions) printResult(result *scanResult) error {
 		for _, n := range result.Nodes {
 			nodeTable.AddRow(n.Type, n.Location)
 		}
-		nodeTable.Render()
+		err := nodeTable.Render()
+		if err != nil {
+			return err
+		}
 		log.Blank()
 
 		serviceTable := o.CreateTable()
@@ -268,7 +271,10 @@ func (o *ScanClusterOptions) printResult(result *scanResult) error {
 		for _, s := range result.Services {
 			serviceTable.AddRow(s.Service, s.Location, s.Description)
 		}
-		serviceTable.Render()
+		err = serviceTable.Render()
+		if err != nil {
+			return err
+		}
 		log.Blank()
 
 		vulnTable := o.CreateTable()
@@ -281,7 +287,10 @@ func (o *ScanClusterOptions) printResult(result *scanResult) error {
 		for _, vuln := range result.Vulnerabilities {
 			vulnTable.AddRow(vuln.Vulnerability, vuln.Location, vuln.Category, vuln.Description, vuln.Evidence)
 		}
-		vulnTable.Render()
+		err = vulnTable.Render()
+		if err != nil {
+			return err
+		}
 		log.Blank()
 	}
 	return nil
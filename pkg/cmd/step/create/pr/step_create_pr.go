@@ -57,7 +57,10 @@ func NewCmdStepCreatePr(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepCreatePullRequestChart(commonOpts))
 	cmd.AddCommand(NewCmdStepCreatePullRequestDocker(commonOpts))
 	cmd.AddCommand(NewCmdStepCreatePullRequestGo(commonOpts))
+	cmd.AddCommand(NewCmdStepCreatePullRequestGradle(commonOpts))
 	cmd.AddCommand(NewCmdStepCreatePullRequestMake(commonOpts))
+	cmd.AddCommand(NewCmdStepCreatePullRequestMaven(commonOpts))
+	cmd.AddCommand(NewCmdStepCreatePullRequestNpm(commonOpts))
 	cmd.AddCommand(NewCmdStepCreatePullRequestQuickStarts(commonOpts))
 	cmd.AddCommand(NewCmdStepCreatePullRequestRegex(commonOpts))
 	cmd.AddCommand(NewCmdStepCreatePullRequestRepositories(commonOpts))
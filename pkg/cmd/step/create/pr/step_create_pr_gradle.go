@@ -0,0 +1,101 @@
+package pr
+
+import (
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/gradle"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createPullRequestGradleLong = templates.LongDesc(`
+		Creates a Pull Request to change a gradle dependency or plugin, updating the version catalogs such as gradle/libs.versions.toml to use a new version
+
+		Versions referenced from the [versions] section of a catalog are updated in that section. Any group:artifact:version coordinates in the *.gradle and *.gradle.kts build files are also updated.
+
+		The update is recorded in the dependency matrix of the repository and in the release notes of its next release.
+`)
+
+	createPullRequestGradleExample = templates.Examples(`
+		# update a gradle dependency
+		jx step create pr gradle --name com.myorg:mylib --version 1.2.3 --repo https://github.com/myorg/myapp.git
+
+		# update a gradle plugin
+		jx step create pr gradle --name com.myorg.myplugin --version 1.2.3 --repo https://github.com/myorg/myapp.git
+					`)
+)
+
+// StepCreatePullRequestGradleOptions contains the command line flags
+type StepCreatePullRequestGradleOptions struct {
+	StepCreatePrOptions
+
+	Name string
+}
+
+// NewCmdStepCreatePullRequestGradle Creates a new Command object
+func NewCmdStepCreatePullRequestGradle(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCreatePullRequestGradleOptions{
+		StepCreatePrOptions: StepCreatePrOptions{
+			StepCreateOptions: step.StepCreateOptions{
+				StepOptions: step.StepOptions{
+					CommonOptions: commonOpts,
+				},
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "gradle",
+		Short:   "Creates a Pull Request on a git repository updating a gradle dependency or plugin",
+		Long:    createPullRequestGradleLong,
+		Example: createPullRequestGradleExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	AddStepCreatePrFlags(cmd, &options.StepCreatePrOptions)
+	cmd.Flags().StringVarP(&options.Name, "name", "", "", "The group:artifact of the gradle dependency or the id of the plugin to update")
+	return cmd
+}
+
+// ValidateGradleOptions validates the common options for gradle pr steps
+func (o *StepCreatePullRequestGradleOptions) ValidateGradleOptions() error {
+	if err := o.ValidateOptions(false); err != nil {
+		return errors.WithStack(err)
+	}
+	if o.Name == "" {
+		return util.MissingOption("name")
+	}
+	return nil
+}
+
+// Run implements this command
+func (o *StepCreatePullRequestGradleOptions) Run() error {
+	if err := o.ValidateGradleOptions(); err != nil {
+		return errors.WithStack(err)
+	}
+	err := o.CreatePullRequest("gradle",
+		func(dir string, gitInfo *gits.GitRepository) ([]string, error) {
+			oldVersions, err := gradle.UpdateDependencyVersion(dir, o.Name, o.Version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "updating %s to %s", o.Name, o.Version)
+			}
+			if len(oldVersions) == 0 {
+				log.Logger().Warnf("no version catalogs or build files in %s depend on %s", gitInfo.URL, util.ColorInfo(o.Name))
+			}
+			return oldVersions, nil
+		})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package pr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/maven"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createPullRequestMavenLong = templates.LongDesc(`
+		Creates a Pull Request to change a maven dependency, updating every pom.xml file to use a new version
+
+		The versions of any dependencies, plugins, parents and extensions with the group id and artifact id are updated. If the version is a property such as ${mylib.version} the property is updated instead.
+
+		The update is recorded in the dependency matrix of the repository and in the release notes of its next release.
+`)
+
+	createPullRequestMavenExample = templates.Examples(`
+		# update a maven dependency
+		jx step create pr maven --name com.myorg:mylib --version 1.2.3 --repo https://github.com/myorg/myapp.git
+
+		# update a maven dependency and a property which is not used as the version of the dependency
+		jx step create pr maven --name com.myorg:mylib --version 1.2.3 --property mylib.version --repo https://github.com/myorg/myapp.git
+					`)
+)
+
+// StepCreatePullRequestMavenOptions contains the command line flags
+type StepCreatePullRequestMavenOptions struct {
+	StepCreatePrOptions
+
+	Name       string
+	Properties []string
+}
+
+// NewCmdStepCreatePullRequestMaven Creates a new Command object
+func NewCmdStepCreatePullRequestMaven(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCreatePullRequestMavenOptions{
+		StepCreatePrOptions: StepCreatePrOptions{
+			StepCreateOptions: step.StepCreateOptions{
+				StepOptions: step.StepOptions{
+					CommonOptions: commonOpts,
+				},
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "maven",
+		Short:   "Creates a Pull Request on a git repository updating a maven dependency",
+		Long:    createPullRequestMavenLong,
+		Example: createPullRequestMavenExample,
+		Aliases: []string{"mvn", "pom"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	AddStepCreatePrFlags(cmd, &options.StepCreatePrOptions)
+	cmd.Flags().StringVarP(&options.Name, "name", "", "", "The groupId:artifactId of the maven dependency to update")
+	cmd.Flags().StringArrayVarP(&options.Properties, "property", "", []string{}, "The name of a property in the pom.xml files to update to the version")
+	return cmd
+}
+
+// ValidateMavenOptions validates the common options for maven pr steps
+func (o *StepCreatePullRequestMavenOptions) ValidateMavenOptions() error {
+	if err := o.ValidateOptions(false); err != nil {
+		return errors.WithStack(err)
+	}
+	if o.Name == "" {
+		return util.MissingOption("name")
+	}
+	if len(strings.Split(o.Name, ":")) != 2 {
+		return fmt.Errorf("invalid maven dependency %s, expected groupId:artifactId", o.Name)
+	}
+	return nil
+}
+
+// Run implements this command
+func (o *StepCreatePullRequestMavenOptions) Run() error {
+	if err := o.ValidateMavenOptions(); err != nil {
+		return errors.WithStack(err)
+	}
+	ids := strings.Split(o.Name, ":")
+	err := o.CreatePullRequest("maven",
+		func(dir string, gitInfo *gits.GitRepository) ([]string, error) {
+			oldVersions, err := maven.UpdateDependencyVersion(dir, ids[0], ids[1], o.Version, o.Properties...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "updating %s to %s", o.Name, o.Version)
+			}
+			if len(oldVersions) == 0 {
+				log.Logger().Warnf("no %s files in %s depend on %s", maven.PomFileName, gitInfo.URL, util.ColorInfo(o.Name))
+			}
+			return oldVersions, nil
+		})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
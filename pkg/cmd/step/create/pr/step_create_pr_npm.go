@@ -0,0 +1,141 @@
+package pr
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/npm"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createPullRequestNpmLong = templates.LongDesc(`
+		Creates a Pull Request to change an npm package dependency, updating every package.json file to use a new version
+
+		Any range prefix of the version such as ^ or ~ is kept. The package-lock.json, npm-shrinkwrap.json, yarn.lock or pnpm-lock.yaml lock files next to the modified package.json files are regenerated.
+
+		The update is recorded in the dependency matrix of the repository and in the release notes of its next release.
+`)
+
+	createPullRequestNpmExample = templates.Examples(`
+		# update an npm dependency
+		jx step create pr npm --name @myorg/mylib --version 1.2.3 --repo https://github.com/myorg/myapp.git
+
+		# update an npm dependency without regenerating the lock files
+		jx step create pr npm --name @myorg/mylib --version 1.2.3 --skip-lock --repo https://github.com/myorg/myapp.git
+					`)
+)
+
+// StepCreatePullRequestNpmOptions contains the command line flags
+type StepCreatePullRequestNpmOptions struct {
+	StepCreatePrOptions
+
+	Name     string
+	SkipLock bool
+}
+
+// NewCmdStepCreatePullRequestNpm Creates a new Command object
+func NewCmdStepCreatePullRequestNpm(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepCreatePullRequestNpmOptions{
+		StepCreatePrOptions: StepCreatePrOptions{
+			StepCreateOptions: step.StepCreateOptions{
+				StepOptions: step.StepOptions{
+					CommonOptions: commonOpts,
+				},
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "npm",
+		Short:   "Creates a Pull Request on a git repository updating an npm package dependency",
+		Long:    createPullRequestNpmLong,
+		Example: createPullRequestNpmExample,
+		Aliases: []string{"node", "yarn"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	AddStepCreatePrFlags(cmd, &options.StepCreatePrOptions)
+	cmd.Flags().StringVarP(&options.Name, "name", "", "", "The name of the npm package dependency to update")
+	cmd.Flags().BoolVarP(&options.SkipLock, "skip-lock", "", false, "Do not regenerate the lock files after updating the package.json files")
+	return cmd
+}
+
+// ValidateNpmOptions validates the common options for npm pr steps
+func (o *StepCreatePullRequestNpmOptions) ValidateNpmOptions() error {
+	if err := o.ValidateOptions(false); err != nil {
+		return errors.WithStack(err)
+	}
+	if o.Name == "" {
+		return util.MissingOption("name")
+	}
+	return nil
+}
+
+// Run implements this command
+func (o *StepCreatePullRequestNpmOptions) Run() error {
+	if err := o.ValidateNpmOptions(); err != nil {
+		return errors.WithStack(err)
+	}
+	err := o.CreatePullRequest("npm",
+		func(dir string, gitInfo *gits.GitRepository) ([]string, error) {
+			oldVersions, changedDirs, err := npm.UpdateDependencyVersion(dir, o.Name, o.Version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "updating %s to %s", o.Name, o.Version)
+			}
+			if len(oldVersions) == 0 {
+				log.Logger().Warnf("no %s files in %s depend on %s", npm.PackageJSONFileName, gitInfo.URL, util.ColorInfo(o.Name))
+				return nil, nil
+			}
+			if !o.SkipLock {
+				// lets regenerate the lock files of any workspace at the root of the repository too
+				err = o.regenerateLockFiles(append([]string{dir}, changedDirs...))
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+			return oldVersions, nil
+		})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (o *StepCreatePullRequestNpmOptions) regenerateLockFiles(dirs []string) error {
+	done := map[string]bool{}
+	for _, dir := range dirs {
+		if done[dir] {
+			continue
+		}
+		done[dir] = true
+		commands, err := npm.LockFileCommands(dir)
+		if err != nil {
+			return err
+		}
+		for _, args := range commands {
+			log.Logger().Infof("running %s in the directory %s to update the lock file", util.ColorInfo(strings.Join(args, " ")), dir)
+			cmd := util.Command{
+				Dir:  dir,
+				Name: args[0],
+				Args: args[1:],
+			}
+			_, err := cmd.RunWithoutRetry()
+			if err != nil {
+				return errors.Wrapf(err, "running %s", cmd.String())
+			}
+		}
+	}
+	return nil
+}
@@ -547,7 +547,7 @@ func (o *StepCreateTaskOptions) generateTektonCRDs(effectiveProjectConfig *confi
 		return nil, errors.Wrapf(err, "unable to extract the requested pipeline")
 	}
 
-	pipeline, tasks, structure, err := effectivePipeline.GenerateCRDs(pipelineName, o.BuildNumber, resourceName, ns, o.PodTemplates, o.VersionResolver.VersionsDir, o.getDefaultTaskInputs().Params, o.SourceName, o.labels, "")
+	pipeline, tasks, structure, err := effectivePipeline.GenerateCRDs(pipelineName, o.BuildNumber, resourceName, ns, o.PodTemplates, o.VersionResolver, o.getDefaultTaskInputs().Params, o.SourceName, o.labels, "")
 	if err != nil {
 		return nil, errors.Wrapf(err, "generation failed for Pipeline")
 	}
@@ -1108,8 +1108,7 @@ func (o *StepCreateTaskOptions) viewSteps(tasks ...*pipelineapi.Task) error {
 			}
 		}
 	}
-	table.Render()
-	return nil
+	return table.Render()
 }
 
 func getVersionFromFile(dir string) (string, error) {
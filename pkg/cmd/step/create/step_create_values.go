@@ -12,6 +12,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/jenkins-x/jx/pkg/helm"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/secreturl/kubesecrets"
 	"github.com/jenkins-x/jx/pkg/surveyutils"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/pkg/errors"
@@ -98,7 +99,7 @@ func NewCmdStepCreateValues(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().StringVarP(&options.Name, "name", "", "values", "the kind of the file to create (and, by default, the schema name)")
 	cmd.Flags().StringVarP(&options.BasePath, "secret-base-path", "", "", fmt.Sprintf("the secret path used to store secrets in vault / file system. Typically a unique name per cluster+team. If none is specified we will default it to the cluster name from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
 	cmd.Flags().StringVarP(&options.ValuesFile, "out", "", "", "the path to the file to create, overrides --dir and --name")
-	cmd.Flags().StringVarP(&options.SecretsScheme, optionSecretsScheme, "", "", fmt.Sprintf("the scheme to store/reference any secrets in, valid options are vault and local. If none are specified we will default it from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
+	cmd.Flags().StringVarP(&options.SecretsScheme, optionSecretsScheme, "", "", fmt.Sprintf("the scheme to store/reference any secrets in, valid options are vault, local and kube. If none are specified we will default it from the %s file in the current or a parent directory.", config.RequirementsConfigFileName))
 	return cmd
 }
 
@@ -143,8 +144,8 @@ func (o *StepCreateValuesOptions) Run() error {
 		}
 
 	}
-	if !(o.SecretsScheme == "vault" || o.SecretsScheme == "local") {
-		util.InvalidArgf(optionSecretsScheme, "Use one of vault or local")
+	if !(o.SecretsScheme == "vault" || o.SecretsScheme == "local" || o.SecretsScheme == "kube") {
+		util.InvalidArgf(optionSecretsScheme, "Use one of vault, local or kube")
 	}
 	if o.Schema == "" {
 		o.Schema = filepath.Join(o.Dir, fmt.Sprintf("%s.schema.json", o.Name))
@@ -191,7 +192,11 @@ func (o *StepCreateValuesOptions) CreateValuesFile() error {
 		return errors.Wrapf(err, "failed to load values file %s", o.ValuesFile)
 	}
 
-	valuesFileName, cleanup, err := apps.ProcessValues(schema, o.Name, gitOpsURL, teamName, o.BasePath, o.BatchMode, false, secretURLClient, existing, o.SecretsScheme, o.In, o.Out, o.Err, o.Verbose)
+	uriScheme := o.SecretsScheme
+	if secrets.ToSecretsLocation(uriScheme) == secrets.KubeLocationKind {
+		uriScheme = kubesecrets.Scheme
+	}
+	valuesFileName, cleanup, err := apps.ProcessValues(schema, o.Name, gitOpsURL, teamName, o.BasePath, o.BatchMode, false, secretURLClient, existing, uriScheme, o.In, o.Out, o.Err, o.Verbose)
 	defer cleanup()
 	if err != nil {
 		return errors.WithStack(err)
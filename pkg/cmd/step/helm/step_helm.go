@@ -67,7 +67,9 @@ func NewCmdStepHelm(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepHelmEnv(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmInstall(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmList(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmMigrate(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmRelease(commonOpts))
+	cmd.AddCommand(NewCmdStepHelmTest(commonOpts))
 	cmd.AddCommand(NewCmdStepHelmVersion(commonOpts))
 	return cmd
 }
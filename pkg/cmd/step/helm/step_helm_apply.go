@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/jenkins-x/jx/pkg/platform"
@@ -46,6 +47,10 @@ type StepHelmApplyOptions struct {
 	NoVault            bool
 	NoMasking          bool
 	ProviderValuesDir  string
+	DryRun             bool
+	DiffFile           string
+	CommentPR          bool
+	PullRequest        string
 }
 
 var (
@@ -59,6 +64,12 @@ var (
 		# apply the chart in the env folder to namespace jx-staging 
 		jx step helm apply --dir env --namespace jx-staging
 
+		# show the resources which would be added, changed or removed in namespace jx-staging without applying the chart
+		jx step helm apply --dir env --namespace jx-staging --dry-run
+
+		# add the changes as a comment on the Pull Request of an environment pipeline
+		jx step helm apply --dir env --namespace jx-staging --dry-run --comment-pr
+
 `)
 
 	defaultValueFileNames = []string{"values.yaml", "myvalues.yaml", helm.SecretsFileName, filepath.Join("env", helm.SecretsFileName)}
@@ -98,6 +109,10 @@ func NewCmdStepHelmApply(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&options.NoMasking, "no-masking", "", false, "The effective 'values.yaml' file is output to the console with parameters masked. Enabling this flag will show the unmasked secrets in the console output")
 	cmd.Flags().BoolVarP(&options.UseTempDir, "use-temp-dir", "", true, "Whether to build and apply the helm chart from a temporary directory - to avoid updating the local values.yaml file from the generated file as part of the apply which could get accidentally checked into git")
 	cmd.Flags().StringVarP(&options.ProviderValuesDir, "provider-values-dir", "", "", "The optional directory of kubernetes provider specific override values.tmpl.yaml files a kubernetes provider specific folder")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "Renders the chart and displays the resources which would be added, changed or removed without applying them. No secrets are read from vault so Secrets populated from vault are displayed as changed. Requires the helm template mode")
+	cmd.Flags().StringVarP(&options.DiffFile, "diff-file", "", "", "The file to write the changes of a dry run to as markdown")
+	cmd.Flags().BoolVarP(&options.CommentPR, "comment-pr", "", false, "Adds the changes of a dry run as a comment on the Pull Request")
+	cmd.Flags().StringVarP(&options.PullRequest, "pull-request", "", "", "The number of the Pull Request to comment on. Defaults to the $PULL_NUMBER environment variable")
 
 	return cmd
 }
@@ -137,6 +152,17 @@ func (o *StepHelmApplyOptions) Run() error {
 	if err != nil {
 		return err
 	}
+	var templater *helm.HelmTemplate
+	if o.DryRun {
+		var ok bool
+		templater, ok = o.Helm().(*helm.HelmTemplate)
+		if !ok {
+			return fmt.Errorf("the --dry-run option requires the helm template mode")
+		}
+		templater.DryRun = true
+	} else if o.DiffFile != "" || o.CommentPR {
+		return fmt.Errorf("the --diff-file and --comment-pr options require the --dry-run option")
+	}
 
 	ns, err := o.GetDeployNamespace(o.Namespace)
 	if err != nil {
@@ -148,9 +174,11 @@ func (o *StepHelmApplyOptions) Run() error {
 		return err
 	}
 
-	err = kube.EnsureNamespaceCreated(kubeClient, ns, nil, nil)
-	if err != nil {
-		return err
+	if !o.DryRun {
+		err = kube.EnsureNamespaceCreated(kubeClient, ns, nil, nil)
+		if err != nil {
+			return err
+		}
 	}
 
 	_, devNs, err := o.KubeClientAndDevNamespace()
@@ -182,7 +210,8 @@ func (o *StepHelmApplyOptions) Run() error {
 		log.Logger().Warnf("could not find a git repository in the directory %s: %s\n", dir, err.Error())
 	}
 
-	if o.UseTempDir {
+	// a dry run must not modify the chart directory
+	if o.UseTempDir || o.DryRun {
 		rootTmpDir, err := ioutil.TempDir("", "jx-helm-apply-")
 		if err != nil {
 			return errors.Wrapf(err, "failed to create a temporary directory to apply the helm chart")
@@ -213,6 +242,64 @@ func (o *StepHelmApplyOptions) Run() error {
 
 	o.Helm().SetCWD(dir)
 
+	valueFiles, requirements, cleanup, err := o.GenerateValues(dir, devGitInfo)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	log.Logger().Infof("Using values files: %s", strings.Join(valueFiles, ", "))
+
+	if o.Boot {
+		err = o.replaceMissingVersionsFromVersionStream(requirements, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to replace missing versions in the requirements.yaml in dir %s", dir)
+		}
+	}
+
+	_, err = o.HelmInitDependencyBuild(dir, o.DefaultReleaseCharts(), valueFiles)
+	if err != nil {
+		return err
+	}
+
+	err = o.applyAppsTemplateOverrides(chartName)
+	if err != nil {
+		return errors.Wrap(err, "applying app chart overrides")
+	}
+	err = o.applyTemplateOverrides(chartName)
+	if err != nil {
+		return errors.Wrap(err, "applying chart overrides")
+	}
+
+	helmOptions := helm.InstallChartOptions{
+		Chart:       chartName,
+		ReleaseName: releaseName,
+		Ns:          ns,
+		NoForce:     !o.Force,
+		ValueFiles:  valueFiles,
+		Dir:         dir,
+		DryRun:      o.DryRun,
+	}
+	if o.Wait {
+		helmOptions.Wait = true
+		err = o.InstallChartWithOptionsAndTimeout(helmOptions, "600")
+	} else {
+		err = o.InstallChartWithOptions(helmOptions)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "upgrading helm chart '%s'", chartName)
+	}
+	if o.DryRun {
+		return o.reportDiff(templater.Diff, releaseName, ns, devGitInfo)
+	}
+	return nil
+}
+
+// GenerateValues generates the values.yaml of the chart in the directory from its values tree, evaluating any
+// values.tmpl.yaml files and replacing secret URIs, and fetches any secrets files from vault. It returns the value
+// files to pass to helm along with a function which removes the secrets files once the chart has been applied
+func (o *StepHelmApplyOptions) GenerateValues(dir string, devGitInfo *gits.GitRepository) ([]string, *config.RequirementsConfig, func(), error) {
+	cleanup := func() {}
 	valueFiles := []string{}
 	for _, name := range defaultValueFileNames {
 		file := filepath.Join(dir, name)
@@ -222,16 +309,18 @@ func (o *StepHelmApplyOptions) Run() error {
 		}
 	}
 
+	// a dry run does not read any secrets from vault
+	noVault := o.NoVault || o.DryRun
 	vaultSecretLocation := o.GetSecretsLocation() == secrets.VaultLocationKind
-	if vaultSecretLocation && o.NoVault {
+	if vaultSecretLocation && noVault {
 		// lets install a fake secret URL client to avoid spurious vault errors
 		o.SetSecretURLClient(fakevault.NewFakeClient())
 	}
-	if (vaultSecretLocation || o.Vault) && !o.NoVault {
+	if (vaultSecretLocation || o.Vault) && !noVault {
 		store := configio.NewFileStore()
 		secretsFiles, err := o.fetchSecretFilesFromVault(dir, store)
 		if err != nil {
-			return errors.Wrap(err, "fetching secrets files from vault")
+			return nil, nil, cleanup, errors.Wrap(err, "fetching secrets files from vault")
 		}
 		for _, sf := range secretsFiles {
 			if util.StringArrayIndex(valueFiles, sf) < 0 {
@@ -239,7 +328,7 @@ func (o *StepHelmApplyOptions) Run() error {
 				valueFiles = append(valueFiles, sf)
 			}
 		}
-		defer func() {
+		cleanup = func() {
 			for _, secretsFile := range secretsFiles {
 				err := util.DestroyFile(secretsFile)
 				if err != nil {
@@ -247,40 +336,40 @@ func (o *StepHelmApplyOptions) Run() error {
 						strings.Join(secretsFiles, ", "), err)
 				}
 			}
-		}()
+		}
 	}
 
 	requirements, requirementsFileName, err := config.LoadRequirementsConfig(o.Dir)
 	if err != nil {
-		return err
+		return nil, nil, cleanup, err
 	}
 
 	secretURLClient, err := o.GetSecretURLClient(secrets.ToSecretsLocation(string(requirements.SecretStorage)))
 	if err != nil {
-		return errors.Wrap(err, "failed to create a Secret RL client")
+		return nil, nil, cleanup, errors.Wrap(err, "failed to create a Secret RL client")
 	}
 
 	DefaultEnvironments(requirements, devGitInfo)
 
 	funcMap, err := o.createFuncMap(requirements)
 	if err != nil {
-		return err
+		return nil, nil, cleanup, err
 	}
 	chartValues, params, err := helm.GenerateValues(requirements, funcMap, dir, nil, true, secretURLClient)
 	if err != nil {
-		return errors.Wrapf(err, "generating values.yaml for tree from %s", dir)
+		return nil, nil, cleanup, errors.Wrapf(err, "generating values.yaml for tree from %s", dir)
 	}
 	if o.ProviderValuesDir != "" {
 		chartValues, err = o.overwriteProviderValues(requirements, requirementsFileName, chartValues, params, o.ProviderValuesDir)
 		if err != nil {
-			return errors.Wrapf(err, "failed to overwrite provider values in dir: %s", dir)
+			return nil, nil, cleanup, errors.Wrapf(err, "failed to overwrite provider values in dir: %s", dir)
 		}
 	}
 
 	chartValuesFile := filepath.Join(dir, helm.ValuesFileName)
 	err = ioutil.WriteFile(chartValuesFile, chartValues, 0755)
 	if err != nil {
-		return errors.Wrapf(err, "writing values.yaml for tree to %s", chartValuesFile)
+		return nil, nil, cleanup, errors.Wrapf(err, "writing values.yaml for tree to %s", chartValuesFile)
 	}
 	log.Logger().Infof("Wrote chart values.yaml %s generated from directory tree", chartValuesFile)
 
@@ -299,46 +388,58 @@ func (o *StepHelmApplyOptions) Run() error {
 		log.Logger().Infof("\n%s\n", util.ColorStatus(valuesText))
 	}
 
-	log.Logger().Infof("Using values files: %s", strings.Join(valueFiles, ", "))
+	return valueFiles, requirements, cleanup, nil
+}
 
-	if o.Boot {
-		err = o.replaceMissingVersionsFromVersionStream(requirements, dir)
-		if err != nil {
-			return errors.Wrapf(err, "failed to replace missing versions in the requirements.yaml in dir %s", dir)
+// reportDiff displays the changes of a dry run and optionally writes them to a file and comments on the Pull Request
+func (o *StepHelmApplyOptions) reportDiff(diff *helm.ReleaseDiff, releaseName string, ns string, gitInfo *gits.GitRepository) error {
+	if diff == nil {
+		diff = &helm.ReleaseDiff{
+			ReleaseName: releaseName,
+			Namespace:   ns,
 		}
 	}
+	log.Logger().Infof("\n%s", diff.String())
 
-	_, err = o.HelmInitDependencyBuild(dir, o.DefaultReleaseCharts(), valueFiles)
-	if err != nil {
-		return err
+	markdown := diff.ToMarkdown()
+	if o.DiffFile != "" {
+		err := ioutil.WriteFile(o.DiffFile, []byte(markdown), util.DefaultWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "writing the changes to %s", o.DiffFile)
+		}
+		log.Logger().Infof("Wrote the changes to %s", util.ColorInfo(o.DiffFile))
 	}
-
-	err = o.applyAppsTemplateOverrides(chartName)
-	if err != nil {
-		return errors.Wrap(err, "applying app chart overrides")
+	if !o.CommentPR {
+		return nil
 	}
-	err = o.applyTemplateOverrides(chartName)
+	if gitInfo == nil {
+		return fmt.Errorf("cannot comment on the Pull Request as no git repository was found")
+	}
+	prNumber := o.PullRequest
+	if prNumber == "" {
+		prNumber = os.Getenv("PULL_NUMBER")
+	}
+	if prNumber == "" {
+		return util.MissingOption("pull-request")
+	}
+	number, err := strconv.Atoi(prNumber)
 	if err != nil {
-		return errors.Wrap(err, "applying chart overrides")
+		return errors.Wrapf(err, "parsing the Pull Request number %s", prNumber)
 	}
-
-	helmOptions := helm.InstallChartOptions{
-		Chart:       chartName,
-		ReleaseName: releaseName,
-		Ns:          ns,
-		NoForce:     !o.Force,
-		ValueFiles:  valueFiles,
-		Dir:         dir,
+	provider, err := o.GitProviderForURL(gitInfo.URL, "commenting on the Pull Request")
+	if err != nil {
+		return errors.Wrapf(err, "creating the git provider for %s", gitInfo.URL)
 	}
-	if o.Wait {
-		helmOptions.Wait = true
-		err = o.InstallChartWithOptionsAndTimeout(helmOptions, "600")
-	} else {
-		err = o.InstallChartWithOptions(helmOptions)
+	pr := &gits.GitPullRequest{
+		Owner:  gitInfo.Organisation,
+		Repo:   gitInfo.Name,
+		Number: &number,
 	}
+	err = provider.AddPRComment(pr, markdown)
 	if err != nil {
-		return errors.Wrapf(err, "upgrading helm chart '%s'", chartName)
+		return errors.Wrapf(err, "commenting on Pull Request %d of %s", number, gitInfo.URL)
 	}
+	log.Logger().Infof("Added the changes as a comment on Pull Request %s", util.ColorInfo(prNumber))
 	return nil
 }
 
@@ -0,0 +1,153 @@
+package helm
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepHelmMigrateOptions contains the command line flags
+type StepHelmMigrateOptions struct {
+	StepHelmOptions
+
+	Namespaces       []string
+	TillerNamespace  string
+	DeleteV2Releases bool
+	DryRun           bool
+}
+
+var (
+	stepHelmMigrateLong = templates.LongDesc(`
+		Migrates the existing releases of the team to helm3 once the team uses helm3 via 'jx edit helmbin helm3'
+
+		The helm 2 releases stored by Tiller are converted to helm3 releases stored as Secrets in the namespace of each
+		release using the helm 2to3 plugin.
+
+		The resources of the releases installed in template mode are labelled so that helm3 adopts them into a release
+		the next time their chart is upgraded. By default the development namespace and the namespaces of all the
+		Environments are migrated.
+`)
+
+	stepHelmMigrateExample = templates.Examples(`
+		# migrate the releases of the team to helm3
+		jx step helm migrate
+
+		# shows what would be migrated without changing any release
+		jx step helm migrate --dry-run
+
+		# migrate the releases and remove the helm 2 releases from Tiller
+		jx step helm migrate --delete-v2-releases
+`)
+)
+
+// NewCmdStepHelmMigrate creates the command object
+func NewCmdStepHelmMigrate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmMigrateOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "migrate",
+		Short:   "Migrates the helm 2 and template mode releases to helm3",
+		Long:    stepHelmMigrateLong,
+		Example: stepHelmMigrateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&options.Namespaces, "namespace", "n", nil, "the namespaces of the template mode releases to migrate. Defaults to the development namespace and the namespaces of the Environments")
+	cmd.Flags().StringVarP(&options.TillerNamespace, "tiller-namespace", "", opts.DefaultTillerNamesapce, "the namespace in which Tiller stores the helm 2 releases")
+	cmd.Flags().BoolVarP(&options.DeleteV2Releases, "delete-v2-releases", "", false, "removes the helm 2 releases from Tiller once they are converted")
+	cmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false, "shows the releases to migrate without changing them")
+	return cmd
+}
+
+// Run performs the CLI command
+func (o *StepHelmMigrateOptions) Run() error {
+	helm3, ok := o.Helm().(*helm.Helm3CLI)
+	if !ok {
+		return fmt.Errorf("the team does not use helm3, please switch to helm3 first via: jx edit helmbin %s", helm.Helm3Binary)
+	}
+	kubeClient, devNs, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+
+	tillerReleases, err := helm.FindTillerReleases(kubeClient, o.TillerNamespace)
+	if err != nil {
+		return err
+	}
+	if len(tillerReleases) > 0 {
+		err = helm3.EnsureHelm2to3Plugin()
+		if err != nil {
+			return err
+		}
+	}
+	for _, releaseName := range tillerReleases {
+		log.Logger().Infof("Converting the helm 2 release %s", util.ColorInfo(releaseName))
+		err = helm3.ConvertHelm2Release(releaseName, o.TillerNamespace, o.DeleteV2Releases, o.DryRun)
+		if err != nil {
+			return err
+		}
+	}
+
+	namespaces := o.Namespaces
+	if len(namespaces) == 0 {
+		namespaces, err = o.teamNamespaces(devNs)
+		if err != nil {
+			return err
+		}
+	}
+	count := 0
+	for _, ns := range namespaces {
+		releases, err := helm.FindTemplateReleases(kubeClient, ns)
+		if err != nil {
+			return err
+		}
+		for _, releaseName := range releases {
+			log.Logger().Infof("Adopting the template mode release %s in namespace %s", util.ColorInfo(releaseName), util.ColorInfo(ns))
+			err = helm3.AdoptTemplateRelease(ns, releaseName, o.DryRun)
+			if err != nil {
+				return err
+			}
+			count++
+		}
+	}
+	log.Logger().Infof("Migrated %d helm 2 releases and %d template mode releases to helm3", len(tillerReleases), count)
+	return nil
+}
+
+// teamNamespaces returns the development namespace and the namespaces of the Environments of the team
+func (o *StepHelmMigrateOptions) teamNamespaces(devNs string) ([]string, error) {
+	jxClient, _, err := o.JXClient()
+	if err != nil {
+		return nil, err
+	}
+	envs, names, err := kube.GetEnvironments(jxClient, devNs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the environments in namespace %s", devNs)
+	}
+	answer := []string{devNs}
+	for _, name := range names {
+		ns := envs[name].Spec.Namespace
+		if ns != "" && util.StringArrayIndex(answer, ns) < 0 {
+			answer = append(answer, ns)
+		}
+	}
+	return answer, nil
+}
@@ -26,16 +26,37 @@ import (
 // StepHelmReleaseOptions contains the command line flags
 type StepHelmReleaseOptions struct {
 	StepHelmOptions
+
+	Sign       bool
+	SigningKey string
+	Keyring    string
+}
+
+// chartSigner packages signed charts
+type chartSigner interface {
+	PackageSignedChart(key string, keyring string) error
 }
 
 var (
 	StepHelmReleaseLong = templates.LongDesc(`
 		This pipeline step releases the Helm chart in the current directory
+
+		When signing is enabled the packaged chart is signed with a GPG key from the keyring generated by
+		'jx step gpg credentials' and its provenance file is uploaded along with the chart.
+
+		When the chart repository is an OCI registry such as oci://gcr.io/myorg/charts the chart is pushed to the
+		registry via helm 3 using the docker credentials of the registry.
 `)
 
 	StepHelmReleaseExample = templates.Examples(`
 		jx step helm release
 
+		# releases the chart signing it with the given GPG key
+		jx step helm release --sign --key "Jenkins X Bot"
+
+		# releases the chart to an OCI registry
+		CHART_REPOSITORY=oci://gcr.io/myorg/charts jx step helm release
+
 `)
 )
 
@@ -61,6 +82,9 @@ func NewCmdStepHelmRelease(commonOpts *opts.CommonOptions) *cobra.Command {
 		},
 	}
 	options.addStepHelmFlags(cmd)
+	cmd.Flags().BoolVarP(&options.Sign, "sign", "", false, "Signs the packaged chart creating a provenance file")
+	cmd.Flags().StringVarP(&options.SigningKey, "key", "", "", "The name of the GPG key to sign the chart with")
+	cmd.Flags().StringVarP(&options.Keyring, "keyring", "", "", "The keyring containing the GPG key. Defaults to the secret keyring generated by 'jx step gpg credentials'")
 	return cmd
 }
 
@@ -75,8 +99,27 @@ func (o *StepHelmReleaseOptions) Run() error {
 		return errors.Wrapf(err, "failed to build dependencies for chart from directory '%s'", dir)
 	}
 
+	chartRepo := o.ReleaseChartMuseumUrl()
+	if helm.IsOCIChart(chartRepo) {
+		if o.Sign {
+			return fmt.Errorf("signing charts is not supported for the OCI registry %s", chartRepo)
+		}
+		return o.releaseToOCIRegistry(dir, chartRepo)
+	}
+
 	o.Helm().SetCWD(dir)
-	err = o.Helm().PackageChart()
+	if o.Sign {
+		if o.SigningKey == "" {
+			return util.MissingOption("key")
+		}
+		signer, ok := o.Helm().(chartSigner)
+		if !ok {
+			return fmt.Errorf("signing charts is not supported by %T", o.Helm())
+		}
+		err = signer.PackageSignedChart(o.SigningKey, o.Keyring)
+	} else {
+		err = o.Helm().PackageChart()
+	}
 	if err != nil {
 		return errors.Wrapf(err, "failed to package the chart from directory '%s'", dir)
 	}
@@ -102,8 +145,17 @@ func (o *StepHelmReleaseOptions) Run() error {
 		return fmt.Errorf("Generated helm file %s does not exist!", tarball)
 	}
 	defer os.Remove(tarball)
-
-	chartRepo := o.ReleaseChartMuseumUrl()
+	provenanceFile := helm.ProvenanceFile(tarball)
+	if o.Sign {
+		exists, err = util.FileExists(provenanceFile)
+		if err != nil {
+			return errors.Wrapf(err, "checking the provenance file '%s' exists", provenanceFile)
+		}
+		if !exists {
+			return fmt.Errorf("Generated provenance file %s does not exist!", provenanceFile)
+		}
+		defer os.Remove(provenanceFile)
+	}
 
 	userName := os.Getenv("CHARTMUSEUM_CREDS_USR")
 	password := os.Getenv("CHARTMUSEUM_CREDS_PSW")
@@ -135,21 +187,83 @@ func (o *StepHelmReleaseOptions) Run() error {
 	}
 
 	// post the tarball to the chart repository
-	client := http.Client{}
-
 	u := util.UrlJoin(chartRepo, "/api/charts")
+	err = uploadChartFile(u, tarball, "application/gzip", userName, password)
+	if err != nil {
+		return err
+	}
+	if o.Sign {
+		u = util.UrlJoin(chartRepo, "/api/prov")
+		err = uploadChartFile(u, provenanceFile, "application/octet-stream", userName, password)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseToOCIRegistry pushes the chart in the given directory to the OCI registry using the docker credentials of the
+// registry from either the local docker config or the docker config secret created via jx create docker auth
+func (o *StepHelmReleaseOptions) releaseToOCIRegistry(dir string, chartRepo string) error {
+	name, version, err := helm.LoadChartNameAndVersion(filepath.Join(dir, "Chart.yaml"))
+	if err != nil {
+		return errors.Wrap(err, "failed to load chart name and version")
+	}
+	ref, err := helm.OCIReferenceForChart(name, chartRepo, version)
+	if err != nil {
+		return err
+	}
+	err = o.InstallHelm3()
+	if err != nil {
+		return errors.Wrap(err, "failed to install helm 3 which is required for OCI registries")
+	}
+	client := helm.NewOCIClient(helm.OCIBinary(o.Helm().HelmBinary()), dir)
+
+	userName, password, err := helm.LocalDockerRegistryCredentials(ref.Registry)
+	if err != nil {
+		return err
+	}
+	if userName == "" {
+		kubeClient, ns, err := o.KubeClientAndNamespace()
+		if err != nil {
+			return errors.Wrap(err, "failed to create the kube client")
+		}
+		secret, err := kubeClient.CoreV1().Secrets(ns).Get(kube.SecretJenkinsDockerConfig, metav1.GetOptions{})
+		if err != nil {
+			log.Logger().Warnf("Could not load Secret %s in namespace %s: %s", kube.SecretJenkinsDockerConfig, ns, err)
+		} else {
+			userName, password, err = helm.DockerRegistryCredentials(secret.Data["config.json"], ref.Registry)
+			if err != nil {
+				return errors.Wrapf(err, "failed to load the docker credentials from Secret %s", kube.SecretJenkinsDockerConfig)
+			}
+		}
+	}
+	if userName != "" {
+		err = client.Login(ref.Registry, userName, password)
+		if err != nil {
+			return errors.Wrapf(err, "failed to login to the OCI registry %s", ref.Registry)
+		}
+	}
+	log.Logger().Infof("Pushing chart %s to %s", util.ColorInfo(name), util.ColorInfo(helm.OCIScheme+ref.String()))
+	return client.PushChart(dir, ref)
+}
+
+// uploadChartFile posts the chart archive or provenance file to the given chart museum URL
+func uploadChartFile(u string, fileName string, contentType string, userName string, password string) error {
+	client := http.Client{}
 
-	file, err := os.Open(tarball)
+	file, err := os.Open(fileName)
 	if err != nil {
-		return errors.Wrapf(err, "failed to open the chart archive '%s'", tarball)
+		return errors.Wrapf(err, "failed to open the chart file '%s'", fileName)
 	}
-	log.Logger().Infof("Uploading chart file %s to %s", util.ColorInfo(tarball), util.ColorInfo(u))
+	defer file.Close()
+	log.Logger().Infof("Uploading chart file %s to %s", util.ColorInfo(fileName), util.ColorInfo(u))
 	req, err := http.NewRequest(http.MethodPost, u, bufio.NewReader(file))
 	if err != nil {
 		return errors.Wrapf(err, "failed to build the chart upload request for endpoint '%s'", u)
 	}
 	req.SetBasicAuth(userName, password)
-	req.Header.Set("Content-Type", "application/gzip")
+	req.Header.Set("Content-Type", contentType)
 	res, err := client.Do(req)
 	if err != nil {
 		if res == nil {
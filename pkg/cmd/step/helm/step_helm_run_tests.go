@@ -0,0 +1,194 @@
+package helm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/step/report"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// StepHelmTestOptions contains the command line flags
+type StepHelmTestOptions struct {
+	StepHelmOptions
+
+	ReleaseName string
+	Namespace   string
+	Version     string
+	Values      []string
+	ValuesFiles []string
+	Timeout     time.Duration
+	Cleanup     bool
+	JUnitFile   string
+}
+
+var (
+	stepHelmTestLong = templates.LongDesc(`
+		Runs the test hooks of a release and records the results as a JUnit report
+
+		The resources of the chart annotated with the 'helm.sh/hook: test' or 'helm.sh/hook: test-success' helm hooks
+		are run in ascending order of their 'helm.sh/hook-weight' annotation. When using helm template mode the chart in
+		the directory is rendered to find the test hooks of the release.
+
+		If the $REPORTS_DIR environment variable is set and no JUnit file is specified the report is written to that
+		directory so that it can be collected via 'jx step report'.
+`)
+
+	stepHelmTestExample = templates.Examples(`
+		# runs the tests of the release of the chart in the current directory
+		jx step helm test --name jx-staging --namespace jx-staging
+
+		# runs the tests of a release and writes the results to a JUnit file
+		jx step helm test --name myapp --junit-file reports/myapp.junit.xml --cleanup
+`)
+)
+
+// NewCmdStepHelmTest creates the command object
+func NewCmdStepHelmTest(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := StepHelmTestOptions{
+		StepHelmOptions: StepHelmOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "test",
+		Short:   "Runs the test hooks of a release",
+		Long:    stepHelmTestLong,
+		Example: stepHelmTestExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	options.addStepHelmFlags(cmd)
+
+	cmd.Flags().StringVarP(&options.ReleaseName, "name", "", "", "The name of the release to test")
+	cmd.Flags().StringVarP(&options.Namespace, "namespace", "n", "", "The namespace of the release. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&options.Version, "version", "v", "", "The version of the chart when using helm template mode. Defaults to the version in the Chart.yaml")
+	cmd.Flags().StringArrayVarP(&options.Values, "set", "", []string{}, "The values to override in the helm chart when using helm template mode")
+	cmd.Flags().StringArrayVarP(&options.ValuesFiles, "values", "f", []string{}, "The values files to override values in the helm chart when using helm template mode")
+	cmd.Flags().DurationVarP(&options.Timeout, "timeout", "", 5*time.Minute, "The maximum time to wait for each test to complete")
+	cmd.Flags().BoolVarP(&options.Cleanup, "cleanup", "", false, "Removes the test resources once they complete")
+	cmd.Flags().StringVarP(&options.JUnitFile, "junit-file", "", "", "The file to write the JUnit report of the test results to")
+	return cmd
+}
+
+// Run performs the CLI command
+func (o *StepHelmTestOptions) Run() error {
+	if o.ReleaseName == "" {
+		return util.MissingOption("name")
+	}
+	ns := o.Namespace
+	if ns == "" {
+		var err error
+		_, ns, err = o.KubeClientAndNamespace()
+		if err != nil {
+			return err
+		}
+	}
+
+	var results []*helm.ReleaseTestResult
+	var err error
+	switch helmer := o.Helm().(type) {
+	case *helm.HelmTemplate:
+		results, err = helmer.RunTests(o.Dir, o.ReleaseName, ns, o.Version, o.Values, o.ValuesFiles, o.Timeout, o.Cleanup)
+	case *helm.Helm3CLI:
+		results, err = helmer.TestRelease(ns, o.ReleaseName, o.Timeout, o.Cleanup)
+	case *helm.HelmCLI:
+		results, err = helmer.TestRelease(ns, o.ReleaseName, o.Timeout, o.Cleanup)
+	default:
+		return fmt.Errorf("running the tests of a release is not supported by %T", helmer)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "testing release %s in namespace %s", o.ReleaseName, ns)
+	}
+
+	fileName := o.JUnitFile
+	if fileName == "" && os.Getenv("REPORTS_DIR") != "" {
+		fileName = filepath.Join(os.Getenv("REPORTS_DIR"), o.ReleaseName+"-helm-test.junit.xml")
+	}
+	if fileName != "" {
+		err = WriteReleaseTestReport(fileName, o.ReleaseName, results)
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("Wrote the test results to %s", util.ColorInfo(fileName))
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	if len(results) == 0 {
+		log.Logger().Infof("Release %s has no tests", util.ColorInfo(o.ReleaseName))
+		return nil
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests of release %s failed", failed, len(results), o.ReleaseName)
+	}
+	log.Logger().Infof("All %d tests of release %s passed", len(results), util.ColorInfo(o.ReleaseName))
+	return nil
+}
+
+// WriteReleaseTestReport writes the results of the tests of a release to the given file as a JUnit report
+func WriteReleaseTestReport(fileName string, releaseName string, results []*helm.ReleaseTestResult) error {
+	suite := report.TestSuite{
+		Name:     releaseName,
+		Tests:    fmt.Sprintf("%d", len(results)),
+		Errors:   "0",
+		TestCase: []report.TestCase{},
+	}
+	failures := 0
+	var total time.Duration
+	for _, result := range results {
+		testCase := report.TestCase{
+			Name:      result.Name,
+			Classname: releaseName + "." + result.Kind,
+			Time:      fmt.Sprintf("%.3f", result.Duration.Seconds()),
+			SystemOut: result.Output,
+		}
+		if !result.Passed {
+			failures++
+			testCase.Failure = &report.Failure{
+				Text: result.Message,
+				Type: "HelmTestFailure",
+			}
+		}
+		total += result.Duration
+		suite.TestCase = append(suite.TestCase, testCase)
+	}
+	suite.Failures = fmt.Sprintf("%d", failures)
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+
+	data, err := xml.MarshalIndent(report.TestSuites{TestSuites: []report.TestSuite{suite}}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling the JUnit report")
+	}
+	err = os.MkdirAll(filepath.Dir(fileName), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "creating the directory of %s", fileName)
+	}
+	err = ioutil.WriteFile(fileName, append([]byte(xml.Header), data...), util.DefaultWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "writing the JUnit report %s", fileName)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package rotate
+
+import (
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/spf13/cobra"
+)
+
+// StepRotateOptions contains the command line flags
+type StepRotateOptions struct {
+	step.StepOptions
+}
+
+// NewCmdStepRotate Steps a command object for the "step rotate" command
+func NewCmdStepRotate(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepRotateOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "rotate [command]",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.AddCommand(NewCmdStepRotateSecrets(commonOpts))
+	return cmd
+}
+
+// Run implements this command
+func (o *StepRotateOptions) Run() error {
+	return o.Cmd.Help()
+}
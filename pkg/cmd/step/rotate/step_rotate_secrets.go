@@ -0,0 +1,376 @@
+package rotate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/io/secrets"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/vault"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	stepRotateSecretsLong = templates.LongDesc(`
+		Rotates the git, chat and docker registry tokens which have expired or expire soon.
+
+		Tokens are regenerated using the API of the provider where that is supported: Gitea git tokens and Docker Hub
+		access tokens using the password of the user and Slack tokens using the refresh token of the user along with
+		the client ID and secret of the Slack app. The password of the user is saved when it is passed to
+		'jx create git token --password' or 'jx create docker auth --password'. Each new token is saved in the auth
+		configuration, written to the secret storage and any deployments using it are restarted before the previous
+		token is revoked.
+
+		Tokens which cannot be regenerated automatically, such as GitHub tokens, are reported so that they can be
+		rotated by hand. A failure to rotate one token does not stop the other tokens being rotated.
+`)
+
+	stepRotateSecretsExample = templates.Examples(`
+		# Rotate the tokens which have expired or expire in the next week
+		jx step rotate secrets
+
+		# Rotate all the git tokens whatever their expiry, giving the new tokens 90 days to live
+		jx step rotate secrets --all --kind git --expires 2160h
+	`)
+)
+
+// StepRotateSecretsOptions contains the command line flags
+type StepRotateSecretsOptions struct {
+	step.StepOptions
+
+	Kind              string
+	All               bool
+	Within            time.Duration
+	Expires           time.Duration
+	SlackClientID     string
+	SlackClientSecret string
+
+	// TokenProviders creates the providers used to rotate tokens, defaults to NewTokenProviderFactory
+	TokenProviders TokenProviderFactory
+	// ConfigServices the auth configuration services of each kind of token, loaded from the cluster if missing
+	ConfigServices map[string]auth.ConfigService
+}
+
+// NewCmdStepRotateSecrets Creates a new Command object
+func NewCmdStepRotateSecrets(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepRotateSecretsOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: commonOpts,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "secrets",
+		Short:   "Rotates the tokens which have expired or expire soon",
+		Long:    stepRotateSecretsLong,
+		Example: stepRotateSecretsExample,
+		Aliases: []string{"secret", "tokens", "token"},
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Kind, "kind", "k", "", "Only rotate tokens of this kind. One of git, chat or docker")
+	cmd.Flags().BoolVarP(&options.All, "all", "a", false, "Rotates all tokens whatever their expiry")
+	cmd.Flags().DurationVarP(&options.Within, "within", "", 7*24*time.Hour, "Rotates tokens which expire within this duration from now")
+	cmd.Flags().DurationVarP(&options.Expires, "expires", "", 0, "How long the new tokens live for. If not specified the previous lifetime of the token is reused")
+	cmd.Flags().StringVarP(&options.SlackClientID, "slack-client-id", "", os.Getenv("SLACK_CLIENT_ID"), "The client ID of the Slack app used to refresh Slack tokens")
+	cmd.Flags().StringVarP(&options.SlackClientSecret, "slack-client-secret", "", os.Getenv("SLACK_CLIENT_SECRET"), "The client secret of the Slack app used to refresh Slack tokens")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepRotateSecretsOptions) Run() error {
+	kinds := []string{kube.ValueKindGit, kube.ValueKindChat, kube.ValueKindDocker}
+	if o.Kind != "" {
+		if util.StringArrayIndex(kinds, o.Kind) < 0 {
+			return util.InvalidOption("kind", o.Kind, kinds)
+		}
+		kinds = []string{o.Kind}
+	}
+	if o.TokenProviders == nil {
+		o.TokenProviders = NewTokenProviderFactory(o.SlackClientID, o.SlackClientSecret)
+	}
+	now := time.Now()
+	manual := 0
+	errs := []error{}
+	for _, kind := range kinds {
+		authConfigSvc, err := o.authConfigService(kind)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to load the %s auth configuration", kind))
+			continue
+		}
+		config := authConfigSvc.Config()
+		for _, server := range config.Servers {
+			for _, user := range server.Users {
+				if !NeedsRotation(user, now, o.All, o.Within) {
+					continue
+				}
+				provider := o.TokenProviders(kind, server, user)
+				if provider == nil {
+					manual++
+					log.Logger().Warnf("the %s token for user %s on %s cannot be regenerated automatically, please rotate it using: %s",
+						kind, util.ColorInfo(user.Username), util.ColorInfo(server.URL), util.ColorInfo(manualRotationCommand(kind)))
+					continue
+				}
+				err = o.rotateToken(authConfigSvc, provider, kind, server, user, now)
+				if err != nil {
+					log.Logger().Warnf("failed to rotate the %s token for user %s on %s: %s", kind,
+						util.ColorInfo(user.Username), util.ColorInfo(server.URL), err)
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	if manual > 0 {
+		log.Logger().Warnf("%d token(s) need to be rotated manually", manual)
+	}
+	return util.CombineErrors(errs...)
+}
+
+// rotateToken generates a new token for the user and saves it in the auth configuration straight away so that it is
+// never lost. The new token is then written to the secret storage and its consumers restarted before the previous
+// token is revoked
+func (o *StepRotateSecretsOptions) rotateToken(authConfigSvc auth.ConfigService, provider TokenProvider, kind string,
+	server *auth.AuthServer, user *auth.UserAuth, now time.Time) error {
+	previous := *user
+	tokenName := fmt.Sprintf("jx-%s", now.UTC().Format("20060102-150405"))
+	rotated, ttl, err := provider.CreateToken(server, user, tokenName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a new %s token for user %s on %s", kind, user.Username, server.URL)
+	}
+	if o.Expires > 0 || ttl <= 0 {
+		ttl = TokenTimeToLive(user, o.Expires)
+	}
+	*user = *rotated
+	user.SetTokenCreated(now, ttl)
+	err = authConfigSvc.SaveConfig()
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the new %s token %s for user %s on %s", kind, tokenName, user.Username, server.URL)
+	}
+	log.Logger().Infof("rotated the %s token for user %s on %s", kind, util.ColorInfo(user.Username), util.ColorInfo(server.URL))
+
+	secretURLClient, err := o.GetSecretURLClient(secrets.AutoLocationKind)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the secret URL client")
+	}
+	err = writeToken(secretURLClient, kind, server, user)
+	if err != nil {
+		return err
+	}
+	err = o.updateConsumers(authConfigSvc.Config(), kind, server, user)
+	if err != nil {
+		return err
+	}
+
+	err = provider.RevokeToken(server, &previous)
+	if err != nil {
+		log.Logger().Warnf("failed to revoke the previous %s token for user %s on %s, please revoke it by hand: %s",
+			kind, util.ColorInfo(user.Username), util.ColorInfo(server.URL), err)
+	}
+	return nil
+}
+
+// updateConsumers updates the pipeline Secret using the token and restarts the deployments using that Secret
+func (o *StepRotateSecretsOptions) updateConsumers(config *auth.AuthConfig, kind string, server *auth.AuthServer,
+	user *auth.UserAuth) error {
+	var secretName string
+	var err error
+	switch kind {
+	case kube.ValueKindGit:
+		if config.PipeLineUsername != user.Username {
+			return nil
+		}
+		secretName, err = o.UpdatePipelineGitCredentialsSecret(server, user)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the pipeline git credentials for %s", server.URL)
+		}
+	case kube.ValueKindChat:
+		secretName, err = o.updateChatCredentialsSecret(server, user)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the pipeline chat credentials for %s", server.URL)
+		}
+	default:
+		secretName, err = o.updateDockerConfigSecret(server, user)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update the docker config for %s", server.URL)
+		}
+	}
+	if secretName == "" {
+		return nil
+	}
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	restarted, err := kube.RestartDeploymentsUsingSecret(kubeClient, ns, secretName)
+	if err != nil {
+		return err
+	}
+	for _, name := range restarted {
+		log.Logger().Infof("restarted deployment %s to use the new token", util.ColorInfo(name))
+	}
+	return nil
+}
+
+// updateChatCredentialsSecret updates the password of the pipeline chat credentials Secret returning its name or an
+// empty string if there is no such Secret
+func (o *StepRotateSecretsOptions) updateChatCredentialsSecret(server *auth.AuthServer, user *auth.UserAuth) (string, error) {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return "", err
+	}
+	name := naming.ToValidName(kube.SecretJenkinsPipelineChatCredentials + server.Kind + "-" + server.Name)
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if string(secret.Data["username"]) != user.Username {
+		return "", nil
+	}
+	secret.Data["password"] = []byte(user.ApiToken)
+	_, err = kubeClient.CoreV1().Secrets(ns).Update(secret)
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// updateDockerConfigSecret updates the credentials of the registry in the docker config Secret returning its name or
+// an empty string if the Secret does not use the registry
+func (o *StepRotateSecretsOptions) updateDockerConfigSecret(server *auth.AuthServer, user *auth.UserAuth) (string, error) {
+	kubeClient, ns, err := o.KubeClientAndDevNamespace()
+	if err != nil {
+		return "", err
+	}
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get(kube.SecretJenkinsDockerConfig, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	dockerConfig := map[string]interface{}{}
+	err = json.Unmarshal(secret.Data["config.json"], &dockerConfig)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the config.json of Secret %s", secret.Name)
+	}
+	auths, _ := dockerConfig["auths"].(map[string]interface{})
+	found := false
+	for host, v := range auths {
+		entry, ok := v.(map[string]interface{})
+		if !ok || host != server.URL {
+			continue
+		}
+		entry["auth"] = base64.StdEncoding.EncodeToString([]byte(user.Username + ":" + user.ApiToken))
+		found = true
+	}
+	if !found {
+		return "", nil
+	}
+	secret.Data["config.json"], err = json.Marshal(dockerConfig)
+	if err != nil {
+		return "", err
+	}
+	_, err = kubeClient.CoreV1().Secrets(ns).Update(secret)
+	if err != nil {
+		return "", err
+	}
+	return secret.Name, nil
+}
+
+// authConfigService returns the loaded auth configuration for the given kind of token
+func (o *StepRotateSecretsOptions) authConfigService(kind string) (auth.ConfigService, error) {
+	if o.ConfigServices[kind] != nil {
+		return o.ConfigServices[kind], nil
+	}
+	var authConfigSvc auth.ConfigService
+	var err error
+	switch kind {
+	case kube.ValueKindGit:
+		authConfigSvc, err = o.CreateGitAuthConfigService()
+	case kube.ValueKindChat:
+		authConfigSvc, err = o.CreateChatAuthConfigService()
+	default:
+		authConfigSvc, err = o.AuthConfigService(auth.DockerAuthConfigFile)
+	}
+	if err == nil && len(authConfigSvc.Config().Servers) == 0 {
+		_, err = authConfigSvc.LoadConfig()
+	}
+	return authConfigSvc, err
+}
+
+// NeedsRotation returns true if the token should be rotated
+func NeedsRotation(user *auth.UserAuth, now time.Time, all bool, within time.Duration) bool {
+	if user.IsInvalid() {
+		return false
+	}
+	return all || user.ExpiresWithin(now, within)
+}
+
+// TokenTimeToLive returns the time to live of the new token, defaulting to the lifetime of the previous token
+func TokenTimeToLive(user *auth.UserAuth, expires time.Duration) time.Duration {
+	if expires > 0 {
+		return expires
+	}
+	if user.Created != nil && user.Expires != nil {
+		return user.Expires.Sub(*user.Created)
+	}
+	return 0
+}
+
+// TokenSecretPath returns the path in the secret storage of the token for the given user on the server
+func TokenSecretPath(kind string, server *auth.AuthServer, user *auth.UserAuth) string {
+	return fmt.Sprintf("%stokens/%s/%s/%s", vault.AuthSecretsPath, kind, naming.ToValidName(server.Name), naming.ToValidName(user.Username))
+}
+
+func writeToken(client secreturl.Client, kind string, server *auth.AuthServer, user *auth.UserAuth) error {
+	path := TokenSecretPath(kind, server, user)
+	data := map[string]interface{}{
+		"url":      server.URL,
+		"username": user.Username,
+		"token":    user.ApiToken,
+	}
+	if user.RefreshToken != "" {
+		data["refreshToken"] = user.RefreshToken
+	}
+	if user.Expires != nil {
+		data["expires"] = user.Expires.Format(time.RFC3339)
+	}
+	_, err := client.Write(path, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the token to %s", path)
+	}
+	return nil
+}
+
+func manualRotationCommand(kind string) string {
+	switch kind {
+	case kube.ValueKindGit:
+		return "jx create git token"
+	case kube.ValueKindChat:
+		return "jx create chat token"
+	default:
+		return "jx create docker auth"
+	}
+}
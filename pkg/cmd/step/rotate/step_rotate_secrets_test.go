@@ -0,0 +1,195 @@
+package rotate_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/step/rotate"
+	"github.com/jenkins-x/jx/pkg/cmd/testhelpers"
+	gits_test "github.com/jenkins-x/jx/pkg/gits/mocks"
+	helm_test "github.com/jenkins-x/jx/pkg/helm/mocks"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/secreturl/fakevault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonConfigSaver saves a copy of the config so that unsaved changes are not visible
+type jsonConfigSaver struct {
+	data []byte
+}
+
+func (s *jsonConfigSaver) LoadConfig() (*auth.AuthConfig, error) {
+	config := &auth.AuthConfig{}
+	if len(s.data) == 0 {
+		return config, nil
+	}
+	err := json.Unmarshal(s.data, config)
+	return config, err
+}
+
+func (s *jsonConfigSaver) SaveConfig(config *auth.AuthConfig) error {
+	data, err := json.Marshal(config)
+	s.data = data
+	return err
+}
+
+// fakeTokenProvider generates sequential tokens failing for the users in fail and records the revoked tokens along
+// with the saved token of the user at the time of revocation
+type fakeTokenProvider struct {
+	saver         *jsonConfigSaver
+	fail          map[string]bool
+	count         int
+	revoked       []string
+	savedOnRevoke []string
+}
+
+func (p *fakeTokenProvider) CreateToken(server *auth.AuthServer, user *auth.UserAuth, name string) (*auth.UserAuth, time.Duration, error) {
+	if p.fail[user.Username] {
+		return nil, 0, fmt.Errorf("failed to create a token for %s", user.Username)
+	}
+	p.count++
+	answer := *user
+	answer.ApiToken = fmt.Sprintf("new-token-%d", p.count)
+	answer.TokenName = name
+	return &answer, 0, nil
+}
+
+func (p *fakeTokenProvider) RevokeToken(server *auth.AuthServer, user *auth.UserAuth) error {
+	p.revoked = append(p.revoked, user.ApiToken)
+	config, err := p.saver.LoadConfig()
+	if err != nil {
+		return err
+	}
+	p.savedOnRevoke = append(p.savedOnRevoke, config.FindUserAuth(server.URL, user.Username).ApiToken)
+	return nil
+}
+
+func TestNeedsRotation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2019, time.July, 1, 12, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	neverExpires := &auth.UserAuth{Username: "bob", ApiToken: "abc"}
+	assert.False(t, rotate.NeedsRotation(neverExpires, now, false, week))
+	assert.True(t, rotate.NeedsRotation(neverExpires, now, true, week))
+
+	expiresSoon := &auth.UserAuth{Username: "bob", ApiToken: "abc"}
+	expiresSoon.SetTokenCreated(now.Add(-25*24*time.Hour), 30*24*time.Hour)
+	assert.True(t, rotate.NeedsRotation(expiresSoon, now, false, week))
+	assert.False(t, rotate.NeedsRotation(expiresSoon, now, false, 24*time.Hour))
+
+	noToken := &auth.UserAuth{Username: "bob"}
+	noToken.SetTokenCreated(now.Add(-25*24*time.Hour), 30*24*time.Hour)
+	assert.False(t, rotate.NeedsRotation(noToken, now, true, week), "users without a token should not be rotated")
+}
+
+func TestTokenTimeToLive(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2019, time.July, 1, 12, 0, 0, 0, time.UTC)
+	user := &auth.UserAuth{Username: "bob", ApiToken: "abc"}
+	assert.Equal(t, time.Duration(0), rotate.TokenTimeToLive(user, 0))
+	assert.Equal(t, time.Hour, rotate.TokenTimeToLive(user, time.Hour))
+
+	user.SetTokenCreated(now, 30*24*time.Hour)
+	assert.Equal(t, 30*24*time.Hour, rotate.TokenTimeToLive(user, 0))
+	assert.Equal(t, time.Hour, rotate.TokenTimeToLive(user, time.Hour))
+}
+
+func TestTokenSecretPath(t *testing.T) {
+	t.Parallel()
+
+	server := &auth.AuthServer{Name: "GitHub", URL: "https://github.com"}
+	user := &auth.UserAuth{Username: "Bob"}
+	assert.Equal(t, "auth/tokens/git/github/bob", rotate.TokenSecretPath("git", server, user))
+}
+
+func TestStepRotateSecretsRun(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	saver := &jsonConfigSaver{}
+	authConfigSvc := auth.NewAuthConfigService(saver)
+	config := authConfigSvc.Config()
+	server := config.GetOrCreateServerName("https://gitea.example.com", "gitea", "gitea")
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := config.GetOrCreateUserAuth(server.URL, name)
+		user.ApiToken = "old-" + name
+		user.Password = "secret"
+		user.SetTokenCreated(now.Add(-29*24*time.Hour), 30*24*time.Hour)
+	}
+	config.GetOrCreateUserAuth(server.URL, "dave").ApiToken = "old-dave"
+	config.PipeLineUsername = "alice"
+	err := authConfigSvc.SaveConfig()
+	require.NoError(t, err)
+
+	provider := &fakeTokenProvider{
+		saver: saver,
+		fail:  map[string]bool{"bob": true},
+	}
+	o := &rotate.StepRotateSecretsOptions{
+		StepOptions: step.StepOptions{
+			CommonOptions: &opts.CommonOptions{},
+		},
+		Kind: kube.ValueKindGit,
+		TokenProviders: func(kind string, server *auth.AuthServer, user *auth.UserAuth) rotate.TokenProvider {
+			return provider
+		},
+		ConfigServices: map[string]auth.ConfigService{
+			kube.ValueKindGit: authConfigSvc,
+		},
+	}
+	testhelpers.ConfigureTestOptions(o.CommonOptions, gits_test.NewMockGitter(), helm_test.NewMockHelmer())
+	secretURLClient := fakevault.NewFakeClient()
+	o.SetSecretURLClient(secretURLClient)
+
+	err = o.Run()
+	require.Error(t, err, "the token of bob cannot be created")
+
+	saved, err := saver.LoadConfig()
+	require.NoError(t, err)
+	alice := saved.FindUserAuth(server.URL, "alice")
+	assert.Equal(t, "new-token-1", alice.ApiToken, "the token rotated before the failure should be saved")
+	assert.True(t, alice.Expires.After(now.Add(29*24*time.Hour)))
+	assert.Equal(t, "old-bob", saved.FindUserAuth(server.URL, "bob").ApiToken)
+	assert.Equal(t, "new-token-2", saved.FindUserAuth(server.URL, "carol").ApiToken, "the tokens after the failure should still be rotated")
+	assert.Equal(t, "old-dave", saved.FindUserAuth(server.URL, "dave").ApiToken, "tokens without an expiry should not be rotated")
+	assert.Equal(t, []string{"old-alice", "old-carol"}, provider.revoked)
+	assert.Equal(t, []string{"new-token-1", "new-token-2"}, provider.savedOnRevoke, "the old token should be revoked after the new one is saved")
+
+	values, err := secretURLClient.Read(rotate.TokenSecretPath(kube.ValueKindGit, server, alice))
+	require.NoError(t, err)
+	assert.Equal(t, "new-token-1", values["token"])
+
+	delete(provider.fail, "bob")
+	err = o.Run()
+	require.NoError(t, err)
+	saved, err = saver.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "new-token-1", saved.FindUserAuth(server.URL, "alice").ApiToken)
+	assert.Equal(t, "new-token-3", saved.FindUserAuth(server.URL, "bob").ApiToken)
+	assert.Equal(t, "new-token-2", saved.FindUserAuth(server.URL, "carol").ApiToken)
+	assert.Equal(t, []string{"old-alice", "old-carol", "old-bob"}, provider.revoked)
+}
+
+func TestNewTokenProviderFactoryRequiresPassword(t *testing.T) {
+	t.Parallel()
+
+	factory := rotate.NewTokenProviderFactory("", "")
+	gitea := &auth.AuthServer{Kind: "gitea", URL: "https://gitea.example.com"}
+	dockerHub := &auth.AuthServer{URL: "https://index.docker.io/v1/"}
+	withoutPassword := &auth.UserAuth{Username: "bob", ApiToken: "abc"}
+	withPassword := &auth.UserAuth{Username: "bob", ApiToken: "abc", Password: "secret"}
+
+	assert.Nil(t, factory(kube.ValueKindGit, gitea, withoutPassword), "gitea tokens cannot be rotated without a password")
+	assert.NotNil(t, factory(kube.ValueKindGit, gitea, withPassword))
+	assert.Nil(t, factory(kube.ValueKindDocker, dockerHub, withoutPassword), "docker hub tokens cannot be rotated without a password")
+	assert.NotNil(t, factory(kube.ValueKindDocker, dockerHub, withPassword))
+}
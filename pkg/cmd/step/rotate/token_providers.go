@@ -0,0 +1,321 @@
+package rotate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/auth"
+	"github.com/jenkins-x/jx/pkg/chats"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	dockerHubAPIURL = "https://hub.docker.com/v2"
+	slackAPIURL     = "https://slack.com/api"
+
+	tokenProviderTimeout = 30 * time.Second
+)
+
+// TokenProvider generates and revokes the API tokens of users on a server
+type TokenProvider interface {
+	// CreateToken generates a new API token with the given name for the user returning a copy of the user with the new
+	// token along with the time to live of the token if the server chose it
+	CreateToken(server *auth.AuthServer, user *auth.UserAuth, name string) (*auth.UserAuth, time.Duration, error)
+
+	// RevokeToken revokes the API token of the user once it has been replaced
+	RevokeToken(server *auth.AuthServer, user *auth.UserAuth) error
+}
+
+// TokenProviderFactory returns the provider used to rotate the token of the user of the given kind on the server or
+// nil if the token cannot be rotated automatically
+type TokenProviderFactory func(kind string, server *auth.AuthServer, user *auth.UserAuth) TokenProvider
+
+// NewTokenProviderFactory creates the default factory of token providers. Git tokens can be rotated on Gitea using the
+// password of the user, docker tokens on Docker Hub using the password of the user and Slack tokens using the refresh
+// token of the user along with the client ID and secret of the Slack app. The password of the user is only known if
+// it was saved by jx create git token or jx create docker auth.
+//
+// GitHub tokens cannot be rotated automatically as GitHub removed the API to create tokens using a password
+func NewTokenProviderFactory(slackClientID string, slackClientSecret string) TokenProviderFactory {
+	httpClient := &http.Client{Timeout: tokenProviderTimeout}
+	return func(kind string, server *auth.AuthServer, user *auth.UserAuth) TokenProvider {
+		switch kind {
+		case kube.ValueKindGit:
+			if server.Kind == gits.KindGitea && user.Password != "" {
+				return &giteaTokenProvider{client: httpClient}
+			}
+		case kube.ValueKindDocker:
+			if IsDockerHub(server.URL) && user.Password != "" {
+				return &dockerHubTokenProvider{client: httpClient, apiURL: dockerHubAPIURL}
+			}
+		case kube.ValueKindChat:
+			if server.Kind == chats.Slack && slackClientID != "" && slackClientSecret != "" {
+				return &slackTokenProvider{
+					client:       httpClient,
+					apiURL:       slackAPIURL,
+					clientID:     slackClientID,
+					clientSecret: slackClientSecret,
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// IsDockerHub returns true if the registry URL is Docker Hub
+func IsDockerHub(registry string) bool {
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/")
+	host = strings.Split(host, "/")[0]
+	return host == "docker.io" || strings.HasSuffix(host, ".docker.io") || host == "hub.docker.com"
+}
+
+// giteaTokenProvider creates tokens using the basic auth token API of Gitea
+type giteaTokenProvider struct {
+	client *http.Client
+}
+
+type giteaAccessToken struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Sha1 string `json:"sha1"`
+}
+
+func (p *giteaTokenProvider) CreateToken(server *auth.AuthServer, user *auth.UserAuth, name string) (*auth.UserAuth, time.Duration, error) {
+	if user.Username == "" || user.Password == "" {
+		return nil, 0, fmt.Errorf("a username and password are required to generate an API token on %s", server.URL)
+	}
+	token := giteaAccessToken{}
+	err := doJSON(p.client, http.MethodPost, p.tokensURL(server, user), basicAuth(user), map[string]string{"name": name}, &token)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to create a gitea API token for user %s on %s", user.Username, server.URL)
+	}
+	answer := *user
+	answer.ApiToken = token.Sha1
+	answer.TokenName = name
+	return &answer, 0, nil
+}
+
+func (p *giteaTokenProvider) RevokeToken(server *auth.AuthServer, user *auth.UserAuth) error {
+	if user.TokenName == "" {
+		return fmt.Errorf("the name of the previous API token of user %s on %s is not known", user.Username, server.URL)
+	}
+	tokens := []giteaAccessToken{}
+	err := doJSON(p.client, http.MethodGet, p.tokensURL(server, user), basicAuth(user), nil, &tokens)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the gitea API tokens of user %s on %s", user.Username, server.URL)
+	}
+	for _, token := range tokens {
+		if token.Name == user.TokenName {
+			u := fmt.Sprintf("%s/%d", p.tokensURL(server, user), token.ID)
+			err = doJSON(p.client, http.MethodDelete, u, basicAuth(user), nil, nil)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete the gitea API token %s of user %s on %s", token.Name, user.Username, server.URL)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find the gitea API token %s of user %s on %s", user.TokenName, user.Username, server.URL)
+}
+
+func (p *giteaTokenProvider) tokensURL(server *auth.AuthServer, user *auth.UserAuth) string {
+	return util.UrlJoin(server.URL, "api", "v1", "users", url.PathEscape(user.Username), "tokens")
+}
+
+// dockerHubTokenProvider creates personal access tokens on Docker Hub
+type dockerHubTokenProvider struct {
+	client *http.Client
+	apiURL string
+}
+
+type dockerHubAccessToken struct {
+	UUID       string `json:"uuid"`
+	TokenLabel string `json:"token_label"`
+	Token      string `json:"token"`
+}
+
+func (p *dockerHubTokenProvider) CreateToken(server *auth.AuthServer, user *auth.UserAuth, name string) (*auth.UserAuth, time.Duration, error) {
+	headers, err := p.login(user)
+	if err != nil {
+		return nil, 0, err
+	}
+	body := map[string]interface{}{
+		"token_label": name,
+		"scopes":      []string{"repo:admin"},
+	}
+	token := dockerHubAccessToken{}
+	err = doJSON(p.client, http.MethodPost, p.apiURL+"/access-tokens", headers, body, &token)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to create a Docker Hub access token for user %s", user.Username)
+	}
+	answer := *user
+	answer.ApiToken = token.Token
+	answer.TokenName = name
+	return &answer, 0, nil
+}
+
+func (p *dockerHubTokenProvider) RevokeToken(server *auth.AuthServer, user *auth.UserAuth) error {
+	if user.TokenName == "" {
+		return fmt.Errorf("the name of the previous access token of user %s on Docker Hub is not known", user.Username)
+	}
+	headers, err := p.login(user)
+	if err != nil {
+		return err
+	}
+	tokens := struct {
+		Results []dockerHubAccessToken `json:"results"`
+	}{}
+	err = doJSON(p.client, http.MethodGet, p.apiURL+"/access-tokens?page_size=100", headers, nil, &tokens)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the Docker Hub access tokens of user %s", user.Username)
+	}
+	for _, token := range tokens.Results {
+		if token.TokenLabel == user.TokenName {
+			err = doJSON(p.client, http.MethodDelete, p.apiURL+"/access-tokens/"+url.PathEscape(token.UUID), headers, nil, nil)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete the Docker Hub access token %s of user %s", token.TokenLabel, user.Username)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find the Docker Hub access token %s of user %s", user.TokenName, user.Username)
+}
+
+// login returns the headers used to authenticate with Docker Hub using the password of the user
+func (p *dockerHubTokenProvider) login(user *auth.UserAuth) (map[string]string, error) {
+	if user.Username == "" || user.Password == "" {
+		return nil, fmt.Errorf("a username and password are required to generate a Docker Hub access token")
+	}
+	body := map[string]string{
+		"username": user.Username,
+		"password": user.Password,
+	}
+	answer := struct {
+		Token string `json:"token"`
+	}{}
+	err := doJSON(p.client, http.MethodPost, p.apiURL+"/users/login", nil, body, &answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to log in to Docker Hub as user %s", user.Username)
+	}
+	return map[string]string{"Authorization": "Bearer " + answer.Token}, nil
+}
+
+// slackTokenProvider exchanges the refresh token of a Slack app with token rotation enabled for a new token
+type slackTokenProvider struct {
+	client       *http.Client
+	apiURL       string
+	clientID     string
+	clientSecret string
+}
+
+type slackResponse struct {
+	OK           bool   `json:"ok"`
+	Error        string `json:"error"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func (p *slackTokenProvider) CreateToken(server *auth.AuthServer, user *auth.UserAuth, name string) (*auth.UserAuth, time.Duration, error) {
+	if user.RefreshToken == "" {
+		return nil, 0, fmt.Errorf("a refresh token is required to rotate the Slack token of user %s", user.Username)
+	}
+	form := url.Values{}
+	form.Set("client_id", p.clientID)
+	form.Set("client_secret", p.clientSecret)
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", user.RefreshToken)
+	response, err := p.post("oauth.v2.access", form)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to refresh the Slack token of user %s", user.Username)
+	}
+	answer := *user
+	answer.ApiToken = response.AccessToken
+	answer.TokenName = name
+	if response.RefreshToken != "" {
+		answer.RefreshToken = response.RefreshToken
+	}
+	return &answer, time.Duration(response.ExpiresIn) * time.Second, nil
+}
+
+func (p *slackTokenProvider) RevokeToken(server *auth.AuthServer, user *auth.UserAuth) error {
+	form := url.Values{}
+	form.Set("token", user.ApiToken)
+	_, err := p.post("auth.revoke", form)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke the Slack token of user %s", user.Username)
+	}
+	return nil
+}
+
+func (p *slackTokenProvider) post(method string, form url.Values) (*slackResponse, error) {
+	resp, err := p.client.PostForm(p.apiURL+"/"+method, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	answer := &slackResponse{}
+	err = json.NewDecoder(resp.Body).Decode(answer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the response of %s", method)
+	}
+	if !answer.OK {
+		return nil, fmt.Errorf("%s failed: %s", method, answer.Error)
+	}
+	return answer, nil
+}
+
+func basicAuth(user *auth.UserAuth) map[string]string {
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth(user.Username, user.Password)
+	return map[string]string{"Authorization": req.Header.Get("Authorization")}
+}
+
+// doJSON sends the request with the optional JSON body and decodes the JSON response into the result if not nil
+func doJSON(client *http.Client, method string, u string, headers map[string]string, body interface{}, result interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned status %d: %s", method, u, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
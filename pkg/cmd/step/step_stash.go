@@ -116,6 +116,7 @@ func addStorageLocationFlags(cmd *cobra.Command, location *jenkinsv1.StorageLoca
 	cmd.Flags().StringVarP(&location.BucketURL, "bucket-url", "", "", "Specify the cloud storage bucket URL to send each file to. e.g. use 's3://nameOfBucket' on AWS, gs://anotherBucket' on GCP or on Azure 'azblob://thatBucket'")
 	cmd.Flags().StringVarP(&location.GitURL, "git-url", "", "", "Specify the Git URL to of the repository to use for storage")
 	cmd.Flags().StringVarP(&location.GitBranch, "git-branch", "", "gh-pages", "The branch to use to store files in the git repository")
+	cmd.Flags().StringVarP(&location.Directory, "directory", "", "", "Specify the local directory to store files in such as a mounted PersistentVolumeClaim for air gapped clusters")
 }
 
 // Run runs the command
@@ -207,7 +208,7 @@ func (o *StepStashOptions) Run() error {
 
 	storagePath := o.ToPath
 	if storagePath == "" {
-		storagePath = filepath.Join("jenkins-x", classifier, projectOrg, projectRepoName, projectBranchName, buildNo)
+		storagePath = filepath.Join(collector.ClassifierPath(classifier), projectOrg, projectRepoName, projectBranchName, buildNo)
 	}
 
 	urls, err := coll.CollectFiles(o.Pattern, storagePath, o.Basedir)
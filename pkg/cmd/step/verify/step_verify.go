@@ -38,6 +38,7 @@ func NewCmdStepVerify(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdStepVerifyPreInstall(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyRequirements(commonOpts))
 	cmd.AddCommand(NewCmdStepVerifyURL(commonOpts))
+	cmd.AddCommand(NewCmdStepVerifyVersionStream(commonOpts))
 
 	return cmd
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/auth"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
 	"github.com/jenkins-x/jx/pkg/config"
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/kube"
@@ -33,8 +34,37 @@ type StepVerifyEnvironmentsOptions struct {
 	EnvDir         string
 	LazyCreate     bool
 	LazyCreateFlag string
+	Drift          bool
+	DriftIssue     bool
+	FailOnDrift    bool
+	Environments   []string
+
+	// lastDriftReports the last drift report of each Environment so that the drift issue is only commented on when
+	// the drift changes
+	lastDriftReports map[string]string
 }
 
+var (
+	verifyEnvironmentsLong = templates.LongDesc(`
+		Verifies that the Environments have valid git repositories setup - lazily creating them if needed.
+
+		With --drift the chart in the git repository of each permanent Environment is rendered with helm template and
+		compared to the resources in the namespace of the Environment. Fields which are not in the chart, such as the
+		status and metadata managed by Kubernetes, are ignored.
+`)
+
+	verifyEnvironmentsExample = templates.Examples(`
+		# verify the git repositories of the environments
+		jx step verify environments
+
+		# report the resources which have been changed in the cluster without changing git
+		jx step verify environment --drift
+
+		# open an issue on the git repository of staging if it has drifted
+		jx step verify environment --drift --drift-issue -e staging
+	`)
+)
+
 // NewCmdStepVerifyEnvironments creates the `jx step verify pod` command
 func NewCmdStepVerifyEnvironments(commonOpts *opts.CommonOptions) *cobra.Command {
 
@@ -50,6 +80,8 @@ func NewCmdStepVerifyEnvironments(commonOpts *opts.CommonOptions) *cobra.Command
 		Use:     "environments",
 		Aliases: []string{"environment", "env"},
 		Short:   "Verifies that the Environments have valid git repositories setup - lazily creating them if needed",
+		Long:    verifyEnvironmentsLong,
+		Example: verifyEnvironmentsExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
 			options.Args = args
@@ -60,11 +92,18 @@ func NewCmdStepVerifyEnvironments(commonOpts *opts.CommonOptions) *cobra.Command
 	cmd.Flags().StringVarP(&options.LazyCreateFlag, "lazy-create", "", "", fmt.Sprintf("Specify true/false as to whether to lazily create missing resources. If not specified it is enabled if Terraform is not specified in the %s file", config.RequirementsConfigFileName))
 	cmd.Flags().StringVarP(&options.Dir, "dir", "d", "", "the directory to look for the install requirements file, by default the current working directory")
 	cmd.Flags().StringVarP(&options.EnvDir, "env-dir", "", "env", "the directory to look for the install requirements file relative to dir")
+	cmd.Flags().BoolVarP(&options.Drift, "drift", "", false, "Verifies that the resources in the namespaces of the permanent Environments match the charts in their git repositories instead")
+	cmd.Flags().BoolVarP(&options.DriftIssue, "drift-issue", "", false, "Opens an issue on the git repository of each Environment which has drifted, or comments on the open one")
+	cmd.Flags().BoolVarP(&options.FailOnDrift, "fail-on-drift", "", false, "Fails if any Environment has drifted from its git repository")
+	cmd.Flags().StringArrayVarP(&options.Environments, "environment", "e", nil, "The names of the Environments to check for drift. Defaults to all the permanent Environments")
 	return cmd
 }
 
 // Run implements this command
 func (o *StepVerifyEnvironmentsOptions) Run() error {
+	if o.Drift {
+		return o.verifyDrift()
+	}
 	lazyCreate := true
 	jxClient, ns, err := o.JXClientAndDevNamespace()
 	if err != nil {
@@ -126,7 +165,7 @@ func (o *StepVerifyEnvironmentsOptions) prDevEnvironment(gitRepoName string, env
 
 	if fromGitURL == config.DefaultBootRepository && gitRef == "master" {
 		// If the GitURL is not overridden and the GitRef is set to it's default value then look up the version number
-		resolver, err := o.CreateVersionResolver(requirements.VersionStream.URL, requirements.VersionStream.Ref)
+		resolver, err := o.CreateVersionResolverWithOverrides(requirements.VersionStream.URL, requirements.VersionStream.Ref, requirements.VersionStream.Overrides)
 		if err != nil {
 			return errors.Wrapf(err, "failed to create version resolver")
 		}
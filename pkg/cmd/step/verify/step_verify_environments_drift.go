@@ -0,0 +1,210 @@
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	helm_cmd "github.com/jenkins-x/jx/pkg/cmd/step/helm"
+	"github.com/jenkins-x/jx/pkg/environments"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// verifyDrift compares the resources rendered from the git repositories of the permanent Environments with the
+// resources in their namespaces, reporting any differences
+func (o *StepVerifyEnvironmentsOptions) verifyDrift() error {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return err
+	}
+	kubeClient, err := o.KubeClient()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := o.DynamicClient()
+	if err != nil {
+		return errors.Wrap(err, "creating the dynamic client")
+	}
+	groupResources, err := restmapper.GetAPIGroupResources(kubeClient.Discovery())
+	if err != nil {
+		return errors.Wrap(err, "discovering the API resources")
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	envMap, names, err := kube.GetEnvironments(jxClient, ns)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load Environments in namespace %s", ns)
+	}
+	info := util.ColorInfo
+	drifted := []string{}
+	for _, name := range names {
+		env := envMap[name]
+		if len(o.Environments) > 0 && util.StringArrayIndex(o.Environments, name) < 0 {
+			continue
+		}
+		if env.Spec.Kind != v1.EnvironmentKindTypePermanent || env.Spec.Source.URL == "" || env.Spec.RemoteCluster {
+			continue
+		}
+		log.Logger().Infof("checking environment %s for drift from %s", info(name), info(env.Spec.Source.URL))
+		drifts, err := o.detectEnvironmentDrift(env, dynamicClient, mapper)
+		if err != nil {
+			return errors.Wrapf(err, "detecting the drift of environment %s", name)
+		}
+		if len(drifts) == 0 {
+			log.Logger().Infof("environment %s matches its git repository", info(name))
+			continue
+		}
+		drifted = append(drifted, name)
+		for _, drift := range drifts {
+			log.Logger().Warnf("%s %s in namespace %s is %s", drift.Kind, drift.Name, drift.Namespace, drift.Type)
+			for _, f := range drift.Fields {
+				log.Logger().Warnf("  %s is %s in the cluster but %s in git", f.Path, f.Actual, f.Expected)
+			}
+		}
+		if o.DriftIssue {
+			err = o.reportDriftIssue(env, drifts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(drifted) > 0 && o.FailOnDrift {
+		return fmt.Errorf("environments %s have drifted from their git repositories", strings.Join(drifted, ", "))
+	}
+	return nil
+}
+
+// detectEnvironmentDrift renders the chart in the git repository of the Environment and compares it with the
+// resources in the namespace of the Environment
+func (o *StepVerifyEnvironmentsOptions) detectEnvironmentDrift(env *v1.Environment, client dynamic.Interface, mapper meta.RESTMapper) ([]environments.Drift, error) {
+	dir, err := ioutil.TempDir("", "jx-env-drift-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	cloneDir := filepath.Join(dir, "source")
+	err = os.MkdirAll(cloneDir, util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating directory %s", cloneDir)
+	}
+	err = o.Git().ShallowClone(cloneDir, env.Spec.Source.URL, env.Spec.Source.Ref, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cloning %s", env.Spec.Source.URL)
+	}
+	chartDir := filepath.Join(cloneDir, helm.DefaultEnvironmentChartDir)
+	requirements, err := helm.LoadRequirementsFile(filepath.Join(chartDir, helm.RequirementsFileName))
+	if err != nil {
+		return nil, err
+	}
+	for _, dep := range requirements.Dependencies {
+		if dep.Repository != "" && !strings.HasPrefix(dep.Repository, "file://") {
+			_, err = o.AddHelmBinaryRepoIfMissing(dep.Repository, "", "", "")
+			if err != nil {
+				return nil, errors.Wrapf(err, "adding the helm repository %s", dep.Repository)
+			}
+		}
+	}
+
+	// lets generate the values the same way as step helm apply so that templated values and secrets match the cluster
+	gitInfo, err := o.FindGitInfo(cloneDir)
+	if err != nil {
+		log.Logger().Warnf("could not find the git repository of environment %s: %s", env.Name, err.Error())
+	}
+	stepApply := &helm_cmd.StepHelmApplyOptions{
+		StepHelmOptions: helm_cmd.StepHelmOptions{
+			StepOptions: o.StepOptions,
+			Dir:         chartDir,
+		},
+	}
+	valueFiles, _, cleanup, err := stepApply.GenerateValues(chartDir, gitInfo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "generating the values of the chart of environment %s", env.Name)
+	}
+	defer cleanup()
+
+	helmer := o.Helm()
+	helmer.SetCWD(chartDir)
+	err = helmer.BuildDependency()
+	if err != nil {
+		return nil, errors.Wrapf(err, "building the dependencies of the chart of environment %s", env.Name)
+	}
+	outputDir := filepath.Join(dir, "output")
+	err = os.MkdirAll(outputDir, util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating directory %s", outputDir)
+	}
+	ns := env.Spec.Namespace
+	err = helmer.Template(chartDir, ns, ns, outputDir, false, nil, valueFiles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rendering the chart of environment %s", env.Name)
+	}
+	resources, err := environments.LoadRenderedResources(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	return environments.DetectDrift(client, mapper, ns, resources)
+}
+
+// reportDriftIssue opens an issue on the git repository of the Environment with its drift or comments on the issue
+// if it is already open and the drift differs from the last report
+func (o *StepVerifyEnvironmentsOptions) reportDriftIssue(env *v1.Environment, drifts []environments.Drift) error {
+	provider, gitInfo, err := o.CreateGitProviderForURLWithoutKind(env.Spec.Source.URL)
+	if err != nil {
+		return errors.Wrapf(err, "creating the git provider for %s", env.Spec.Source.URL)
+	}
+	title := fmt.Sprintf(environments.DriftIssueTitle, env.Name)
+	body := environments.FormatDriftReport(env.Name, drifts)
+
+	issues, err := provider.SearchIssues(gitInfo.Organisation, gitInfo.Name, "open")
+	if err != nil {
+		return errors.Wrapf(err, "searching the issues of %s", env.Spec.Source.URL)
+	}
+	for _, issue := range issues {
+		if issue.Title == title && issue.Number != nil && !issue.IsPullRequest {
+			last, ok := o.lastDriftReports[env.Name]
+			if !ok {
+				last = issue.Body
+			}
+			if body == last {
+				log.Logger().Infof("the drift of environment %s has not changed since it was last reported", util.ColorInfo(env.Name))
+				return nil
+			}
+			err = provider.CreateIssueComment(gitInfo.Organisation, gitInfo.Name, *issue.Number, body)
+			if err != nil {
+				return errors.Wrapf(err, "commenting on issue %d of %s", *issue.Number, env.Spec.Source.URL)
+			}
+			o.recordDriftReport(env.Name, body)
+			log.Logger().Infof("updated the drift issue %s", util.ColorInfo(provider.IssueURL(gitInfo.Organisation, gitInfo.Name, *issue.Number, false)))
+			return nil
+		}
+	}
+	issue, err := provider.CreateIssue(gitInfo.Organisation, gitInfo.Name, &gits.GitIssue{
+		Title: title,
+		Body:  body,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "creating an issue on %s", env.Spec.Source.URL)
+	}
+	o.recordDriftReport(env.Name, body)
+	log.Logger().Infof("created the drift issue %s", util.ColorInfo(issue.URL))
+	return nil
+}
+
+func (o *StepVerifyEnvironmentsOptions) recordDriftReport(envName string, body string) {
+	if o.lastDriftReports == nil {
+		o.lastDriftReports = map[string]string{}
+	}
+	o.lastDriftReports[envName] = body
+}
@@ -96,7 +96,7 @@ func (o *StepVerifyPackagesOptions) Run() error {
 	ref := vs.Ref
 	log.Logger().Infof("verifying the CLI package using version stream URL: %s and git ref: %s\n", u, vs.Ref)
 
-	resolver, err := o.CreateVersionResolver(u, ref)
+	resolver, err := o.CreateVersionResolverWithOverrides(u, ref, vs.Overrides)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create version resolver")
 	}
@@ -116,9 +116,7 @@ func (o *StepVerifyPackagesOptions) Run() error {
 	log.Logger().Infof("the CLI packages seem to be setup correctly %s\n", util.ColorInfo(strings.Join(o.Packages, ", ")))
 	log.Logger().Infof("\n")
 
-	table.Render()
-
-	return nil
+	return table.Render()
 }
 
 func (o *StepVerifyPackagesOptions) verifyJXVersion(resolver *versionstream.VersionResolver) error {
@@ -83,7 +83,10 @@ func (o *StepVerifyPodReadyOptions) Run() error {
 	log.Logger().Infof("Checking pod statuses")
 
 	table, err := o.waitForReadyPods(kubeClient, ns)
-	table.Render()
+	renderErr := table.Render()
+	if renderErr != nil {
+		return renderErr
+	}
 	if err != nil {
 		if o.WaitDuration.Seconds() == 0 {
 			return err
@@ -96,7 +99,10 @@ func (o *StepVerifyPodReadyOptions) Run() error {
 			table, err = o.waitForReadyPods(kubeClient, ns)
 			return err
 		})
-		table.Render()
+		renderErr = table.Render()
+		if renderErr != nil {
+			return renderErr
+		}
 	}
 	return err
 }
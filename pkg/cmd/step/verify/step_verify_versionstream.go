@@ -0,0 +1,424 @@
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/cmd/helper"
+	"github.com/jenkins-x/jx/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/pkg/cmd/opts/step"
+	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/gits"
+	"github.com/jenkins-x/jx/pkg/gits/operations"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyVersionStreamLong = templates.LongDesc(`
+		Verifies the versions of the charts, docker images and packages used by the team against the version stream.
+
+		The dev environment configuration in the current directory and the git repositories of the permanent
+		Environments are scanned for the charts in their requirements.yaml files and the docker images in their
+		values.yaml files. Versions which are older than the version stream are reported as outdated, versions which
+		do not satisfy the constraints of the version stream as disallowed and any which the version stream deprecates
+		as deprecated.
+
+		With --pr a Pull Request is opened on each Environment git repository upgrading its outdated and disallowed
+		versions to the versions in the version stream. With --fail the command fails if any versions are disallowed.
+`)
+
+	verifyVersionStreamExample = templates.Examples(`
+		# report the charts, images and packages which do not match the version stream
+		jx step verify versionstream
+
+		# fail if the version stream disallows any of the versions
+		jx step verify versionstream --fail
+
+		# open Pull Requests to upgrade the staging and production environments
+		jx step verify versionstream --pr -e staging -e production
+	`)
+
+	imageLineRegex = regexp.MustCompile(`^(\s*-?\s*[iI]mage:\s*["']?)([^\s"'{}]+)(["']?\s*)$`)
+)
+
+// StepVerifyVersionStreamOptions contains the command line flags
+type StepVerifyVersionStreamOptions struct {
+	StepVerifyOptions
+	Dir               string
+	Environments      []string
+	Packages          []string
+	SkipEnvironments  bool
+	CreatePullRequest bool
+	FailOnDisallowed  bool
+}
+
+// VersionReference a version of a chart, image or package found in a source such as an Environment git repository
+type VersionReference struct {
+	versionstream.VersionCheck
+
+	// Source the name of the Environment or configuration the version was found in
+	Source string
+	// File the file the version was found in relative to the root of the source
+	File string
+}
+
+// NewCmdStepVerifyVersionStream creates the `jx step verify versionstream` command
+func NewCmdStepVerifyVersionStream(commonOpts *opts.CommonOptions) *cobra.Command {
+	options := &StepVerifyVersionStreamOptions{
+		StepVerifyOptions: StepVerifyOptions{
+			StepOptions: step.StepOptions{
+				CommonOptions: commonOpts,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "versionstream",
+		Aliases: []string{"version-stream", "versions"},
+		Short:   "Verifies the versions of the charts, images and packages of the team against the version stream",
+		Long:    verifyVersionStreamLong,
+		Example: verifyVersionStreamExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&options.Dir, "dir", "d", ".", "the directory to recursively look upwards for the 'jx-requirements.yml' file of the dev environment configuration")
+	cmd.Flags().StringArrayVarP(&options.Environments, "env", "e", nil, "The names of the Environments to verify. Defaults to all the permanent Environments")
+	cmd.Flags().StringArrayVarP(&options.Packages, "packages", "p", []string{"jx", "kubectl", "git", "helm"}, "The packages to verify")
+	cmd.Flags().BoolVarP(&options.SkipEnvironments, "skip-environments", "", false, "Only verifies the dev environment configuration and packages without cloning the Environment git repositories")
+	cmd.Flags().BoolVarP(&options.CreatePullRequest, "pr", "", false, "Opens a Pull Request on each Environment git repository upgrading its outdated and disallowed versions")
+	cmd.Flags().BoolVarP(&options.FailOnDisallowed, "fail", "", false, "Fails if any versions are disallowed by the version stream")
+	return cmd
+}
+
+// Run implements this command
+func (o *StepVerifyVersionStreamOptions) Run() error {
+	requirements, requirementsFile, err := config.LoadRequirementsConfig(o.Dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the jx-requirements.yml")
+	}
+	requirementsExist, err := util.FileExists(requirementsFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file exists %s", requirementsFile)
+	}
+	var resolver *versionstream.VersionResolver
+	if requirementsExist && requirements.VersionStream.URL != "" {
+		resolver, err = o.CreateVersionResolverWithOverrides(requirements.VersionStream.URL, requirements.VersionStream.Ref, requirements.VersionStream.Overrides)
+	} else {
+		resolver, err = o.GetVersionResolver()
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to create the version resolver")
+	}
+
+	refs := []*VersionReference{}
+	if requirementsExist {
+		dir := filepath.Dir(requirementsFile)
+		log.Logger().Infof("verifying the dev environment configuration in %s", util.ColorInfo(dir))
+		devRefs, err := ScanVersionReferences(resolver, "dev", dir)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, devRefs...)
+	}
+
+	packageRefs, err := o.packageVersionReferences(resolver)
+	if err != nil {
+		return err
+	}
+	refs = append(refs, packageRefs...)
+
+	if !o.SkipEnvironments {
+		envRefs, err := o.environmentVersionReferences(resolver)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, envRefs...)
+	}
+
+	disallowed, err := o.reportVersionReferences(refs)
+	if err != nil {
+		return err
+	}
+	if len(disallowed) > 0 && o.FailOnDisallowed {
+		return fmt.Errorf("the version stream does not allow %s", strings.Join(disallowed, ", "))
+	}
+	return nil
+}
+
+// packageVersionReferences checks the versions of the locally installed packages
+func (o *StepVerifyVersionStreamOptions) packageVersionReferences(resolver *versionstream.VersionResolver) ([]*VersionReference, error) {
+	packages, _ := o.GetPackageVersions("", false)
+	answer := []*VersionReference{}
+	for _, name := range o.Packages {
+		version := packages[name]
+		if version == "" {
+			continue
+		}
+		check, err := resolver.CheckVersion(versionstream.KindPackage, name, version)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, &VersionReference{VersionCheck: *check, Source: "packages"})
+	}
+	return answer, nil
+}
+
+// environmentVersionReferences clones the git repositories of the permanent Environments and checks their versions,
+// opening Pull Requests to upgrade them if enabled
+func (o *StepVerifyVersionStreamOptions) environmentVersionReferences(resolver *versionstream.VersionResolver) ([]*VersionReference, error) {
+	jxClient, ns, err := o.JXClientAndDevNamespace()
+	if err != nil {
+		return nil, err
+	}
+	envMap, names, err := kube.GetEnvironments(jxClient, ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load Environments in namespace %s", ns)
+	}
+	answer := []*VersionReference{}
+	for _, name := range names {
+		env := envMap[name]
+		if len(o.Environments) > 0 && util.StringArrayIndex(o.Environments, name) < 0 {
+			continue
+		}
+		if env.Spec.Kind != v1.EnvironmentKindTypePermanent || env.Spec.Source.URL == "" {
+			continue
+		}
+		log.Logger().Infof("verifying environment %s from %s", util.ColorInfo(name), util.ColorInfo(env.Spec.Source.URL))
+		refs, err := o.scanEnvironment(resolver, env)
+		if err != nil {
+			return nil, errors.Wrapf(err, "verifying environment %s", name)
+		}
+		answer = append(answer, refs...)
+
+		if o.CreatePullRequest && len(UpgradableVersionReferences(refs)) > 0 {
+			err = o.createUpgradePullRequest(env, refs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "creating the upgrade Pull Request for environment %s", name)
+			}
+		}
+	}
+	return answer, nil
+}
+
+func (o *StepVerifyVersionStreamOptions) scanEnvironment(resolver *versionstream.VersionResolver, env *v1.Environment) ([]*VersionReference, error) {
+	dir, err := ioutil.TempDir("", "jx-verify-versionstream-")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	err = o.Git().ShallowClone(dir, env.Spec.Source.URL, env.Spec.Source.Ref, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cloning %s", env.Spec.Source.URL)
+	}
+	return ScanVersionReferences(resolver, env.Name, dir)
+}
+
+// createUpgradePullRequest opens a Pull Request on the git repository of the Environment upgrading the outdated and
+// disallowed versions to the versions in the version stream
+func (o *StepVerifyVersionStreamOptions) createUpgradePullRequest(env *v1.Environment, refs []*VersionReference) error {
+	base := env.Spec.Source.Ref
+	if base == "" {
+		base = "master"
+	}
+	op := operations.PullRequestOperation{
+		CommonOptions: o.CommonOptions,
+		GitURLs:       []string{env.Spec.Source.URL},
+		Base:          base,
+	}
+	pr, err := op.CreatePullRequest("versionstream", func(dir string, gitInfo *gits.GitRepository) ([]string, error) {
+		return UpgradeVersionReferences(dir, refs)
+	})
+	if err != nil {
+		return err
+	}
+	if pr != nil && pr.PullRequest != nil {
+		log.Logger().Infof("created Pull Request %s to upgrade environment %s", util.ColorInfo(pr.PullRequest.URL), util.ColorInfo(env.Name))
+	}
+	return nil
+}
+
+// reportVersionReferences logs the versions which do not match the version stream returning the disallowed ones
+func (o *StepVerifyVersionStreamOptions) reportVersionReferences(refs []*VersionReference) ([]string, error) {
+	disallowed := []string{}
+	table := o.CreateTable()
+	table.AddRow("SOURCE", "KIND", "NAME", "VERSION", "STABLE", "STATUS", "MESSAGE")
+	count := 0
+	for _, ref := range refs {
+		if ref.Status == versionstream.VersionStatusValid || ref.Status == versionstream.VersionStatusUnknown {
+			continue
+		}
+		status := util.ColorWarning(string(ref.Status))
+		if ref.Status == versionstream.VersionStatusDisallowed {
+			status = util.ColorError(string(ref.Status))
+			disallowed = append(disallowed, fmt.Sprintf("%s %s in %s", ref.Name, ref.CurrentVersion, ref.Source))
+		}
+		table.AddRow(ref.Source, string(ref.Kind), ref.Name, ref.CurrentVersion, ref.StableVersion, status, ref.Message)
+		count++
+	}
+	if count == 0 {
+		log.Logger().Infof("all %d versions match the version stream", len(refs))
+		return disallowed, nil
+	}
+	return disallowed, table.Render()
+}
+
+// ScanVersionReferences finds the chart versions in the requirements.yaml files and the docker image versions in the
+// values.yaml files of the directory and checks them against the version stream
+func ScanVersionReferences(resolver *versionstream.VersionResolver, source string, dir string) ([]*VersionReference, error) {
+	prefixes, err := resolver.GetRepositoryPrefixes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the repository prefixes of the version stream")
+	}
+	answer := []*VersionReference{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		file, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		switch info.Name() {
+		case helm.RequirementsFileName:
+			requirements, err := helm.LoadRequirementsFile(path)
+			if err != nil {
+				return err
+			}
+			for _, dep := range requirements.Dependencies {
+				if dep.Version == "" || strings.HasPrefix(dep.Repository, "file://") {
+					continue
+				}
+				name := dep.Name
+				prefix := prefixes.PrefixForURL(dep.Repository)
+				if prefix != "" {
+					name = prefix + "/" + dep.Name
+				}
+				check, err := resolver.CheckVersion(versionstream.KindChart, name, dep.Version)
+				if err != nil {
+					return err
+				}
+				answer = append(answer, &VersionReference{VersionCheck: *check, Source: source, File: file})
+			}
+		case helm.ValuesFileName:
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read file %s", path)
+			}
+			for _, line := range strings.Split(string(data), "\n") {
+				image, tag := parseImageLine(line)
+				if image == "" {
+					continue
+				}
+				check, err := resolver.CheckVersion(versionstream.KindDocker, image, tag)
+				if err != nil {
+					return err
+				}
+				answer = append(answer, &VersionReference{VersionCheck: *check, Source: source, File: file})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scan the versions in %s", dir)
+	}
+	return answer, nil
+}
+
+// UpgradableVersionReferences returns the references which should be upgraded to the version of the version stream
+func UpgradableVersionReferences(refs []*VersionReference) []*VersionReference {
+	answer := []*VersionReference{}
+	for _, ref := range refs {
+		if ref.File != "" && ref.NeedsUpgrade() {
+			answer = append(answer, ref)
+		}
+	}
+	return answer
+}
+
+// UpgradeVersionReferences upgrades the outdated and disallowed chart and image versions in the files of the
+// directory to the versions of the version stream returning the old versions
+func UpgradeVersionReferences(dir string, refs []*VersionReference) ([]string, error) {
+	oldVersions := []string{}
+	for _, ref := range UpgradableVersionReferences(refs) {
+		path := filepath.Join(dir, ref.File)
+		switch ref.Kind {
+		case versionstream.KindChart:
+			requirements, err := helm.LoadRequirementsFile(path)
+			if err != nil {
+				return nil, err
+			}
+			paths := strings.Split(ref.Name, "/")
+			name := paths[len(paths)-1]
+			for _, dep := range requirements.Dependencies {
+				if dep.Name == name && dep.Version == ref.CurrentVersion {
+					dep.Version = ref.StableVersion
+				}
+			}
+			err = helm.SaveFile(path, *requirements)
+			if err != nil {
+				return nil, errors.Wrapf(err, "saving %s", path)
+			}
+		case versionstream.KindDocker:
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading %s", path)
+			}
+			lines := strings.Split(string(data), "\n")
+			for i, line := range lines {
+				image, tag := parseImageLine(line)
+				if image == ref.Name && tag == ref.CurrentVersion {
+					lines[i] = strings.Replace(line, image+":"+tag, image+":"+ref.StableVersion, 1)
+				}
+			}
+			err = ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")), util.DefaultWritePermissions)
+			if err != nil {
+				return nil, errors.Wrapf(err, "writing %s", path)
+			}
+		default:
+			continue
+		}
+		oldVersions = append(oldVersions, ref.CurrentVersion)
+	}
+	return oldVersions, nil
+}
+
+// parseImageLine returns the image and tag of a YAML line such as `image: gcr.io/jenkinsxio/builder-go:1.2.3`
+// or blank values if the line has no image with a tag
+func parseImageLine(line string) (string, string) {
+	groups := imageLineRegex.FindStringSubmatch(line)
+	if len(groups) < 3 {
+		return "", ""
+	}
+	ref := groups[2]
+	if strings.Contains(ref, "@") {
+		return "", ""
+	}
+	idx := strings.LastIndex(ref, ":")
+	if idx <= 0 || strings.Contains(ref[idx+1:], "/") {
+		return "", ""
+	}
+	return ref[:idx], ref[idx+1:]
+}
@@ -0,0 +1,63 @@
+package verify_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/cmd/step/verify"
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanAndUpgradeVersionReferences(t *testing.T) {
+	t.Parallel()
+
+	testData := filepath.Join("test_data", "verify_versionstream")
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: filepath.Join(testData, "versions"),
+	}
+	dir, err := ioutil.TempDir("", "test-verify-versionstream")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = util.CopyDir(filepath.Join(testData, "env"), dir, true)
+	require.NoError(t, err)
+
+	refs, err := verify.ScanVersionReferences(resolver, "staging", dir)
+	require.NoError(t, err)
+
+	statuses := map[string]versionstream.VersionStatus{}
+	for _, ref := range refs {
+		statuses[ref.Name] = ref.Status
+		assert.Equal(t, "staging", ref.Source)
+	}
+	assert.Equal(t, map[string]versionstream.VersionStatus{
+		"jenkins-x/exposecontroller":   versionstream.VersionStatusDisallowed,
+		"jenkins-x/lighthouse":         versionstream.VersionStatusValid,
+		"jenkins-x/heapster":           versionstream.VersionStatusDeprecated,
+		"gcr.io/jenkinsxio/builder-go": versionstream.VersionStatusOutdated,
+	}, statuses)
+	assert.Len(t, verify.UpgradableVersionReferences(refs), 2)
+
+	oldVersions, err := verify.UpgradeVersionReferences(dir, refs)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"3.0.1", "2.0.900"}, oldVersions)
+
+	requirements, err := helm.LoadRequirementsFile(filepath.Join(dir, "env", helm.RequirementsFileName))
+	require.NoError(t, err)
+	assert.Equal(t, "2.3.89", requirements.Dependencies[0].Version)
+	assert.Equal(t, "0.0.500", requirements.Dependencies[1].Version)
+
+	values, err := ioutil.ReadFile(filepath.Join(dir, "env", helm.ValuesFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(values), "image: gcr.io/jenkinsxio/builder-go:2.0.1000")
+	assert.Contains(t, string(values), `image: "localhost:5000/myimage"`)
+
+	refs, err = verify.ScanVersionReferences(resolver, "staging", dir)
+	require.NoError(t, err)
+	assert.Empty(t, verify.UpgradableVersionReferences(refs), "should have upgraded all the versions")
+}
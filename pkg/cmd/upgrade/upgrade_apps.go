@@ -2,6 +2,7 @@ package upgrade
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jenkins-x/jx/pkg/cmd/add"
 	"github.com/jenkins-x/jx/pkg/cmd/helper"
@@ -13,6 +14,7 @@ import (
 	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/cmd/opts"
 	"github.com/jenkins-x/jx/pkg/cmd/templates"
+	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 	"github.com/spf13/cobra"
 )
@@ -20,14 +22,31 @@ import (
 var (
 	upgradeAppsLong = templates.LongDesc(`
 		Upgrades Apps to newer releases
+
+		When no app is specified all the apps are upgraded, using a single Pull Request when using GitOps for your dev environment. The version each app is upgraded to is chosen by its upgrade policy:
+
+		* pinned - the app is not upgraded
+		* patch - the app is upgraded to the newest patch release of its current major and minor version
+		* minor - the app is upgraded to the newest minor or patch release of its current major version
+		* latest - the app is upgraded to the latest release
+
+		The upgrade policy of an app is stored in the 'jenkins.io/upgrade-policy' annotation of its App resource (in the environment repository when using GitOps). It can be set using 'jx add app --upgrade-policy'. Apps without an upgrade policy use --policy.
+
+		Use --dry-run to display the upgrade plan, including any apps whose deployed version differs from the version they should be using, without upgrading them.
 `)
 
 	upgradeAppsExample = templates.Examples(`
-		# Upgrade all apps 
+		# Upgrade all apps using their upgrade policies
 		jx upgrade apps
- 
-        # Upgrade a specific app
-        jx upgrade app cheese
+
+		# Display what upgrading all apps would change
+		jx upgrade apps --dry-run
+
+		# Upgrade all apps without an upgrade policy to patch releases only
+		jx upgrade apps --policy patch
+
+		# Upgrade a specific app
+		jx upgrade app cheese
 	`)
 )
 
@@ -56,7 +75,8 @@ type UpgradeAppsOptions struct {
 	AutoMerge  bool
 
 	Version string
-	All     bool
+	DryRun  bool
+	Policy  string
 
 	Namespace string
 	Set       []string
@@ -85,9 +105,9 @@ func NewCmdUpgradeApps(commonOpts *opts.CommonOptions) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&o.BatchMode, opts.OptionBatchMode, "b", false, "Enable batch mode")
-	cmd.Flags().StringVarP(&o.Version, "username", "", "",
+	cmd.Flags().StringVarP(&o.Username, "username", "", "",
 		"The username for the repository")
-	cmd.Flags().StringVarP(&o.Version, "password", "", "",
+	cmd.Flags().StringVarP(&o.Password, "password", "", "",
 		"The password for the repository")
 	cmd.Flags().StringVarP(&o.Repo, "repository", "", "",
 		"The repository from which the app should be installed")
@@ -104,6 +124,9 @@ func NewCmdUpgradeApps(commonOpts *opts.CommonOptions) *cobra.Command {
 	cmd.Flags().BoolVarP(&o.AskAll, "ask-all", "", false, "Ask all configuration questions. "+
 		"By default existing answers are reused automatically.")
 	cmd.Flags().BoolVarP(&o.AutoMerge, "auto-merge", "", false, "Automatically merge GitOps pull requests that pass CI")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "Display the upgrade plan without upgrading any apps")
+	cmd.Flags().StringVarP(&o.Policy, "policy", "", "",
+		"The upgrade policy of apps without one. One of: "+strings.Join(apps.UpgradePolicies, ", ")+" (default latest)")
 	return cmd
 }
 
@@ -204,6 +227,9 @@ func (o *UpgradeAppsOptions) Run() error {
 	} else if len(o.Args) == 1 {
 		app = o.Args[0]
 	}
+	if app == "" || o.DryRun {
+		return o.upgradeWithPlan(&installOpts, app)
+	}
 
 	var version string
 	if o.Version != "" {
@@ -213,3 +239,70 @@ func (o *UpgradeAppsOptions) Run() error {
 	return installOpts.UpgradeApp(app, version, o.Repo, o.Username, o.Password, o.ReleaseName, o.Alias, o.HelmUpdate, o.AskAll)
 
 }
+
+// upgradeWithPlan upgrades the app (or all apps if empty) using the upgrade plan created from their upgrade policies
+func (o *UpgradeAppsOptions) upgradeWithPlan(installOpts *apps.InstallOptions, app string) error {
+	if o.Version != "" {
+		return util.InvalidOptionf(optionVersion, o.Version,
+			"unable to specify --%s when upgrading all apps or using --dry-run", optionVersion)
+	}
+	policy, err := apps.ParseUpgradePolicy(o.Policy)
+	if err != nil {
+		return util.InvalidOptionError("policy", o.Policy, err)
+	}
+	appNames := []string{}
+	if app != "" {
+		appNames = append(appNames, app)
+	}
+	plan, err := installOpts.CreateUpgradePlan(appNames, policy, o.Username, o.Password, o.HelmUpdate)
+	if err != nil {
+		return err
+	}
+	if app != "" && len(plan.Items) == 0 {
+		return errors.Errorf("no app found for %s", app)
+	}
+	for _, item := range plan.Items {
+		if item.Drifted() {
+			log.Logger().Warnf("%s is deployed at version %s but should be at version %s", item.Name,
+				util.ColorWarning(item.DeployedVersion), util.ColorInfo(item.CurrentVersion))
+		}
+	}
+	if o.DryRun {
+		return o.renderUpgradePlan(plan)
+	}
+	for _, item := range plan.Upgrades() {
+		log.Logger().Infof("Upgrading %s from %s to %s using the %s upgrade policy", util.ColorInfo(item.Name),
+			item.CurrentVersion, util.ColorInfo(item.TargetVersion), item.Policy)
+	}
+	return installOpts.UpgradeApps(plan, o.Username, o.Password, o.HelmUpdate, o.AskAll)
+}
+
+func (o *UpgradeAppsOptions) renderUpgradePlan(plan *apps.UpgradePlan) error {
+	if len(plan.Items) == 0 {
+		log.Logger().Infof("No apps found")
+		return nil
+	}
+	deployedTitle := "RELEASE"
+	if plan.GitOps {
+		deployedTitle = "DEPLOYED"
+	}
+	table := o.CreateTable()
+	table.AddRow("NAME", "POLICY", "CURRENT", deployedTitle, "LATEST", "TARGET", "ACTION")
+	for _, item := range plan.Items {
+		deployedVersion := item.DeployedVersion
+		if item.Drifted() {
+			deployedVersion = util.ColorWarning(deployedVersion)
+		}
+		targetVersion := item.TargetVersion
+		if targetVersion == "" {
+			targetVersion = "latest"
+		}
+		action := item.Action()
+		if action == apps.UpgradeActionUpgrade {
+			action = util.ColorInfo(action)
+		}
+		table.AddRow(item.Name, string(item.Policy), item.CurrentVersion, deployedVersion, item.LatestVersion,
+			targetVersion, action)
+	}
+	return table.Render()
+}
@@ -3,13 +3,16 @@ package upgrade_test
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/jenkins-x/jx/pkg/apps"
 	"github.com/jenkins-x/jx/pkg/cmd/testhelpers"
 	"github.com/jenkins-x/jx/pkg/cmd/upgrade"
+	"github.com/jenkins-x/jx/pkg/table"
 
 	"github.com/jenkins-x/jx/pkg/cmd/add"
 
@@ -678,3 +681,166 @@ func TestUpgradeAllAppsForGitOps(t *testing.T) {
 	}
 	assert.Len(t, found, 2)
 }
+
+func TestUpgradeAllAppsForGitOpsWithUpgradePolicies(t *testing.T) {
+	testOptions := testhelpers.CreateAppTestOptions(true, "", t)
+	defer func() {
+		err := testOptions.Cleanup()
+		assert.NoError(t, err)
+	}()
+	name1, alias1, version1, err := testOptions.DirectlyAddAppToGitOps("", nil, "")
+	assert.NoError(t, err)
+	name2, alias2, version2, err := testOptions.DirectlyAddAppToGitOps("", nil, "")
+	assert.NoError(t, err)
+	err = setUpgradePolicyInGitOps(testOptions, name1, apps.UpgradePolicyPinned)
+	assert.NoError(t, err)
+
+	// The newest patch release is allowed by the patch policy but the minor release is not
+	pegomock.When(testOptions.MockHelmer.SearchCharts(pegomock.EqString(name2), pegomock.EqBool(true))).ThenReturn(
+		[]helm.ChartSummary{
+			{
+				Name:         fmt.Sprintf("repo1/%s", name2),
+				ChartVersion: "0.1.0",
+			},
+			{
+				Name:         fmt.Sprintf("repo1/%s", name2),
+				ChartVersion: "0.0.3",
+			},
+			{
+				Name:         fmt.Sprintf("repo1/%s", name2),
+				ChartVersion: version2,
+			},
+		},
+		nil,
+	)
+	helm_test.StubFetchChart(name2, "0.0.3", helm.FakeChartmusuem, &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:    name2,
+			Version: "0.0.3",
+		},
+	}, testOptions.MockHelmer)
+
+	commonOpts := *testOptions.CommonOptions
+	o := &upgrade.UpgradeAppsOptions{
+		AddOptions: add.AddOptions{
+			CommonOptions: &commonOpts,
+		},
+		Repo:       helm.FakeChartmusuem,
+		GitOps:     true,
+		HelmUpdate: true,
+		DevEnv:     testOptions.DevEnv,
+		Policy:     "patch",
+	}
+
+	err = o.Run()
+	assert.NoError(t, err)
+	// Validate a single PR was created which only upgrades the app which is not pinned
+	pr, err := testOptions.FakeGitProvider.GetPullRequest(testOptions.OrgName, testOptions.DevEnvRepoInfo, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Upgrade all apps", pr.Title)
+	assert.Equal(t, fmt.Sprintf("Upgrade all apps:\n\n* %s from %s to 0.0.3", name2, version2), pr.Body)
+	_, err = testOptions.FakeGitProvider.GetPullRequest(testOptions.OrgName, testOptions.DevEnvRepoInfo, 2)
+	assert.Error(t, err)
+	// Validate the updated Requirements.yaml
+	envDir, err := o.CommonOptions.EnvironmentsDir()
+	assert.NoError(t, err)
+	devEnvDir := testOptions.GetFullDevEnvDir(envDir)
+	requirements, err := helm.LoadRequirementsFile(filepath.Join(devEnvDir, helm.RequirementsFileName))
+	assert.NoError(t, err)
+	found := make([]*helm.Dependency, 0)
+	for _, d := range requirements.Dependencies {
+		if d.Name == name1 && d.Alias == alias1 {
+			found = append(found, d)
+			assert.Equal(t, version1, d.Version)
+		}
+		if d.Name == name2 && d.Alias == alias2 {
+			found = append(found, d)
+			assert.Equal(t, "0.0.3", d.Version)
+		}
+	}
+	assert.Len(t, found, 2)
+}
+
+func TestUpgradeAllAppsDryRunForGitOps(t *testing.T) {
+	testOptions := testhelpers.CreateAppTestOptions(true, "", t)
+	defer func() {
+		err := testOptions.Cleanup()
+		assert.NoError(t, err)
+	}()
+	name, alias, version, err := testOptions.DirectlyAddAppToGitOps("", nil, "")
+	assert.NoError(t, err)
+
+	pegomock.When(testOptions.MockHelmer.SearchCharts(pegomock.EqString(name), pegomock.EqBool(true))).ThenReturn(
+		[]helm.ChartSummary{
+			{
+				Name:         fmt.Sprintf("repo1/%s", name),
+				ChartVersion: "1.0.0",
+			},
+		},
+		nil,
+	)
+	pegomock.When(testOptions.MockFactory.CreateTable(os.Stdout)).ThenReturn(table.CreateTable(os.Stdout))
+
+	commonOpts := *testOptions.CommonOptions
+	commonOpts.Out = os.Stdout
+	o := &upgrade.UpgradeAppsOptions{
+		AddOptions: add.AddOptions{
+			CommonOptions: &commonOpts,
+		},
+		Repo:       helm.FakeChartmusuem,
+		GitOps:     true,
+		HelmUpdate: true,
+		DevEnv:     testOptions.DevEnv,
+		DryRun:     true,
+	}
+
+	err = o.Run()
+	assert.NoError(t, err)
+	// Validate no PR was created
+	_, err = testOptions.FakeGitProvider.GetPullRequest(testOptions.OrgName, testOptions.DevEnvRepoInfo, 1)
+	assert.Error(t, err)
+	// Validate the Requirements.yaml is unchanged
+	devEnvDir := testOptions.DevEnvRepo.CloneDir
+	requirements, err := helm.LoadRequirementsFile(filepath.Join(devEnvDir, helm.RequirementsFileName))
+	assert.NoError(t, err)
+	for _, d := range requirements.Dependencies {
+		if d.Name == name && d.Alias == alias {
+			assert.Equal(t, version, d.Version)
+		}
+	}
+}
+
+// setUpgradePolicyInGitOps adds an App resource with the upgrade policy for the app to the dev environment repository
+func setUpgradePolicyInGitOps(testOptions *testhelpers.AppTestOptions, name string, policy apps.UpgradePolicy) error {
+	dir := testOptions.DevEnvRepo.CloneDir
+	gitter := testOptions.CommonOptions.Git()
+	err := gitter.Checkout(dir, "master")
+	if err != nil {
+		return err
+	}
+	templatesDir := filepath.Join(dir, name, "templates")
+	err = os.MkdirAll(templatesDir, 0700)
+	if err != nil {
+		return err
+	}
+	app := fmt.Sprintf(`apiVersion: jenkins.io/v1
+kind: App
+metadata:
+  name: %s
+  annotations:
+    %s: %s
+`, name, apps.UpgradePolicyAnnotation, string(policy))
+	err = ioutil.WriteFile(filepath.Join(templatesDir, "app.yaml"), []byte(app), 0600)
+	if err != nil {
+		return err
+	}
+	err = gitter.Add(dir, filepath.Join(name, "templates", "app.yaml"))
+	if err != nil {
+		return err
+	}
+	err = gitter.CommitDir(dir, fmt.Sprintf("set the upgrade policy of %s", name))
+	if err != nil {
+		return err
+	}
+	return gitter.Checkout(dir, "--detach")
+}
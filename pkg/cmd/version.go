@@ -74,7 +74,10 @@ func (o *VersionOptions) Run() error {
 		table.AddRow("Operating System", util.ColorInfo(osVersion))
 	}
 
-	table.Render()
+	err = table.Render()
+	if err != nil {
+		return err
+	}
 	if o.NoVerify {
 		return nil
 	}
@@ -2,12 +2,14 @@ package collector
 
 import (
 	"context"
-	"github.com/jenkins-x/jx/pkg/util"
-	"github.com/pkg/errors"
-	"gocloud.dev/blob"
+	"io"
 	"io/ioutil"
 	"path/filepath"
 	"time"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"gocloud.dev/blob"
 )
 
 // BucketCollector stores the state for the git collector
@@ -96,6 +98,32 @@ func (c *BucketCollector) CollectData(data []byte, outputName string) (string, e
 	return u, nil
 }
 
+// Prune removes the objects of the classifier in the bucket which were last modified before the given time
+func (c *BucketCollector) Prune(before time.Time) ([]string, error) {
+	urls := []string{}
+	iter := c.bucket.List(&blob.ListOptions{
+		Prefix: filepath.ToSlash(ClassifierPath(c.classifier)) + "/",
+	})
+	for {
+		obj, err := iter.Next(c.createContext())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return urls, errors.Wrapf(err, "failed to list bucket %s", c.bucketURL)
+		}
+		if obj.IsDir || !obj.ModTime.Before(before) {
+			continue
+		}
+		err = c.bucket.Delete(c.createContext(), obj.Key)
+		if err != nil {
+			return urls, errors.Wrapf(err, "failed to delete %s from bucket %s", obj.Key, c.bucketURL)
+		}
+		urls = append(urls, util.UrlJoin(c.bucketURL, obj.Key))
+	}
+	return urls, nil
+}
+
 func (c *BucketCollector) createContext() context.Context {
 	ctx, _ := context.WithTimeout(context.Background(), c.Timeout)
 	return ctx
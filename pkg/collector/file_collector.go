@@ -0,0 +1,250 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// objectsDir the folder inside the storage directory which contains the content addressed files
+	objectsDir = ".objects"
+)
+
+// FileCollector stores files in a local directory such as a mounted PersistentVolumeClaim which is useful for
+// air gapped clusters. The content of each file is stored once using its SHA-256 hash and the collected paths
+// are symlinks to the content so that identical files are only stored once
+type FileCollector struct {
+	dir        string
+	classifier string
+}
+
+// NewFileCollector creates a new file system based collector for the files of the classifier
+func NewFileCollector(dir string, classifier string) (Collector, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the absolute path of %s", dir)
+	}
+	err = os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create directory %s", dir)
+	}
+	return &FileCollector{
+		dir:        dir,
+		classifier: classifier,
+	}, nil
+}
+
+// CollectFiles collects files and returns the URLs
+func (c *FileCollector) CollectFiles(patterns []string, outputPath string, basedir string) ([]string, error) {
+	urls := []string{}
+	for _, p := range patterns {
+		fn := func(name string) error {
+			var err error
+			toName := name
+			if basedir != "" {
+				toName, err = filepath.Rel(basedir, name)
+				if err != nil {
+					return errors.Wrapf(err, "failed to remove basedir %s from %s", basedir, name)
+				}
+			}
+			if outputPath != "" {
+				toName = filepath.Join(outputPath, toName)
+			}
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read file %s", name)
+			}
+			u, err := c.CollectData(data, toName)
+			if err != nil {
+				return err
+			}
+			urls = append(urls, u)
+			return nil
+		}
+
+		err := util.GlobAllFiles("", p, fn)
+		if err != nil {
+			return urls, err
+		}
+	}
+	return urls, nil
+}
+
+// CollectData collects the data storing it at the given output path and returning the URL
+// to access it
+func (c *FileCollector) CollectData(data []byte, outputPath string) (string, error) {
+	u := ""
+	toFile := filepath.Join(c.dir, outputPath)
+	if !strings.HasPrefix(toFile, c.dir+string(os.PathSeparator)) {
+		return u, errors.Errorf("the output path %s is outside of the storage directory %s", outputPath, c.dir)
+	}
+	objectFile, err := c.storeObject(data)
+	if err != nil {
+		return u, err
+	}
+
+	toDir := filepath.Dir(toFile)
+	err = os.MkdirAll(toDir, util.DefaultWritePermissions)
+	if err != nil {
+		return u, errors.Wrapf(err, "failed to create directory %s", toDir)
+	}
+	target, err := filepath.Rel(toDir, objectFile)
+	if err != nil {
+		return u, errors.Wrapf(err, "failed to find the relative path from %s to %s", toDir, objectFile)
+	}
+	// lets always recreate the link so that its modification time reflects when it was last collected
+	err = os.Remove(toFile)
+	if err != nil && !os.IsNotExist(err) {
+		return u, errors.Wrapf(err, "failed to remove the previous file %s", toFile)
+	}
+	err = os.Symlink(target, toFile)
+	if err != nil {
+		return u, errors.Wrapf(err, "failed to link %s to %s", toFile, objectFile)
+	}
+	return c.fileURL(toFile), nil
+}
+
+// Prune removes the files of the classifier collected before the given time along with any content no longer
+// referenced by the files of any classifier
+func (c *FileCollector) Prune(before time.Time) ([]string, error) {
+	urls := []string{}
+	referenced := map[string]bool{}
+	dirs := []string{}
+	objectsPath := filepath.Join(c.dir, objectsDir)
+	classifierPath := filepath.Join(c.dir, ClassifierPath(c.classifier))
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		inClassifier := path == classifierPath || strings.HasPrefix(path, classifierPath+string(os.PathSeparator))
+		if info.IsDir() {
+			if path == objectsPath {
+				return filepath.SkipDir
+			}
+			if inClassifier && path != classifierPath {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if inClassifier && info.ModTime().Before(before) {
+			err = os.Remove(path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove %s", path)
+			}
+			urls = append(urls, c.fileURL(path))
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read link %s", path)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			referenced[filepath.Clean(target)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return urls, errors.Wrapf(err, "failed to prune directory %s", c.dir)
+	}
+
+	exists, err := util.DirExists(objectsPath)
+	if err != nil {
+		return urls, err
+	}
+	if exists {
+		err = filepath.Walk(objectsPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				dirs = append(dirs, path)
+				return nil
+			}
+			// objects written after the cutoff may be about to be linked by a concurrent collect
+			if referenced[path] || !info.ModTime().Before(before) {
+				return nil
+			}
+			return os.Remove(path)
+		})
+		if err != nil {
+			return urls, errors.Wrapf(err, "failed to remove unreferenced content from %s", objectsPath)
+		}
+	}
+
+	// lets remove the deepest empty directories first
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return urls, errors.Wrapf(err, "failed to read directory %s", dir)
+		}
+		if len(files) == 0 {
+			err = os.Remove(dir)
+			if err != nil {
+				return urls, errors.Wrapf(err, "failed to remove empty directory %s", dir)
+			}
+		}
+	}
+	return urls, nil
+}
+
+// storeObject stores the data using its hash unless the same content is already stored, returning its file name
+func (c *FileCollector) storeObject(data []byte) (string, error) {
+	hash := sha256.Sum256(data)
+	name := hex.EncodeToString(hash[:])
+	dir := filepath.Join(c.dir, objectsDir, name[0:2])
+	objectFile := filepath.Join(dir, name)
+	exists, err := util.FileExists(objectFile)
+	if err != nil {
+		return objectFile, err
+	}
+	if exists {
+		// lets touch the existing content so that a concurrent prune does not remove it before it is linked
+		now := time.Now()
+		err = os.Chtimes(objectFile, now, now)
+		if err != nil {
+			return objectFile, errors.Wrapf(err, "failed to update the modification time of %s", objectFile)
+		}
+		return objectFile, nil
+	}
+	err = os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return objectFile, errors.Wrapf(err, "failed to create directory %s", dir)
+	}
+	// lets write to a temporary file first so that a partially written object is never linked
+	tmpFile, err := ioutil.TempFile(dir, name+"-")
+	if err != nil {
+		return objectFile, errors.Wrapf(err, "failed to create a temporary file in %s", dir)
+	}
+	_, err = tmpFile.Write(data)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return objectFile, errors.Wrapf(err, "failed to write %s", tmpFile.Name())
+	}
+	err = os.Rename(tmpFile.Name(), objectFile)
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return objectFile, errors.Wrapf(err, "failed to rename %s to %s", tmpFile.Name(), objectFile)
+	}
+	return objectFile, nil
+}
+
+func (c *FileCollector) fileURL(path string) string {
+	return "file://" + filepath.ToSlash(path)
+}
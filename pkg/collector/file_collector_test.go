@@ -0,0 +1,81 @@
+package collector_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/collector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCollectorDeduplicatesAndPrunes(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "test-file-collector")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	coll, err := collector.NewFileCollector(dir, "logs")
+	require.NoError(t, err)
+	reports, err := collector.NewFileCollector(dir, "reports")
+	require.NoError(t, err)
+
+	u1, err := coll.CollectData([]byte("same content"), "jenkins-x/logs/jstrachan/demo/master/1.log")
+	require.NoError(t, err)
+	_, err = coll.CollectData([]byte("same content"), "jenkins-x/logs/jstrachan/demo/master/2.log")
+	require.NoError(t, err)
+	_, err = coll.CollectData([]byte("other content"), "jenkins-x/logs/jstrachan/demo/master/3.log")
+	require.NoError(t, err)
+	_, err = reports.CollectData([]byte("other content"), "jenkins-x/reports/jstrachan/demo/master/1.xml")
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(dir, "jenkins-x/logs/jstrachan/demo/master/1.log")), u1)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "jenkins-x/logs/jstrachan/demo/master/2.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "same content", string(data))
+	assert.Equal(t, 2, countObjects(t, dir), "identical content should only be stored once")
+
+	_, err = coll.CollectData([]byte("escape"), "../outside.log")
+	assert.Error(t, err)
+
+	removed, err := coll.Prune(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(removed))
+	assert.Equal(t, 2, countObjects(t, dir))
+
+	removed, err = coll.Prune(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(removed))
+	assert.Equal(t, 1, countObjects(t, dir), "content referenced by other classifiers should be kept")
+	data, err = ioutil.ReadFile(filepath.Join(dir, "jenkins-x/reports/jstrachan/demo/master/1.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, "other content", string(data), "files of other classifiers should be kept")
+	files, err := ioutil.ReadDir(filepath.Join(dir, "jenkins-x", "logs"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(files), "empty directories should be removed")
+
+	removed, err = reports.Prune(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(removed))
+	assert.Equal(t, 0, countObjects(t, dir), "unreferenced content should be removed")
+}
+
+func countObjects(t *testing.T, dir string) int {
+	count := 0
+	objectsDir := filepath.Join(dir, ".objects")
+	if _, err := os.Stat(objectsDir); os.IsNotExist(err) {
+		return 0
+	}
+	err := filepath.Walk(objectsDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			count++
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return count
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/log"
@@ -158,6 +159,11 @@ func (c *GitCollector) CollectData(data []byte, outputPath string) (string, erro
 	return u, err
 }
 
+// Prune is not supported for git storage as the history of the branch keeps the content anyway
+func (c *GitCollector) Prune(before time.Time) ([]string, error) {
+	return nil, fmt.Errorf("pruning is not supported for the git storage %s branch %s", c.gitInfo.URL, c.gitBranch)
+}
+
 func (c *GitCollector) generateURL(storageOrg string, storageRepoName string, rPath string) string {
 	// TODO only supporting github for now!!!
 	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", storageOrg, storageRepoName, c.gitBranch, rPath)
@@ -3,6 +3,7 @@ package collector
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	jenkinsv1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
@@ -17,6 +18,11 @@ import (
 	_ "gocloud.dev/blob/s3blob"
 )
 
+// ClassifierPath returns the path which the files of the classifier are stored under
+func ClassifierPath(classifier string) string {
+	return filepath.Join("jenkins-x", classifier)
+}
+
 // NewCollector creates a new collector from the storage configuration
 func NewCollector(storageLocation jenkinsv1.StorageLocation, settings *jenkinsv1.TeamSettings, gitter gits.Gitter) (Collector, error) {
 	classifier := storageLocation.Classifier
@@ -27,10 +33,13 @@ func NewCollector(storageLocation jenkinsv1.StorageLocation, settings *jenkinsv1
 	if gitURL != "" {
 		return NewGitCollector(gitter, gitURL, storageLocation.GetGitBranch())
 	}
+	if storageLocation.Directory != "" {
+		return NewFileCollector(storageLocation.Directory, classifier)
+	}
 	ctx, _ := context.WithTimeout(context.Background(), time.Second*20)
 	u := storageLocation.BucketURL
 	if u == "" {
-		return nil, fmt.Errorf("No GitURL or BucketURL is configured for the storage location in the TeamSettings")
+		return nil, fmt.Errorf("No GitURL, BucketURL or Directory is configured for the storage location in the TeamSettings")
 	}
 	bucket, err := blob.Open(ctx, u)
 	if err != nil {
@@ -1,5 +1,7 @@
 package collector
 
+import "time"
+
 // Collector an interface to collect data for storage in git or cloud storage etc
 type Collector interface {
 
@@ -10,4 +12,8 @@ type Collector interface {
 	// CollectData collects the data storing it at the given output path and returning the URL
 	// to access it
 	CollectData(data []byte, outputPath string) (string, error)
+
+	// Prune removes any data of the classifier of the collector collected before the given time returning the URLs
+	// of the removed data
+	Prune(before time.Time) ([]string, error)
 }
@@ -69,6 +69,9 @@ const (
 	// SecretStorageTypeLocal specifies that we use the local file system in
 	// `~/.jx/localSecrets` to store secrets
 	SecretStorageTypeLocal SecretStorageType = "local"
+	// SecretStorageTypeKube specifies that we use Kubernetes Secrets in the `cluster.secretsNamespace`
+	// (or the dev namespace if not specified) to store secrets
+	SecretStorageTypeKube SecretStorageType = "kube"
 )
 
 // WebhookType is the type of a webhook strategy
@@ -184,6 +187,8 @@ type ClusterConfig struct {
 	ClusterName string `json:"clusterName,omitempty"`
 	// VaultName the name of the vault if using vault for secretts
 	VaultName string `json:"vaultName,omitempty"`
+	// SecretsNamespace the namespace used to store secrets when using the kube secret storage
+	SecretsNamespace string `json:"secretsNamespace,omitempty"`
 	// Region the cloud region being used
 	Region string `json:"region,omitempty"`
 	// Zone the cloud zone being used
@@ -208,6 +213,19 @@ type VersionStreamConfig struct {
 	URL string `json:"url"`
 	// Ref of the version stream to use
 	Ref string `json:"ref"`
+	// Overrides the version streams layered on top of this version stream such as a team level versions repository.
+	// The first override takes the highest precedence
+	Overrides []VersionStreamOverride `json:"overrides,omitempty"`
+}
+
+// VersionStreamOverride a version stream layered on top of the version stream
+type VersionStreamOverride struct {
+	// Name of the override which is used when explaining where versions are resolved from
+	Name string `json:"name"`
+	// URL the git URL of the override or a local directory
+	URL string `json:"url"`
+	// Ref of the override to use
+	Ref string `json:"ref,omitempty"`
 }
 
 // RequirementsConfig contains the logical installation requirements
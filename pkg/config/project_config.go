@@ -43,6 +43,40 @@ type ProjectConfig struct {
 type PreviewEnvironmentConfig struct {
 	Disabled         bool `json:"disabled,omitempty"`
 	MaximumInstances int  `json:"maximumInstances,omitempty"`
+	// TTL is the duration such as '72h' after which a preview environment is deleted even if its pull request is still open
+	TTL string `json:"ttl,omitempty"`
+	// IdleTimeout is the duration such as '4h' without HTTP traffic or new commits after which a preview environment is scaled to zero
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// Dependencies are the sibling applications and seed Jobs deployed with a preview environment
+	Dependencies *PreviewDependenciesConfig `json:"dependencies,omitempty"`
+}
+
+// PreviewDependenciesConfig configures the sibling applications deployed into a preview environment and the Jobs
+// which seed their data before the post preview Jobs run
+type PreviewDependenciesConfig struct {
+	// Environment is the name of the Environment whose git repository defines the versions of the applications. Defaults to staging
+	Environment string `json:"environment,omitempty"`
+	// Applications are the sibling applications deployed into the preview environment
+	Applications []PreviewDependencyApplication `json:"applications,omitempty"`
+	// Seeds are the Jobs run in the preview environment once it is deployed such as restoring a database fixture
+	Seeds []PreviewSeedJob `json:"seeds,omitempty"`
+}
+
+// PreviewDependencyApplication is a sibling application deployed into a preview environment
+type PreviewDependencyApplication struct {
+	// Name is the name of the chart of the application in the requirements.yaml of the Environment
+	Name string `json:"name"`
+	// Version overrides the version of the application in the Environment
+	Version string `json:"version,omitempty"`
+}
+
+// PreviewSeedJob is a Job run in a preview environment to seed the data of its applications
+type PreviewSeedJob struct {
+	Name    string            `json:"name"`
+	Image   string            `json:"image"`
+	Command []string          `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
 }
 
 type IssueTrackerConfig struct {
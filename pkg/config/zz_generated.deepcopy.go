@@ -700,9 +700,62 @@ func (in *Preview) DeepCopy() *Preview {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewDependenciesConfig) DeepCopyInto(out *PreviewDependenciesConfig) {
+	*out = *in
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]PreviewDependencyApplication, len(*in))
+		copy(*out, *in)
+	}
+	if in.Seeds != nil {
+		in, out := &in.Seeds, &out.Seeds
+		*out = make([]PreviewSeedJob, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewDependenciesConfig.
+func (in *PreviewDependenciesConfig) DeepCopy() *PreviewDependenciesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewDependenciesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewDependencyApplication) DeepCopyInto(out *PreviewDependencyApplication) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewDependencyApplication.
+func (in *PreviewDependencyApplication) DeepCopy() *PreviewDependencyApplication {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewDependencyApplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PreviewEnvironmentConfig) DeepCopyInto(out *PreviewEnvironmentConfig) {
 	*out = *in
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PreviewDependenciesConfig)
+			(*in).DeepCopyInto(*out)
+		}
+	}
 	return
 }
 
@@ -716,6 +769,39 @@ func (in *PreviewEnvironmentConfig) DeepCopy() *PreviewEnvironmentConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreviewSeedJob) DeepCopyInto(out *PreviewSeedJob) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreviewSeedJob.
+func (in *PreviewSeedJob) DeepCopy() *PreviewSeedJob {
+	if in == nil {
+		return nil
+	}
+	out := new(PreviewSeedJob)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PreviewValuesConfig) DeepCopyInto(out *PreviewValuesConfig) {
 	*out = *in
@@ -766,7 +852,7 @@ func (in *ProjectConfig) DeepCopyInto(out *ProjectConfig) {
 			*out = nil
 		} else {
 			*out = new(PreviewEnvironmentConfig)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.IssueTracker != nil {
@@ -857,7 +943,7 @@ func (in *RequirementsConfig) DeepCopyInto(out *RequirementsConfig) {
 	}
 	out.Ingress = in.Ingress
 	out.Storage = in.Storage
-	out.VersionStream = in.VersionStream
+	in.VersionStream.DeepCopyInto(&out.VersionStream)
 	return
 }
 
@@ -925,6 +1011,11 @@ func (in *TLSConfig) DeepCopy() *TLSConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionStreamConfig) DeepCopyInto(out *VersionStreamConfig) {
 	*out = *in
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]VersionStreamOverride, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -938,6 +1029,22 @@ func (in *VersionStreamConfig) DeepCopy() *VersionStreamConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionStreamOverride) DeepCopyInto(out *VersionStreamOverride) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionStreamOverride.
+func (in *VersionStreamOverride) DeepCopy() *VersionStreamOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionStreamOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WikiConfig) DeepCopyInto(out *WikiConfig) {
 	*out = *in
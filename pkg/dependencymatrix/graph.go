@@ -0,0 +1,341 @@
+package dependencymatrix
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DependencyGraph is the graph of dependencies between repositories built from the dependency matrices of one or
+// more repositories
+type DependencyGraph struct {
+	// Nodes are the repositories in the graph keyed by their host/owner/repo:component
+	Nodes map[string]*DependencyDetails `json:"nodes"`
+	Edges []*DependencyEdge             `json:"edges"`
+}
+
+// DependencyEdge is a dependency of a repository on another repository at a version
+type DependencyEdge struct {
+	// From is the key of the dependent repository
+	From string `json:"from"`
+	// To is the key of the repository depended on
+	To string `json:"to"`
+	// Version is the version of the repository depended on
+	Version string `json:"version"`
+	// UpgradeVersion is the version the dependency would be upgraded to, if any
+	UpgradeVersion string `json:"upgradeVersion,omitempty"`
+}
+
+// Dependent is a repository which depends on a dependency directly or transitively
+type Dependent struct {
+	Repository DependencyDetails `json:"repository"`
+	// Version is the version of the dependency the repository depends on
+	Version string `json:"version"`
+	// Path is the repositories through which the repository depends on the dependency, empty for direct dependents
+	Path DependencyPath `json:"path,omitempty"`
+}
+
+// Direct returns true if the repository depends on the dependency directly
+func (d *Dependent) Direct() bool {
+	return len(d.Path) == 0
+}
+
+// Impact is a pull request which would be created in a downstream repository by upgrading a dependency
+type Impact struct {
+	Repository DependencyDetails `json:"repository"`
+	// Dependency is the dependency which would be upgraded in the repository
+	Dependency DependencyDetails `json:"dependency"`
+	// FromVersion is the version of the dependency the repository currently depends on
+	FromVersion string `json:"fromVersion"`
+	// ToVersion is the version the dependency would be upgraded to. It is empty if the dependency is an upstream
+	// repository which would first have to release a new version
+	ToVersion string `json:"toVersion,omitempty"`
+	// Depth is the number of releases between the upgraded dependency and the repository, 1 for direct dependents
+	Depth int `json:"depth"`
+}
+
+// NewDependencyGraph creates an empty dependency graph
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		Nodes: map[string]*DependencyDetails{},
+	}
+}
+
+// AddMatrix adds the dependencies of the dependency matrix stored in the root repository to the graph. Every source
+// path of a dependency becomes a chain of edges from the root repository through the path to the dependency
+func (g *DependencyGraph) AddMatrix(root DependencyDetails, matrix *DependencyMatrix) {
+	rootKey := g.addNode(root)
+	for _, d := range matrix.Dependencies {
+		key := g.addNode(d.DependencyDetails)
+		if len(d.Sources) == 0 {
+			g.addEdge(rootKey, key, d.Version)
+			continue
+		}
+		for _, source := range d.Sources {
+			from := rootKey
+			for _, p := range source.Path {
+				to := g.addNode(*p)
+				g.addEdge(from, to, p.Version)
+				from = to
+			}
+			g.addEdge(from, key, source.Version)
+		}
+	}
+}
+
+func (g *DependencyGraph) addNode(details DependencyDetails) string {
+	key := details.String()
+	if _, ok := g.Nodes[key]; !ok {
+		node := details
+		// the version of a repository depends on the edge so lets not store it on the node
+		node.Version = ""
+		node.VersionURL = ""
+		g.Nodes[key] = &node
+	}
+	return key
+}
+
+func (g *DependencyGraph) addEdge(from string, to string, version string) {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to && e.Version == version {
+			return
+		}
+	}
+	g.Edges = append(g.Edges, &DependencyEdge{
+		From:    from,
+		To:      to,
+		Version: version,
+	})
+}
+
+// FindNode finds the repository matching the name which can be repo, owner/repo or host/owner/repo optionally
+// followed by :component
+func (g *DependencyGraph) FindNode(name string) (*DependencyDetails, error) {
+	matches := []string{}
+	for key, node := range g.Nodes {
+		if node.Matches(name) {
+			matches = append(matches, key)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, errors.Errorf("no repository matching %s in the dependency matrix", name)
+	case 1:
+		return g.Nodes[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return nil, errors.Errorf("more than one repository matches %s: %s", name, strings.Join(matches, ", "))
+	}
+}
+
+// Matches returns true if the name which can be repo, owner/repo or host/owner/repo optionally followed by :component
+// refers to the dependency. If the name has no component it matches any component of the repository
+func (d *DependencyDetails) Matches(name string) bool {
+	component := ""
+	idx := strings.LastIndex(name, ":")
+	if idx >= 0 {
+		component = name[idx+1:]
+		name = name[:idx]
+	}
+	if component != "" && component != d.Component {
+		return false
+	}
+	names := strings.Split(name, "/")
+	keys := []string{d.Host, d.Owner, d.Repo}
+	if len(names) > len(keys) {
+		return false
+	}
+	keys = keys[len(keys)-len(names):]
+	for i, n := range names {
+		if !strings.EqualFold(n, keys[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dependents returns the repositories which depend on the named repository and the versions of it they depend on.
+// Transitive dependents are included unless direct is true
+func (g *DependencyGraph) Dependents(name string, direct bool) ([]*Dependent, error) {
+	node, err := g.FindNode(name)
+	if err != nil {
+		return nil, err
+	}
+	type state struct {
+		key     string
+		version string
+		path    DependencyPath
+	}
+	answer := []*Dependent{}
+	visited := map[string]bool{}
+	queue := []state{}
+	for _, e := range g.dependentEdges(node.String()) {
+		queue = append(queue, state{key: e.From, version: e.Version, path: DependencyPath{}})
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		// lets report each version of the dependency a repository depends on
+		id := s.key + "@" + s.version
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		answer = append(answer, &Dependent{
+			Repository: *g.Nodes[s.key],
+			Version:    s.version,
+			Path:       s.path,
+		})
+		if direct {
+			continue
+		}
+		for _, e := range g.dependentEdges(s.key) {
+			via := g.versionedNode(s.key, e.Version)
+			path := append(DependencyPath{via}, s.path...)
+			queue = append(queue, state{key: e.From, version: s.version, path: path})
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		if len(answer[i].Path) != len(answer[j].Path) {
+			return len(answer[i].Path) < len(answer[j].Path)
+		}
+		return answer[i].Repository.String() < answer[j].Repository.String()
+	})
+	return answer, nil
+}
+
+// Impact returns the pull requests which would be created in the downstream repositories by upgrading the named
+// repository to the version. The direct dependents get pull requests upgrading the repository and once they release
+// their dependents get pull requests upgrading them and so on. A repository gets a pull request for each of its
+// dependencies which is upgraded
+func (g *DependencyGraph) Impact(name string, version string) ([]*Impact, error) {
+	node, err := g.FindNode(name)
+	if err != nil {
+		return nil, err
+	}
+	type upgrade struct {
+		key     string
+		version string
+		depth   int
+	}
+	answer := []*Impact{}
+	// the pull requests keyed by the repository and the dependency it upgrades
+	upgraded := map[string]bool{}
+	released := map[string]bool{node.String(): true}
+	queue := []upgrade{{key: node.String(), version: version, depth: 1}}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, e := range g.dependentEdges(u.key) {
+			if u.version != "" && e.Version == u.version {
+				// already on the version so there is nothing to upgrade
+				continue
+			}
+			id := e.From + " -> " + u.key
+			if upgraded[id] {
+				continue
+			}
+			upgraded[id] = true
+			answer = append(answer, &Impact{
+				Repository:  *g.Nodes[e.From],
+				Dependency:  *g.Nodes[u.key],
+				FromVersion: e.Version,
+				ToVersion:   u.version,
+				Depth:       u.depth,
+			})
+			if released[e.From] {
+				continue
+			}
+			released[e.From] = true
+			// the new release of the dependent is not known yet
+			queue = append(queue, upgrade{key: e.From, depth: u.depth + 1})
+		}
+	}
+	return answer, nil
+}
+
+// ImpactGraph returns the graph of the pull requests which would be created by the impacts
+func ImpactGraph(impacts []*Impact) *DependencyGraph {
+	g := NewDependencyGraph()
+	for _, i := range impacts {
+		from := g.addNode(i.Repository)
+		to := g.addNode(i.Dependency)
+		upgradeVersion := i.ToVersion
+		if upgradeVersion == "" {
+			upgradeVersion = "next release"
+		}
+		g.Edges = append(g.Edges, &DependencyEdge{
+			From:           from,
+			To:             to,
+			Version:        i.FromVersion,
+			UpgradeVersion: upgradeVersion,
+		})
+	}
+	return g
+}
+
+// DependentsGraph returns the graph of the edges between the dependents and the dependency
+func DependentsGraph(dependency *DependencyDetails, dependents []*Dependent) *DependencyGraph {
+	g := NewDependencyGraph()
+	dependencyKey := g.addNode(*dependency)
+	for _, d := range dependents {
+		from := g.addNode(d.Repository)
+		for _, p := range d.Path {
+			to := g.addNode(*p)
+			g.addEdge(from, to, p.Version)
+			from = to
+		}
+		g.addEdge(from, dependencyKey, d.Version)
+	}
+	return g
+}
+
+// WriteDOT writes the graph in the graphviz DOT format
+func (g *DependencyGraph) WriteDOT(out io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	keys := []string{}
+	for key := range g.Nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		node := g.Nodes[key]
+		label := node.Owner + "/" + node.Repo
+		if node.Component != "" {
+			label += ":" + node.Component
+		}
+		b.WriteString(fmt.Sprintf("  %q [label=%q];\n", key, label))
+	}
+	for _, e := range g.Edges {
+		label := e.Version
+		if e.UpgradeVersion != "" {
+			label = fmt.Sprintf("%s -> %s", e.Version, e.UpgradeVersion)
+		}
+		b.WriteString(fmt.Sprintf("  %q -> %q [label=%q];\n", e.From, e.To, label))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// dependentEdges returns the edges to the repository with the given key
+func (g *DependencyGraph) dependentEdges(key string) []*DependencyEdge {
+	answer := []*DependencyEdge{}
+	for _, e := range g.Edges {
+		if e.To == key {
+			answer = append(answer, e)
+		}
+	}
+	return answer
+}
+
+func (g *DependencyGraph) versionedNode(key string, version string) *DependencyDetails {
+	node := *g.Nodes[key]
+	node.Version = version
+	return &node
+}
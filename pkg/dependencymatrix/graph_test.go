@@ -0,0 +1,144 @@
+package dependencymatrix_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/dependencymatrix"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestDependencyGraph(t *testing.T) *dependencymatrix.DependencyGraph {
+	matrix, err := dependencymatrix.LoadDependencyMatrix(filepath.Join("testdata", "graph_matrix"))
+	require.NoError(t, err)
+	graph := dependencymatrix.NewDependencyGraph()
+	graph.AddMatrix(dependencymatrix.DependencyDetails{
+		Host:  "fake.git",
+		Owner: "acme",
+		Repo:  "app",
+		URL:   "https://fake.git/acme/app.git",
+	}, matrix)
+	return graph
+}
+
+func dependentNames(dependents []*dependencymatrix.Dependent) []string {
+	answer := []string{}
+	for _, d := range dependents {
+		name := d.Repository.Repo + "@" + d.Version
+		for _, p := range d.Path {
+			name += " via " + p.Repo + "@" + p.Version
+		}
+		answer = append(answer, name)
+	}
+	return answer
+}
+
+func TestFindNode(t *testing.T) {
+	graph := loadTestDependencyGraph(t)
+
+	for _, name := range []string{"roadrunner", "acme/roadrunner", "fake.git/acme/roadrunner", "ACME/Roadrunner"} {
+		node, err := graph.FindNode(name)
+		require.NoError(t, err, "finding %s", name)
+		assert.Equal(t, "fake.git/acme/roadrunner", node.String(), "finding %s", name)
+	}
+
+	_, err := graph.FindNode("cheese/roadrunner")
+	assert.Error(t, err, "should not find a repository in another owner")
+	_, err = graph.FindNode("roadrunner:cheese")
+	assert.Error(t, err, "should not find a missing component")
+}
+
+func TestDirectDependents(t *testing.T) {
+	graph := loadTestDependencyGraph(t)
+
+	dependents, err := graph.Dependents("roadrunner", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"coyote@0.0.1", "wiley@0.0.2"}, dependentNames(dependents))
+	for _, d := range dependents {
+		assert.True(t, d.Direct(), "%s should be a direct dependent", d.Repository.Repo)
+	}
+}
+
+func TestTransitiveDependents(t *testing.T) {
+	graph := loadTestDependencyGraph(t)
+
+	dependents, err := graph.Dependents("acme/roadrunner", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"coyote@0.0.1",
+		"wiley@0.0.2",
+		"app@0.0.2 via wiley@0.0.2",
+		"brie@0.0.1 via coyote@0.0.1",
+		"app@0.0.1 via brie@0.0.2 via coyote@0.0.1",
+	}, dependentNames(dependents))
+}
+
+func TestImpactOfUpgrade(t *testing.T) {
+	graph := loadTestDependencyGraph(t)
+
+	impacts, err := graph.Impact("roadrunner", "0.0.2")
+	require.NoError(t, err)
+
+	require.Len(t, impacts, 3)
+	assert.Equal(t, "coyote", impacts[0].Repository.Repo)
+	assert.Equal(t, "roadrunner", impacts[0].Dependency.Repo)
+	assert.Equal(t, "0.0.1", impacts[0].FromVersion)
+	assert.Equal(t, "0.0.2", impacts[0].ToVersion)
+	assert.Equal(t, 1, impacts[0].Depth)
+
+	assert.Equal(t, "brie", impacts[1].Repository.Repo)
+	assert.Equal(t, "coyote", impacts[1].Dependency.Repo)
+	assert.Equal(t, "0.0.1", impacts[1].FromVersion)
+	assert.Equal(t, "", impacts[1].ToVersion, "the next release of coyote is not known")
+	assert.Equal(t, 2, impacts[1].Depth)
+
+	assert.Equal(t, "app", impacts[2].Repository.Repo)
+	assert.Equal(t, "brie", impacts[2].Dependency.Repo)
+	assert.Equal(t, 3, impacts[2].Depth)
+}
+
+func TestImpactUpgradesEachDependencyOnce(t *testing.T) {
+	graph := loadTestDependencyGraph(t)
+
+	impacts, err := graph.Impact("roadrunner", "0.0.3")
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, i := range impacts {
+		names = append(names, i.Repository.Repo+" upgrading "+i.Dependency.Repo)
+	}
+	assert.Equal(t, []string{
+		"wiley upgrading roadrunner",
+		"coyote upgrading roadrunner",
+		"app upgrading wiley",
+		"brie upgrading coyote",
+		"app upgrading brie",
+	}, names)
+}
+
+func TestWriteDOT(t *testing.T) {
+	graph := loadTestDependencyGraph(t)
+	impacts, err := graph.Impact("roadrunner", "0.0.2")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	err = dependencymatrix.ImpactGraph(impacts).WriteDOT(&out)
+	require.NoError(t, err)
+
+	assert.Equal(t, `digraph dependencies {
+  rankdir=LR;
+  "fake.git/acme/app" [label="acme/app"];
+  "fake.git/acme/coyote" [label="acme/coyote"];
+  "fake.git/acme/roadrunner" [label="acme/roadrunner"];
+  "fake.git/cheese/brie" [label="cheese/brie"];
+  "fake.git/acme/coyote" -> "fake.git/acme/roadrunner" [label="0.0.1 -> 0.0.2"];
+  "fake.git/cheese/brie" -> "fake.git/acme/coyote" [label="0.0.1 -> next release"];
+  "fake.git/acme/app" -> "fake.git/cheese/brie" [label="0.0.2 -> next release"];
+}
+`, out.String())
+}
@@ -0,0 +1,291 @@
+package environments
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// DriftType is the type of difference between a resource in the git repository of an Environment and the cluster
+type DriftType string
+
+const (
+	// DriftTypeMissing the resource is in the git repository but not in the cluster
+	DriftTypeMissing DriftType = "Missing"
+	// DriftTypeModified the resource in the cluster has fields which differ from the git repository
+	DriftTypeModified DriftType = "Modified"
+
+	// DriftIssueTitle the title of the issue opened on the git repository of an Environment which has drifted
+	DriftIssueTitle = "Environment %s has drifted from its git repository"
+
+	hiddenValue  = "<hidden>"
+	missingValue = "<missing>"
+)
+
+// Drift is a resource of an Environment whose live state differs from its git repository
+type Drift struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Name       string       `json:"name"`
+	Namespace  string       `json:"namespace,omitempty"`
+	Type       DriftType    `json:"type"`
+	Fields     []FieldDrift `json:"fields,omitempty"`
+}
+
+// FieldDrift is a field of a resource whose live value differs from its git repository
+type FieldDrift struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ignoredDriftPaths are the fields managed by Kubernetes which are never compared. The stringData of Secrets is
+// write only so cannot be compared either
+var ignoredDriftPaths = map[string]bool{
+	"status":                     true,
+	"metadata.namespace":         true,
+	"metadata.creationTimestamp": true,
+	"metadata.resourceVersion":   true,
+	"metadata.uid":               true,
+	"metadata.selfLink":          true,
+	"metadata.generation":        true,
+	"metadata.managedFields":     true,
+	"metadata.ownerReferences":   true,
+	"metadata.finalizers":        true,
+	"stringData":                 true,
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration": true,
+	"metadata.annotations.deployment.kubernetes.io/revision":                true,
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// LoadRenderedResources loads the resources in the YAML files output by helm template into the directory, skipping
+// helm hooks as they are not expected to exist once they have run
+func LoadRenderedResources(dir string) ([]*unstructured.Unstructured, error) {
+	answer := []*unstructured.Unstructured{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", path)
+		}
+		for _, doc := range documentSeparator.Split(string(data), -1) {
+			if strings.TrimSpace(doc) == "" {
+				continue
+			}
+			m := map[string]interface{}{}
+			err = yaml.Unmarshal([]byte(doc), &m)
+			if err != nil {
+				return errors.Wrapf(err, "parsing %s", path)
+			}
+			u := &unstructured.Unstructured{Object: m}
+			if u.GetKind() == "" || u.GetName() == "" || u.GetAnnotations()["helm.sh/hook"] != "" {
+				continue
+			}
+			answer = append(answer, u)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the rendered resources in %s", dir)
+	}
+	return answer, nil
+}
+
+// DetectDrift compares the rendered resources of an Environment with the live resources in the cluster. Resources
+// whose kind is not known to the cluster, such as those of a CRD which is not installed, are skipped
+func DetectDrift(client dynamic.Interface, mapper meta.RESTMapper, ns string, resources []*unstructured.Unstructured) ([]Drift, error) {
+	answer := []Drift{}
+	for _, expected := range resources {
+		gvk := expected.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				log.Logger().Warnf("skipping %s %s as the cluster has no resource for %s", expected.GetKind(), expected.GetName(), gvk.String())
+				continue
+			}
+			return nil, errors.Wrapf(err, "finding the resource of %s", gvk.String())
+		}
+		resourceNS := ""
+		var ri dynamic.ResourceInterface = client.Resource(mapping.Resource)
+		if mapping.Scope.Name() != meta.RESTScopeNameRoot {
+			resourceNS = expected.GetNamespace()
+			if resourceNS == "" {
+				resourceNS = ns
+			}
+			ri = client.Resource(mapping.Resource).Namespace(resourceNS)
+		}
+		drift := Drift{
+			APIVersion: expected.GetAPIVersion(),
+			Kind:       expected.GetKind(),
+			Name:       expected.GetName(),
+			Namespace:  resourceNS,
+		}
+		actual, err := ri.Get(expected.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				drift.Type = DriftTypeMissing
+				answer = append(answer, drift)
+				continue
+			}
+			return nil, errors.Wrapf(err, "getting %s %s", expected.GetKind(), expected.GetName())
+		}
+		fields := CompareResource(expected.Object, actual.Object)
+		if len(fields) > 0 {
+			if expected.GetKind() == "Secret" {
+				for i := range fields {
+					fields[i].Expected = hiddenValue
+					fields[i].Actual = hiddenValue
+				}
+			}
+			drift.Type = DriftTypeModified
+			drift.Fields = fields
+			answer = append(answer, drift)
+		}
+	}
+	return answer, nil
+}
+
+// CompareResource returns the fields of the expected resource whose values differ in the actual resource. Fields
+// which are not in the expected resource are defaulted or managed by Kubernetes so are ignored
+func CompareResource(expected map[string]interface{}, actual map[string]interface{}) []FieldDrift {
+	answer := []FieldDrift{}
+	compareValues("", expected, actual, &answer)
+	return answer
+}
+
+func compareValues(path string, expected interface{}, actual interface{}, answer *[]FieldDrift) {
+	if ignoredDriftPaths[path] || expected == nil {
+		return
+	}
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			if len(e) > 0 {
+				*answer = append(*answer, FieldDrift{Path: path, Expected: formatValue(e), Actual: formatValue(actual)})
+			}
+			return
+		}
+		keys := []string{}
+		for k := range e {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			compareValues(childPath, e[k], a[k], answer)
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			if len(e) > 0 || len(a) > 0 {
+				*answer = append(*answer, FieldDrift{Path: path, Expected: formatValue(e), Actual: formatValue(actual)})
+			}
+			return
+		}
+		for i := range e {
+			compareValues(fmt.Sprintf("%s[%d]", path, i), e[i], a[i], answer)
+		}
+	default:
+		if !equalScalars(expected, actual) {
+			*answer = append(*answer, FieldDrift{Path: path, Expected: formatValue(expected), Actual: formatValue(actual)})
+		}
+	}
+}
+
+// equalScalars compares numbers regardless of their type and quantities such as '0.5' and '500m' by value
+func equalScalars(expected interface{}, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	if actual == nil {
+		return false
+	}
+	e := fmt.Sprint(expected)
+	a := fmt.Sprint(actual)
+	if e == a {
+		return true
+	}
+	ef, err1 := strconv.ParseFloat(e, 64)
+	af, err2 := strconv.ParseFloat(a, 64)
+	if err1 == nil && err2 == nil {
+		return ef == af
+	}
+	eq, err1 := resource.ParseQuantity(e)
+	aq, err2 := resource.ParseQuantity(a)
+	if err1 == nil && err2 == nil {
+		return eq.Cmp(aq) == 0
+	}
+	return false
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return missingValue
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// FormatDriftReport formats the drift of the Environment as markdown for an issue
+func FormatDriftReport(envName string, drifts []Drift) string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("The resources of environment **%s** in the cluster differ from its git repository.\n\n", envName))
+	buf.WriteString("Either revert the changes in the cluster or make them in the git repository.\n\n")
+	for _, drift := range drifts {
+		name := drift.Name
+		if drift.Namespace != "" {
+			name = drift.Namespace + "/" + drift.Name
+		}
+		buf.WriteString(fmt.Sprintf("### %s %s: %s\n\n", drift.Kind, name, drift.Type))
+		if len(drift.Fields) == 0 {
+			continue
+		}
+		buf.WriteString("| Field | Git | Cluster |\n| --- | --- | --- |\n")
+		for _, f := range drift.Fields {
+			buf.WriteString(fmt.Sprintf("| `%s` | `%s` | `%s` |\n", f.Path, escapeTableCell(f.Expected), escapeTableCell(f.Actual)))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func escapeTableCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "`", "'").Replace(text)
+}
@@ -0,0 +1,131 @@
+package environments_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/environments"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fake_dynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestLoadRenderedResources(t *testing.T) {
+	t.Parallel()
+	resources, err := environments.LoadRenderedResources(filepath.Join("test_data", "drift"))
+	require.NoError(t, err)
+	require.Len(t, resources, 2, "the helm hook should be skipped")
+	assert.Equal(t, "Deployment", resources[0].GetKind())
+	assert.Equal(t, "jx-myapp", resources[0].GetName())
+	assert.Equal(t, "Service", resources[1].GetKind())
+}
+
+func TestCompareResource(t *testing.T) {
+	t.Parallel()
+	resources, err := environments.LoadRenderedResources(filepath.Join("test_data", "drift"))
+	require.NoError(t, err)
+	expected := resources[0].Object
+
+	actual := liveDeployment(int64(2), "myorg/myapp:1.0.1")
+	assert.Empty(t, environments.CompareResource(expected, actual), "defaulted and managed fields should be ignored")
+
+	actual = liveDeployment(int64(5), "myorg/myapp:1.0.2-hotfix")
+	fields := environments.CompareResource(expected, actual)
+	assert.Equal(t, []environments.FieldDrift{
+		{Path: "spec.replicas", Expected: "2", Actual: "5"},
+		{Path: "spec.template.spec.containers[0].image", Expected: "myorg/myapp:1.0.1", Actual: "myorg/myapp:1.0.2-hotfix"},
+	}, fields)
+}
+
+func TestDetectDrift(t *testing.T) {
+	t.Parallel()
+	resources, err := environments.LoadRenderedResources(filepath.Join("test_data", "drift"))
+	require.NoError(t, err)
+
+	deployment := &unstructured.Unstructured{Object: liveDeployment(int64(1), "myorg/myapp:1.0.1")}
+	client := fake_dynamic.NewSimpleDynamicClient(runtime.NewScheme(), deployment)
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+
+	drifts, err := environments.DetectDrift(client, mapper, "jx-staging", resources)
+	require.NoError(t, err)
+	require.Len(t, drifts, 2)
+	assert.Equal(t, environments.DriftTypeModified, drifts[0].Type)
+	assert.Equal(t, "jx-staging", drifts[0].Namespace)
+	assert.Equal(t, []environments.FieldDrift{{Path: "spec.replicas", Expected: "2", Actual: "1"}}, drifts[0].Fields)
+	assert.Equal(t, environments.Drift{APIVersion: "v1", Kind: "Service", Name: "myapp", Namespace: "jx-staging", Type: environments.DriftTypeMissing}, drifts[1])
+
+	report := environments.FormatDriftReport("staging", drifts)
+	assert.Contains(t, report, "### Deployment jx-staging/jx-myapp: Modified")
+	assert.Contains(t, report, "| `spec.replicas` | `2` | `1` |")
+	assert.Contains(t, report, "### Service jx-staging/myapp: Missing")
+}
+
+func TestDetectDriftSkipsUnknownKinds(t *testing.T) {
+	t.Parallel()
+	resources, err := environments.LoadRenderedResources(filepath.Join("test_data", "drift"))
+	require.NoError(t, err)
+
+	deployment := &unstructured.Unstructured{Object: liveDeployment(int64(2), "myorg/myapp:1.0.1")}
+	client := fake_dynamic.NewSimpleDynamicClient(runtime.NewScheme(), deployment)
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	drifts, err := environments.DetectDrift(client, mapper, "jx-staging", resources)
+	require.NoError(t, err)
+	assert.Empty(t, drifts, "the Service should be skipped as the cluster has no resource for it")
+}
+
+func liveDeployment(replicas int64, image string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":              "jx-myapp",
+			"namespace":         "jx-staging",
+			"uid":               "0a5c6b8e",
+			"resourceVersion":   "1234",
+			"creationTimestamp": metav1.Now().Format("2006-01-02T15:04:05Z"),
+			"labels": map[string]interface{}{
+				"app":                      "myapp",
+				"jenkins.io/chart-release": "jx-staging",
+			},
+			"annotations": map[string]interface{}{
+				"deployment.kubernetes.io/revision": "3",
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas":             replicas,
+			"revisionHistoryLimit": int64(10),
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "myapp"},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":            "myapp",
+							"image":           image,
+							"imagePullPolicy": "IfNotPresent",
+							"resources": map[string]interface{}{
+								"limits": map[string]interface{}{
+									"cpu":    "500m",
+									"memory": "256Mi",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}
+}
@@ -227,7 +227,7 @@ func CreateAddRequirementFn(chartName string, alias string, version string, repo
 				if version != d.Version {
 					log.Logger().Infof("To upgrade the chartName use %s or %s",
 						util.ColorInfo("jx upgrade chartName <chartName>"),
-						util.ColorInfo("jx upgrade apps --all"))
+						util.ColorInfo("jx upgrade apps"))
 				}
 				found = true
 				break
@@ -0,0 +1,45 @@
+package flagger
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdatePromoteCanaryStep updates the canary step of a promotion from the status of the Canary, returning true if
+// the canary analysis of the revision promoted at the started time has finished
+func UpdatePromoteCanaryStep(step *v1.PromoteCanaryStep, canary *Canary, started time.Time) bool {
+	status := canary.Status
+	step.Name = canary.Name
+	step.Phase = string(status.Phase)
+	step.Weight = status.CanaryWeight
+	step.Iterations = status.Iterations
+	step.FailedChecks = status.FailedChecks
+	step.Threshold = canary.Spec.CanaryAnalysis.Threshold
+	if step.StartedTimestamp == nil {
+		step.StartedTimestamp = &metav1.Time{Time: started}
+	}
+
+	// a finished phase from before the promotion belongs to the previous revision
+	finished := status.Phase.IsFinished() && !status.LastTransitionTime.Time.Before(started)
+	switch {
+	case !finished && status.Phase.IsRunning():
+		step.Status = v1.ActivityStatusTypeRunning
+		step.Description = fmt.Sprintf("Canary %s has %d%% of the traffic with %d of %d failed checks", canary.Name, step.Weight, step.FailedChecks, step.Threshold)
+	case !finished:
+		step.Status = v1.ActivityStatusTypePending
+		step.Description = fmt.Sprintf("Waiting for canary %s", canary.Name)
+	case status.Phase == CanaryPhaseSucceeded:
+		step.Status = v1.ActivityStatusTypeSucceeded
+		step.Description = fmt.Sprintf("Canary %s succeeded", canary.Name)
+	default:
+		step.Status = v1.ActivityStatusTypeFailed
+		step.Description = fmt.Sprintf("Canary %s failed: %s", canary.Name, status.Message())
+	}
+	if finished && step.CompletedTimestamp == nil {
+		step.CompletedTimestamp = &metav1.Time{Time: status.LastTransitionTime.Time}
+	}
+	return finished
+}
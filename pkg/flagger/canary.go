@@ -0,0 +1,346 @@
+package flagger
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CanaryAPIVersion the API version of the Flagger Canary resource
+	CanaryAPIVersion = "flagger.app/v1alpha3"
+	// CanaryKind the kind of the Flagger Canary resource
+	CanaryKind = "Canary"
+
+	// DefaultRollbackGateURL the default URL of the Flagger load tester which is used to abort canaries
+	DefaultRollbackGateURL = "http://flagger-loadtester.istio-system/"
+	// AbortWebhookName the name of the rollback webhook used to abort canaries
+	AbortWebhookName = "jx-abort"
+	// WebhookTypeRollback the type of Flagger webhook which rolls back the canary when it returns a successful response
+	WebhookTypeRollback = "rollback"
+
+	// LabelCanaryGenerated the label added to Canary resources generated from the chart values
+	LabelCanaryGenerated = "jenkins.io/canary-generated"
+)
+
+// CanaryPhase the phase of a Flagger Canary
+type CanaryPhase string
+
+const (
+	// CanaryPhaseInitializing the canary is initializing the primary deployment
+	CanaryPhaseInitializing CanaryPhase = "Initializing"
+	// CanaryPhaseInitialized the primary deployment is ready and waiting for a new revision
+	CanaryPhaseInitialized CanaryPhase = "Initialized"
+	// CanaryPhaseWaiting the canary is waiting for the confirm-rollout webhooks
+	CanaryPhaseWaiting CanaryPhase = "Waiting"
+	// CanaryPhaseProgressing the canary analysis is shifting traffic to the new revision
+	CanaryPhaseProgressing CanaryPhase = "Progressing"
+	// CanaryPhasePromoting the new revision is being copied to the primary deployment
+	CanaryPhasePromoting CanaryPhase = "Promoting"
+	// CanaryPhaseFinalising the traffic is being routed back to the primary deployment
+	CanaryPhaseFinalising CanaryPhase = "Finalising"
+	// CanaryPhaseSucceeded the new revision was promoted
+	CanaryPhaseSucceeded CanaryPhase = "Succeeded"
+	// CanaryPhaseFailed the canary analysis failed and the new revision was rolled back
+	CanaryPhaseFailed CanaryPhase = "Failed"
+)
+
+// IsFinished returns true if the canary analysis of a revision has completed
+func (p CanaryPhase) IsFinished() bool {
+	return p == CanaryPhaseSucceeded || p == CanaryPhaseFailed
+}
+
+// IsRunning returns true if the canary analysis of a revision is in progress
+func (p CanaryPhase) IsRunning() bool {
+	return p == CanaryPhaseWaiting || p == CanaryPhaseProgressing || p == CanaryPhasePromoting || p == CanaryPhaseFinalising
+}
+
+// Canary a Flagger Canary resource
+type Canary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CanarySpec   `json:"spec"`
+	Status CanaryStatus `json:"status,omitempty"`
+}
+
+// CanarySpec the specification of a Flagger Canary
+type CanarySpec struct {
+	TargetRef               CrossNamespaceObjectReference  `json:"targetRef"`
+	AutoscalerRef           *CrossNamespaceObjectReference `json:"autoscalerRef,omitempty"`
+	ProgressDeadlineSeconds *int32                         `json:"progressDeadlineSeconds,omitempty"`
+	Service                 CanaryService                  `json:"service"`
+	CanaryAnalysis          CanaryAnalysis                 `json:"canaryAnalysis"`
+	SkipAnalysis            bool                           `json:"skipAnalysis,omitempty"`
+}
+
+// CrossNamespaceObjectReference a reference to the resource the canary controls
+type CrossNamespaceObjectReference struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// CanaryService the service the canary routes traffic through
+type CanaryService struct {
+	Port     int32    `json:"port"`
+	Gateways []string `json:"gateways,omitempty"`
+	Hosts    []string `json:"hosts,omitempty"`
+}
+
+// CanaryAnalysis the configuration of the canary analysis
+type CanaryAnalysis struct {
+	Interval   string          `json:"interval"`
+	Threshold  int             `json:"threshold"`
+	MaxWeight  int             `json:"maxWeight,omitempty"`
+	StepWeight int             `json:"stepWeight,omitempty"`
+	Iterations int             `json:"iterations,omitempty"`
+	Metrics    []CanaryMetric  `json:"metrics,omitempty"`
+	Webhooks   []CanaryWebhook `json:"webhooks,omitempty"`
+}
+
+// CanaryMetric a metric checked during the canary analysis
+type CanaryMetric struct {
+	Name      string  `json:"name"`
+	Interval  string  `json:"interval,omitempty"`
+	Threshold float64 `json:"threshold"`
+	Query     string  `json:"query,omitempty"`
+}
+
+// CanaryWebhook a webhook called during the canary analysis
+type CanaryWebhook struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type,omitempty"`
+	URL      string            `json:"url"`
+	Timeout  string            `json:"timeout,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CanaryStatus the status of a Flagger Canary
+type CanaryStatus struct {
+	Phase              CanaryPhase       `json:"phase,omitempty"`
+	FailedChecks       int               `json:"failedChecks"`
+	CanaryWeight       int               `json:"canaryWeight"`
+	Iterations         int               `json:"iterations"`
+	LastAppliedSpec    string            `json:"lastAppliedSpec,omitempty"`
+	LastTransitionTime metav1.Time       `json:"lastTransitionTime,omitempty"`
+	Conditions         []CanaryCondition `json:"conditions,omitempty"`
+}
+
+// CanaryCondition a condition of a Flagger Canary
+type CanaryCondition struct {
+	Type               string      `json:"type"`
+	Status             string      `json:"status"`
+	LastUpdateTime     metav1.Time `json:"lastUpdateTime,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// Message returns the message of the latest condition of the canary
+func (s *CanaryStatus) Message() string {
+	for i := len(s.Conditions) - 1; i >= 0; i-- {
+		if s.Conditions[i].Message != "" {
+			return s.Conditions[i].Message
+		}
+	}
+	return ""
+}
+
+// CanaryValues the canary section of the values.yaml of a chart
+type CanaryValues struct {
+	Enabled                 bool                 `json:"enabled"`
+	ProgressDeadlineSeconds *int32               `json:"progressDeadlineSeconds,omitempty"`
+	CanaryAnalysis          CanaryAnalysisValues `json:"canaryAnalysis,omitempty"`
+	Service                 CanaryService        `json:"service,omitempty"`
+	RollbackGateURL         string               `json:"rollbackGateURL,omitempty"`
+}
+
+// CanaryAnalysisValues the canary analysis section of the values.yaml of a chart
+type CanaryAnalysisValues struct {
+	Interval   string                        `json:"interval,omitempty"`
+	Threshold  int                           `json:"threshold,omitempty"`
+	MaxWeight  int                           `json:"maxWeight,omitempty"`
+	StepWeight int                           `json:"stepWeight,omitempty"`
+	Metrics    map[string]CanaryMetricValues `json:"metrics,omitempty"`
+}
+
+// CanaryMetricValues the values of a metric checked during the canary analysis
+type CanaryMetricValues struct {
+	Threshold float64 `json:"threshold"`
+	Interval  string  `json:"interval,omitempty"`
+	Query     string  `json:"query,omitempty"`
+}
+
+// GetCanaryValues returns the canary values from the values of a chart or nil if there are none. The service port
+// defaults to the 'service.internalPort' value of the chart
+func GetCanaryValues(values map[string]interface{}) (*CanaryValues, error) {
+	section, ok := values["canary"]
+	if !ok || section == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(section)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling the canary values")
+	}
+	answer := &CanaryValues{}
+	err = json.Unmarshal(data, answer)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling the canary values")
+	}
+	if answer.Service.Port == 0 {
+		if service, ok := values["service"].(map[string]interface{}); ok {
+			switch port := service["internalPort"].(type) {
+			case int:
+				answer.Service.Port = int32(port)
+			case int64:
+				answer.Service.Port = int32(port)
+			case float64:
+				answer.Service.Port = int32(port)
+			}
+		}
+	}
+	return answer, nil
+}
+
+// GenerateCanary generates the Canary for the deployment in the namespace from the canary values of its chart
+func GenerateCanary(deployment string, ns string, values *CanaryValues) *Canary {
+	analysis := values.CanaryAnalysis
+	interval := analysis.Interval
+	if interval == "" {
+		interval = "1m"
+	}
+	threshold := analysis.Threshold
+	if threshold <= 0 {
+		threshold = 5
+	}
+	maxWeight := analysis.MaxWeight
+	if maxWeight <= 0 {
+		maxWeight = 60
+	}
+	stepWeight := analysis.StepWeight
+	if stepWeight <= 0 {
+		stepWeight = 20
+	}
+	metrics := analysis.Metrics
+	if len(metrics) == 0 {
+		metrics = map[string]CanaryMetricValues{
+			"requestSuccessRate": {Threshold: 99},
+			"requestDuration":    {Threshold: 1000},
+		}
+	}
+	port := values.Service.Port
+	if port == 0 {
+		port = 8080
+	}
+
+	canary := &Canary{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: CanaryAPIVersion,
+			Kind:       CanaryKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deployment,
+			Namespace: ns,
+			Labels: map[string]string{
+				LabelCanaryGenerated: "true",
+			},
+		},
+		Spec: CanarySpec{
+			TargetRef: CrossNamespaceObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       deployment,
+			},
+			ProgressDeadlineSeconds: values.ProgressDeadlineSeconds,
+			Service: CanaryService{
+				Port:     port,
+				Gateways: values.Service.Gateways,
+				Hosts:    values.Service.Hosts,
+			},
+			CanaryAnalysis: CanaryAnalysis{
+				Interval:   interval,
+				Threshold:  threshold,
+				MaxWeight:  maxWeight,
+				StepWeight: stepWeight,
+			},
+		},
+	}
+	names := []string{}
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metric := metrics[name]
+		metricInterval := metric.Interval
+		if metricInterval == "" {
+			metricInterval = interval
+		}
+		canary.Spec.CanaryAnalysis.Metrics = append(canary.Spec.CanaryAnalysis.Metrics, CanaryMetric{
+			Name:      metricName(name),
+			Interval:  metricInterval,
+			Threshold: metric.Threshold,
+			Query:     metric.Query,
+		})
+	}
+	gate := values.RollbackGateURL
+	if gate == "" {
+		gate = DefaultRollbackGateURL
+	}
+	canary.Spec.CanaryAnalysis.Webhooks = append(canary.Spec.CanaryAnalysis.Webhooks, CanaryWebhook{
+		Name: AbortWebhookName,
+		Type: WebhookTypeRollback,
+		URL:  util.UrlJoin(gate, "rollback/check"),
+	})
+	return canary
+}
+
+// FindAbortWebhook returns the rollback webhook used to abort the canary or nil if there is none
+func FindAbortWebhook(canary *Canary) *CanaryWebhook {
+	for i := range canary.Spec.CanaryAnalysis.Webhooks {
+		webhook := &canary.Spec.CanaryAnalysis.Webhooks[i]
+		if webhook.Type == WebhookTypeRollback {
+			return webhook
+		}
+	}
+	return nil
+}
+
+// ParseGateService returns the service name, namespace and port of the load tester from the URL of a gate webhook
+func ParseGateService(gateURL string, defaultNamespace string) (string, string, string, error) {
+	u, err := url.Parse(gateURL)
+	if err != nil {
+		return "", "", "", errors.Wrapf(err, "parsing gate URL %s", gateURL)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return "", "", "", errors.Errorf("no host in gate URL %s", gateURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+	}
+	parts := strings.Split(host, ".")
+	ns := defaultNamespace
+	if len(parts) > 1 {
+		ns = parts[1]
+	}
+	return parts[0], ns, port, nil
+}
+
+// metricName converts the name of a metric in the chart values to the name of the builtin Flagger metric
+func metricName(name string) string {
+	switch name {
+	case "requestSuccessRate":
+		return "request-success-rate"
+	case "requestDuration":
+		return "request-duration"
+	}
+	return name
+}
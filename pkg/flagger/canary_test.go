@@ -0,0 +1,122 @@
+package flagger_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateCanaryFromValues(t *testing.T) {
+	t.Parallel()
+	values := map[string]interface{}{
+		"service": map[string]interface{}{
+			"internalPort": float64(9090),
+		},
+		"canary": map[string]interface{}{
+			"enabled": true,
+			"canaryAnalysis": map[string]interface{}{
+				"interval":   "30s",
+				"threshold":  float64(3),
+				"stepWeight": float64(10),
+				"metrics": map[string]interface{}{
+					"requestSuccessRate": map[string]interface{}{"threshold": float64(99.5)},
+				},
+			},
+			"service": map[string]interface{}{
+				"hosts":    []interface{}{"myapp.example.com"},
+				"gateways": []interface{}{"jx-gateway.istio-system.svc.cluster.local"},
+			},
+		},
+	}
+
+	canaryValues, err := flagger.GetCanaryValues(values)
+	require.NoError(t, err)
+	require.NotNil(t, canaryValues)
+	assert.True(t, canaryValues.Enabled)
+	assert.Equal(t, int32(9090), canaryValues.Service.Port)
+
+	canary := flagger.GenerateCanary("jx-myapp", "jx-production", canaryValues)
+	assert.Equal(t, flagger.CanaryAPIVersion, canary.APIVersion)
+	assert.Equal(t, "jx-myapp", canary.Name)
+	assert.Equal(t, "jx-production", canary.Namespace)
+	assert.Equal(t, "jx-myapp", canary.Spec.TargetRef.Name)
+	assert.Equal(t, int32(9090), canary.Spec.Service.Port)
+	assert.Equal(t, []string{"myapp.example.com"}, canary.Spec.Service.Hosts)
+
+	analysis := canary.Spec.CanaryAnalysis
+	assert.Equal(t, "30s", analysis.Interval)
+	assert.Equal(t, 3, analysis.Threshold)
+	assert.Equal(t, 60, analysis.MaxWeight)
+	assert.Equal(t, 10, analysis.StepWeight)
+	require.Len(t, analysis.Metrics, 1)
+	assert.Equal(t, flagger.CanaryMetric{Name: "request-success-rate", Interval: "30s", Threshold: 99.5}, analysis.Metrics[0])
+
+	webhook := flagger.FindAbortWebhook(canary)
+	require.NotNil(t, webhook)
+	assert.Equal(t, "http://flagger-loadtester.istio-system/rollback/check", webhook.URL)
+}
+
+func TestGetCanaryValuesMissing(t *testing.T) {
+	t.Parallel()
+	canaryValues, err := flagger.GetCanaryValues(map[string]interface{}{"replicaCount": float64(1)})
+	require.NoError(t, err)
+	assert.Nil(t, canaryValues)
+}
+
+func TestParseGateService(t *testing.T) {
+	t.Parallel()
+	name, ns, port, err := flagger.ParseGateService("http://flagger-loadtester.istio-system/rollback/check", "jx-production")
+	require.NoError(t, err)
+	assert.Equal(t, "flagger-loadtester", name)
+	assert.Equal(t, "istio-system", ns)
+	assert.Equal(t, "80", port)
+
+	name, ns, port, err = flagger.ParseGateService("http://loadtester:8080/rollback/check", "jx-production")
+	require.NoError(t, err)
+	assert.Equal(t, "loadtester", name)
+	assert.Equal(t, "jx-production", ns)
+	assert.Equal(t, "8080", port)
+}
+
+func TestUpdatePromoteCanaryStep(t *testing.T) {
+	t.Parallel()
+	started := time.Now()
+	canary := &flagger.Canary{
+		ObjectMeta: metav1.ObjectMeta{Name: "jx-myapp"},
+		Spec: flagger.CanarySpec{
+			CanaryAnalysis: flagger.CanaryAnalysis{Threshold: 5},
+		},
+		Status: flagger.CanaryStatus{
+			Phase:              flagger.CanaryPhaseSucceeded,
+			LastTransitionTime: metav1.Time{Time: started.Add(-time.Hour)},
+		},
+	}
+	step := &v1.PromoteCanaryStep{}
+
+	assert.False(t, flagger.UpdatePromoteCanaryStep(step, canary, started), "the previous revision should not finish the canary")
+	assert.Equal(t, v1.ActivityStatusTypePending, step.Status)
+
+	canary.Status = flagger.CanaryStatus{
+		Phase:              flagger.CanaryPhaseProgressing,
+		CanaryWeight:       20,
+		FailedChecks:       1,
+		LastTransitionTime: metav1.Time{Time: started.Add(time.Minute)},
+	}
+	assert.False(t, flagger.UpdatePromoteCanaryStep(step, canary, started))
+	assert.Equal(t, v1.ActivityStatusTypeRunning, step.Status)
+	assert.Equal(t, 20, step.Weight)
+	assert.Equal(t, 1, step.FailedChecks)
+	assert.Equal(t, 5, step.Threshold)
+
+	canary.Status.Phase = flagger.CanaryPhaseFailed
+	canary.Status.Conditions = []flagger.CanaryCondition{{Message: "Canary analysis failed, Deployment scaled to zero."}}
+	assert.True(t, flagger.UpdatePromoteCanaryStep(step, canary, started))
+	assert.Equal(t, v1.ActivityStatusTypeFailed, step.Status)
+	assert.NotNil(t, step.CompletedTimestamp)
+	assert.Contains(t, step.Description, "Deployment scaled to zero")
+}
@@ -0,0 +1,165 @@
+package flagger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CanaryResource the resource of the Flagger Canary custom resource definition
+var CanaryResource = schema.GroupVersionResource{
+	Group:    "flagger.app",
+	Version:  "v1alpha3",
+	Resource: "canaries",
+}
+
+// IsInstalled returns true if the Flagger Canary custom resource definition is installed
+func IsInstalled(client dynamic.Interface, ns string) (bool, error) {
+	_, err := client.Resource(CanaryResource).Namespace(ns).List(metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "listing Canaries")
+	}
+	return true, nil
+}
+
+// GetCanary returns the Canary with the given name in the namespace
+func GetCanary(client dynamic.Interface, ns string, name string) (*Canary, error) {
+	u, err := client.Resource(CanaryResource).Namespace(ns).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(u)
+}
+
+// ListCanaries returns the Canaries in the namespace
+func ListCanaries(client dynamic.Interface, ns string) ([]Canary, error) {
+	list, err := client.Resource(CanaryResource).Namespace(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing Canaries in namespace %s", ns)
+	}
+	answer := []Canary{}
+	for i := range list.Items {
+		canary, err := fromUnstructured(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, *canary)
+	}
+	return answer, nil
+}
+
+// FindCanaryForDeployment returns the Canary which targets the deployment or nil if there is none
+func FindCanaryForDeployment(canaries []Canary, deployment string) *Canary {
+	for i := range canaries {
+		if canaries[i].Spec.TargetRef.Name == deployment {
+			return &canaries[i]
+		}
+	}
+	return nil
+}
+
+// ApplyCanary creates the Canary or updates the specification of the existing Canary with the same name
+func ApplyCanary(client dynamic.Interface, canary *Canary) (*Canary, error) {
+	resources := client.Resource(CanaryResource).Namespace(canary.Namespace)
+	existing, err := GetCanary(client, canary.Namespace, canary.Name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "getting Canary %s in namespace %s", canary.Name, canary.Namespace)
+		}
+		u, err := toUnstructured(canary)
+		if err != nil {
+			return nil, err
+		}
+		u, err = resources.Create(u, metav1.CreateOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating Canary %s in namespace %s", canary.Name, canary.Namespace)
+		}
+		return fromUnstructured(u)
+	}
+	existing.Spec = canary.Spec
+	if existing.Labels == nil {
+		existing.Labels = map[string]string{}
+	}
+	for k, v := range canary.Labels {
+		existing.Labels[k] = v
+	}
+	u, err := toUnstructured(existing)
+	if err != nil {
+		return nil, err
+	}
+	u, err = resources.Update(u, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating Canary %s in namespace %s", canary.Name, canary.Namespace)
+	}
+	return fromUnstructured(u)
+}
+
+// AbortCanary aborts the analysis of the canary by opening the rollback gate of its load tester so that Flagger
+// routes all the traffic back to the primary deployment
+func AbortCanary(kubeClient kubernetes.Interface, canary *Canary) error {
+	webhook := FindAbortWebhook(canary)
+	if webhook == nil {
+		return fmt.Errorf("the Canary %s in namespace %s has no %s webhook so cannot be aborted", canary.Name, canary.Namespace, WebhookTypeRollback)
+	}
+	name, ns, port, err := ParseGateService(webhook.URL, canary.Namespace)
+	if err != nil {
+		return err
+	}
+	payload := map[string]string{
+		"name":      canary.Name,
+		"namespace": canary.Namespace,
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshalling the gate payload")
+	}
+	err = kubeClient.CoreV1().RESTClient().Post().
+		Namespace(ns).
+		Resource("services").
+		Name(name+":"+port).
+		SubResource("proxy").
+		Suffix("rollback", "open").
+		Body(data).
+		Do().
+		Error()
+	if err != nil {
+		return errors.Wrapf(err, "opening the rollback gate of service %s in namespace %s", name, ns)
+	}
+	return nil
+}
+
+func toUnstructured(canary *Canary) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(canary)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshalling Canary %s", canary.Name)
+	}
+	u := &unstructured.Unstructured{}
+	err = json.Unmarshal(data, &u.Object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling Canary %s", canary.Name)
+	}
+	return u, nil
+}
+
+func fromUnstructured(u *unstructured.Unstructured) (*Canary, error) {
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshalling Canary %s", u.GetName())
+	}
+	canary := &Canary{}
+	err = json.Unmarshal(data, canary)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling Canary %s", u.GetName())
+	}
+	return canary, nil
+}
@@ -0,0 +1,209 @@
+package gradle
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// VersionCatalogSuffix the suffix of the version catalog files such as gradle/libs.versions.toml
+	VersionCatalogSuffix = ".versions.toml"
+
+	versionsSection  = "versions"
+	librariesSection = "libraries"
+	pluginsSection   = "plugins"
+)
+
+var (
+	sectionRegex = regexp.MustCompile(`^\s*\[([\w.-]+)\]`)
+	entryRegex   = regexp.MustCompile(`^\s*([\w.-]+)\s*=\s*(.*)$`)
+	// stringNotationRegex matches group:artifact:version or plugin.id:version
+	stringNotationRegex = regexp.MustCompile(`^"([^"]+):([^":]+)"`)
+	fieldRegex          = regexp.MustCompile(`\b(module|group|name|id)\s*=\s*"([^"]*)"`)
+	versionFieldRegex   = regexp.MustCompile(`\bversion\s*=\s*"([^"]*)"`)
+	versionRefRegex     = regexp.MustCompile(`\bversion(?:\.ref\s*=|\s*=\s*\{[^}]*\bref\s*=)\s*"([^"]*)"`)
+	versionValueRegex   = regexp.MustCompile(`^"([^"]*)"`)
+)
+
+// UpdateDependencyVersion updates the version of the library or plugin in the version catalogs such as
+// gradle/libs.versions.toml and of any group:artifact:version coordinates in the build files in the directory tree
+// rooted in dir. The name is group:artifact for libraries or the id of a plugin. Versions referenced from the
+// [versions] section of a catalog are updated in that section. It returns the old versions
+func UpdateDependencyVersion(dir string, name string, version string) ([]string, error) {
+	catalogs, buildFiles, err := FindGradleFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	oldVersions := map[string]bool{}
+	for _, path := range catalogs {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", path)
+		}
+		text := UpdateVersionCatalog(string(data), name, version, oldVersions)
+		err = writeIfChanged(path, string(data), text)
+		if err != nil {
+			return nil, err
+		}
+	}
+	coordinateRegex, err := regexp.Compile(`["']` + regexp.QuoteMeta(name+":") + `([^"'$:@\s]+)`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the regex for %s", name)
+	}
+	for _, path := range buildFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", path)
+		}
+		text := util.ReplaceAllStringSubmatchFunc(coordinateRegex, string(data), func(groups []util.Group) []string {
+			oldVersions[groups[0].Value] = true
+			return []string{version}
+		})
+		err = writeIfChanged(path, string(data), text)
+		if err != nil {
+			return nil, err
+		}
+	}
+	answer := []string{}
+	for v := range oldVersions {
+		answer = append(answer, v)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// UpdateVersionCatalog updates the version of the library or plugin in the text of a version catalog adding the old
+// versions to the map
+func UpdateVersionCatalog(text string, name string, version string, oldVersions map[string]bool) string {
+	lines := strings.Split(text, "\n")
+	refs := map[string]bool{}
+	section := ""
+	for i, line := range lines {
+		sections := sectionRegex.FindStringSubmatch(line)
+		if sections != nil {
+			section = sections[1]
+			continue
+		}
+		if section != librariesSection && section != pluginsSection {
+			continue
+		}
+		entry := entryRegex.FindStringSubmatchIndex(line)
+		if entry == nil {
+			continue
+		}
+		valueStart := entry[4]
+		value := line[valueStart:]
+		if strings.HasPrefix(value, "\"") {
+			// lets handle the string notation
+			groups := stringNotationRegex.FindStringSubmatchIndex(value)
+			if groups != nil && value[groups[2]:groups[3]] == name {
+				oldVersions[value[groups[4]:groups[5]]] = true
+				lines[i] = line[:valueStart+groups[4]] + version + line[valueStart+groups[5]:]
+			}
+			continue
+		}
+		if catalogEntryName(value) != name {
+			continue
+		}
+		ref := versionRefRegex.FindStringSubmatch(value)
+		if ref != nil {
+			refs[ref[1]] = true
+			continue
+		}
+		v := versionFieldRegex.FindStringSubmatchIndex(value)
+		if v != nil {
+			oldVersions[value[v[2]:v[3]]] = true
+			lines[i] = line[:valueStart+v[2]] + version + line[valueStart+v[3]:]
+		}
+	}
+	if len(refs) > 0 {
+		section = ""
+		for i, line := range lines {
+			sections := sectionRegex.FindStringSubmatch(line)
+			if sections != nil {
+				section = sections[1]
+				continue
+			}
+			if section != versionsSection {
+				continue
+			}
+			entry := entryRegex.FindStringSubmatchIndex(line)
+			if entry == nil || !refs[line[entry[2]:entry[3]]] {
+				continue
+			}
+			valueStart := entry[4]
+			v := versionValueRegex.FindStringSubmatchIndex(line[valueStart:])
+			if v != nil {
+				oldVersions[line[valueStart+v[2]:valueStart+v[3]]] = true
+				lines[i] = line[:valueStart+v[2]] + version + line[valueStart+v[3]:]
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// catalogEntryName returns the group:artifact of a library or the id of a plugin defined as an inline table
+func catalogEntryName(value string) string {
+	fields := map[string]string{}
+	for _, f := range fieldRegex.FindAllStringSubmatch(value, -1) {
+		fields[f[1]] = f[2]
+	}
+	if fields["module"] != "" {
+		return fields["module"]
+	}
+	if fields["group"] != "" && fields["name"] != "" {
+		return fields["group"] + ":" + fields["name"]
+	}
+	return fields["id"]
+}
+
+// FindGradleFiles returns the version catalogs and build files in the directory tree rooted in dir ignoring any build
+// and hidden directories
+func FindGradleFiles(dir string) ([]string, []string, error) {
+	catalogs := []string{}
+	buildFiles := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != dir && (name == "build" || name == "node_modules" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, VersionCatalogSuffix) {
+			catalogs = append(catalogs, path)
+		} else if strings.HasSuffix(name, ".gradle") || strings.HasSuffix(name, ".gradle.kts") {
+			buildFiles = append(buildFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "finding gradle files in %s", dir)
+	}
+	return catalogs, buildFiles, nil
+}
+
+func writeIfChanged(path string, oldText string, text string) error {
+	if oldText == text {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "reading mode of %s", path)
+	}
+	err = ioutil.WriteFile(path, []byte(text), info.Mode())
+	if err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+	return nil
+}
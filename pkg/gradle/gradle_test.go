@@ -0,0 +1,61 @@
+package gradle_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/gradle"
+	"github.com/jenkins-x/jx/pkg/tests"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDependencyVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gradle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srcDir := filepath.Join("test_data", "standard")
+	err = util.CopyDir(srcDir, dir, true)
+	require.NoError(t, err)
+
+	oldVersions, err := gradle.UpdateDependencyVersion(dir, "com.acme:widgets", "1.3.0")
+	require.NoError(t, err)
+
+	tests.AssertDirContentsEqual(t, fmt.Sprintf("%s.golden", srcDir), dir)
+	assert.Equal(t, []string{"1.1.0", "1.2.0"}, oldVersions)
+}
+
+func TestUpdateVersionCatalog(t *testing.T) {
+	catalog := `[versions]
+kotlin = "1.3.61"
+
+[libraries]
+widgets-testing = { group = "com.acme", name = "widgets-testing", version = "1.2.0" }
+widgets-legacy = "com.acme:widgets-legacy:1.0.0"
+
+[plugins]
+kotlin-jvm = { id = "org.jetbrains.kotlin.jvm", version = { ref = "kotlin" } }
+acme-widgets = { id = "com.acme.widgets", version = "0.9.0" }
+`
+	testCases := []struct {
+		name       string
+		version    string
+		expected   string
+		oldVersion string
+	}{
+		{"com.acme:widgets-testing", "1.3.0", `widgets-testing = { group = "com.acme", name = "widgets-testing", version = "1.3.0" }`, "1.2.0"},
+		{"com.acme:widgets-legacy", "1.1.0", `widgets-legacy = "com.acme:widgets-legacy:1.1.0"`, "1.0.0"},
+		{"org.jetbrains.kotlin.jvm", "1.3.70", `kotlin = "1.3.70"`, "1.3.61"},
+		{"com.acme.widgets", "1.0.0", `acme-widgets = { id = "com.acme.widgets", version = "1.0.0" }`, "0.9.0"},
+	}
+	for _, tc := range testCases {
+		oldVersions := map[string]bool{}
+		actual := gradle.UpdateVersionCatalog(catalog, tc.name, tc.version, oldVersions)
+		assert.Contains(t, actual, tc.expected, "updating %s", tc.name)
+		assert.Equal(t, map[string]bool{tc.oldVersion: true}, oldVersions, "updating %s", tc.name)
+	}
+}
@@ -0,0 +1,9 @@
+plugins {
+    alias(libs.plugins.kotlin.jvm)
+}
+
+dependencies {
+    implementation(libs.widgets.core)
+    implementation("com.acme:widgets:1.3.0")
+    testImplementation(libs.widgets.testing)
+}
@@ -0,0 +1,344 @@
+package helm
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/kubernetes/pkg/util/slice"
+)
+
+// Helm3Binary is the name of the helm 3 binary installed by jx alongside helm 2
+const Helm3Binary = "helm3"
+
+// Helm3CLI implements common helm actions based on the helm 3 CLI which has no Tiller and stores the releases as
+// Secrets in the namespace of each release. The client side actions which have not changed since helm 2 are
+// delegated to HelmCLI
+type Helm3CLI struct {
+	*HelmCLI
+}
+
+// helm3Release is a release in the JSON output of helm 3 list
+type helm3Release struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revision   string `json:"revision"`
+	Updated    string `json:"updated"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"app_version"`
+}
+
+// NewHelm3CLI creates a new Helm3CLI instance configured to use the provided helm 3 CLI in the given current working
+// directory
+func NewHelm3CLI(binary string, cwd string, debug bool) *Helm3CLI {
+	if binary == "" {
+		binary = Helm3Binary
+	}
+	return &Helm3CLI{
+		HelmCLI: NewHelmCLI(binary, V3, cwd, debug),
+	}
+}
+
+// NewHelm3CLIWithRunner creates a new Helm3CLI instance for the given runner
+func NewHelm3CLIWithRunner(runner util.Commander, binary string, cwd string, debug bool, kuber kube.Kuber) *Helm3CLI {
+	return &Helm3CLI{
+		HelmCLI: NewHelmCLIWithRunner(runner, binary, V3, cwd, debug, kuber),
+	}
+}
+
+// SetHost is a NOOP as helm 3 talks to the Kubernetes API server directly
+func (h *Helm3CLI) SetHost(tillerAddress string) {
+	log.Logger().Debugf("Ignoring the tiller address %s as helm 3 does not use Tiller", tillerAddress)
+}
+
+// Init is a NOOP as helm 3 does not need to be initialised and has no Tiller to install
+func (h *Helm3CLI) Init(clientOnly bool, serviceAccount string, tillerNamespace string, upgrade bool) error {
+	if h.Debug {
+		log.Logger().Debugf("Skipping the initialisation of %s as helm 3 does not use Tiller", h.Binary)
+	}
+	return nil
+}
+
+// SearchCharts searches for all the charts matching the given filter in the helm repositories
+func (h *Helm3CLI) SearchCharts(filter string, allVersions bool) ([]ChartSummary, error) {
+	answer := []ChartSummary{}
+	args := []string{"search", "repo", filter}
+	if allVersions {
+		args = append(args, "--versions")
+	}
+	output, err := h.runHelmWithOutput(args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to search charts")
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "NAME") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		chart := ChartSummary{
+			Name: strings.TrimSpace(fields[0]),
+		}
+		if len(fields) > 1 {
+			chart.ChartVersion = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			chart.AppVersion = strings.TrimSpace(fields[2])
+		}
+		if len(fields) > 3 {
+			chart.Description = strings.TrimSpace(fields[3])
+		}
+		answer = append(answer, chart)
+	}
+	return answer, nil
+}
+
+// InstallChart installs a helm chart according with the given flags
+func (h *Helm3CLI) InstallChart(chart string, releaseName string, ns string, version string, timeout int,
+	values []string, valueFiles []string, repo string, username string, password string) error {
+	chart, cleanup, err := h.installableChart(chart, version, repo, username, password)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	args := []string{"install", releaseName, chart, "--wait", "--namespace", ns}
+	args, err = h.appendChartArgs(args, version, timeout, values, valueFiles, repo, username, password)
+	if err != nil {
+		return err
+	}
+	if h.Debug {
+		log.Logger().Infof("Installing Chart '%s'", util.ColorInfo(strings.Join(args, " ")))
+	}
+	return h.runHelm(args...)
+}
+
+// UpgradeChart upgrades a helm chart according with given helm flags
+func (h *Helm3CLI) UpgradeChart(chart string, releaseName string, ns string, version string, install bool, timeout int,
+	force bool, wait bool, values []string, valueFiles []string, repo string, username string, password string) error {
+	chart, cleanup, err := h.installableChart(chart, version, repo, username, password)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	args := []string{"upgrade", releaseName, chart, "--namespace", ns}
+	if install {
+		args = append(args, "--install")
+	}
+	if wait {
+		args = append(args, "--wait")
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	args, err = h.appendChartArgs(args, version, timeout, values, valueFiles, repo, username, password)
+	if err != nil {
+		return err
+	}
+	if h.Debug {
+		log.Logger().Infof("Upgrading Chart '%s'", util.ColorInfo(strings.Join(args, " ")))
+	}
+	return h.runHelm(args...)
+}
+
+// appendChartArgs appends the arguments shared by install and upgrade
+func (h *Helm3CLI) appendChartArgs(args []string, version string, timeout int, values []string, valueFiles []string,
+	repo string, username string, password string) ([]string, error) {
+	repo, err := addUsernamePasswordToURL(repo, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if timeout != -1 {
+		args = append(args, "--timeout", strconv.Itoa(timeout)+"s")
+	}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	for _, value := range values {
+		args = append(args, "--set", value)
+	}
+	for _, valueFile := range valueFiles {
+		args = append(args, "--values", valueFile)
+	}
+	if repo != "" {
+		args = append(args, "--repo", repo)
+	}
+	if username != "" {
+		args = append(args, "--username", username)
+	}
+	if password != "" {
+		args = append(args, "--password", password)
+	}
+	return args, nil
+}
+
+// FetchChart fetches a Helm Chart
+func (h *Helm3CLI) FetchChart(chart string, version string, untar bool, untardir string, repo string,
+	username string, password string) error {
+	if IsOCIChart(chart) || IsOCIChart(repo) {
+		if untardir == "" {
+			untardir = "."
+		}
+		_, err := h.fetchOCIChart(chart, version, untar, untardir, repo, username, password)
+		return err
+	}
+	fetched, err := h.fetchVerifiedChartTo(chart, version, untar, untardir, repo, username, password)
+	if fetched || err != nil {
+		return err
+	}
+	args := []string{"pull", chart}
+	repo, err = addUsernamePasswordToURL(repo, username, password)
+	if err != nil {
+		return err
+	}
+	if untardir != "" {
+		args = append(args, "--untardir", untardir)
+	}
+	if untar {
+		args = append(args, "--untar")
+	}
+	if username != "" {
+		args = append(args, "--username", username)
+	}
+	if password != "" {
+		args = append(args, "--password", password)
+	}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	if repo != "" {
+		args = append(args, "--repo", repo)
+	}
+	if h.Debug {
+		log.Logger().Infof("Fetching Chart '%s'", util.ColorInfo(strings.Join(args, " ")))
+	}
+	return h.runHelm(args...)
+}
+
+// Template generates the YAML from the chart template to the given directory
+func (h *Helm3CLI) Template(chart string, releaseName string, ns string, outDir string, upgrade bool,
+	values []string, valueFiles []string) error {
+	args := []string{"template", releaseName, chart, "--namespace", ns, "--output-dir", outDir, "--debug"}
+	if upgrade {
+		args = append(args, "--is-upgrade")
+	}
+	for _, value := range values {
+		args = append(args, "--set", value)
+	}
+	for _, valueFile := range valueFiles {
+		args = append(args, "--values", valueFile)
+	}
+	if h.Debug {
+		log.Logger().Debugf("Generating Chart Template '%s'", util.ColorInfo(strings.Join(args, " ")))
+	}
+	err := h.runHelm(args...)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to run helm %s", strings.Join(args, " "))
+	}
+	return nil
+}
+
+// DeleteRelease removes the given release. The release history is kept unless purge is specified
+func (h *Helm3CLI) DeleteRelease(ns string, releaseName string, purge bool) error {
+	args := []string{"uninstall", releaseName}
+	if ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+	if !purge {
+		args = append(args, "--keep-history")
+	}
+	return h.runHelm(args...)
+}
+
+// ListReleases lists the releases in ns or in all the namespaces if ns is blank in which case the releases are keyed
+// by namespace/name
+func (h *Helm3CLI) ListReleases(ns string) (map[string]ReleaseSummary, []string, error) {
+	args := []string{"list", "--all", "--output", "json"}
+	if ns != "" {
+		args = append(args, "--namespace", ns)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	output, err := h.runHelmWithOutput(args...)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "running %s %s", h.Binary, strings.Join(args, " "))
+	}
+	releases := []helm3Release{}
+	output = strings.TrimSpace(output)
+	if output != "" {
+		err = json.Unmarshal([]byte(output), &releases)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "parsing the output of %s list", h.Binary)
+		}
+	}
+	// release names are only unique within a namespace so qualify the names which are in more than one namespace
+	namespaces := map[string]int{}
+	for _, r := range releases {
+		namespaces[r.Name]++
+	}
+	result := make(map[string]ReleaseSummary, 0)
+	keys := make([]string, 0)
+	for _, r := range releases {
+		summary := ReleaseSummary{
+			ReleaseName:   r.Name,
+			Revision:      r.Revision,
+			Updated:       r.Updated,
+			Status:        strings.ToUpper(r.Status),
+			ChartFullName: r.Chart,
+			Chart:         r.Chart,
+			AppVersion:    r.AppVersion,
+			Namespace:     r.Namespace,
+		}
+		lastDash := strings.LastIndex(r.Chart, "-")
+		if lastDash > 0 {
+			summary.Chart = r.Chart[:lastDash]
+			summary.ChartVersion = r.Chart[lastDash+1:]
+		}
+		key := r.Name
+		if namespaces[r.Name] > 1 {
+			key = r.Namespace + "/" + r.Name
+		}
+		keys = append(keys, key)
+		result[key] = summary
+	}
+	slice.SortStrings(keys)
+	return result, keys, nil
+}
+
+// StatusRelease returns the output of the helm status command for a given release
+func (h *Helm3CLI) StatusRelease(ns string, releaseName string) error {
+	return h.runHelm("status", releaseName, "--namespace", ns)
+}
+
+// StatusReleaseWithOutput returns the output of the helm status command for a given release
+func (h *Helm3CLI) StatusReleaseWithOutput(ns string, releaseName string, outputFormat string) (string, error) {
+	args := []string{"status", releaseName, "--namespace", ns}
+	if outputFormat != "" {
+		args = append(args, "--output", outputFormat)
+	}
+	return h.runHelmWithOutput(args...)
+}
+
+// Version executes the helm version command and returns its output. TLS is ignored as there is no Tiller to talk to
+func (h *Helm3CLI) Version(tls bool) (string, error) {
+	return h.runHelmWithOutput("version", "--short")
+}
+
+// ParseHelm3Version returns the semantic version of the output of helm 3 version --short such as v3.0.2+g19e47ee
+func ParseHelm3Version(output string) string {
+	answer := strings.TrimPrefix(strings.TrimSpace(output), "v")
+	idx := strings.Index(answer, "+")
+	if idx >= 0 {
+		answer = answer[:idx]
+	}
+	return answer
+}
+
+// IsHelm3 returns true if the helmer uses helm 3
+func IsHelm3(helmer Helmer) bool {
+	_, ok := helmer.(*Helm3CLI)
+	return ok
+}
@@ -0,0 +1,134 @@
+package helm_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/helm"
+	kube_test "github.com/jenkins-x/jx/pkg/kube/mocks"
+	mocks "github.com/jenkins-x/jx/pkg/util/mocks"
+	. "github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_mocks "k8s.io/client-go/kubernetes/fake"
+)
+
+const listReleasesOutputHelm3JSON = `[{"name":"jxing","namespace":"jx","revision":"2","updated":"2019-12-17 15:30:07.629472 +0000 UTC","status":"deployed","chart":"nginx-ingress-1.3.1","app_version":"0.25.1"}]`
+
+func createHelm3(t *testing.T, expectedError error, expectedOutput string) (*helm.Helm3CLI, *mocks.MockCommander) {
+	RegisterMockTestingT(t)
+	runner := mocks.NewMockCommander()
+	When(runner.RunWithoutRetry()).ThenReturn(expectedOutput, expectedError)
+	cli := helm.NewHelm3CLIWithRunner(runner, binaryV3, cwd, true, kube_test.NewMockKuber())
+	return cli, runner
+}
+
+func TestHelm3InitIsNoop(t *testing.T) {
+	helm3, runner := createHelm3(t, nil, "")
+
+	err := helm3.Init(false, serviceAccount, namespace, true)
+	assert.NoError(t, err)
+	helm3.SetHost("localhost:44134")
+	runner.VerifyWasCalled(Never()).RunWithoutRetry()
+}
+
+func TestHelm3InstallChart(t *testing.T) {
+	helm3, runner := createHelm3(t, nil, "")
+
+	err := helm3.InstallChart(chart, releaseName, namespace, "1.2.3", 600, []string{"a=b"}, nil, "", "", "")
+	assert.NoError(t, err)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"install", releaseName, chart, "--wait", "--namespace", namespace,
+		"--timeout", "600s", "--version", "1.2.3", "--set", "a=b"})
+}
+
+func TestHelm3UpgradeChart(t *testing.T) {
+	helm3, runner := createHelm3(t, nil, "")
+
+	err := helm3.UpgradeChart(chart, releaseName, namespace, "", true, -1, false, true, nil, []string{"values.yaml"}, "", "", "")
+	assert.NoError(t, err)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"upgrade", releaseName, chart, "--namespace", namespace, "--install",
+		"--wait", "--values", "values.yaml"})
+}
+
+func TestHelm3DeleteRelease(t *testing.T) {
+	helm3, runner := createHelm3(t, nil, "")
+
+	err := helm3.DeleteRelease(namespace, releaseName, false)
+	assert.NoError(t, err)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"uninstall", releaseName, "--namespace", namespace, "--keep-history"})
+}
+
+func TestHelm3ListReleases(t *testing.T) {
+	helm3, runner := createHelm3(t, nil, listReleasesOutputHelm3JSON)
+
+	releases, keys, err := helm3.ListReleases(namespace)
+	require.NoError(t, err)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"list", "--all", "--output", "json", "--namespace", namespace})
+	assert.Equal(t, []string{"jxing"}, keys)
+	assert.Equal(t, helm.ReleaseSummary{
+		ReleaseName:   "jxing",
+		Revision:      "2",
+		Updated:       "2019-12-17 15:30:07.629472 +0000 UTC",
+		Status:        "DEPLOYED",
+		ChartFullName: "nginx-ingress-1.3.1",
+		Chart:         "nginx-ingress",
+		ChartVersion:  "1.3.1",
+		AppVersion:    "0.25.1",
+		Namespace:     "jx",
+	}, releases["jxing"])
+}
+
+func TestHelm3ListReleasesInAllNamespaces(t *testing.T) {
+	output := `[{"name":"jx","namespace":"jx-staging","revision":"1","status":"deployed","chart":"env-0.0.1"},` +
+		`{"name":"jx","namespace":"jx-production","revision":"3","status":"deployed","chart":"env-0.0.2"},` +
+		`{"name":"jxing","namespace":"kube-system","revision":"2","status":"deployed","chart":"nginx-ingress-1.3.1"}]`
+	helm3, runner := createHelm3(t, nil, output)
+
+	releases, keys, err := helm3.ListReleases("")
+	require.NoError(t, err)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"list", "--all", "--output", "json", "--all-namespaces"})
+	assert.Equal(t, []string{"jx-production/jx", "jx-staging/jx", "jxing"}, keys, "only names in more than one namespace should be qualified")
+	assert.Equal(t, "0.0.1", releases["jx-staging/jx"].ChartVersion)
+	assert.Equal(t, "0.0.2", releases["jx-production/jx"].ChartVersion)
+	assert.Equal(t, "kube-system", releases["jxing"].Namespace)
+}
+
+func TestHelm3AdoptTemplateReleaseRequiresHelm32(t *testing.T) {
+	helm3, runner := createHelm3(t, nil, "v3.0.2+g19e47ee")
+
+	err := helm3.AdoptTemplateRelease("jx-staging", "jx", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), helm.MinHelm3AdoptionVersion)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"version", "--short"})
+}
+
+func TestParseHelm3Version(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "3.0.2", helm.ParseHelm3Version("v3.0.2+g19e47ee\n"))
+	assert.Equal(t, "3.0.0-beta.3", helm.ParseHelm3Version("v3.0.0-beta.3"))
+}
+
+func TestFindReleasesToMigrate(t *testing.T) {
+	t.Parallel()
+	kubeClient := kube_mocks.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "jenkins-x.v1", Namespace: "kube-system",
+			Labels: map[string]string{"OWNER": "TILLER", "NAME": "jenkins-x"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "jenkins-x.v2", Namespace: "kube-system",
+			Labels: map[string]string{"OWNER": "TILLER", "NAME": "jenkins-x"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "jxing.v1", Namespace: "kube-system",
+			Labels: map[string]string{"OWNER": "TILLER", "NAME": "jxing"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "jx-staging-myapp", Namespace: "jx-staging",
+			Labels: map[string]string{helm.LabelReleaseName: "jx-staging"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "jx-staging"}},
+	)
+
+	tillerReleases, err := helm.FindTillerReleases(kubeClient, "kube-system")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jenkins-x", "jxing"}, tillerReleases)
+
+	templateReleases, err := helm.FindTemplateReleases(kubeClient, "jx-staging")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jx-staging"}, templateReleases)
+}
@@ -0,0 +1,198 @@
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Helm2to3PluginName the name of the helm 3 plugin which converts helm 2 releases
+	Helm2to3PluginName = "2to3"
+	// Helm2to3PluginURL the URL of the helm 3 plugin which converts helm 2 releases
+	Helm2to3PluginURL = "https://github.com/helm/helm-2to3"
+
+	// LabelTillerOwner the label on the ConfigMaps in which Tiller stores the helm 2 releases
+	LabelTillerOwner = "OWNER"
+	// LabelTillerReleaseName the label with the release name on the ConfigMaps in which Tiller stores the helm 2 releases
+	LabelTillerReleaseName = "NAME"
+
+	// AnnotationHelm3ReleaseName the annotation helm 3 uses to adopt existing resources into a release
+	AnnotationHelm3ReleaseName = "meta.helm.sh/release-name"
+	// AnnotationHelm3ReleaseNamespace the annotation helm 3 uses to adopt existing resources into a release
+	AnnotationHelm3ReleaseNamespace = "meta.helm.sh/release-namespace"
+	// LabelHelm3ManagedBy the label helm 3 requires on resources it adopts into a release
+	LabelHelm3ManagedBy = "app.kubernetes.io/managed-by"
+
+	// MinHelm3AdoptionVersion the first version of helm 3 which adopts existing resources into a release
+	MinHelm3AdoptionVersion = "3.2.0"
+)
+
+var (
+	// adoptedKinds the kinds of the namespaced resources of a template mode release adopted by helm 3
+	adoptedKinds = []string{"all", "pvc", "configmap", "sa", "role", "rolebinding", "secret", "ingress",
+		"poddisruptionbudget", "networkpolicy", "resourcequota", "limitrange"}
+	// adoptedClusterKinds the kinds of the cluster resources of a template mode release adopted by helm 3
+	adoptedClusterKinds = []string{"clusterrole", "clusterrolebinding", "customresourcedefinition",
+		"podsecuritypolicy", "priorityclass", "storageclass", "mutatingwebhookconfiguration",
+		"validatingwebhookconfiguration", "apiservice"}
+)
+
+// FindTillerReleases returns the sorted names of the helm 2 releases stored by Tiller in the given namespace
+func FindTillerReleases(kubeClient kubernetes.Interface, tillerNamespace string) ([]string, error) {
+	list, err := kubeClient.CoreV1().ConfigMaps(tillerNamespace).List(metav1.ListOptions{
+		LabelSelector: LabelTillerOwner + "=TILLER",
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the Tiller releases in namespace %s", tillerNamespace)
+	}
+	names := map[string]bool{}
+	for _, cm := range list.Items {
+		name := cm.Labels[LabelTillerReleaseName]
+		if name != "" {
+			names[name] = true
+		}
+	}
+	return sortedNames(names), nil
+}
+
+// FindTemplateReleases returns the sorted names of the releases installed with HelmTemplate in the given namespace
+func FindTemplateReleases(kubeClient kubernetes.Interface, ns string) ([]string, error) {
+	list, err := kubeClient.AppsV1().Deployments(ns).List(metav1.ListOptions{
+		LabelSelector: LabelReleaseName,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the template releases in namespace %s", ns)
+	}
+	names := map[string]bool{}
+	for _, d := range list.Items {
+		name := d.Labels[LabelReleaseName]
+		if name != "" {
+			names[name] = true
+		}
+	}
+	return sortedNames(names), nil
+}
+
+func sortedNames(names map[string]bool) []string {
+	answer := []string{}
+	for name := range names {
+		answer = append(answer, name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// EnsureHelm2to3Plugin installs the helm 3 plugin which converts helm 2 releases if it is not already installed
+func (h *Helm3CLI) EnsureHelm2to3Plugin() error {
+	output, err := h.runHelmWithOutput("plugin", "list")
+	if err != nil {
+		return errors.Wrapf(err, "listing the plugins of %s", h.Binary)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == Helm2to3PluginName {
+			return nil
+		}
+	}
+	log.Logger().Infof("Installing the %s plugin", util.ColorInfo("helm "+Helm2to3PluginName))
+	err = h.runHelm("plugin", "install", Helm2to3PluginURL)
+	if err != nil {
+		return errors.Wrapf(err, "installing the helm plugin %s", Helm2to3PluginURL)
+	}
+	return nil
+}
+
+// ConvertHelm2Release converts the helm 2 release stored by Tiller in the given namespace into a helm 3 release
+// stored as Secrets in the namespace of the release
+func (h *Helm3CLI) ConvertHelm2Release(releaseName string, tillerNamespace string, deleteHelm2Release bool, dryRun bool) error {
+	args := []string{Helm2to3PluginName, "convert", releaseName, "--tiller-ns", tillerNamespace}
+	if deleteHelm2Release {
+		args = append(args, "--delete-v2-releases")
+	}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	output, err := h.runHelmWithOutput(args...)
+	if err != nil {
+		return errors.Wrapf(err, "converting the helm 2 release %s", releaseName)
+	}
+	log.Logger().Debugf(output)
+	return nil
+}
+
+// AdoptTemplateRelease labels and annotates the resources of a release installed with HelmTemplate so that helm 3
+// adopts them into the release of the same name the next time the chart is upgraded
+func (h *Helm3CLI) AdoptTemplateRelease(ns string, releaseName string, dryRun bool) error {
+	err := h.checkAdoptionSupported()
+	if err != nil {
+		return err
+	}
+	selector := LabelReleaseName + "=" + releaseName
+
+	errList := []error{}
+	for _, kind := range adoptedKinds {
+		errList = append(errList, h.adoptResources(kind, ns, selector, releaseName, ns, dryRun)...)
+	}
+	selector += "," + LabelNamespace + "=" + ns
+	for _, kind := range adoptedClusterKinds {
+		errList = append(errList, h.adoptResources(kind, "", selector, releaseName, ns, dryRun)...)
+	}
+	return util.CombineErrors(errList...)
+}
+
+// checkAdoptionSupported returns an error if the version of helm 3 is too old to adopt existing resources
+func (h *Helm3CLI) checkAdoptionSupported() error {
+	output, err := h.Version(false)
+	if err != nil {
+		return errors.Wrapf(err, "getting the version of %s", h.Binary)
+	}
+	version, err := semver.ParseTolerant(ParseHelm3Version(output))
+	if err != nil {
+		return errors.Wrapf(err, "parsing the version %s of %s", output, h.Binary)
+	}
+	if version.LT(semver.MustParse(MinHelm3AdoptionVersion)) {
+		return fmt.Errorf("%s %s cannot adopt the resources of template mode releases, please upgrade it to %s or later",
+			h.Binary, version.String(), MinHelm3AdoptionVersion)
+	}
+	return nil
+}
+
+func (h *Helm3CLI) adoptResources(kind string, ns string, selector string, releaseName string, releaseNamespace string, dryRun bool) []error {
+	commands := [][]string{
+		{"annotate", kind, "-l", selector, "--overwrite",
+			AnnotationHelm3ReleaseName + "=" + releaseName,
+			AnnotationHelm3ReleaseNamespace + "=" + releaseNamespace},
+		{"label", kind, "-l", selector, "--overwrite", LabelHelm3ManagedBy + "=Helm"},
+	}
+	errList := []error{}
+	for _, args := range commands {
+		if ns != "" {
+			args = append(args, "--namespace", ns)
+		}
+		if dryRun {
+			args = append(args, "--dry-run")
+		}
+		h.Runner.SetDir(h.CWD)
+		h.Runner.SetName("kubectl")
+		h.Runner.SetArgs(args)
+		output, err := h.Runner.RunWithoutRetry()
+		if err != nil {
+			// kubectl fails when there are no resources of the kind in the release or the cluster lacks the kind
+			if strings.Contains(output, "no objects passed to") || strings.Contains(output, "doesn't have a resource type") {
+				continue
+			}
+			errList = append(errList, errors.Wrapf(err, "running kubectl %s", strings.Join(args, " ")))
+			continue
+		}
+		log.Logger().Debugf(output)
+	}
+	return errList
+}
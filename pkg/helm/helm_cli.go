@@ -15,6 +15,7 @@ import (
 
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/versionstream"
 	"github.com/pkg/errors"
 )
 
@@ -26,6 +27,14 @@ type HelmCLI struct {
 	Runner     util.Commander
 	Debug      bool
 	kuber      kube.Kuber
+
+	// ChartProvenance returns the provenance policy and keyring of the repository of a chart which is fetched or
+	// installed. If it is nil the charts are not verified
+	ChartProvenance func(chart string, repo string) (versionstream.ProvenancePolicy, string, error)
+
+	// InstallHelm3 installs the helm 3 binary which is required to fetch charts from OCI registries. If it is nil
+	// helm 3 has to be installed already
+	InstallHelm3 func() error
 }
 
 // NewHelmCLIWithRunner creates a new HelmCLI interface for the given runner
@@ -148,10 +157,14 @@ func (h *HelmCLI) RemoveRepo(repo string) error {
 // ListRepos list the installed helm repos together with their URL
 func (h *HelmCLI) ListRepos() (map[string]string, error) {
 	output, err := h.runHelmWithOutput("repo", "list")
+	repos := map[string]string{}
 	if err != nil {
+		// helm 3 fails when no repositories have been added
+		if strings.Contains(output, "no repositories to show") {
+			return repos, nil
+		}
 		return nil, errors.Wrap(err, "failed to list repositories")
 	}
-	repos := map[string]string{}
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	for _, line := range lines[1:] {
 		line = strings.TrimSpace(line)
@@ -265,7 +278,11 @@ func (h *HelmCLI) BuildDependency() error {
 // InstallChart installs a helm chart according with the given flags
 func (h *HelmCLI) InstallChart(chart string, releaseName string, ns string, version string, timeout int,
 	values []string, valueFiles []string, repo string, username string, password string) error {
-	var err error
+	chart, cleanup, err := h.installableChart(chart, version, repo, username, password)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
 	currentNamespace := ""
 	if h.Binary == "helm3" {
 		log.Logger().Warnf("Manually switching namespace to for helm3 alpha - %s, this code should be removed once --namespaces is implemented", ns)
@@ -334,9 +351,20 @@ func (h *HelmCLI) InstallChart(chart string, releaseName string, ns string, vers
 // FetchChart fetches a Helm Chart
 func (h *HelmCLI) FetchChart(chart string, version string, untar bool, untardir string, repo string,
 	username string, password string) error {
+	if IsOCIChart(chart) || IsOCIChart(repo) {
+		if untardir == "" {
+			untardir = "."
+		}
+		_, err := h.fetchOCIChart(chart, version, untar, untardir, repo, username, password)
+		return err
+	}
+	fetched, err := h.fetchVerifiedChartTo(chart, version, untar, untardir, repo, username, password)
+	if fetched || err != nil {
+		return err
+	}
 	args := []string{}
 	args = append(args, "fetch", chart)
-	repo, err := addUsernamePasswordToURL(repo, username, password)
+	repo, err = addUsernamePasswordToURL(repo, username, password)
 	if err != nil {
 		return err
 	}
@@ -396,7 +424,11 @@ func (h *HelmCLI) Template(chart string, releaseName string, ns string, outDir s
 
 // UpgradeChart upgrades a helm chart according with given helm flags
 func (h *HelmCLI) UpgradeChart(chart string, releaseName string, ns string, version string, install bool, timeout int, force bool, wait bool, values []string, valueFiles []string, repo string, username string, password string) error {
-	var err error
+	chart, cleanup, err := h.installableChart(chart, version, repo, username, password)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
 	currentNamespace := ""
 	if h.Binary == "helm3" {
 		log.Logger().Warnf("Manually switching namespace to for helm3 alpha - %s, this code should be removed once --namespaces is implemented", ns)
@@ -136,6 +136,14 @@ func TestListRepos(t *testing.T) {
 	}
 }
 
+func TestListReposWithoutRepositories(t *testing.T) {
+	helm, _ := createHelmWithVersion(t, helm.V3, fmt.Errorf("exit status 1"), "Error: no repositories to show")
+
+	repos, err := helm.ListRepos()
+	assert.NoError(t, err, "should not fail when there are no helm repos")
+	assert.Empty(t, repos)
+}
+
 func TestIsRepoMissing(t *testing.T) {
 	expectedArgs := []string{"repo", "list"}
 	helm, runner := createHelm(t, nil, listRepoOutput)
@@ -516,24 +516,27 @@ func InspectChart(chart string, version string, repo string, username string, pa
 }
 
 type InstallChartOptions struct {
-	Dir            string
-	ReleaseName    string
-	Chart          string
-	Version        string
-	Ns             string
-	HelmUpdate     bool
-	SetValues      []string
-	ValueFiles     []string
-	Repository     string
-	Username       string
-	Password       string
-	VersionsDir    string
-	VersionsGitURL string
-	VersionsGitRef string
-	InstallOnly    bool
-	NoForce        bool
-	Wait           bool
-	UpgradeOnly    bool
+	Dir             string
+	ReleaseName     string
+	Chart           string
+	Version         string
+	Ns              string
+	HelmUpdate      bool
+	SetValues       []string
+	ValueFiles      []string
+	Repository      string
+	Username        string
+	Password        string
+	VersionsDir     string
+	VersionsGitURL  string
+	VersionsGitRef  string
+	VersionResolver *versionstream.VersionResolver
+	InstallOnly     bool
+	NoForce         bool
+	Wait            bool
+	UpgradeOnly     bool
+	// DryRun when enabled the repositories are not updated, no secrets are read and no namespace is created
+	DryRun bool
 }
 
 // InstallFromChartOptions uses the helmer and kubeClient interfaces to install the chart from the options,
@@ -542,6 +545,13 @@ type InstallChartOptions struct {
 func InstallFromChartOptions(options InstallChartOptions, helmer Helmer, kubeClient kubernetes.Interface,
 	installTimeout string, secretURLClient secreturl.Client) error {
 	chart := options.Chart
+	if options.Version == "" && options.VersionResolver != nil {
+		var err error
+		options.Version, err = options.VersionResolver.StableVersionNumber(versionstream.KindChart, chart)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve the stable version of chart %s", chart)
+		}
+	}
 	if options.Version == "" {
 		versionsDir := options.VersionsDir
 		if versionsDir == "" {
@@ -553,7 +563,7 @@ func InstallFromChartOptions(options InstallChartOptions, helmer Helmer, kubeCli
 			return errors.Wrapf(err, "failed to load stable version in dir %s for chart %s", versionsDir, chart)
 		}
 	}
-	if options.HelmUpdate {
+	if options.HelmUpdate && !options.DryRun {
 		log.Logger().Debugf("Updating Helm repository...")
 		err := helmer.UpdateRepo()
 		if err != nil {
@@ -561,12 +571,14 @@ func InstallFromChartOptions(options InstallChartOptions, helmer Helmer, kubeCli
 		}
 		log.Logger().Debugf("Helm repository update done.")
 	}
-	cleanup, err := DecorateWithSecrets(&options, secretURLClient)
-	defer cleanup()
-	if err != nil {
-		return errors.WithStack(err)
+	if !options.DryRun {
+		cleanup, err := DecorateWithSecrets(&options, secretURLClient)
+		defer cleanup()
+		if err != nil {
+			return errors.WithStack(err)
+		}
 	}
-	if options.Ns != "" {
+	if options.Ns != "" && !options.DryRun {
 		annotations := map[string]string{"jenkins-x.io/created-by": "Jenkins X"}
 		kube.EnsureNamespaceCreated(kubeClient, options.Ns, nil, annotations)
 	}
@@ -904,7 +916,10 @@ func RenderReleasesAsTable(releases map[string]ReleaseSummary, sortedKeys []stri
 		t.AddRow(info.ReleaseName, info.Revision, info.Updated, info.Status, info.ChartFullName, info.AppVersion,
 			info.Namespace)
 	}
-	t.Render()
+	err := t.Render()
+	if err != nil {
+		return "", err
+	}
 	writer.Flush()
 	return buffer.String(), nil
 }
@@ -0,0 +1,223 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/pkg/errors"
+)
+
+// ProvenanceFileExtension the extension of the provenance file of a packaged chart
+const ProvenanceFileExtension = ".prov"
+
+// DefaultSecretKeyring returns the keyring with the secret keys generated by jx step gpg credentials
+func DefaultSecretKeyring() string {
+	return filepath.Join(util.HomeDir(), ".gnupg", "secring.gpg")
+}
+
+// DefaultPublicKeyring returns the keyring with the public keys generated by jx step gpg credentials
+func DefaultPublicKeyring() string {
+	return filepath.Join(util.HomeDir(), ".gnupg", "pubring.gpg")
+}
+
+// ProvenanceFile returns the provenance file of the given chart archive
+func ProvenanceFile(chartArchive string) string {
+	return chartArchive + ProvenanceFileExtension
+}
+
+// IsMissingProvenance returns true if there is no provenance file next to the chart archive so it is not signed
+func IsMissingProvenance(chartArchive string) (bool, error) {
+	exists, err := util.FileExists(ProvenanceFile(chartArchive))
+	if err != nil {
+		return false, errors.Wrapf(err, "checking the provenance file of chart %s", chartArchive)
+	}
+	return !exists, nil
+}
+
+// PackageSignedChart packages the chart from the current working directory and signs it with the given key
+// creating a provenance file next to the chart archive
+func (h *HelmCLI) PackageSignedChart(key string, keyring string) error {
+	if keyring == "" {
+		keyring = DefaultSecretKeyring()
+	}
+	return h.runHelm("package", "--sign", "--key", key, "--keyring", keyring, h.CWD)
+}
+
+// VerifyChart verifies the provenance file of the given chart archive using the public keys of the keyring
+func (h *HelmCLI) VerifyChart(chartArchive string, keyring string) error {
+	if keyring == "" {
+		keyring = DefaultPublicKeyring()
+	}
+	output, err := h.runHelmWithOutput("verify", chartArchive, "--keyring", keyring)
+	if err != nil {
+		return errors.Wrapf(err, "verifying the provenance of chart %s: %s", chartArchive, output)
+	}
+	return nil
+}
+
+// PackageSignedChart packages the chart from the current working directory and signs it with the given key
+// creating a provenance file next to the chart archive
+func (h *HelmTemplate) PackageSignedChart(key string, keyring string) error {
+	return h.Client.PackageSignedChart(key, keyring)
+}
+
+// VerifyChart verifies the provenance file of the given chart archive using the public keys of the keyring
+func (h *HelmTemplate) VerifyChart(chartArchive string, keyring string) error {
+	return h.Client.VerifyChart(chartArchive, keyring)
+}
+
+// chartProvenance returns the provenance policy and keyring of the repository of a chart
+func (h *HelmCLI) chartProvenance(chart string, repo string) (versionstream.ProvenancePolicy, string, error) {
+	if h.ChartProvenance == nil {
+		return versionstream.ProvenanceNone, "", nil
+	}
+	policy, keyring, err := h.ChartProvenance(chart, repo)
+	if err != nil {
+		return versionstream.ProvenanceNone, "", errors.Wrapf(err, "resolving the provenance policy of chart %s", chart)
+	}
+	if policy == "" {
+		policy = versionstream.ProvenanceNone
+	}
+	if keyring == "" {
+		keyring = DefaultPublicKeyring()
+	}
+	return policy, keyring, nil
+}
+
+// fetchVerifiedChart fetches the archive of a chart along with its provenance file into a temporary directory and
+// verifies it according to the provenance policy of its repository. Charts which are tampered with are always
+// rejected, unsigned charts only if the repository requires signed charts. The archive is blank if the repository
+// has no provenance policy. The returned function removes the temporary directory
+func (h *HelmCLI) fetchVerifiedChart(chart string, version string, repo string, username string, password string) (string, func(), error) {
+	cleanup := func() {}
+	policy, keyring, err := h.chartProvenance(chart, repo)
+	if err != nil || policy == versionstream.ProvenanceNone {
+		return "", cleanup, err
+	}
+	dir, err := ioutil.TempDir("", "jx-helm-verify-")
+	if err != nil {
+		return "", cleanup, errors.Wrap(err, "creating a temporary directory")
+	}
+	cleanup = func() {
+		os.RemoveAll(dir)
+	}
+	repo, err = addUsernamePasswordToURL(repo, username, password)
+	if err != nil {
+		return "", cleanup, err
+	}
+	args := []string{"fetch", chart, "-d", dir, "--prov"}
+	if repo != "" {
+		args = append(args, "--repo", repo)
+	}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	if username != "" {
+		args = append(args, "--username", username)
+	}
+	if password != "" {
+		args = append(args, "--password", password)
+	}
+	err = h.runHelm(args...)
+	if err != nil {
+		return "", cleanup, errors.Wrapf(err, "fetching chart %s", chart)
+	}
+	archives, err := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if err != nil || len(archives) != 1 {
+		return "", cleanup, fmt.Errorf("could not find the archive of chart %s in %s", chart, dir)
+	}
+	archive := archives[0]
+	missing, err := IsMissingProvenance(archive)
+	if err != nil {
+		return "", cleanup, err
+	}
+	if missing {
+		if policy == versionstream.ProvenanceRequired {
+			return "", cleanup, fmt.Errorf("chart %s is not signed but its repository requires signed charts", chart)
+		}
+		log.Logger().Warnf("Chart %s is not signed so its provenance cannot be verified", chart)
+		return archive, cleanup, nil
+	}
+	err = h.VerifyChart(archive, keyring)
+	if err != nil {
+		return "", cleanup, err
+	}
+	log.Logger().Debugf("Verified the provenance of chart %s", chart)
+	return archive, cleanup, nil
+}
+
+// fetchVerifiedChartTo fetches and verifies a chart from a repository with a provenance policy into the directory
+// relative to the current working directory, returning false if the repository has no provenance policy so that the
+// chart is fetched as usual
+func (h *HelmCLI) fetchVerifiedChartTo(chart string, version string, untar bool, dir string, repo string,
+	username string, password string) (bool, error) {
+	archive, cleanup, err := h.fetchVerifiedChart(chart, version, repo, username, password)
+	defer cleanup()
+	if err != nil {
+		return true, err
+	}
+	if archive == "" {
+		return false, nil
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) && h.CWD != "" {
+		dir = filepath.Join(h.CWD, dir)
+	}
+	if untar {
+		err = util.UnTargzAll(archive, dir)
+		if err != nil {
+			return true, errors.Wrapf(err, "extracting chart %s into %s", chart, dir)
+		}
+		return true, nil
+	}
+	err = util.CopyFile(archive, filepath.Join(dir, filepath.Base(archive)))
+	if err != nil {
+		return true, errors.Wrapf(err, "copying chart %s into %s", chart, dir)
+	}
+	return true, nil
+}
+
+// installableChart returns the chart to install or upgrade which is the verified archive of the chart if it is
+// fetched from a repository with a provenance policy. Helm installs a chart on the local file system in preference to
+// the repository. The returned function removes the archive once it is installed
+func (h *HelmCLI) installableChart(chart string, version string, repo string, username string, password string) (string, func(), error) {
+	path := chart
+	if !filepath.IsAbs(path) && h.CWD != "" {
+		path = filepath.Join(h.CWD, path)
+	}
+	exists, err := util.FileExists(path)
+	if err != nil || exists {
+		return chart, func() {}, err
+	}
+	if IsOCIChart(chart) || IsOCIChart(repo) {
+		return chart, func() {}, h.checkOCIChartProvenance(chart, repo)
+	}
+	archive, cleanup, err := h.fetchVerifiedChart(chart, version, repo, username, password)
+	if err != nil || archive == "" {
+		return chart, cleanup, err
+	}
+	return archive, cleanup, nil
+}
+
+// checkOCIChartProvenance rejects a chart from an OCI registry if its repository requires signed charts as charts in
+// OCI registries have no provenance files which could be verified
+func (h *HelmCLI) checkOCIChartProvenance(chart string, repo string) error {
+	policy, _, err := h.chartProvenance(chart, repo)
+	if err != nil {
+		return err
+	}
+	switch policy {
+	case versionstream.ProvenanceRequired:
+		return fmt.Errorf("chart %s is in an OCI registry without provenance files but its repository requires signed charts", chart)
+	case versionstream.ProvenanceVerify:
+		log.Logger().Warnf("Chart %s is in an OCI registry without provenance files so its provenance cannot be verified", chart)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package helm_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/util"
+	mocks "github.com/jenkins-x/jx/pkg/util/mocks"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	. "github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageSignedChart(t *testing.T) {
+	helm, runner := createHelm(t, nil, "")
+
+	err := helm.PackageSignedChart("Jenkins X Bot", "/keys/secring.gpg")
+
+	assert.NoError(t, err, "should package a signed chart without any error")
+	verifyArgs(t, helm, runner, "package", "--sign", "--key", "Jenkins X Bot", "--keyring", "/keys/secring.gpg", cwd)
+}
+
+func TestIsMissingProvenance(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "test-provenance")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "test-chart-1.0.0.tgz")
+
+	missing, err := helm.IsMissingProvenance(archive)
+	require.NoError(t, err)
+	assert.True(t, missing, "the chart should not be signed without a provenance file")
+
+	err = ioutil.WriteFile(helm.ProvenanceFile(archive), []byte("signature"), util.DefaultWritePermissions)
+	require.NoError(t, err)
+	missing, err = helm.IsMissingProvenance(archive)
+	require.NoError(t, err)
+	assert.False(t, missing, "the chart should be signed with a provenance file")
+}
+
+// fakeHelmRunner creates the archive of the chart in the destination of helm fetch along with its provenance file if
+// the chart is signed and returns the result of helm verify
+func fakeHelmRunner(t *testing.T, runner *mocks.MockCommander, signed bool, verifyOutput string, verifyErr error) {
+	When(runner.RunWithoutRetry()).Then(func(params []Param) ReturnValues {
+		args := runner.VerifyWasCalled(AtLeast(1)).SetArgs(AnyStringSlice()).GetCapturedArguments()
+		switch args[0] {
+		case "fetch":
+			dir := args[util.StringArrayIndex(args, "-d")+1]
+			archive := filepath.Join(dir, chart+"-1.0.0.tgz")
+			writeChartArchive(t, archive)
+			if signed {
+				err := ioutil.WriteFile(helm.ProvenanceFile(archive), []byte("signature"), util.DefaultWritePermissions)
+				require.NoError(t, err)
+			}
+		case "verify":
+			return []ReturnValue{verifyOutput, verifyErr}
+		}
+		return []ReturnValue{"", nil}
+	})
+}
+
+func writeChartArchive(t *testing.T, archive string) {
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	data := []byte("name: " + chart + "\nversion: 1.0.0\n")
+	err = tw.WriteHeader(&tar.Header{Name: chart + "/Chart.yaml", Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	require.NoError(t, err)
+	_, err = tw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func withProvenance(cli *helm.HelmCLI, policy versionstream.ProvenancePolicy) {
+	cli.ChartProvenance = func(chart string, repo string) (versionstream.ProvenancePolicy, string, error) {
+		return policy, "/keys/pubring.gpg", nil
+	}
+}
+
+func createHelmTemplateWithProvenance(t *testing.T, cli *helm.HelmCLI, policy versionstream.ProvenancePolicy) (*helm.HelmTemplate, string) {
+	dir, err := ioutil.TempDir("", "test-fetch-chart")
+	require.NoError(t, err)
+	withProvenance(cli, policy)
+	return helm.NewHelmTemplate(cli, dir, nil, namespace), dir
+}
+
+// calledArgs returns the arguments of the helm commands which were run with the given command
+func calledArgs(runner *mocks.MockCommander, command string) [][]string {
+	answer := [][]string{}
+	for _, args := range runner.VerifyWasCalled(AtLeast(1)).SetArgs(AnyStringSlice()).GetAllCapturedArguments() {
+		if len(args) > 0 && args[0] == command {
+			answer = append(answer, args)
+		}
+	}
+	return answer
+}
+
+func TestFetchChartVerifiesProvenance(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	fakeHelmRunner(t, runner, true, "", nil)
+	templater, dir := createHelmTemplateWithProvenance(t, cli, versionstream.ProvenanceRequired)
+	defer os.RemoveAll(dir)
+
+	err := templater.FetchChart(chart, "1.0.0", true, dir, repoURL, "", "")
+
+	require.NoError(t, err, "should fetch a signed chart")
+	fetches := calledArgs(runner, "fetch")
+	require.Len(t, fetches, 1)
+	assert.Equal(t, []string{"fetch", chart, "-d"}, fetches[0][:3])
+	assert.Equal(t, []string{"--prov", "--repo", repoURL, "--version", "1.0.0"}, fetches[0][4:])
+	verifies := calledArgs(runner, "verify")
+	require.Len(t, verifies, 1)
+	assert.Equal(t, []string{"--keyring", "/keys/pubring.gpg"}, verifies[0][2:])
+	assert.FileExists(t, filepath.Join(dir, chart, "Chart.yaml"), "the verified chart should be extracted")
+}
+
+func TestFetchChartRejectsUnsignedChart(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	fakeHelmRunner(t, runner, false, "", nil)
+	templater, dir := createHelmTemplateWithProvenance(t, cli, versionstream.ProvenanceRequired)
+	defer os.RemoveAll(dir)
+
+	err := templater.FetchChart(chart, "1.0.0", true, dir, repoURL, "", "")
+
+	require.Error(t, err, "should reject an unsigned chart")
+	assert.Contains(t, err.Error(), "is not signed")
+	assert.Empty(t, calledArgs(runner, "verify"))
+}
+
+func TestFetchChartAllowsUnsignedChartWhenVerifying(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	fakeHelmRunner(t, runner, false, "", nil)
+	templater, dir := createHelmTemplateWithProvenance(t, cli, versionstream.ProvenanceVerify)
+	defer os.RemoveAll(dir)
+
+	err := templater.FetchChart(chart, "1.0.0", true, dir, repoURL, "", "")
+
+	require.NoError(t, err, "should fetch an unsigned chart")
+	assert.Empty(t, calledArgs(runner, "verify"))
+	assert.FileExists(t, filepath.Join(dir, chart, "Chart.yaml"), "the unsigned chart should be extracted")
+}
+
+func TestFetchChartRejectsTamperedChart(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	fakeHelmRunner(t, runner, true, "Error: openpgp: invalid signature: hash tag doesn't match", errors.New("exit status 1"))
+	templater, dir := createHelmTemplateWithProvenance(t, cli, versionstream.ProvenanceVerify)
+	defer os.RemoveAll(dir)
+
+	err := templater.FetchChart(chart, "1.0.0", true, dir, repoURL, "", "")
+
+	require.Error(t, err, "should reject a tampered chart")
+	assert.Contains(t, err.Error(), "invalid signature")
+}
+
+func TestHelmCLIFetchChartVerifiesProvenance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-fetch-chart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cli, runner := createHelmWithCwdAndHelmVersion(t, helm.V2, dir, nil, "")
+	fakeHelmRunner(t, runner, true, "", nil)
+	withProvenance(cli, versionstream.ProvenanceRequired)
+
+	err = cli.FetchChart(chart, "1.0.0", false, "", repoURL, "", "")
+
+	require.NoError(t, err, "should fetch a signed chart")
+	assert.Len(t, calledArgs(runner, "verify"), 1)
+	assert.FileExists(t, filepath.Join(dir, chart+"-1.0.0.tgz"), "the verified archive should be copied")
+}
+
+func TestInstallChartInstallsTheVerifiedArchive(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	fakeHelmRunner(t, runner, true, "", nil)
+	withProvenance(cli, versionstream.ProvenanceRequired)
+
+	err := cli.InstallChart(chart, releaseName, namespace, "1.0.0", -1, nil, nil, repoURL, "", "")
+
+	require.NoError(t, err, "should install a signed chart")
+	assert.Len(t, calledArgs(runner, "verify"), 1)
+	installs := calledArgs(runner, "install")
+	require.Len(t, installs, 1)
+	installed := installs[0][util.StringArrayIndex(installs[0], namespace)+1]
+	assert.True(t, strings.HasSuffix(installed, chart+"-1.0.0.tgz"), "should install the verified archive rather than %s", installed)
+}
+
+func TestUpgradeChartRejectsUnsignedChart(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	fakeHelmRunner(t, runner, false, "", nil)
+	withProvenance(cli, versionstream.ProvenanceRequired)
+
+	err := cli.UpgradeChart(chart, releaseName, namespace, "1.0.0", true, -1, false, false, nil, nil, repoURL, "", "")
+
+	require.Error(t, err, "should reject an unsigned chart")
+	assert.Empty(t, calledArgs(runner, "upgrade"))
+}
@@ -0,0 +1,266 @@
+package helm
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReleaseTestResult the result of running a test hook of a release
+type ReleaseTestResult struct {
+	Name     string
+	Kind     string
+	Passed   bool
+	Message  string
+	Output   string
+	Duration time.Duration
+}
+
+// TestHooks returns the test hooks of a chart sorted by their weight and then their name
+func TestHooks(hooks []*HelmHook) []*HelmHook {
+	answer := []*HelmHook{}
+	for _, hook := range hooks {
+		for _, name := range []string{hookTest, hookTestSuccess, hookTestFailure} {
+			if util.StringArrayIndex(hook.Hooks, name) >= 0 {
+				answer = append(answer, hook)
+				break
+			}
+		}
+	}
+	sortHooks(answer)
+	return answer
+}
+
+// RunTests renders the chart of a release then runs its test hooks against the release returning the result of each
+// test Pod or Job. If cleanup is enabled the test resources are removed once they complete
+func (h *HelmTemplate) RunTests(chart string, releaseName string, ns string, version string, values []string,
+	valueFiles []string, timeout time.Duration, cleanup bool) ([]*ReleaseTestResult, error) {
+	if ns == "" {
+		ns = h.Namespace
+	}
+	err := h.clearOutputDir(releaseName)
+	if err != nil {
+		return nil, err
+	}
+	outputDir, _, _, err := h.getDirectories(releaseName)
+	if err != nil {
+		return nil, err
+	}
+	chartDir := chart
+	if !filepath.IsAbs(chartDir) {
+		chartDir = filepath.Join(h.CWD, chart)
+	}
+	err = h.Client.Template(chartDir, releaseName, ns, outputDir, false, values, valueFiles)
+	if err != nil {
+		return nil, err
+	}
+	metadata, versionText, err := h.getChart(chartDir, version)
+	if err != nil {
+		return nil, err
+	}
+	helmHooks, err := h.addLabelsToFiles(chart, releaseName, versionText, metadata, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*ReleaseTestResult{}
+	for _, hook := range TestHooks(helmHooks) {
+		result, err := h.runTestHook(hook, ns, timeout)
+		if err != nil {
+			return results, err
+		}
+		if result == nil {
+			continue
+		}
+		results = append(results, result)
+
+		deletePolicy := hookFailed
+		if result.Passed {
+			deletePolicy = hookSucceeded
+		}
+		if cleanup || util.StringArrayIndex(hook.HookDeletePolicies, deletePolicy) >= 0 {
+			err = h.kubectlDeleteFile(ns, hook.File)
+			if err != nil {
+				log.Logger().Warnf("Failed to remove the test %s %s: %s", hook.Kind, hook.Name, err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// runTestHook applies the test hook and waits for it to complete. Resources other than Pods and Jobs are only applied
+// so that the tests can use them and have no result
+func (h *HelmTemplate) runTestHook(hook *HelmHook, ns string, timeout time.Duration) (*ReleaseTestResult, error) {
+	// test Pods cannot be updated so any previous run is always replaced
+	_, err := h.runKubectlWithOutput("delete", "-f", hook.File, "--namespace", ns, "--ignore-not-found", "--wait")
+	if err != nil {
+		return nil, errors.Wrapf(err, "removing the previous run of test %s %s", hook.Kind, hook.Name)
+	}
+	log.Logger().Infof("Running test %s %s", hook.Kind, util.ColorInfo(hook.Name))
+	start := time.Now()
+	err = h.kubectlApplyFile(ns, hookTest, false, true, false, hook.File)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the test %s %s", hook.Kind, hook.Name)
+	}
+
+	expectFailure := util.StringArrayIndex(hook.Hooks, hookTestFailure) >= 0
+	result := &ReleaseTestResult{
+		Name: hook.Name,
+		Kind: hook.Kind,
+	}
+	logsName := hook.Name
+	switch hook.Kind {
+	case "Pod":
+		err = kube.WaitForPodNameToBeComplete(h.KubeClient, ns, hook.Name, timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "waiting for test Pod %s", hook.Name)
+		}
+		pod, err := h.KubeClient.CoreV1().Pods(ns).Get(hook.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting test Pod %s", hook.Name)
+		}
+		result.Passed = pod.Status.Phase == v1.PodSucceeded
+		if !kube.IsPodCompleted(pod) {
+			result.Message = fmt.Sprintf("timed out after %s with Pod phase %s", timeout.String(), string(pod.Status.Phase))
+		} else if !result.Passed {
+			result.Message = fmt.Sprintf("Pod phase %s", string(pod.Status.Phase))
+		}
+	case "Job":
+		err = kube.WaitForJobToComplete(h.KubeClient, ns, hook.Name, timeout, false)
+		if err != nil {
+			result.Message = err.Error()
+		}
+		job, err := h.KubeClient.BatchV1().Jobs(ns).Get(hook.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting test Job %s", hook.Name)
+		}
+		result.Passed = kube.IsJobSucceeded(job)
+		if !result.Passed && result.Message == "" {
+			result.Message = fmt.Sprintf("Job failed with %d failed Pods", job.Status.Failed)
+		}
+		logsName = "job/" + hook.Name
+	default:
+		return nil, nil
+	}
+	result.Duration = time.Since(start)
+	if expectFailure {
+		result.Passed = !result.Passed
+		if result.Passed {
+			result.Message = ""
+		} else if result.Message == "" {
+			result.Message = "the test was expected to fail"
+		}
+	}
+
+	output, err := h.runKubectlWithOutput("logs", logsName, "--namespace", ns)
+	if err != nil {
+		log.Logger().Warnf("Failed to get the logs of test %s %s: %s", hook.Kind, hook.Name, err)
+	}
+	result.Output = output
+
+	if result.Passed {
+		log.Logger().Infof("PASSED: %s", util.ColorInfo(hook.Name))
+	} else {
+		log.Logger().Infof("FAILED: %s %s", util.ColorError(hook.Name), result.Message)
+	}
+	return result, nil
+}
+
+// TestRelease runs the tests of a release via helm test returning the result of each test
+func (h *HelmCLI) TestRelease(ns string, releaseName string, timeout time.Duration, cleanup bool) ([]*ReleaseTestResult, error) {
+	args := []string{"test", releaseName}
+	if timeout > 0 {
+		args = append(args, "--timeout", fmt.Sprintf("%d", int(timeout.Seconds())))
+	}
+	if cleanup {
+		args = append(args, "--cleanup")
+	}
+	return h.runTests(releaseName, args...)
+}
+
+// TestRelease runs the tests of a release via helm test returning the result of each test
+func (h *Helm3CLI) TestRelease(ns string, releaseName string, timeout time.Duration, cleanup bool) ([]*ReleaseTestResult, error) {
+	args := []string{"test", releaseName}
+	if ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+	if timeout > 0 {
+		args = append(args, "--timeout", timeout.String())
+	}
+	results, err := h.runTests(releaseName, args...)
+	if cleanup {
+		for _, result := range results {
+			_, err2 := h.runKubectlWithOutput("delete", strings.ToLower(result.Kind), result.Name, "--namespace", ns, "--ignore-not-found")
+			if err2 != nil {
+				log.Logger().Warnf("Failed to remove the test %s %s: %s", result.Kind, result.Name, err2)
+			}
+		}
+	}
+	return results, err
+}
+
+func (h *HelmCLI) runTests(releaseName string, args ...string) ([]*ReleaseTestResult, error) {
+	start := time.Now()
+	output, err := h.runHelmWithOutput(args...)
+	results := ParseHelmTestOutput(output)
+	if len(results) == 0 && err != nil {
+		return nil, errors.Wrapf(err, "testing release %s: %s", releaseName, output)
+	}
+	duration := time.Since(start)
+	for _, result := range results {
+		result.Duration = duration
+		if !result.Passed {
+			result.Output = output
+		}
+	}
+	return results, nil
+}
+
+func (h *HelmCLI) runKubectlWithOutput(args ...string) (string, error) {
+	h.Runner.SetDir(h.CWD)
+	h.Runner.SetName("kubectl")
+	h.Runner.SetArgs(args)
+	return h.Runner.RunWithoutRetry()
+}
+
+// ParseHelmTestOutput parses the output of helm test of both helm 2 and helm 3 into the test results
+func ParseHelmTestOutput(output string) []*ReleaseTestResult {
+	results := []*ReleaseTestResult{}
+	var current *ReleaseTestResult
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "PASSED:"):
+			name := strings.TrimSpace(strings.TrimPrefix(line, "PASSED:"))
+			results = append(results, &ReleaseTestResult{Name: name, Kind: "Pod", Passed: true})
+		case strings.HasPrefix(line, "FAILED:"):
+			name := strings.TrimSpace(strings.Split(strings.TrimPrefix(line, "FAILED:"), ",")[0])
+			results = append(results, &ReleaseTestResult{Name: name, Kind: "Pod", Message: "Pod phase Failed"})
+		case strings.HasPrefix(line, "TEST SUITE:"):
+			name := strings.TrimSpace(strings.TrimPrefix(line, "TEST SUITE:"))
+			if name == "None" {
+				continue
+			}
+			current = &ReleaseTestResult{Name: name, Kind: "Pod"}
+			results = append(results, current)
+		case strings.HasPrefix(line, "Phase:") && current != nil:
+			phase := strings.TrimSpace(strings.TrimPrefix(line, "Phase:"))
+			current.Passed = phase == string(v1.PodSucceeded)
+			if !current.Passed {
+				current.Message = "Pod phase " + phase
+			}
+			current = nil
+		}
+	}
+	return results
+}
@@ -0,0 +1,121 @@
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const weightedHooksYaml = `apiVersion: v1
+kind: Pod
+metadata:
+  name: test-connection
+  annotations:
+    helm.sh/hook: test-success
+    helm.sh/hook-weight: "5"
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate-db
+  annotations:
+    helm.sh/hook: pre-upgrade,pre-rollback
+    helm.sh/hook-weight: "-5"
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: backup-db
+  annotations:
+    helm.sh/hook: pre-upgrade
+    helm.sh/hook-weight: "10"
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-api
+  annotations:
+    helm.sh/hook: test
+    helm.sh/hook-weight: "1"
+`
+
+func TestHooksAreSortedByWeight(t *testing.T) {
+	t.Parallel()
+
+	baseDir, err := ioutil.TempDir("", "test-hook-weights")
+	require.NoError(t, err)
+	defer os.RemoveAll(baseDir)
+
+	outDir := filepath.Join(baseDir, "output")
+	hooksDir := filepath.Join(baseDir, "hooks")
+	require.NoError(t, os.MkdirAll(outDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outDir, "hooks.yaml"), []byte(weightedHooksYaml), 0644))
+
+	hooks, err := addLabelsToChartYaml(outDir, hooksDir, "mychart", "myrelease", "1.0.0", nil, "jx")
+	require.NoError(t, err)
+	require.Len(t, hooks, 4)
+
+	assert.Equal(t, []string{"migrate-db", "backup-db"}, hookNames(MatchingHooks(hooks, hookPreUpgrade, "")))
+	assert.Equal(t, []string{"migrate-db"}, hookNames(MatchingHooks(hooks, hookPreRollback, "")))
+	assert.Equal(t, []string{"test-api", "test-connection"}, hookNames(TestHooks(hooks)))
+}
+
+func TestMatchingHooksWithSameWeightAreSortedByName(t *testing.T) {
+	t.Parallel()
+
+	hooks := []*HelmHook{
+		NewHelmHook("Job", "b", "b.yaml", hookPostInstall, ""),
+		NewHelmHook("Job", "a", "a.yaml", hookPostInstall, hookSucceeded),
+		NewHelmHook("Job", "c", "c.yaml", hookPostUpgrade, ""),
+	}
+	assert.Equal(t, []string{"a", "b"}, hookNames(MatchingHooks(hooks, hookPostInstall, "")))
+	assert.Equal(t, []string{"a"}, hookNames(MatchingHooks(hooks, hookPostInstall, hookSucceeded)))
+}
+
+func TestParseHelmTestOutput(t *testing.T) {
+	t.Parallel()
+
+	helm2Output := `RUNNING: myapp-test-connection
+PASSED: myapp-test-connection
+RUNNING: myapp-test-api
+FAILED: myapp-test-api, run ` + "`kubectl logs myapp-test-api --namespace jx`" + ` for more info
+`
+	results := ParseHelmTestOutput(helm2Output)
+	require.Len(t, results, 2)
+	assert.Equal(t, "myapp-test-connection", results[0].Name)
+	assert.True(t, results[0].Passed)
+	assert.False(t, results[1].Passed)
+
+	helm3Output := `Pod myapp-test-connection pending
+Pod myapp-test-connection succeeded
+NAME: myapp
+STATUS: deployed
+TEST SUITE:     myapp-test-connection
+Last Started:   Tue Jan  7 10:00:00 2020
+Last Completed: Tue Jan  7 10:00:05 2020
+Phase:          Succeeded
+TEST SUITE:     myapp-test-api
+Last Started:   Tue Jan  7 10:00:05 2020
+Last Completed: Tue Jan  7 10:00:09 2020
+Phase:          Failed
+`
+	results = ParseHelmTestOutput(helm3Output)
+	require.Len(t, results, 2)
+	assert.Equal(t, "myapp-test-connection", results[0].Name)
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, "myapp-test-api", results[1].Name)
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, "Pod phase Failed", results[1].Message)
+}
+
+func hookNames(hooks []*HelmHook) []string {
+	answer := []string{}
+	for _, hook := range hooks {
+		answer = append(answer, hook.Name)
+	}
+	return answer
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"k8s.io/helm/pkg/chartutil"
 	"k8s.io/helm/pkg/proto/hapi/chart"
 
+	"github.com/blang/semver"
 	"github.com/jenkins-x/jx/pkg/kube"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
@@ -49,6 +51,17 @@ const (
 	hookFailed    = "hook-failed"
 	hookSucceeded = "hook-succeeded"
 
+	hookCrdInstall   = "crd-install"
+	hookPreInstall   = "pre-install"
+	hookPostInstall  = "post-install"
+	hookPreUpgrade   = "pre-upgrade"
+	hookPostUpgrade  = "post-upgrade"
+	hookPreRollback  = "pre-rollback"
+	hookPostRollback = "post-rollback"
+	hookTest         = "test"
+	hookTestSuccess  = "test-success"
+	hookTestFailure  = "test-failure"
+
 	// resourcesSeparator is used to separate multiple objects stored in the same YAML file
 	resourcesSeparator = "---"
 )
@@ -64,6 +77,10 @@ type HelmTemplate struct {
 	KubectlValidate bool
 	KubeClient      kubernetes.Interface
 	Namespace       string
+
+	// DryRun when enabled UpgradeChart only compares the rendered chart with the live resources storing the result in Diff
+	DryRun bool
+	Diff   *ReleaseDiff
 }
 
 // NewHelmTemplate creates a new HelmTemplate instance configured to the given client side Helmer
@@ -81,12 +98,15 @@ func NewHelmTemplate(client *HelmCLI, workDir string, kubeClient kubernetes.Inte
 	return cli
 }
 
+// HelmHook a resource of a chart annotated with helm.sh/hook which is applied in the matching hook phases
+// in ascending order of the helm.sh/hook-weight annotation
 type HelmHook struct {
 	Kind               string
 	Name               string
 	File               string
 	Hooks              []string
 	HookDeletePolicies []string
+	Weight             int
 }
 
 // SetHost is used to point at a locally running tiller
@@ -265,9 +285,9 @@ func (h *HelmTemplate) InstallChart(chart string, releaseName string, ns string,
 	if err != nil {
 		return err
 	}
-	helmCrdPhase := "crd-install"
-	helmPrePhase := "pre-install"
-	helmPostPhase := "post-install"
+	helmCrdPhase := hookCrdInstall
+	helmPrePhase := hookPreInstall
+	helmPostPhase := hookPostInstall
 	wait := true
 	create := true
 	force := true
@@ -352,9 +372,19 @@ func (h *HelmTemplate) UpgradeChart(chart string, releaseName string, ns string,
 		return err
 	}
 
-	helmCrdPhase := "crd-install"
-	helmPrePhase := "pre-upgrade"
-	helmPostPhase := "post-upgrade"
+	if h.DryRun {
+		h.Diff, err = h.diffRelease(ns, releaseName, versionText, outputDir)
+		return err
+	}
+
+	helmCrdPhase := hookCrdInstall
+	helmPrePhase := hookPreUpgrade
+	helmPostPhase := hookPostUpgrade
+	if h.isRollback(ns, releaseName, versionText) {
+		log.Logger().Infof("Rolling back release %s to version %s", util.ColorInfo(releaseName), util.ColorInfo(versionText))
+		helmPrePhase = hookPreRollback
+		helmPostPhase = hookPostRollback
+	}
 	create := false
 
 	err = h.runHooks(helmHooks, helmCrdPhase, ns, chart, releaseName, wait, create, force)
@@ -498,8 +528,8 @@ func (h *HelmTemplate) deleteOldResources(ns string, releaseName string, version
 }
 
 func (h *HelmTemplate) deleteResourcesAndClusterResourcesBySelector(ns string, selector string, wait bool, message string) error {
-	kinds := []string{"all", "pvc", "configmap", "release", "sa", "role", "rolebinding", "secret"}
-	clusterKinds := []string{"clusterrole", "clusterrolebinding"}
+	kinds := releaseResourceKinds
+	clusterKinds := releaseClusterResourceKinds
 
 	errList := []error{}
 
@@ -617,6 +647,9 @@ func (h *HelmTemplate) fetchChart(chart string, version string, dir string, repo
 	if dir == "" {
 		return "", fmt.Errorf("must specify dir for chart %s", chart)
 	}
+	if IsOCIChart(chart) || IsOCIChart(repo) {
+		return h.Client.fetchOCIChart(chart, version, true, dir, repo, username, password)
+	}
 	args := []string{
 		"fetch", "-d", dir, "--untar", chart,
 	}
@@ -632,10 +665,16 @@ func (h *HelmTemplate) fetchChart(chart string, version string, dir string, repo
 	if password != "" {
 		args = append(args, "--password", password)
 	}
-	err = h.Client.runHelm(args...)
+	fetched, err := h.Client.fetchVerifiedChartTo(chart, version, true, dir, repo, username, password)
 	if err != nil {
 		return "", err
 	}
+	if !fetched {
+		err = h.Client.runHelm(args...)
+		if err != nil {
+			return "", err
+		}
+	}
 	answer := dir
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -823,6 +862,10 @@ func addLabelsToChartYaml(basedir string, hooksDir string, chart string, release
 						return fmt.Errorf("Failed to find relative path of basedir %s and path %s", basedir, file)
 					}
 					newPath := filepath.Join(hooksDir, relPath)
+					if len(objFiles) > 1 {
+						// keep each hook of a file with several objects in its own file
+						newPath = filepath.Join(hooksDir, filepath.Dir(relPath), filepath.Base(file))
+					}
 					newDir, _ := filepath.Split(newPath)
 					err = os.MkdirAll(newDir, util.DefaultWritePermissions)
 					if err != nil {
@@ -835,8 +878,16 @@ func addLabelsToChartYaml(basedir string, hooksDir string, chart string, release
 					}
 					name := getYamlValueString(&m, "metadata", "name")
 					helmDeletePolicy := getYamlValueString(&m, "metadata", "annotations", "helm.sh/hook-delete-policy")
-					helmHooks = append(helmHooks, NewHelmHook(kind, name, newPath, helmHook, helmDeletePolicy))
-					return nil
+					hook := NewHelmHook(kind, name, newPath, helmHook, helmDeletePolicy)
+					weight := getYamlValue(&m, "metadata", "annotations", "helm.sh/hook-weight")
+					if weight != nil {
+						hook.Weight, err = strconv.Atoi(strings.TrimSpace(fmt.Sprintf("%v", weight)))
+						if err != nil {
+							return errors.Wrapf(err, "parsing the helm.sh/hook-weight annotation of file %s", file)
+						}
+					}
+					helmHooks = append(helmHooks, hook)
+					continue
 				}
 				err = setYamlValue(&m, releaseName, "metadata", "labels", LabelReleaseName)
 				if err != nil {
@@ -1111,6 +1162,7 @@ func NewHelmHook(kind string, name string, file string, hook string, hookDeleteP
 }
 
 // MatchingHooks returns the matching files which have the given hook name and if hookPolicy is not blank the hook policy too
+// sorted by their weight and then their name
 func MatchingHooks(hooks []*HelmHook, hook string, hookDeletePolicy string) []*HelmHook {
 	answer := []*HelmHook{}
 	for _, h := range hooks {
@@ -1119,5 +1171,40 @@ func MatchingHooks(hooks []*HelmHook, hook string, hookDeletePolicy string) []*H
 			answer = append(answer, h)
 		}
 	}
+	sortHooks(answer)
 	return answer
 }
+
+func sortHooks(hooks []*HelmHook) {
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Weight != hooks[j].Weight {
+			return hooks[i].Weight < hooks[j].Weight
+		}
+		return hooks[i].Name < hooks[j].Name
+	})
+}
+
+// isRollback returns true if the release is currently deployed with a newer chart version than the given version
+func (h *HelmTemplate) isRollback(ns string, releaseName string, versionText string) bool {
+	if h.KubeClient == nil {
+		return false
+	}
+	newVersion, err := semver.ParseTolerant(versionText)
+	if err != nil {
+		return false
+	}
+	releases, _, err := h.ListReleases(ns)
+	if err != nil {
+		log.Logger().Warnf("Failed to list the releases in namespace %s: %s", ns, err)
+		return false
+	}
+	release, ok := releases[releaseName]
+	if !ok {
+		return false
+	}
+	currentVersion, err := semver.ParseTolerant(release.ChartVersion)
+	if err != nil {
+		return false
+	}
+	return newVersion.LT(currentVersion)
+}
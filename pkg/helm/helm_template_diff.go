@@ -0,0 +1,624 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// ResourceChange is the type of change to a resource of a release
+type ResourceChange string
+
+const (
+	// ResourceAdded the resource is rendered by the chart but does not exist yet
+	ResourceAdded ResourceChange = "Added"
+	// ResourceChanged the resource exists and would be modified by applying the chart
+	ResourceChanged ResourceChange = "Changed"
+	// ResourceRemoved the resource belongs to an older version of the release and would be pruned
+	ResourceRemoved ResourceChange = "Removed"
+
+	// diffContextLines the number of unchanged lines displayed around the changed lines of a resource
+	diffContextLines = 3
+	// maxDiffEdits the number of changed lines beyond which a resource is displayed as entirely replaced rather than
+	// searching for the shortest diff
+	maxDiffEdits = 1000
+
+	// hiddenSecretValue replaces the values of Secrets in a diff so they are never disclosed
+	hiddenSecretValue = "<hidden>"
+	// hiddenChangedSecretValue replaces the values of Secrets which differ from the live Secret in a diff
+	hiddenChangedSecretValue = "<hidden (changed)>"
+)
+
+// ResourceDiff is the change to a single resource of a release
+type ResourceDiff struct {
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace,omitempty"`
+	Change    ResourceChange `json:"change"`
+	Diff      string         `json:"diff,omitempty"`
+}
+
+// ReleaseDiff is the difference between the resources rendered by a chart and the live resources of its release
+type ReleaseDiff struct {
+	ReleaseName string         `json:"releaseName"`
+	Namespace   string         `json:"namespace"`
+	Version     string         `json:"version,omitempty"`
+	Resources   []ResourceDiff `json:"resources"`
+}
+
+// releaseResourceKinds the kinds of namespaced resources which are labelled with the release
+var releaseResourceKinds = []string{"all", "pvc", "configmap", "release", "sa", "role", "rolebinding", "secret"}
+
+// releaseClusterResourceKinds the kinds of cluster wide resources which are labelled with the release
+var releaseClusterResourceKinds = []string{"clusterrole", "clusterrolebinding"}
+
+// ignoredDiffMetadata the metadata fields managed by Kubernetes which are not compared
+var ignoredDiffMetadata = []string{"creationTimestamp", "resourceVersion", "uid", "selfLink", "generation", "managedFields"}
+
+// ignoredDiffAnnotations the annotations managed by Kubernetes which are not compared
+var ignoredDiffAnnotations = []string{"kubectl.kubernetes.io/last-applied-configuration", "deployment.kubernetes.io/revision"}
+
+// HasChanges returns true if applying the chart would change any resource
+func (d *ReleaseDiff) HasChanges() bool {
+	return len(d.Resources) > 0
+}
+
+// Count returns the number of resources with the given change
+func (d *ReleaseDiff) Count(change ResourceChange) int {
+	answer := 0
+	for _, r := range d.Resources {
+		if r.Change == change {
+			answer++
+		}
+	}
+	return answer
+}
+
+// Summary returns a one line summary of the changes
+func (d *ReleaseDiff) Summary() string {
+	return fmt.Sprintf("%d to add, %d to change, %d to remove", d.Count(ResourceAdded), d.Count(ResourceChanged), d.Count(ResourceRemoved))
+}
+
+// String renders the diff as text for the console
+func (d *ReleaseDiff) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Release %s in namespace %s: %s\n", d.ReleaseName, d.Namespace, d.Summary()))
+	for _, r := range d.Resources {
+		buf.WriteString(fmt.Sprintf("\n%s %s %s\n", r.Change, r.Kind, r.qualifiedName()))
+		if r.Diff != "" {
+			buf.WriteString(r.Diff)
+		}
+	}
+	return buf.String()
+}
+
+// ToMarkdown renders the diff as markdown so that it can be added as a comment on a Pull Request
+func (d *ReleaseDiff) ToMarkdown() string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("### Changes to release `%s` in namespace `%s`\n\n", d.ReleaseName, d.Namespace))
+	if !d.HasChanges() {
+		buf.WriteString("No resources would be changed.\n")
+		return buf.String()
+	}
+	buf.WriteString(d.Summary() + "\n\n")
+	buf.WriteString("| Change | Kind | Name |\n| --- | --- | --- |\n")
+	for _, r := range d.Resources {
+		buf.WriteString(fmt.Sprintf("| %s | %s | `%s` |\n", r.Change, r.Kind, r.qualifiedName()))
+	}
+	for _, r := range d.Resources {
+		if r.Diff == "" {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("\n<details>\n<summary>%s %s %s</summary>\n\n```diff\n%s```\n</details>\n", r.Change, r.Kind, r.qualifiedName(), r.Diff))
+	}
+	return buf.String()
+}
+
+func (r *ResourceDiff) qualifiedName() string {
+	if r.Namespace == "" {
+		return r.Name
+	}
+	return r.Namespace + "/" + r.Name
+}
+
+// diffRelease compares the resources rendered into the output directory with the live resources of the release. The
+// live resources are compared with the result of a server side dry run of the apply so that the fields defaulted by
+// Kubernetes do not show up as changes
+func (h *HelmTemplate) diffRelease(ns string, releaseName string, versionText string, outputDir string) (*ReleaseDiff, error) {
+	answer := &ReleaseDiff{
+		ReleaseName: releaseName,
+		Namespace:   ns,
+		Version:     versionText,
+	}
+	rendered := map[string]bool{}
+	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".yaml" {
+			return nil
+		}
+		expected, err := loadObjectFile(path)
+		if err != nil || expected == nil {
+			return err
+		}
+		kind, name, namespace := objectKindNameNamespace(expected, ns)
+		rendered[resourceKey(kind, namespace, name)] = true
+
+		live, err := h.getLiveObject(expected, namespace)
+		if err != nil {
+			return err
+		}
+		if live == nil {
+			text, err := objectToDiffText(expected, nil)
+			if err != nil {
+				return err
+			}
+			answer.Resources = append(answer.Resources, ResourceDiff{
+				Kind:      kind,
+				Name:      name,
+				Namespace: namespace,
+				Change:    ResourceAdded,
+				Diff:      UnifiedDiff("", text),
+			})
+			return nil
+		}
+		merged, err := h.serverDryRunApply(path, namespace)
+		if err != nil {
+			log.Logger().Warnf("Failed to run a server side dry run of %s %s so comparing the rendered fields only: %s", kind, name, err)
+			merged = mergeObjects(copyObject(live), expected)
+		}
+		liveText, err := objectToDiffText(live, nil)
+		if err != nil {
+			return err
+		}
+		mergedText, err := objectToDiffText(merged, live)
+		if err != nil {
+			return err
+		}
+		if liveText != mergedText {
+			answer.Resources = append(answer.Resources, ResourceDiff{
+				Kind:      kind,
+				Name:      name,
+				Namespace: namespace,
+				Change:    ResourceChanged,
+				Diff:      UnifiedDiff(liveText, mergedText),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "comparing the rendered resources in %s with release %s", outputDir, releaseName)
+	}
+
+	selector := LabelReleaseName + "=" + releaseName
+	removed := h.findPrunedObjects(ns, releaseResourceKinds, selector, versionText, rendered)
+	selector += "," + LabelNamespace + "=" + ns
+	removed = append(removed, h.findPrunedObjects("", releaseClusterResourceKinds, selector, versionText, rendered)...)
+	answer.Resources = append(answer.Resources, removed...)
+
+	sort.SliceStable(answer.Resources, func(i, j int) bool {
+		ri := answer.Resources[i]
+		rj := answer.Resources[j]
+		if ri.Kind != rj.Kind {
+			return ri.Kind < rj.Kind
+		}
+		return ri.qualifiedName() < rj.qualifiedName()
+	})
+	return answer, nil
+}
+
+// findPrunedObjects returns the live resources of the release which deleteOldResources would remove as they are not
+// rendered by the new version of the chart
+func (h *HelmTemplate) findPrunedObjects(ns string, kinds []string, selector string, versionText string, rendered map[string]bool) []ResourceDiff {
+	answer := []ResourceDiff{}
+	for _, kind := range kinds {
+		args := []string{"get", kind, "-l", selector, "-o", "json"}
+		if ns != "" {
+			args = append(args, "--namespace", ns)
+		}
+		output, err := h.runKubectlWithOutput(args...)
+		if err != nil {
+			log.Logger().Debugf("Failed to list the %s resources of selector %s: %s", kind, selector, err)
+			continue
+		}
+		list := map[string]interface{}{}
+		err = json.Unmarshal([]byte(output), &list)
+		if err != nil {
+			log.Logger().Warnf("Failed to parse the %s resources of selector %s: %s", kind, selector, err)
+			continue
+		}
+		items, _ := list["items"].([]interface{})
+		for _, item := range items {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			metadata, _ := obj["metadata"].(map[string]interface{})
+			if metadata == nil || metadata["ownerReferences"] != nil {
+				// resources owned by other resources such as Pods are removed by Kubernetes
+				continue
+			}
+			labels, _ := metadata["labels"].(map[string]interface{})
+			if fmt.Sprint(labels[LabelReleaseChartVersion]) == versionText {
+				continue
+			}
+			k, name, namespace := objectKindNameNamespace(obj, "")
+			if rendered[resourceKey(k, namespace, name)] {
+				continue
+			}
+			answer = append(answer, ResourceDiff{
+				Kind:      k,
+				Name:      name,
+				Namespace: namespace,
+				Change:    ResourceRemoved,
+			})
+		}
+	}
+	return answer
+}
+
+// getLiveObject returns the live resource or nil if it does not exist
+func (h *HelmTemplate) getLiveObject(expected map[string]interface{}, ns string) (map[string]interface{}, error) {
+	kind, name, _ := objectKindNameNamespace(expected, ns)
+	resource := strings.ToLower(kind)
+	apiVersion, _ := expected["apiVersion"].(string)
+	if idx := strings.Index(apiVersion, "/"); idx > 0 {
+		resource += "." + apiVersion[:idx]
+	}
+	args := []string{"get", resource, name, "-o", "json", "--ignore-not-found"}
+	if ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+	output, err := h.runKubectlWithOutput(args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting %s %s", kind, name)
+	}
+	return parseObject(output)
+}
+
+// serverDryRunApply returns the resource in the file as it would be after applying it
+func (h *HelmTemplate) serverDryRunApply(file string, ns string) (map[string]interface{}, error) {
+	args := []string{"apply", "--server-dry-run", "-f", file, "-o", "json"}
+	if ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+	if !h.KubectlValidate {
+		args = append(args, "--validate=false")
+	}
+	output, err := h.runKubectlWithOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	answer, err := parseObject(output)
+	if err == nil && answer == nil {
+		err = fmt.Errorf("no resource returned by the server side dry run of %s", file)
+	}
+	return answer, err
+}
+
+func loadObjectFile(file string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", file)
+	}
+	m := map[string]interface{}{}
+	err = yaml.Unmarshal(data, &m)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", file)
+	}
+	if m["kind"] == nil {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func parseObject(output string) (map[string]interface{}, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+	m := map[string]interface{}{}
+	err := json.Unmarshal([]byte(output), &m)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the output of kubectl")
+	}
+	return m, nil
+}
+
+func objectKindNameNamespace(obj map[string]interface{}, defaultNamespace string) (string, string, string) {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if isClusterKind(kind) {
+		namespace = ""
+	} else if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return kind, name, namespace
+}
+
+func resourceKey(kind string, namespace string, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// objectToDiffText renders the resource as YAML without the fields managed by Kubernetes or the chart version label
+// and with the values of Secrets replaced by a placeholder so they are never disclosed in a diff. The values of a
+// Secret which differ from the previous version of the resource, if any, use a different placeholder so that the
+// change still shows in the diff
+func objectToDiffText(obj map[string]interface{}, previous map[string]interface{}) (string, error) {
+	obj = copyObject(obj)
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range ignoredDiffMetadata {
+			delete(metadata, field)
+		}
+		// the chart version label changes on every upgrade so would make every resource look changed
+		if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+			delete(labels, LabelReleaseChartVersion)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			for _, annotation := range ignoredDiffAnnotations {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	if obj["kind"] == "Secret" {
+		var previousValues map[string]string
+		if previous != nil {
+			previousValues = secretValues(previous)
+		}
+		values := secretValues(obj)
+		hideSecretValues(obj, "data", values, previousValues)
+		hideSecretValues(obj, "stringData", values, previousValues)
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling the resource to YAML")
+	}
+	return string(data), nil
+}
+
+// secretValues returns the decoded values of the Secret with its stringData overriding its data as in Kubernetes
+func secretValues(obj map[string]interface{}) map[string]string {
+	answer := map[string]string{}
+	if values, ok := obj["data"].(map[string]interface{}); ok {
+		for k, v := range values {
+			text := fmt.Sprint(v)
+			decoded, err := base64.StdEncoding.DecodeString(text)
+			if err == nil {
+				text = string(decoded)
+			}
+			answer[k] = text
+		}
+	}
+	if values, ok := obj["stringData"].(map[string]interface{}); ok {
+		for k, v := range values {
+			answer[k] = fmt.Sprint(v)
+		}
+	}
+	return answer
+}
+
+func hideSecretValues(obj map[string]interface{}, field string, values map[string]string, previousValues map[string]string) {
+	fieldValues, ok := obj[field].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k := range fieldValues {
+		fieldValues[k] = hiddenSecretValue
+		if previousValues != nil {
+			previous, ok := previousValues[k]
+			if !ok || previous != values[k] {
+				fieldValues[k] = hiddenChangedSecretValue
+			}
+		}
+	}
+}
+
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	answer := map[string]interface{}{}
+	err = json.Unmarshal(data, &answer)
+	if err != nil {
+		return obj
+	}
+	return answer
+}
+
+// mergeObjects overlays the fields of the rendered resource onto the live resource approximating an apply
+func mergeObjects(live map[string]interface{}, rendered map[string]interface{}) map[string]interface{} {
+	for k, v := range rendered {
+		renderedMap, ok1 := v.(map[string]interface{})
+		liveMap, ok2 := live[k].(map[string]interface{})
+		if ok1 && ok2 {
+			live[k] = mergeObjects(liveMap, renderedMap)
+		} else {
+			live[k] = v
+		}
+	}
+	return live
+}
+
+// UnifiedDiff returns the changed lines between the two texts with a few lines of context prefixed with '+' for added
+// lines, '-' for removed lines and ' ' for unchanged lines
+func UnifiedDiff(before string, after string) string {
+	lines := diffLines(splitLines(before), splitLines(after))
+
+	// only keep the unchanged lines close to a change
+	keep := make([]bool, len(lines))
+	for idx, line := range lines {
+		if line[0] == ' ' {
+			continue
+		}
+		for k := idx - diffContextLines; k <= idx+diffContextLines; k++ {
+			if k >= 0 && k < len(lines) {
+				keep[k] = true
+			}
+		}
+	}
+	var buf bytes.Buffer
+	skipped := false
+	for idx, line := range lines {
+		if !keep[idx] {
+			skipped = true
+			continue
+		}
+		if skipped && buf.Len() > 0 {
+			buf.WriteString("@@\n")
+		}
+		skipped = false
+		buf.WriteString(line + "\n")
+	}
+	return buf.String()
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns the lines of a and b prefixed with ' ', '-' or '+' using the Myers diff algorithm on the hashes of
+// the lines whose cost grows with the number of changes rather than the size of the resources
+func diffLines(a []string, b []string) []string {
+	ids := map[string]int{}
+	x := lineIDs(a, ids)
+	y := lineIDs(b, ids)
+
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	answer := []string{}
+	for _, line := range a[:prefix] {
+		answer = append(answer, " "+line)
+	}
+	answer = append(answer, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		answer = append(answer, " "+line)
+	}
+	return answer
+}
+
+func lineIDs(lines []string, ids map[string]int) []int {
+	answer := make([]int, len(lines))
+	for i, line := range lines {
+		id, ok := ids[line]
+		if !ok {
+			id = len(ids)
+			ids[line] = id
+		}
+		answer[i] = id
+	}
+	return answer
+}
+
+// myersDiff finds the shortest edit script turning the lines a into the lines b whose ids are x and y. If there are
+// more than maxDiffEdits changes all the lines of a are removed and all the lines of b added
+func myersDiff(a []string, b []string, x []int, y []int) []string {
+	n, m := len(x), len(y)
+	limit := n + m
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace holds the furthest x reached on each diagonal -d..d after each number of edits d
+	trace := [][]int{}
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+		}
+		trace = append(trace, append([]int{}, v[offset-d:offset+d+1]...))
+		if n-m >= -d && n-m <= d && v[offset+n-m] >= n {
+			return myersBacktrack(a, b, trace)
+		}
+	}
+	answer := []string{}
+	for _, line := range a {
+		answer = append(answer, "-"+line)
+	}
+	for _, line := range b {
+		answer = append(answer, "+"+line)
+	}
+	return answer
+}
+
+// myersBacktrack walks back through the trace from the end of both sequences to build the edit script
+func myersBacktrack(a []string, b []string, trace [][]int) []string {
+	reversed := []string{}
+	i, j := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := i - j
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevI := prev[prevK+d-1]
+		prevJ := prevI - prevK
+		// the edit moves from the previous diagonal to this one then any equal lines are skipped
+		midI := prevI
+		if prevK == k-1 {
+			midI++
+		}
+		for i > midI {
+			reversed = append(reversed, " "+a[i-1])
+			i--
+			j--
+		}
+		if prevK == k+1 {
+			reversed = append(reversed, "+"+b[j-1])
+		} else {
+			reversed = append(reversed, "-"+a[i-1])
+		}
+		i, j = prevI, prevJ
+	}
+	for i > 0 && j > 0 {
+		reversed = append(reversed, " "+a[i-1])
+		i--
+		j--
+	}
+	answer := make([]string, len(reversed))
+	for idx, line := range reversed {
+		answer[len(reversed)-1-idx] = line
+	}
+	return answer
+}
@@ -0,0 +1,113 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+	before := "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 7\nh: 8\ni: 9\n"
+	after := "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 7\nh: 80\ni: 9\n"
+
+	assert.Equal(t, " e: 5\n f: 6\n g: 7\n-h: 8\n+h: 80\n i: 9\n", UnifiedDiff(before, after))
+	assert.Equal(t, "+a: 1\n+b: 2\n", UnifiedDiff("", "a: 1\nb: 2\n"))
+	assert.Equal(t, "", UnifiedDiff(before, before))
+}
+
+func TestUnifiedDiffOfLargeResources(t *testing.T) {
+	t.Parallel()
+	before := []string{}
+	for i := 0; i < 20000; i++ {
+		before = append(before, fmt.Sprintf("line%d: %d", i%100, i))
+	}
+	after := append([]string{}, before...)
+	after[10000] = "changed: true"
+
+	assert.Equal(t, " line97: 9997\n line98: 9998\n line99: 9999\n-line0: 10000\n+changed: true\n line1: 10001\n line2: 10002\n line3: 10003\n",
+		UnifiedDiff(strings.Join(before, "\n"), strings.Join(after, "\n")))
+
+	replaced := []string{}
+	for i := 0; i < maxDiffEdits; i++ {
+		replaced = append(replaced, fmt.Sprintf("other%d", i))
+	}
+	diff := UnifiedDiff(strings.Join(before[:maxDiffEdits], "\n"), strings.Join(replaced, "\n"))
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	require.Len(t, lines, 2*maxDiffEdits)
+	assert.Equal(t, "-line0: 0", lines[0])
+	assert.Equal(t, "+other0", lines[maxDiffEdits])
+}
+
+func TestObjectToDiffTextIgnoresManagedFieldsAndHidesSecrets(t *testing.T) {
+	t.Parallel()
+	live := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":            "mysecret",
+			"resourceVersion": "1234",
+			"uid":             "abc",
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		},
+		"data": map[string]interface{}{
+			"password": "c2VjcmV0",
+		},
+	}
+	text, err := objectToDiffText(live, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, text, "resourceVersion")
+	assert.NotContains(t, text, "last-applied-configuration")
+	assert.NotContains(t, text, "c2VjcmV0")
+	assert.Contains(t, text, "password: <hidden>")
+
+	rendered := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "mysecret"},
+		"data":       map[string]interface{}{"password": "c2VjcmV0"},
+	}
+	renderedText, err := objectToDiffText(mergeObjects(copyObject(live), rendered), live)
+	require.NoError(t, err)
+	assert.Equal(t, text, renderedText, "the same secret value should not be a change")
+
+	rendered["data"] = map[string]interface{}{"password": "Y2hhbmdlZA=="}
+	renderedText, err = objectToDiffText(mergeObjects(copyObject(live), rendered), live)
+	require.NoError(t, err)
+	assert.NotContains(t, renderedText, "Y2hhbmdlZA==")
+	assert.Contains(t, renderedText, "password: <hidden (changed)>")
+	assert.Contains(t, UnifiedDiff(text, renderedText), "+  password: <hidden (changed)>\n")
+
+	rendered["data"] = map[string]interface{}{}
+	rendered["stringData"] = map[string]interface{}{"password": "secret"}
+	renderedText, err = objectToDiffText(mergeObjects(copyObject(live), rendered), live)
+	require.NoError(t, err)
+	assert.NotContains(t, renderedText, "(changed)", "the same value in stringData should not be a change")
+}
+
+func TestReleaseDiffToMarkdown(t *testing.T) {
+	t.Parallel()
+	diff := &ReleaseDiff{
+		ReleaseName: "jx",
+		Namespace:   "jx-staging",
+		Resources: []ResourceDiff{
+			{Kind: "Deployment", Name: "myapp", Namespace: "jx-staging", Change: ResourceChanged, Diff: "-image: myapp:1.0.0\n+image: myapp:1.0.1\n"},
+			{Kind: "Service", Name: "old", Namespace: "jx-staging", Change: ResourceRemoved},
+		},
+	}
+	assert.Equal(t, "0 to add, 1 to change, 1 to remove", diff.Summary())
+
+	markdown := diff.ToMarkdown()
+	assert.Contains(t, markdown, "| Changed | Deployment | `jx-staging/myapp` |")
+	assert.Contains(t, markdown, "| Removed | Service | `jx-staging/old` |")
+	assert.Contains(t, markdown, "```diff\n-image: myapp:1.0.0\n+image: myapp:1.0.1\n```")
+	assert.Equal(t, 1, strings.Count(markdown, "<details>"))
+
+	empty := &ReleaseDiff{ReleaseName: "jx", Namespace: "jx-staging"}
+	assert.Contains(t, empty.ToMarkdown(), "No resources would be changed.")
+}
@@ -21,6 +21,8 @@ type Helmer interface {
 	FetchChart(chart string, version string, untar bool, untardir string, repo string, username string,
 		password string) error
 	DeleteRelease(ns string, releaseName string, purge bool) error
+	// ListReleases returns the releases in the namespace, or in all namespaces if it is empty, keyed by release name.
+	// When listing all namespaces a release name which is in more than one namespace is keyed by namespace/name
 	ListReleases(ns string) (map[string]ReleaseSummary, []string, error)
 	FindChart() (string, error)
 	PackageChart() error
@@ -0,0 +1,334 @@
+package helm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OCIScheme the scheme of the URLs of charts stored in OCI registries
+	OCIScheme = "oci://"
+
+	// OCIChartsDir the directory of a chart into which its dependencies from OCI registries are exported
+	OCIChartsDir = "oci-charts"
+
+	// enableOCIEnvVar the environment variable which enables the experimental OCI support of helm 3
+	enableOCIEnvVar = "HELM_EXPERIMENTAL_OCI"
+)
+
+// OCIReference a reference to a chart in an OCI registry such as oci://registry/org/chart:version
+type OCIReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// IsOCIChart returns true if the chart or repository URL refers to an OCI registry
+func IsOCIChart(chartOrRepo string) bool {
+	return strings.HasPrefix(chartOrRepo, OCIScheme)
+}
+
+// ParseOCIReference parses a chart reference such as oci://registry/org/chart:version. The tag defaults to the
+// given version if the reference has none
+func ParseOCIReference(text string, version string) (*OCIReference, error) {
+	ref := strings.TrimPrefix(text, OCIScheme)
+	ref = strings.TrimSuffix(ref, "/")
+	paths := strings.SplitN(ref, "/", 2)
+	if len(paths) < 2 || paths[0] == "" || paths[1] == "" {
+		return nil, fmt.Errorf("invalid OCI chart reference %q, expected oci://registry/repository/chart:version", text)
+	}
+	answer := &OCIReference{
+		Registry:   paths[0],
+		Repository: paths[1],
+		Tag:        version,
+	}
+	idx := strings.LastIndex(answer.Repository, ":")
+	if idx > 0 {
+		answer.Tag = answer.Repository[idx+1:]
+		answer.Repository = answer.Repository[:idx]
+	}
+	return answer, nil
+}
+
+// OCIReferenceForChart returns the reference of a chart which is either an OCI reference itself or the name of a chart
+// in the OCI registry of the given repository URL
+func OCIReferenceForChart(chart string, repo string, version string) (*OCIReference, error) {
+	if IsOCIChart(chart) {
+		return ParseOCIReference(chart, version)
+	}
+	paths := strings.Split(chart, "/")
+	return ParseOCIReference(util.UrlJoin(repo, paths[len(paths)-1]), version)
+}
+
+// Name returns the name of the chart
+func (r *OCIReference) Name() string {
+	paths := strings.Split(r.Repository, "/")
+	return paths[len(paths)-1]
+}
+
+// String returns the reference without the scheme as used by the helm chart commands
+func (r *OCIReference) String() string {
+	answer := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		answer += ":" + r.Tag
+	}
+	return answer
+}
+
+// OCIBinary returns the helm binary to use for OCI registries as only helm 3 supports them
+func OCIBinary(helmBinary string) string {
+	if helmBinary == Helm3Binary {
+		return helmBinary
+	}
+	return Helm3Binary
+}
+
+// OCIClient pushes and pulls charts to and from OCI registries using the experimental OCI support of helm 3
+type OCIClient struct {
+	Binary string
+	CWD    string
+	Runner util.Commander
+}
+
+// NewOCIClient creates a new OCIClient using the given helm 3 binary
+func NewOCIClient(binary string, cwd string) *OCIClient {
+	return NewOCIClientWithRunner(&util.Command{}, binary, cwd)
+}
+
+// NewOCIClientWithRunner creates a new OCIClient with the given runner
+func NewOCIClientWithRunner(runner util.Commander, binary string, cwd string) *OCIClient {
+	if binary == "" {
+		binary = Helm3Binary
+	}
+	return &OCIClient{
+		Binary: binary,
+		CWD:    cwd,
+		Runner: runner,
+	}
+}
+
+func (c *OCIClient) runHelm(args ...string) error {
+	return c.runHelmWithStdin(nil, args...)
+}
+
+// runHelmWithStdin runs helm reading its standard input from the given reader. The standard input and the environment
+// are reset afterwards as the runner is shared with other helm commands
+func (c *OCIClient) runHelmWithStdin(in io.Reader, args ...string) error {
+	previousEnv := c.Runner.CurrentEnv()
+	env := map[string]string{}
+	for k, v := range previousEnv {
+		env[k] = v
+	}
+	env[enableOCIEnvVar] = "1"
+	c.Runner.SetDir(c.CWD)
+	c.Runner.SetName(c.Binary)
+	c.Runner.SetEnv(env)
+	c.Runner.SetArgs(args)
+	c.Runner.SetStdin(in)
+	output, err := c.Runner.RunWithoutRetry()
+	c.Runner.SetStdin(nil)
+	c.Runner.SetEnv(previousEnv)
+	if err != nil {
+		return errors.Wrapf(err, "running %s %s: %s", c.Binary, strings.Join(redactArgs(args), " "), output)
+	}
+	log.Logger().Debugf(output)
+	return nil
+}
+
+// redactArgs returns a copy of the arguments with the values of any username or password arguments masked
+func redactArgs(args []string) []string {
+	answer := make([]string, len(args))
+	copy(answer, args)
+	for i, arg := range answer {
+		lower := strings.ToLower(arg)
+		if (strings.Contains(lower, "password") || strings.Contains(lower, "username")) && i < len(answer)-1 &&
+			!strings.HasPrefix(answer[i+1], "-") {
+			answer[i+1] = "*****"
+		}
+	}
+	return answer
+}
+
+// Login logs into the OCI registry with the given credentials passing the password on the standard input of helm
+// so that it does not show up in the process list
+func (c *OCIClient) Login(registry string, username string, password string) error {
+	return c.runHelmWithStdin(strings.NewReader(password), "registry", "login", registry, "--username", username, "--password-stdin")
+}
+
+// LoginWithDockerConfig logs into the OCI registry using the docker credentials of the registry if there are any
+func (c *OCIClient) LoginWithDockerConfig(registry string) error {
+	username, password, err := LocalDockerRegistryCredentials(registry)
+	if err != nil {
+		return err
+	}
+	if username == "" {
+		log.Logger().Debugf("No docker credentials found for registry %s so using it anonymously", registry)
+		return nil
+	}
+	return c.Login(registry, username, password)
+}
+
+// PullChart pulls the chart from the OCI registry and exports it into the given directory returning the chart directory
+func (c *OCIClient) PullChart(ref *OCIReference, dir string) (string, error) {
+	err := c.runHelm("chart", "pull", ref.String())
+	if err != nil {
+		return "", err
+	}
+	err = c.runHelm("chart", "export", ref.String(), "--destination", dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ref.Name()), nil
+}
+
+// PushChart saves the chart in the given directory and pushes it to the OCI registry
+func (c *OCIClient) PushChart(chartDir string, ref *OCIReference) error {
+	err := c.runHelm("chart", "save", chartDir, ref.String())
+	if err != nil {
+		return err
+	}
+	return c.runHelm("chart", "push", ref.String())
+}
+
+// ExportDependency exports a dependency of a chart from an OCI registry into the OCIChartsDir of the chart returning
+// the local repository which replaces the OCI repository of the dependency
+func (c *OCIClient) ExportDependency(chartDir string, dep *Dependency) (string, error) {
+	ref, err := OCIReferenceForChart(dep.Name, dep.Repository, dep.Version)
+	if err != nil {
+		return "", err
+	}
+	err = c.LoginWithDockerConfig(ref.Registry)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(chartDir, OCIChartsDir)
+	err = os.MkdirAll(dir, util.DefaultWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "creating directory %s", dir)
+	}
+	_, err = c.PullChart(ref, dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "pulling the chart %s", ref.String())
+	}
+	return "file://" + OCIChartsDir + "/" + ref.Name(), nil
+}
+
+// fetchOCIChart fetches the chart from an OCI registry into the given directory returning the chart directory or the
+// archive of the chart if it is not untarred. Charts in OCI registries have no provenance files so they are rejected if
+// their repository requires signed charts
+func (h *HelmCLI) fetchOCIChart(chart string, version string, untar bool, dir string, repo string, username string, password string) (string, error) {
+	err := h.checkOCIChartProvenance(chart, repo)
+	if err != nil {
+		return "", err
+	}
+	ref, err := OCIReferenceForChart(chart, repo, version)
+	if err != nil {
+		return "", err
+	}
+	if h.InstallHelm3 != nil {
+		err = h.InstallHelm3()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to install helm 3 which is required for OCI registries")
+		}
+	}
+	client := NewOCIClientWithRunner(h.Runner, OCIBinary(h.Binary), h.CWD)
+	if username != "" && password != "" {
+		err = client.Login(ref.Registry, username, password)
+	} else {
+		err = client.LoginWithDockerConfig(ref.Registry)
+	}
+	if err != nil {
+		return "", err
+	}
+	if untar {
+		return client.PullChart(ref, dir)
+	}
+	// helm exports charts from OCI registries as directories so lets package the chart into the directory instead
+	tmpDir, err := ioutil.TempDir("", "jx-helm-oci-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+	chartDir, err := client.PullChart(ref, tmpDir)
+	if err != nil {
+		return "", err
+	}
+	err = client.runHelm("package", chartDir, "--destination", dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "packaging chart %s", ref.String())
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", ref.Name(), ref.Tag)), nil
+}
+
+// dockerConfig the docker config.json file containing the credentials of registries
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths,omitempty"`
+}
+
+type dockerAuth struct {
+	Auth     string `json:"auth,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// LocalDockerRegistryCredentials returns the credentials of the registry from the docker config.json file in
+// $DOCKER_CONFIG or ~/.docker. The username is blank if there are no credentials for the registry
+func LocalDockerRegistryCredentials(registry string) (string, string, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		dir = filepath.Join(util.HomeDir(), ".docker")
+	}
+	fileName := filepath.Join(dir, "config.json")
+	exists, err := util.FileExists(fileName)
+	if err != nil || !exists {
+		return "", "", err
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "reading file %s", fileName)
+	}
+	return DockerRegistryCredentials(data, registry)
+}
+
+// DockerRegistryCredentials returns the credentials of the registry from the contents of a docker config.json file
+// such as the one created via jx create docker auth. The username is blank if there are no credentials for the registry
+func DockerRegistryCredentials(configJSON []byte, registry string) (string, string, error) {
+	config := dockerConfig{}
+	err := json.Unmarshal(configJSON, &config)
+	if err != nil {
+		return "", "", errors.Wrap(err, "parsing the docker config.json")
+	}
+	for host, auth := range config.Auths {
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+		host = strings.Split(host, "/")[0]
+		if host != registry {
+			continue
+		}
+		if auth.Username != "" {
+			return auth.Username, auth.Password, nil
+		}
+		if auth.Auth == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "decoding the docker auth of registry %s", registry)
+		}
+		paths := strings.SplitN(string(data), ":", 2)
+		if len(paths) != 2 {
+			return "", "", fmt.Errorf("invalid docker auth of registry %s", registry)
+		}
+		return paths[0], paths[1], nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,127 @@
+package helm_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/helm"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	. "github.com/petergtz/pegomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	ref, err := helm.ParseOCIReference("oci://gcr.io/jenkinsxio/charts/myapp:1.2.3", "")
+	require.NoError(t, err)
+	assert.Equal(t, "gcr.io", ref.Registry)
+	assert.Equal(t, "jenkinsxio/charts/myapp", ref.Repository)
+	assert.Equal(t, "1.2.3", ref.Tag)
+	assert.Equal(t, "myapp", ref.Name())
+	assert.Equal(t, "gcr.io/jenkinsxio/charts/myapp:1.2.3", ref.String())
+
+	ref, err = helm.ParseOCIReference("oci://localhost:5000/myapp", "0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:5000", ref.Registry)
+	assert.Equal(t, "myapp", ref.Repository)
+	assert.Equal(t, "0.0.1", ref.Tag)
+
+	_, err = helm.ParseOCIReference("oci://gcr.io", "0.0.1")
+	assert.Error(t, err, "should reject a reference without a repository")
+}
+
+func TestOCIReferenceForChart(t *testing.T) {
+	ref, err := helm.OCIReferenceForChart("jenkins-x/myapp", "oci://gcr.io/jenkinsxio/charts", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "gcr.io/jenkinsxio/charts/myapp:1.0.0", ref.String())
+}
+
+func TestDockerRegistryCredentials(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("myuser:mypassword"))
+	config := []byte(`{"auths": {"https://gcr.io/v1/": {"auth": "` + auth + `"}, "docker.io": {"username": "other", "password": "secret"}}}`)
+
+	username, password, err := helm.DockerRegistryCredentials(config, "gcr.io")
+	require.NoError(t, err)
+	assert.Equal(t, "myuser", username)
+	assert.Equal(t, "mypassword", password)
+
+	username, password, err = helm.DockerRegistryCredentials(config, "docker.io")
+	require.NoError(t, err)
+	assert.Equal(t, "other", username)
+	assert.Equal(t, "secret", password)
+
+	username, _, err = helm.DockerRegistryCredentials(config, "quay.io")
+	require.NoError(t, err)
+	assert.Equal(t, "", username)
+}
+
+func TestFetchOCIChart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-fetch-oci-chart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	oldDockerConfig := os.Getenv("DOCKER_CONFIG")
+	defer os.Setenv("DOCKER_CONFIG", oldDockerConfig)
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	cli, runner := createHelm(t, nil, "")
+
+	err = cli.FetchChart("oci://gcr.io/jenkinsxio/charts/myapp", "1.0.0", true, dir, "", "", "")
+
+	assert.NoError(t, err, "should fetch a chart from an OCI registry")
+	runner.VerifyWasCalled(Times(2)).SetName(helm.Helm3Binary)
+	runner.VerifyWasCalledOnce().SetArgs([]string{"chart", "pull", "gcr.io/jenkinsxio/charts/myapp:1.0.0"})
+	runner.VerifyWasCalledOnce().SetArgs([]string{"chart", "export", "gcr.io/jenkinsxio/charts/myapp:1.0.0", "--destination", dir})
+
+	envs := runner.VerifyWasCalled(AtLeast(1)).SetEnv(anyStringMap()).GetAllCapturedArguments()
+	require.Len(t, envs, 4, "should set and reset the environment for each helm command")
+	for i := 0; i < len(envs); i += 2 {
+		assert.Equal(t, map[string]string{"HELM_EXPERIMENTAL_OCI": "1"}, envs[i], "should enable the OCI support of helm")
+		assert.Nil(t, envs[i+1], "should reset the environment of the shared runner")
+	}
+}
+
+func anyStringMap() map[string]string {
+	RegisterMatcher(NewAnyMatcher(reflect.TypeOf(map[string]string{})))
+	return nil
+}
+
+func TestFetchOCIChartLogsInWithPasswordFromStdin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-fetch-oci-chart")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cli, runner := createHelm(t, nil, "")
+	installed := false
+	cli.InstallHelm3 = func() error {
+		installed = true
+		return nil
+	}
+
+	err = cli.FetchChart("oci://gcr.io/jenkinsxio/charts/myapp", "1.0.0", false, dir, "", "myuser", "mypassword")
+
+	assert.NoError(t, err, "should fetch a chart from an OCI registry")
+	assert.True(t, installed, "should install helm 3 before fetching the chart")
+	runner.VerifyWasCalledOnce().SetArgs([]string{"registry", "login", "gcr.io", "--username", "myuser", "--password-stdin"})
+	for _, args := range runner.VerifyWasCalled(AtLeast(1)).SetArgs(AnyStringSlice()).GetAllCapturedArguments() {
+		assert.NotContains(t, args, "mypassword", "the password should not be passed as an argument")
+	}
+	runner.VerifyWasCalled(AtLeast(1)).SetStdin(nil)
+
+	packages := calledArgs(runner, "package")
+	require.Len(t, packages, 1, "should package the chart as it is not untarred")
+	assert.Equal(t, []string{"--destination", dir}, packages[0][2:])
+}
+
+func TestFetchOCIChartRejectsUnsignedChart(t *testing.T) {
+	cli, runner := createHelm(t, nil, "")
+	templater, dir := createHelmTemplateWithProvenance(t, cli, versionstream.ProvenanceRequired)
+	defer os.RemoveAll(dir)
+
+	err := templater.FetchChart("oci://gcr.io/jenkinsxio/charts/myapp", "1.0.0", true, dir, "", "", "")
+
+	require.Error(t, err, "should reject a chart from an OCI registry when signed charts are required")
+	runner.VerifyWasCalled(Never()).RunWithoutRetry()
+}
@@ -58,8 +58,13 @@ func (s *secretLocation) Location() SecretsLocationKind {
 		return s.location
 	}
 	value, ok := configMap[SecretsLocationKey]
-	if ok && value == string(VaultLocationKind) {
-		return VaultLocationKind
+	if ok {
+		switch value {
+		case string(VaultLocationKind):
+			return VaultLocationKind
+		case string(KubeLocationKind):
+			return KubeLocationKind
+		}
 	}
 	return s.location
 }
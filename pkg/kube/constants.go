@@ -29,6 +29,9 @@ const (
 	ChartFlaggerGrafana       = "flagger/grafana"
 	DefaultFlaggerReleaseName = "flagger"
 
+	// ChartFlaggerLoadTester the default chart for the Flagger load tester which gates canary releases
+	ChartFlaggerLoadTester = "flagger/loadtester"
+
 	// ChartIstio the default chart for the Istio chart
 	ChartIstio = "install/kubernetes/helm/istio"
 
@@ -97,6 +100,9 @@ const (
 	// SecretJenkinsChartMuseum the chart museum secret
 	SecretJenkinsChartMuseum = "jenkins-x-chartmuseum"
 
+	// SecretJenkinsDockerConfig the secret with the docker config.json containing the credentials of registries
+	SecretJenkinsDockerConfig = "jenkins-docker-cfg"
+
 	// SecretJenkinsReleaseGPG the GPG secrets for doing releases
 	SecretJenkinsReleaseGPG = "jenkins-release-gpg"
 
@@ -173,6 +179,9 @@ const (
 	// ValueKindCVE an CVS App secret/credentials
 	ValueKindCVE = "cve"
 
+	// ValueKindDocker a docker registry auth secret/credentials
+	ValueKindDocker = "docker"
+
 	// ValueKindEnvironmentRole to indicate a Role which maps to an EnvironmentRoleBinding
 	ValueKindEnvironmentRole = "EnvironmentRole"
 
@@ -285,6 +294,12 @@ const (
 	// AnnotationReleaseName is the name of the annotation that stores the release name in the preview environment
 	AnnotationReleaseName = "jenkins.io/chart-release"
 
+	// AnnotationPreviewReplicas the annotation that stores the replicas of a preview deployment scaled to zero when idle
+	AnnotationPreviewReplicas = "jenkins.io/preview-replicas"
+
+	// AnnotationRestartedAt the pod template annotation used to trigger a rolling restart of a deployment
+	AnnotationRestartedAt = "jenkins.io/restartedAt"
+
 	// SecretDataUsername the username in a Secret/Credentials
 	SecretDataUsername = "username"
 
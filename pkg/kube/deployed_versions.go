@@ -0,0 +1,73 @@
+package kube
+
+import (
+	"sort"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+)
+
+// DeployedVersion is a version of an application which was successfully promoted to an Environment
+type DeployedVersion struct {
+	Version             string
+	Activity            *v1.PipelineActivity
+	Promoted            time.Time
+	Rollback            bool
+	RollbackFromVersion string
+}
+
+// GetDeployedVersions returns the versions of the application which were successfully promoted to the Environment
+// with the most recent first
+func GetDeployedVersions(activities []v1.PipelineActivity, app string, envName string) []DeployedVersion {
+	answer := []DeployedVersion{}
+	for i := range activities {
+		activity := &activities[i]
+		if activity.RepositoryName() != app {
+			continue
+		}
+		for _, step := range activity.Spec.Steps {
+			promote := step.Promote
+			if promote == nil || promote.Environment != envName || promote.Status != v1.ActivityStatusTypeSucceeded {
+				continue
+			}
+			version := activity.Spec.Version
+			if version == "" {
+				continue
+			}
+			promoted := activity.CreationTimestamp.Time
+			if promote.CompletedTimestamp != nil {
+				promoted = promote.CompletedTimestamp.Time
+			} else if promote.StartedTimestamp != nil {
+				promoted = promote.StartedTimestamp.Time
+			}
+			answer = append(answer, DeployedVersion{
+				Version:             version,
+				Activity:            activity,
+				Promoted:            promoted,
+				Rollback:            promote.Rollback,
+				RollbackFromVersion: promote.RollbackFromVersion,
+			})
+		}
+	}
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Promoted.After(answer[j].Promoted)
+	})
+	return answer
+}
+
+// FindRollbackVersion returns the most recently deployed version before the current version which has not been
+// rolled back from or nil if there is none
+func FindRollbackVersion(history []DeployedVersion, current string) *DeployedVersion {
+	bad := map[string]bool{current: true}
+	for _, deployed := range history {
+		if deployed.Rollback && deployed.RollbackFromVersion != "" {
+			bad[deployed.RollbackFromVersion] = true
+		}
+	}
+	for i := range history {
+		if !bad[history[i].Version] {
+			return &history[i]
+		}
+	}
+	return nil
+}
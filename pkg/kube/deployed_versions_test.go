@@ -0,0 +1,77 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func promotedActivity(build string, version string, envName string, completed time.Time, status v1.ActivityStatusType, rollbackFrom string) v1.PipelineActivity {
+	return v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-" + build},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:      "myorg/myapp/master",
+			Build:         build,
+			Version:       version,
+			GitOwner:      "myorg",
+			GitRepository: "myapp",
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Status:             status,
+							CompletedTimestamp: &metav1.Time{Time: completed},
+						},
+						Environment:         envName,
+						Rollback:            rollbackFrom != "",
+						RollbackFromVersion: rollbackFrom,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestFindRollbackVersion(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	activities := []v1.PipelineActivity{
+		promotedActivity("1", "1.0.0", "production", now.Add(-5*time.Hour), v1.ActivityStatusTypeSucceeded, ""),
+		promotedActivity("2", "1.0.1", "production", now.Add(-4*time.Hour), v1.ActivityStatusTypeSucceeded, ""),
+		promotedActivity("3", "1.0.2", "staging", now.Add(-3*time.Hour), v1.ActivityStatusTypeSucceeded, ""),
+		promotedActivity("4", "1.0.3", "production", now.Add(-2*time.Hour), v1.ActivityStatusTypeFailed, ""),
+		promotedActivity("5", "1.0.4", "production", now.Add(-1*time.Hour), v1.ActivityStatusTypeSucceeded, ""),
+	}
+
+	history := kube.GetDeployedVersions(activities, "myapp", "production")
+	require.Len(t, history, 3)
+	assert.Equal(t, "1.0.4", history[0].Version)
+	assert.Equal(t, "1.0.1", history[1].Version)
+	assert.Equal(t, "1.0.0", history[2].Version)
+
+	target := kube.FindRollbackVersion(history, "1.0.4")
+	require.NotNil(t, target)
+	assert.Equal(t, "1.0.1", target.Version)
+	assert.Equal(t, "myorg-myapp-master-2", target.Activity.Name)
+
+	// after rolling back to 1.0.1 and deploying 1.0.5 the versions rolled back from are not good
+	activities = append(activities,
+		promotedActivity("6", "1.0.1", "production", now.Add(-50*time.Minute), v1.ActivityStatusTypeSucceeded, "1.0.4"),
+		promotedActivity("7", "1.0.5", "production", now.Add(-10*time.Minute), v1.ActivityStatusTypeSucceeded, ""),
+	)
+	history = kube.GetDeployedVersions(activities, "myapp", "production")
+	require.Len(t, history, 5)
+	assert.True(t, history[1].Rollback)
+
+	target = kube.FindRollbackVersion(history, "1.0.5")
+	require.NotNil(t, target)
+	assert.Equal(t, "1.0.1", target.Version)
+
+	assert.Nil(t, kube.FindRollbackVersion(history[len(history)-1:], "1.0.0"))
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jenkins-x/jx/pkg/flagger"
 	"github.com/jenkins-x/jx/pkg/log"
+	"github.com/pkg/errors"
 	"k8s.io/api/apps/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -29,6 +31,25 @@ func GetDeployments(kubeClient kubernetes.Interface, ns string) (map[string]v1be
 	return answer, nil
 }
 
+// GetDeployedAppVersions returns the versions of the applications deployed in the namespace indexed by application name
+func GetDeployedAppVersions(kubeClient kubernetes.Interface, ns string) (map[string]string, error) {
+	deployments, err := GetDeployments(kubeClient, ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the deployments in namespace %s", ns)
+	}
+	answer := map[string]string{}
+	for name, d := range deployments {
+		if flagger.IsCanaryAuxiliaryDeployment(d) {
+			continue
+		}
+		version := GetVersion(&d.ObjectMeta)
+		if version != "" {
+			answer[GetAppName(name, ns)] = version
+		}
+	}
+	return answer, nil
+}
+
 func GetDeploymentNames(client kubernetes.Interface, ns string, filter string) ([]string, error) {
 	names := []string{}
 	list, err := client.AppsV1beta1().Deployments(ns).List(metav1.ListOptions{})
@@ -176,3 +197,54 @@ func GetDeploymentPods(client kubernetes.Interface, name, namespace string) ([]v
 
 	return pods.Items, err
 }
+
+// RestartDeploymentsUsingSecret triggers a rolling restart of any deployments in the namespace whose pods reference the
+// given secret via environment variables or volumes. The names of the restarted deployments are returned
+func RestartDeploymentsUsingSecret(client kubernetes.Interface, namespace string, secretName string) ([]string, error) {
+	restarted := []string{}
+	deployments := client.AppsV1().Deployments(namespace)
+	list, err := deployments.List(metav1.ListOptions{})
+	if err != nil {
+		return restarted, errors.Wrapf(err, "failed to list deployments in namespace %s", namespace)
+	}
+	for i := range list.Items {
+		d := &list.Items[i]
+		if !PodSpecUsesSecret(&d.Spec.Template.Spec, secretName) {
+			continue
+		}
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+		d.Spec.Template.Annotations[AnnotationRestartedAt] = time.Now().UTC().Format(time.RFC3339)
+		_, err = deployments.Update(d)
+		if err != nil {
+			return restarted, errors.Wrapf(err, "failed to restart deployment %s in namespace %s", d.Name, namespace)
+		}
+		restarted = append(restarted, d.Name)
+	}
+	return restarted, nil
+}
+
+// PodSpecUsesSecret returns true if the pod spec references the given secret via environment variables or volumes
+func PodSpecUsesSecret(spec *v1.PodSpec, secretName string) bool {
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			return true
+		}
+	}
+	containers := append([]v1.Container{}, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
+				return true
+			}
+		}
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
+				return true
+			}
+		}
+	}
+	return false
+}
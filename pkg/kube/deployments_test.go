@@ -86,3 +86,67 @@ func TestWaitForDeploymentToBeReady(t *testing.T) {
 	assert.NoError(t, err, "Should not error")
 
 }
+
+func TestRestartDeploymentsUsingSecret(t *testing.T) {
+	t.Parallel()
+
+	ns := "jx-testing"
+	newDeployment := func(name string, spec v1.PodSpec) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: spec,
+				},
+			},
+		}
+	}
+	envUser := newDeployment("env-user", v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name: "web",
+				Env: []v1.EnvVar{
+					{
+						Name: "TOKEN",
+						ValueFrom: &v1.EnvVarSource{
+							SecretKeyRef: &v1.SecretKeySelector{
+								LocalObjectReference: v1.LocalObjectReference{Name: "my-secret"},
+								Key:                  "password",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	volumeUser := newDeployment("volume-user", v1.PodSpec{
+		Volumes: []v1.Volume{
+			{
+				Name: "creds",
+				VolumeSource: v1.VolumeSource{
+					Secret: &v1.SecretVolumeSource{SecretName: "my-secret"},
+				},
+			},
+		},
+	})
+	other := newDeployment("other", v1.PodSpec{
+		Containers: []v1.Container{{Name: "web"}},
+	})
+
+	client := kube_mocks.NewSimpleClientset(envUser, volumeUser, other)
+
+	restarted, err := kube.RestartDeploymentsUsingSecret(client, ns, "my-secret")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"env-user", "volume-user"}, restarted)
+
+	d, err := client.AppsV1().Deployments(ns).Get("env-user", meta_v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, d.Spec.Template.Annotations[kube.AnnotationRestartedAt])
+
+	d, err = client.AppsV1().Deployments(ns).Get("other", meta_v1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, d.Spec.Template.Annotations[kube.AnnotationRestartedAt])
+}
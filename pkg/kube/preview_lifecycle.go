@@ -0,0 +1,190 @@
+package kube
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewPreviewLifecycle creates the lifecycle of a Preview Environment from the preview environment configuration of
+// a project or returns nil if there is nothing configured
+func NewPreviewLifecycle(cfg *config.PreviewEnvironmentConfig) (*v1.PreviewLifecycle, error) {
+	if cfg == nil || (cfg.TTL == "" && cfg.IdleTimeout == "" && cfg.MaximumInstances <= 0) {
+		return nil, nil
+	}
+	lifecycle := &v1.PreviewLifecycle{
+		MaximumInstances: cfg.MaximumInstances,
+	}
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing the preview environment ttl %s", cfg.TTL)
+		}
+		lifecycle.TTL = metav1.Duration{Duration: ttl}
+	}
+	if cfg.IdleTimeout != "" {
+		idleTimeout, err := time.ParseDuration(cfg.IdleTimeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing the preview environment idleTimeout %s", cfg.IdleTimeout)
+		}
+		lifecycle.IdleTimeout = metav1.Duration{Duration: idleTimeout}
+	}
+	return lifecycle, nil
+}
+
+// IsPreviewExpired returns true if the Preview Environment has lived longer than its TTL
+func IsPreviewExpired(env *v1.Environment, now time.Time) bool {
+	lifecycle := env.Spec.PreviewLifecycle
+	if lifecycle == nil || lifecycle.TTL.Duration <= 0 {
+		return false
+	}
+	return now.After(env.CreationTimestamp.Add(lifecycle.TTL.Duration))
+}
+
+// GetOrCreatePreviewStatus returns the status of the Preview Environment, lazily creating it if required
+func GetOrCreatePreviewStatus(env *v1.Environment) *v1.PreviewStatus {
+	if env.Status.Preview == nil {
+		env.Status.Preview = &v1.PreviewStatus{}
+	}
+	return env.Status.Preview
+}
+
+// IsPreviewScaledDown returns true if the deployments of the Preview Environment have been scaled to zero
+func IsPreviewScaledDown(env *v1.Environment) bool {
+	status := env.Status.Preview
+	return status != nil && status.ScaledDownTimestamp != nil
+}
+
+// IsPreviewIdle returns true if the Preview Environment is running and has had no new commits or HTTP traffic for
+// longer than its idle timeout
+func IsPreviewIdle(env *v1.Environment, now time.Time) bool {
+	lifecycle := env.Spec.PreviewLifecycle
+	if lifecycle == nil || lifecycle.IdleTimeout.Duration <= 0 || IsPreviewScaledDown(env) {
+		return false
+	}
+	return now.After(GetPreviewLastActivity(env).Add(lifecycle.IdleTimeout.Duration))
+}
+
+// GetPreviewLastActivity returns the time of the last commit or HTTP traffic of the Preview Environment, defaulting
+// to when it was created
+func GetPreviewLastActivity(env *v1.Environment) time.Time {
+	answer := env.CreationTimestamp.Time
+	status := env.Status.Preview
+	if status == nil {
+		return answer
+	}
+	for _, t := range []*metav1.Time{status.LastCommitTimestamp, status.LastTrafficTimestamp} {
+		if t != nil && t.Time.After(answer) {
+			answer = t.Time
+		}
+	}
+	return answer
+}
+
+// GetPreviewsToEvict returns the oldest Preview Environments of each application which exceed the maximum
+// number of instances configured on the newest Preview Environment of the application
+func GetPreviewsToEvict(envs []v1.Environment) []v1.Environment {
+	apps := map[string][]v1.Environment{}
+	keys := []string{}
+	for _, env := range envs {
+		if !IsPreviewEnvironment(&env) {
+			continue
+		}
+		key := env.Spec.Source.URL
+		if key == "" {
+			key = env.Spec.PreviewGitSpec.ApplicationName
+		}
+		if _, ok := apps[key]; !ok {
+			keys = append(keys, key)
+		}
+		apps[key] = append(apps[key], env)
+	}
+	sort.Strings(keys)
+
+	answer := []v1.Environment{}
+	for _, key := range keys {
+		previews := apps[key]
+		sort.SliceStable(previews, func(i, j int) bool {
+			ti := previews[i].CreationTimestamp
+			tj := previews[j].CreationTimestamp
+			if ti.Equal(&tj) {
+				return previews[i].Name < previews[j].Name
+			}
+			return ti.Before(&tj)
+		})
+		lifecycle := previews[len(previews)-1].Spec.PreviewLifecycle
+		if lifecycle == nil || lifecycle.MaximumInstances <= 0 || len(previews) <= lifecycle.MaximumInstances {
+			continue
+		}
+		answer = append(answer, previews[:len(previews)-lifecycle.MaximumInstances]...)
+	}
+	return answer
+}
+
+// ScaleDownPreview scales the deployments of the Preview Environment to zero replicas, recording their replicas in an
+// annotation so that they can be restored by WakePreview
+func ScaleDownPreview(kubeClient kubernetes.Interface, env *v1.Environment) error {
+	ns := env.Spec.Namespace
+	deployments := kubeClient.AppsV1().Deployments(ns)
+	list, err := deployments.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "listing Deployments in namespace %s", ns)
+	}
+	for i := range list.Items {
+		d := &list.Items[i]
+		if d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
+			continue
+		}
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		if d.Annotations == nil {
+			d.Annotations = map[string]string{}
+		}
+		d.Annotations[AnnotationPreviewReplicas] = strconv.Itoa(int(replicas))
+		zero := int32(0)
+		d.Spec.Replicas = &zero
+		_, err = deployments.Update(d)
+		if err != nil {
+			return errors.Wrapf(err, "scaling down Deployment %s in namespace %s", d.Name, ns)
+		}
+	}
+	return nil
+}
+
+// WakePreview restores the replicas of the deployments of the Preview Environment which were scaled to zero by
+// ScaleDownPreview
+func WakePreview(kubeClient kubernetes.Interface, env *v1.Environment) error {
+	ns := env.Spec.Namespace
+	deployments := kubeClient.AppsV1().Deployments(ns)
+	list, err := deployments.List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "listing Deployments in namespace %s", ns)
+	}
+	for i := range list.Items {
+		d := &list.Items[i]
+		value := d.Annotations[AnnotationPreviewReplicas]
+		if value == "" {
+			continue
+		}
+		replicas, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "parsing the %s annotation of Deployment %s", AnnotationPreviewReplicas, d.Name)
+		}
+		r := int32(replicas)
+		d.Spec.Replicas = &r
+		delete(d.Annotations, AnnotationPreviewReplicas)
+		_, err = deployments.Update(d)
+		if err != nil {
+			return errors.Wrapf(err, "scaling up Deployment %s in namespace %s", d.Name, ns)
+		}
+	}
+	return nil
+}
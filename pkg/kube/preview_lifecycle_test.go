@@ -0,0 +1,120 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/config"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_mocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewPreviewLifecycle(t *testing.T) {
+	t.Parallel()
+	lifecycle, err := kube.NewPreviewLifecycle(&config.PreviewEnvironmentConfig{Disabled: true})
+	require.NoError(t, err)
+	assert.Nil(t, lifecycle)
+
+	lifecycle, err = kube.NewPreviewLifecycle(&config.PreviewEnvironmentConfig{TTL: "72h", IdleTimeout: "30m", MaximumInstances: 3})
+	require.NoError(t, err)
+	require.NotNil(t, lifecycle)
+	assert.Equal(t, 72*time.Hour, lifecycle.TTL.Duration)
+	assert.Equal(t, 30*time.Minute, lifecycle.IdleTimeout.Duration)
+	assert.Equal(t, 3, lifecycle.MaximumInstances)
+
+	_, err = kube.NewPreviewLifecycle(&config.PreviewEnvironmentConfig{TTL: "3 days"})
+	assert.Error(t, err)
+}
+
+func TestPreviewExpiredAndIdle(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	lastCommit := metav1.NewTime(now.Add(-2 * time.Hour))
+	env := newPreview("pr-1", "https://github.com/myorg/myapp.git", now.Add(-10*time.Hour), &v1.PreviewLifecycle{
+		TTL:         metav1.Duration{Duration: 24 * time.Hour},
+		IdleTimeout: metav1.Duration{Duration: 4 * time.Hour},
+	})
+	env.Status.Preview = &v1.PreviewStatus{
+		LastCommitTimestamp: &lastCommit,
+	}
+
+	assert.False(t, kube.IsPreviewExpired(&env, now))
+	assert.True(t, kube.IsPreviewExpired(&env, now.Add(15*time.Hour)))
+
+	assert.False(t, kube.IsPreviewIdle(&env, now), "the last commit should keep the preview running")
+	assert.True(t, kube.IsPreviewIdle(&env, now.Add(3*time.Hour)))
+
+	lastTraffic := metav1.NewTime(now.Add(time.Hour))
+	env.Status.Preview.LastTrafficTimestamp = &lastTraffic
+	assert.False(t, kube.IsPreviewIdle(&env, now.Add(3*time.Hour)), "traffic should keep the preview running")
+
+	scaledDown := metav1.NewTime(now)
+	env.Status.Preview.ScaledDownTimestamp = &scaledDown
+	assert.True(t, kube.IsPreviewScaledDown(&env))
+	assert.False(t, kube.IsPreviewIdle(&env, now.Add(24*time.Hour)), "a scaled down preview is not scaled down again")
+}
+
+func TestGetPreviewsToEvict(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	lifecycle := &v1.PreviewLifecycle{MaximumInstances: 2}
+	envs := []v1.Environment{
+		newPreview("myapp-pr-3", "https://github.com/myorg/myapp.git", now.Add(-1*time.Hour), lifecycle),
+		newPreview("myapp-pr-1", "https://github.com/myorg/myapp.git", now.Add(-3*time.Hour), lifecycle),
+		newPreview("other-pr-1", "https://github.com/myorg/other.git", now.Add(-5*time.Hour), nil),
+		newPreview("other-pr-2", "https://github.com/myorg/other.git", now.Add(-4*time.Hour), nil),
+		newPreview("myapp-pr-2", "https://github.com/myorg/myapp.git", now.Add(-2*time.Hour), lifecycle),
+		newPreview("myapp-pr-0", "https://github.com/myorg/myapp.git", now.Add(-4*time.Hour), lifecycle),
+	}
+
+	evicted := kube.GetPreviewsToEvict(envs)
+	names := []string{}
+	for _, env := range evicted {
+		names = append(names, env.Name)
+	}
+	assert.Equal(t, []string{"myapp-pr-0", "myapp-pr-1"}, names)
+}
+
+func TestScaleDownAndWakePreview(t *testing.T) {
+	t.Parallel()
+	replicas := int32(2)
+	kubeClient := kube_mocks.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "jx-myorg-myapp-pr-1"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+	env := newPreview("myorg-myapp-pr-1", "https://github.com/myorg/myapp.git", time.Now(), nil)
+	env.Spec.Namespace = "jx-myorg-myapp-pr-1"
+
+	err := kube.ScaleDownPreview(kubeClient, &env)
+	require.NoError(t, err)
+	d, err := kubeClient.AppsV1().Deployments(env.Spec.Namespace).Get("myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), *d.Spec.Replicas)
+	assert.Equal(t, "2", d.Annotations[kube.AnnotationPreviewReplicas])
+
+	err = kube.WakePreview(kubeClient, &env)
+	require.NoError(t, err)
+	d, err = kubeClient.AppsV1().Deployments(env.Spec.Namespace).Get("myapp", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), *d.Spec.Replicas)
+	assert.NotContains(t, d.Annotations, kube.AnnotationPreviewReplicas)
+}
+
+func newPreview(name string, sourceURL string, created time.Time, lifecycle *v1.PreviewLifecycle) v1.Environment {
+	return v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.EnvironmentSpec{
+			Kind:             v1.EnvironmentKindTypePreview,
+			Source:           v1.EnvironmentRepository{URL: sourceURL},
+			PreviewLifecycle: lifecycle,
+		},
+	}
+}
@@ -0,0 +1,168 @@
+package kube
+
+import (
+	"regexp"
+	"sort"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+)
+
+const (
+	// PromotionSourceActivity the promotion was found in the promote step of a PipelineActivity
+	PromotionSourceActivity = "activity"
+	// PromotionSourceCommit the promotion was found in a commit of the git repository of the Environment
+	PromotionSourceCommit = "commit"
+)
+
+var (
+	promoteCommitMessage  = regexp.MustCompile(`(?m)Promote (\S+) to version (\S+)`)
+	rollbackCommitMessage = regexp.MustCompile(`(?m)Rollback (\S+) from version (\S+) to version (\S+)`)
+)
+
+// PromotionRecord is a promotion of a version of an application to an Environment
+type PromotionRecord struct {
+	Application    string                `json:"application"`
+	Environment    string                `json:"environment"`
+	FromVersion    string                `json:"fromVersion,omitempty"`
+	Version        string                `json:"version"`
+	PullRequestURL string                `json:"pullRequestURL,omitempty"`
+	Author         string                `json:"author,omitempty"`
+	Status         v1.ActivityStatusType `json:"status,omitempty"`
+	Rollback       bool                  `json:"rollback,omitempty"`
+	Timestamp      time.Time             `json:"timestamp"`
+	Source         string                `json:"source"`
+}
+
+// GetPromotionRecords returns the promotions to the Environment in the promote steps of the PipelineActivities
+func GetPromotionRecords(activities []v1.PipelineActivity, envName string) []PromotionRecord {
+	answer := []PromotionRecord{}
+	for i := range activities {
+		activity := &activities[i]
+		for _, step := range activity.Spec.Steps {
+			promote := step.Promote
+			if step.Kind != v1.ActivityStepKindTypePromote || promote == nil || promote.Environment != envName {
+				continue
+			}
+			record := PromotionRecord{
+				Application: activity.RepositoryName(),
+				Environment: envName,
+				Version:     activity.Spec.Version,
+				Author:      activity.Spec.Author,
+				Status:      promote.Status,
+				Rollback:    promote.Rollback,
+				Source:      PromotionSourceActivity,
+			}
+			if promote.Rollback {
+				record.FromVersion = promote.RollbackFromVersion
+			}
+			if promote.PullRequest != nil {
+				record.PullRequestURL = promote.PullRequest.PullRequestURL
+			}
+			switch {
+			case promote.CompletedTimestamp != nil:
+				record.Timestamp = promote.CompletedTimestamp.Time
+			case promote.StartedTimestamp != nil:
+				record.Timestamp = promote.StartedTimestamp.Time
+			default:
+				record.Timestamp = activity.CreationTimestamp.Time
+			}
+			answer = append(answer, record)
+		}
+	}
+	return answer
+}
+
+// ParsePromotionCommit returns the promotion in the message of a commit to the git repository of the Environment or
+// nil if the commit is not a promotion
+func ParsePromotionCommit(envName string, author string, timestamp time.Time, message string) *PromotionRecord {
+	record := &PromotionRecord{
+		Environment: envName,
+		Author:      author,
+		Status:      v1.ActivityStatusTypeSucceeded,
+		Timestamp:   timestamp,
+		Source:      PromotionSourceCommit,
+	}
+	if m := rollbackCommitMessage.FindStringSubmatch(message); m != nil {
+		record.Application = m[1]
+		record.FromVersion = m[2]
+		record.Version = m[3]
+		record.Rollback = true
+		return record
+	}
+	if m := promoteCommitMessage.FindStringSubmatch(message); m != nil {
+		record.Application = m[1]
+		record.Version = m[2]
+		return record
+	}
+	return nil
+}
+
+// MergePromotionHistory merges the promotions of the PipelineActivities with the promotions found in the commits of
+// the git repository of the Environment, which are only used for promotions without a PipelineActivity. The previous
+// version of each promotion is filled in from the previous successful promotion of the application and the history is
+// returned newest first
+func MergePromotionHistory(activityRecords []PromotionRecord, commitRecords []PromotionRecord) []PromotionRecord {
+	answer := append([]PromotionRecord{}, activityRecords...)
+	known := map[string]bool{}
+	for _, r := range activityRecords {
+		known[r.Application+"@"+r.Version] = true
+	}
+	for _, r := range commitRecords {
+		if !known[r.Application+"@"+r.Version] {
+			answer = append(answer, r)
+		}
+	}
+
+	sort.SliceStable(answer, func(i, j int) bool {
+		return answer[i].Timestamp.Before(answer[j].Timestamp)
+	})
+	current := map[string]string{}
+	for i := range answer {
+		r := &answer[i]
+		if r.FromVersion == "" {
+			r.FromVersion = current[r.Application]
+		}
+		if r.Status == v1.ActivityStatusTypeSucceeded {
+			current[r.Application] = r.Version
+		}
+	}
+	for i, j := 0, len(answer)-1; i < j; i, j = i+1, j-1 {
+		answer[i], answer[j] = answer[j], answer[i]
+	}
+	return answer
+}
+
+// AppVersionDiff is an application whose version differs between two Environments
+type AppVersionDiff struct {
+	Application string `json:"application"`
+	Left        string `json:"left,omitempty"`
+	Right       string `json:"right,omitempty"`
+}
+
+// DiffAppVersions returns the applications whose versions differ between the two Environments sorted by name,
+// including the applications which are the same if all is true
+func DiffAppVersions(left map[string]string, right map[string]string, all bool) []AppVersionDiff {
+	names := []string{}
+	for name := range left {
+		names = append(names, name)
+	}
+	for name := range right {
+		if _, ok := left[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	answer := []AppVersionDiff{}
+	for _, name := range names {
+		if !all && left[name] == right[name] {
+			continue
+		}
+		answer = append(answer, AppVersionDiff{
+			Application: name,
+			Left:        left[name],
+			Right:       right[name],
+		})
+	}
+	return answer
+}
@@ -0,0 +1,110 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePromotionCommit(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	record := kube.ParsePromotionCommit("staging", "jenkins-x-bot", now, "chore: Promote myapp to version 1.2.3\n\nsome details")
+	require.NotNil(t, record)
+	assert.Equal(t, "myapp", record.Application)
+	assert.Equal(t, "1.2.3", record.Version)
+	assert.Equal(t, "jenkins-x-bot", record.Author)
+	assert.Equal(t, kube.PromotionSourceCommit, record.Source)
+	assert.False(t, record.Rollback)
+
+	record = kube.ParsePromotionCommit("staging", "bob", now, "Merge pull request #3 from promote-myapp-1.2.3\n\nchore: Rollback myapp from version 1.2.3 to version 1.2.2")
+	require.NotNil(t, record)
+	assert.Equal(t, "myapp", record.Application)
+	assert.Equal(t, "1.2.3", record.FromVersion)
+	assert.Equal(t, "1.2.2", record.Version)
+	assert.True(t, record.Rollback)
+
+	assert.Nil(t, kube.ParsePromotionCommit("staging", "bob", now, "fix: the ingress of the environment"))
+}
+
+func TestPromotionHistory(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	activities := []v1.PipelineActivity{
+		newPromoteActivity("myapp", "1.0.1", "staging", v1.ActivityStatusTypeSucceeded, now.Add(-3*time.Hour)),
+		newPromoteActivity("myapp", "1.0.2", "staging", v1.ActivityStatusTypeFailed, now.Add(-2*time.Hour)),
+		newPromoteActivity("myapp", "1.0.3", "staging", v1.ActivityStatusTypeSucceeded, now.Add(-1*time.Hour)),
+		newPromoteActivity("other", "2.0.0", "production", v1.ActivityStatusTypeSucceeded, now.Add(-1*time.Hour)),
+	}
+	activityRecords := kube.GetPromotionRecords(activities, "staging")
+	require.Len(t, activityRecords, 3)
+	assert.Equal(t, "https://github.com/myorg/environment-staging/pull/1.0.1", activityRecords[0].PullRequestURL)
+
+	commitRecords := []kube.PromotionRecord{
+		*kube.ParsePromotionCommit("staging", "bot", now.Add(-4*time.Hour), "chore: Promote myapp to version 1.0.0"),
+		*kube.ParsePromotionCommit("staging", "bot", now.Add(-1*time.Hour), "chore: Promote myapp to version 1.0.3"),
+	}
+
+	history := kube.MergePromotionHistory(activityRecords, commitRecords)
+	require.Len(t, history, 4, "the commit of a promotion with a PipelineActivity should be ignored")
+	versions := []string{}
+	from := []string{}
+	for _, r := range history {
+		versions = append(versions, r.Version)
+		from = append(from, r.FromVersion)
+	}
+	assert.Equal(t, []string{"1.0.3", "1.0.2", "1.0.1", "1.0.0"}, versions)
+	assert.Equal(t, []string{"1.0.1", "1.0.1", "1.0.0", ""}, from, "a failed promotion should not change the previous version")
+	assert.Equal(t, kube.PromotionSourceActivity, history[0].Source)
+	assert.Equal(t, kube.PromotionSourceCommit, history[3].Source)
+}
+
+func TestDiffAppVersions(t *testing.T) {
+	t.Parallel()
+	staging := map[string]string{"myapp": "1.0.3", "other": "2.0.0", "new": "0.0.1"}
+	production := map[string]string{"myapp": "1.0.1", "other": "2.0.0", "old": "3.0.0"}
+
+	diffs := kube.DiffAppVersions(staging, production, false)
+	assert.Equal(t, []kube.AppVersionDiff{
+		{Application: "myapp", Left: "1.0.3", Right: "1.0.1"},
+		{Application: "new", Left: "0.0.1"},
+		{Application: "old", Right: "3.0.0"},
+	}, diffs)
+
+	assert.Len(t, kube.DiffAppVersions(staging, production, true), 4)
+}
+
+func newPromoteActivity(app string, version string, envName string, status v1.ActivityStatusType, completed time.Time) v1.PipelineActivity {
+	timestamp := metav1.NewTime(completed)
+	return v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myorg-" + app + "-master-" + version,
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:      "myorg/" + app + "/master",
+			GitRepository: app,
+			Version:       version,
+			Author:        "alice",
+			Steps: []v1.PipelineActivityStep{
+				{
+					Kind: v1.ActivityStepKindTypePromote,
+					Promote: &v1.PromoteActivityStep{
+						CoreActivityStep: v1.CoreActivityStep{
+							Status:             status,
+							CompletedTimestamp: &timestamp,
+						},
+						Environment: envName,
+						PullRequest: &v1.PromotePullRequestStep{
+							PullRequestURL: "https://github.com/myorg/environment-" + envName + "/pull/" + version,
+						},
+					},
+				},
+			},
+		},
+	}
+}
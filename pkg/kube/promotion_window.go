@@ -0,0 +1,165 @@
+package kube
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	cron "gopkg.in/robfig/cron.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxPromotionWindowSearches bounds the search for the next time promotions are allowed when freeze periods
+// and promotion windows overlap
+const maxPromotionWindowSearches = 1000
+
+// PromotionWindowStatus is the result of checking the promotion windows and freeze periods of an Environment
+type PromotionWindowStatus struct {
+	// Allowed is true if promotions to the Environment are currently allowed
+	Allowed bool
+	// Reason describes why promotions are not currently allowed
+	Reason string
+	// NextAllowed is the next time promotions are allowed or nil if promotions are allowed now or never will be
+	NextAllowed *time.Time
+}
+
+type promotionWindowSchedule struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// ValidatePromotionWindows returns an error if any of the promotion windows or freeze periods of the Environment are invalid
+func ValidatePromotionWindows(env *v1.Environment) error {
+	_, err := parsePromotionWindows(env)
+	if err != nil {
+		return err
+	}
+	for _, freeze := range env.Spec.FreezePeriods {
+		if !freeze.End.After(freeze.Start.Time) {
+			return fmt.Errorf("the freeze period %s of Environment %s must end after it starts", freeze.Name, env.Name)
+		}
+	}
+	return nil
+}
+
+// GetPromotionWindowStatus returns whether promotions to the Environment are allowed at the given time based on
+// its promotion windows and freeze periods. If not, the status contains the next time promotions are allowed
+func GetPromotionWindowStatus(env *v1.Environment, now time.Time) (*PromotionWindowStatus, error) {
+	schedules, err := parsePromotionWindows(env)
+	if err != nil {
+		return nil, err
+	}
+	status := &PromotionWindowStatus{}
+	freeze := activeFreezePeriod(env.Spec.FreezePeriods, now)
+	if freeze != nil {
+		status.Reason = fmt.Sprintf("the Environment %s is frozen until %s", env.Name, freeze.End.Format(time.RFC3339))
+		if freeze.Reason != "" {
+			status.Reason += ": " + freeze.Reason
+		}
+	} else if len(schedules) > 0 && !inPromotionWindow(schedules, now) {
+		status.Reason = fmt.Sprintf("the Environment %s is outside of its promotion windows", env.Name)
+	} else {
+		status.Allowed = true
+		return status, nil
+	}
+
+	t := now
+	for i := 0; i < maxPromotionWindowSearches && !t.IsZero(); i++ {
+		freeze := activeFreezePeriod(env.Spec.FreezePeriods, t)
+		if freeze != nil {
+			t = freeze.End.Time
+			continue
+		}
+		if len(schedules) > 0 && !inPromotionWindow(schedules, t) {
+			t = nextPromotionWindow(schedules, t)
+			continue
+		}
+		status.NextAllowed = &t
+		break
+	}
+	return status, nil
+}
+
+// QueuePromoteStep marks the promote step as pending until promotions to its Environment are next allowed.
+// Returns true if the step was modified
+func QueuePromoteStep(promote *v1.PromoteActivityStep, status *PromotionWindowStatus) bool {
+	var queuedUntil *metav1.Time
+	if status.NextAllowed != nil {
+		queuedUntil = &metav1.Time{Time: *status.NextAllowed}
+	}
+	if promote.Status == v1.ActivityStatusTypePending && promote.Description == status.Reason &&
+		queuedUntil.Equal(promote.QueuedUntil) {
+		return false
+	}
+	promote.Status = v1.ActivityStatusTypePending
+	promote.Description = status.Reason
+	promote.QueuedUntil = queuedUntil
+	return true
+}
+
+// IsPromoteStepQueued returns true if the promote step is waiting for a promotion window of its Environment to open
+func IsPromoteStepQueued(promote *v1.PromoteActivityStep) bool {
+	return promote != nil && promote.Status == v1.ActivityStatusTypePending && promote.QueuedUntil != nil
+}
+
+func parsePromotionWindows(env *v1.Environment) ([]promotionWindowSchedule, error) {
+	answer := []promotionWindowSchedule{}
+	for _, window := range env.Spec.PromotionWindows {
+		if window.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("the promotion window %s of Environment %s has no duration", window.Name, env.Name)
+		}
+		// lets always use an explicit timezone as cron defaults to the local time of the controller
+		timezone := window.Timezone
+		if timezone == "" {
+			timezone = time.UTC.String()
+		}
+		_, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid timezone %s for promotion window %s of Environment %s", timezone, window.Name, env.Name)
+		}
+		spec := "TZ=" + timezone + " " + window.Schedule
+		schedule, err := cron.Parse(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule %s for promotion window %s of Environment %s", window.Schedule, window.Name, env.Name)
+		}
+		answer = append(answer, promotionWindowSchedule{
+			schedule: schedule,
+			duration: window.Duration.Duration,
+		})
+	}
+	return answer, nil
+}
+
+// inPromotionWindow returns true if one of the windows opened within its duration before the given time
+func inPromotionWindow(schedules []promotionWindowSchedule, t time.Time) bool {
+	for _, s := range schedules {
+		opened := s.schedule.Next(t.Add(-s.duration))
+		if !opened.IsZero() && !opened.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextPromotionWindow returns the next time one of the windows opens after the given time or the zero time if none will
+func nextPromotionWindow(schedules []promotionWindowSchedule, t time.Time) time.Time {
+	answer := time.Time{}
+	for _, s := range schedules {
+		next := s.schedule.Next(t)
+		if !next.IsZero() && (answer.IsZero() || next.Before(answer)) {
+			answer = next
+		}
+	}
+	return answer
+}
+
+func activeFreezePeriod(freezes []v1.FreezePeriod, t time.Time) *v1.FreezePeriod {
+	for i := range freezes {
+		freeze := &freezes[i]
+		if !t.Before(freeze.Start.Time) && t.Before(freeze.End.Time) {
+			return freeze
+		}
+	}
+	return nil
+}
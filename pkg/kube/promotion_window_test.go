@@ -0,0 +1,135 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetPromotionWindowStatus(t *testing.T) {
+	t.Parallel()
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: v1.EnvironmentSpec{
+			PromotionWindows: []v1.PromotionWindow{
+				{
+					Name:     "office-hours",
+					Schedule: "0 9 * * MON-FRI",
+					Duration: metav1.Duration{Duration: 8 * time.Hour},
+					Timezone: "America/New_York",
+				},
+			},
+		},
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Wednesday
+	status, err := kube.GetPromotionWindowStatus(env, time.Date(2019, 7, 10, 10, 30, 0, 0, newYork))
+	require.NoError(t, err)
+	assert.True(t, status.Allowed)
+
+	status, err = kube.GetPromotionWindowStatus(env, time.Date(2019, 7, 10, 17, 30, 0, 0, newYork))
+	require.NoError(t, err)
+	assert.False(t, status.Allowed)
+	assert.Contains(t, status.Reason, "outside of its promotion windows")
+	require.NotNil(t, status.NextAllowed)
+	assert.True(t, time.Date(2019, 7, 11, 9, 0, 0, 0, newYork).Equal(*status.NextAllowed))
+
+	// Saturday
+	status, err = kube.GetPromotionWindowStatus(env, time.Date(2019, 7, 13, 10, 30, 0, 0, newYork))
+	require.NoError(t, err)
+	assert.False(t, status.Allowed)
+	require.NotNil(t, status.NextAllowed)
+	assert.True(t, time.Date(2019, 7, 15, 9, 0, 0, 0, newYork).Equal(*status.NextAllowed))
+}
+
+func TestGetPromotionWindowStatusWithFreeze(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2019, 12, 20, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: v1.EnvironmentSpec{
+			FreezePeriods: []v1.FreezePeriod{
+				{
+					Name:   "holidays",
+					Start:  metav1.Time{Time: start},
+					End:    metav1.Time{Time: end},
+					Reason: "end of year code freeze",
+				},
+			},
+		},
+	}
+
+	status, err := kube.GetPromotionWindowStatus(env, start.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.True(t, status.Allowed)
+
+	status, err = kube.GetPromotionWindowStatus(env, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, status.Allowed)
+	assert.Contains(t, status.Reason, "end of year code freeze")
+	require.NotNil(t, status.NextAllowed)
+	assert.True(t, end.Equal(*status.NextAllowed))
+
+	// the freeze ends on a Thursday at midnight so the next window is at 9am
+	env.Spec.PromotionWindows = []v1.PromotionWindow{
+		{
+			Schedule: "0 9 * * MON-FRI",
+			Duration: metav1.Duration{Duration: 8 * time.Hour},
+		},
+	}
+	status, err = kube.GetPromotionWindowStatus(env, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, status.Allowed)
+	require.NotNil(t, status.NextAllowed)
+	assert.True(t, time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC).Equal(*status.NextAllowed))
+}
+
+func TestValidatePromotionWindows(t *testing.T) {
+	t.Parallel()
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: v1.EnvironmentSpec{
+			PromotionWindows: []v1.PromotionWindow{
+				{
+					Schedule: "0 9 * * MON-FRI",
+					Duration: metav1.Duration{Duration: time.Hour},
+				},
+			},
+		},
+	}
+	assert.NoError(t, kube.ValidatePromotionWindows(env))
+
+	env.Spec.PromotionWindows[0].Timezone = "Not/AZone"
+	assert.Error(t, kube.ValidatePromotionWindows(env))
+
+	env.Spec.PromotionWindows[0].Timezone = ""
+	env.Spec.PromotionWindows[0].Schedule = "not a schedule"
+	assert.Error(t, kube.ValidatePromotionWindows(env))
+
+	env.Spec.PromotionWindows[0].Schedule = "0 9 * * *"
+	env.Spec.PromotionWindows[0].Duration = metav1.Duration{}
+	assert.Error(t, kube.ValidatePromotionWindows(env))
+}
+
+func TestQueuePromoteStep(t *testing.T) {
+	t.Parallel()
+	next := time.Date(2019, 7, 11, 9, 0, 0, 0, time.UTC)
+	status := &kube.PromotionWindowStatus{
+		Reason:      "the Environment production is outside of its promotion windows",
+		NextAllowed: &next,
+	}
+	promote := &v1.PromoteActivityStep{Environment: "production"}
+
+	assert.True(t, kube.QueuePromoteStep(promote, status))
+	assert.True(t, kube.IsPromoteStepQueued(promote))
+	assert.Equal(t, status.Reason, promote.Description)
+	assert.False(t, kube.QueuePromoteStep(promote, status), "should not modify an already queued step")
+}
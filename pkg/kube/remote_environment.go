@@ -0,0 +1,105 @@
+package kube
+
+import (
+	"sort"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/flagger"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RemoteEnvironmentReport is the status of an Environment in a remote cluster sent by its agent to the development
+// cluster
+type RemoteEnvironmentReport struct {
+	Environment  string                            `json:"environment"`
+	Version      string                            `json:"version,omitempty"`
+	Applications []v1.EnvironmentApplicationStatus `json:"applications,omitempty"`
+	Timestamp    metav1.Time                       `json:"timestamp"`
+}
+
+// GetEnvironmentApplicationStatuses returns the status of the applications deployed in the namespace sorted by name
+func GetEnvironmentApplicationStatuses(kubeClient kubernetes.Interface, ns string) ([]v1.EnvironmentApplicationStatus, error) {
+	deployments, err := GetDeployments(kubeClient, ns)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the deployments in namespace %s", ns)
+	}
+	answer := []v1.EnvironmentApplicationStatus{}
+	for name, d := range deployments {
+		if flagger.IsCanaryAuxiliaryDeployment(d) {
+			continue
+		}
+		status := v1.EnvironmentApplicationStatus{
+			Name:          GetAppName(name, ns),
+			Version:       GetVersion(&d.ObjectMeta),
+			ReadyReplicas: d.Status.ReadyReplicas,
+		}
+		if d.Spec.Replicas != nil {
+			status.Replicas = *d.Spec.Replicas
+		}
+		answer = append(answer, status)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Name < answer[j].Name
+	})
+	return answer, nil
+}
+
+// GetDeployedEnvironmentRevision returns the version and commit of the latest successful pipeline of the git repository
+// of an Environment which is the revision of the Environment deployed in its cluster
+func GetDeployedEnvironmentRevision(activities []v1.PipelineActivity, owner string, repo string) (string, string) {
+	var latest *v1.PipelineActivity
+	for i := range activities {
+		activity := &activities[i]
+		spec := &activity.Spec
+		if spec.Status != v1.ActivityStatusTypeSucceeded || activity.RepositoryOwner() != owner || activity.RepositoryName() != repo {
+			continue
+		}
+		if latest == nil || completedTime(activity).After(completedTime(latest).Time) {
+			latest = activity
+		}
+	}
+	if latest == nil {
+		return "", ""
+	}
+	version := latest.Spec.Version
+	if version == "" {
+		version = latest.Spec.LastCommitSHA
+	}
+	return version, latest.Spec.LastCommitSHA
+}
+
+func completedTime(activity *v1.PipelineActivity) metav1.Time {
+	if activity.Spec.CompletedTimestamp != nil {
+		return *activity.Spec.CompletedTimestamp
+	}
+	return activity.CreationTimestamp
+}
+
+// CountReadyApplications returns the number of applications which have all their replicas ready
+func CountReadyApplications(apps []v1.EnvironmentApplicationStatus) int {
+	answer := 0
+	for _, app := range apps {
+		if app.ReadyReplicas >= app.Replicas {
+			answer++
+		}
+	}
+	return answer
+}
+
+// ApplyRemoteEnvironmentReport updates the status of the Environment with the report from its agent returning false
+// if the report is older than the last report applied
+func ApplyRemoteEnvironmentReport(env *v1.Environment, report *RemoteEnvironmentReport) bool {
+	status := &env.Status
+	if status.LastReportTimestamp != nil && report.Timestamp.Before(status.LastReportTimestamp) {
+		return false
+	}
+	if report.Version != "" {
+		status.Version = report.Version
+	}
+	status.Applications = report.Applications
+	timestamp := report.Timestamp
+	status.LastReportTimestamp = &timestamp
+	return true
+}
@@ -0,0 +1,109 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/apps/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_mocks "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetEnvironmentApplicationStatuses(t *testing.T) {
+	t.Parallel()
+	replicas := int32(2)
+	kubeClient := kube_mocks.NewSimpleClientset(
+		&v1beta1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "jx-production-myapp",
+				Namespace: "jx-production",
+				Labels:    map[string]string{"version": "1.2.3"},
+			},
+			Spec:   v1beta1.DeploymentSpec{Replicas: &replicas},
+			Status: v1beta1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&v1beta1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "jx-production-other",
+				Namespace: "jx-production",
+				Labels:    map[string]string{"version": "0.0.1"},
+			},
+			Spec:   v1beta1.DeploymentSpec{Replicas: &replicas},
+			Status: v1beta1.DeploymentStatus{ReadyReplicas: 2},
+		},
+	)
+
+	apps, err := kube.GetEnvironmentApplicationStatuses(kubeClient, "jx-production")
+	require.NoError(t, err)
+	assert.Equal(t, []v1.EnvironmentApplicationStatus{
+		{Name: "myapp", Version: "1.2.3", Replicas: 2, ReadyReplicas: 1},
+		{Name: "other", Version: "0.0.1", Replicas: 2, ReadyReplicas: 2},
+	}, apps)
+	assert.Equal(t, 1, kube.CountReadyApplications(apps))
+}
+
+func TestGetDeployedEnvironmentRevision(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	activities := []v1.PipelineActivity{
+		newEnvironmentActivity("environment-production", "0.0.2", "def", v1.ActivityStatusTypeSucceeded, now.Add(-time.Hour)),
+		newEnvironmentActivity("environment-production", "0.0.3", "ghi", v1.ActivityStatusTypeFailed, now),
+		newEnvironmentActivity("environment-production", "0.0.1", "abc", v1.ActivityStatusTypeSucceeded, now.Add(-2*time.Hour)),
+		newEnvironmentActivity("environment-staging", "0.0.9", "xyz", v1.ActivityStatusTypeSucceeded, now),
+	}
+	version, sha := kube.GetDeployedEnvironmentRevision(activities, "myorg", "environment-production")
+	assert.Equal(t, "0.0.2", version)
+	assert.Equal(t, "def", sha)
+
+	version, sha = kube.GetDeployedEnvironmentRevision(activities, "myorg", "environment-dev")
+	assert.Equal(t, "", version)
+	assert.Equal(t, "", sha)
+}
+
+func TestApplyRemoteEnvironmentReport(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	env := &v1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec:       v1.EnvironmentSpec{RemoteCluster: true},
+	}
+	report := &kube.RemoteEnvironmentReport{
+		Environment:  "production",
+		Version:      "0.0.2",
+		Applications: []v1.EnvironmentApplicationStatus{{Name: "myapp", Version: "1.2.3"}},
+		Timestamp:    metav1.NewTime(now),
+	}
+	assert.True(t, kube.ApplyRemoteEnvironmentReport(env, report))
+	assert.Equal(t, "0.0.2", env.Status.Version)
+	assert.Len(t, env.Status.Applications, 1)
+
+	old := &kube.RemoteEnvironmentReport{
+		Environment: "production",
+		Version:     "0.0.1",
+		Timestamp:   metav1.NewTime(now.Add(-time.Minute)),
+	}
+	assert.False(t, kube.ApplyRemoteEnvironmentReport(env, old), "an older report should be ignored")
+	assert.Equal(t, "0.0.2", env.Status.Version)
+}
+
+func newEnvironmentActivity(repo string, version string, sha string, status v1.ActivityStatusType, completed time.Time) v1.PipelineActivity {
+	timestamp := metav1.NewTime(completed)
+	return v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myorg-" + repo + "-master-" + version,
+		},
+		Spec: v1.PipelineActivitySpec{
+			Pipeline:           "myorg/" + repo + "/master",
+			GitOwner:           "myorg",
+			GitRepository:      repo,
+			Version:            version,
+			LastCommitSHA:      sha,
+			Status:             status,
+			CompletedTimestamp: &timestamp,
+		},
+	}
+}
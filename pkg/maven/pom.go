@@ -0,0 +1,181 @@
+package maven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PomFileName the name of the maven project file
+	PomFileName = "pom.xml"
+
+	// defaultPluginGroupID the group id of plugins which do not specify one
+	defaultPluginGroupID = "org.apache.maven.plugins"
+)
+
+var (
+	// pomElementRegexes match the elements which refer to an artifact by its group id, artifact id and version
+	pomElementRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)<dependency>.*?</dependency>`),
+		regexp.MustCompile(`(?s)<plugin>.*?</plugin>`),
+		regexp.MustCompile(`(?s)<parent>.*?</parent>`),
+		regexp.MustCompile(`(?s)<extension>.*?</extension>`),
+	}
+	// nestedElementRegex matches the start of the nested elements of a plugin which may contain other artifacts
+	nestedElementRegex = regexp.MustCompile(`<(dependencies|configuration|executions)>`)
+	propertiesRegex    = regexp.MustCompile(`(?s)<properties>.*?</properties>`)
+	groupIDRegex       = regexp.MustCompile(`<groupId>\s*([^<\s]+)\s*</groupId>`)
+	artifactIDRegex    = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+	versionRegex       = regexp.MustCompile(`<version>\s*([^<\s]+)\s*</version>`)
+	propertyRefRegex   = regexp.MustCompile(`^\$\{([^}]+)\}$`)
+)
+
+// UpdateDependencyVersion updates the version of the dependency, plugin, parent or extension with the group id and
+// artifact id in every pom.xml in the directory tree rooted in dir. If the version is a reference to a property such
+// as ${foo.version} the property is updated instead. Any of the given properties are also updated. It returns the
+// old versions
+func UpdateDependencyVersion(dir string, groupID string, artifactID string, version string, properties ...string) ([]string, error) {
+	files, err := FindPomFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	oldVersions := map[string]bool{}
+	propertyNames := map[string]bool{}
+	for _, p := range properties {
+		propertyNames[p] = true
+	}
+	poms := map[string]string{}
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", path)
+		}
+		poms[path] = updateArtifactVersions(string(data), groupID, artifactID, version, oldVersions, propertyNames)
+	}
+	for _, path := range files {
+		text := updateProperties(poms[path], version, oldVersions, propertyNames)
+		err = writeIfChanged(path, text)
+		if err != nil {
+			return nil, err
+		}
+	}
+	answer := []string{}
+	for v := range oldVersions {
+		answer = append(answer, v)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// updateArtifactVersions updates the literal versions of the artifact in the pom collecting the names of any
+// properties used as its version
+func updateArtifactVersions(text string, groupID string, artifactID string, version string, oldVersions map[string]bool, propertyNames map[string]bool) string {
+	for _, r := range pomElementRegexes {
+		text = r.ReplaceAllStringFunc(text, func(element string) string {
+			// lets ignore the artifacts nested inside a plugin
+			head := element
+			idx := nestedElementRegex.FindStringIndex(element)
+			if idx != nil {
+				head = element[:idx[0]]
+			}
+			group := ""
+			groups := groupIDRegex.FindStringSubmatch(head)
+			if groups != nil {
+				group = groups[1]
+			} else if strings.HasPrefix(element, "<plugin>") {
+				group = defaultPluginGroupID
+			}
+			artifacts := artifactIDRegex.FindStringSubmatch(head)
+			if group != groupID || artifacts == nil || artifacts[1] != artifactID {
+				return element
+			}
+			v := versionRegex.FindStringSubmatchIndex(head)
+			if v == nil {
+				// the version is managed elsewhere such as in a parent or BOM
+				return element
+			}
+			oldVersion := head[v[2]:v[3]]
+			refs := propertyRefRegex.FindStringSubmatch(oldVersion)
+			if refs != nil {
+				if !strings.HasPrefix(refs[1], "project.") && !strings.HasPrefix(refs[1], "pom.") {
+					propertyNames[refs[1]] = true
+				}
+				return element
+			}
+			oldVersions[oldVersion] = true
+			return element[:v[2]] + version + element[v[3]:]
+		})
+	}
+	return text
+}
+
+// updateProperties updates the values of the properties in the properties elements of the pom
+func updateProperties(text string, version string, oldVersions map[string]bool, propertyNames map[string]bool) string {
+	if len(propertyNames) == 0 {
+		return text
+	}
+	return propertiesRegex.ReplaceAllStringFunc(text, func(properties string) string {
+		for name := range propertyNames {
+			r := regexp.MustCompile(`(<` + regexp.QuoteMeta(name) + `>\s*)([^<\s]+)(\s*</` + regexp.QuoteMeta(name) + `>)`)
+			properties = r.ReplaceAllStringFunc(properties, func(property string) string {
+				groups := r.FindStringSubmatch(property)
+				if propertyRefRegex.MatchString(groups[2]) {
+					return property
+				}
+				oldVersions[groups[2]] = true
+				return groups[1] + version + groups[3]
+			})
+		}
+		return properties
+	})
+}
+
+// FindPomFiles returns the pom.xml files in the directory tree rooted in dir ignoring any target and hidden directories
+func FindPomFiles(dir string) ([]string, error) {
+	answer := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != dir && (name == "target" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == PomFileName {
+			answer = append(answer, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding %s files in %s", PomFileName, dir)
+	}
+	return answer, nil
+}
+
+func writeIfChanged(path string, text string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "reading mode of %s", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", path)
+	}
+	if string(data) == text {
+		return nil
+	}
+	err = ioutil.WriteFile(path, []byte(text), info.Mode())
+	if err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+	return nil
+}
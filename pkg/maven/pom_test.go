@@ -0,0 +1,55 @@
+package maven_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/maven"
+	"github.com/jenkins-x/jx/pkg/tests"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDependencyVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maven")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srcDir := filepath.Join("test_data", "standard")
+	err = util.CopyDir(srcDir, dir, true)
+	require.NoError(t, err)
+
+	oldVersions, err := maven.UpdateDependencyVersion(dir, "com.acme", "widgets", "1.3.0")
+	require.NoError(t, err)
+
+	tests.AssertDirContentsEqual(t, fmt.Sprintf("%s.golden", srcDir), dir)
+	assert.Equal(t, []string{"1.1.0", "1.2.0"}, oldVersions)
+}
+
+func TestUpdateDependencyVersionOfPluginWithoutGroupID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maven")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	pom := filepath.Join(dir, maven.PomFileName)
+	err = ioutil.WriteFile(pom, []byte(`<project>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-surefire-plugin</artifactId>
+        <version>2.22.1</version>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`), util.DefaultWritePermissions)
+	require.NoError(t, err)
+
+	oldVersions, err := maven.UpdateDependencyVersion(dir, "org.apache.maven.plugins", "maven-surefire-plugin", "2.22.2")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2.22.1"}, oldVersions)
+	tests.AssertFileContains(t, pom, "<version>2.22.2</version>")
+}
@@ -0,0 +1,135 @@
+package npm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PackageJSONFileName the name of the file containing the dependencies of a package
+	PackageJSONFileName = "package.json"
+
+	nodeModulesDirName = "node_modules"
+)
+
+var (
+	// lockFileCommands the commands which regenerate each kind of lock file without installing any packages
+	lockFileCommands = []struct {
+		fileName string
+		command  []string
+	}{
+		{"package-lock.json", []string{"npm", "install", "--package-lock-only", "--ignore-scripts"}},
+		{"npm-shrinkwrap.json", []string{"npm", "install", "--package-lock-only", "--ignore-scripts"}},
+		{"yarn.lock", []string{"yarn", "install", "--ignore-scripts"}},
+		{"pnpm-lock.yaml", []string{"pnpm", "install", "--lockfile-only", "--ignore-scripts"}},
+	}
+)
+
+// UpdateDependencyVersion updates the version of the dependency in every package.json file in the directory tree
+// rooted in dir, keeping any range prefix such as ^ or ~. It returns the old versions and the directories of the
+// package.json files which were modified
+func UpdateDependencyVersion(dir string, name string, version string) ([]string, []string, error) {
+	r, err := dependencyRegex(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := FindPackageJSONFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldVersions := map[string]bool{}
+	changedDirs := []string{}
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "reading %s", path)
+		}
+		text := string(data)
+		changed := false
+		updated := r.ReplaceAllStringFunc(text, func(match string) string {
+			groups := r.FindStringSubmatch(match)
+			oldVersions[groups[3]] = true
+			if groups[3] == version {
+				return match
+			}
+			changed = true
+			return groups[1] + groups[2] + version + groups[4]
+		})
+		if !changed {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "reading mode of %s", path)
+		}
+		err = ioutil.WriteFile(path, []byte(updated), info.Mode())
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "writing %s", path)
+		}
+		changedDirs = append(changedDirs, filepath.Dir(path))
+	}
+	answer := []string{}
+	for v := range oldVersions {
+		answer = append(answer, v)
+	}
+	sort.Strings(answer)
+	return answer, changedDirs, nil
+}
+
+// dependencyRegex matches the version of the dependency in package.json capturing the prefix, the range operator,
+// the version and the suffix. Versions which are not semantic versions such as file: or git URLs are not matched
+func dependencyRegex(name string) (*regexp.Regexp, error) {
+	r, err := regexp.Compile(`("` + regexp.QuoteMeta(name) + `"\s*:\s*")(\^|~|>=|=|)(\d[^"\s]*)(")`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the regex for npm package %s", name)
+	}
+	return r, nil
+}
+
+// FindPackageJSONFiles returns the package.json files in the directory tree rooted in dir ignoring any node_modules
+// and hidden directories
+func FindPackageJSONFiles(dir string) ([]string, error) {
+	answer := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != dir && (name == nodeModulesDirName || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == PackageJSONFileName {
+			answer = append(answer, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding %s files in %s", PackageJSONFileName, dir)
+	}
+	return answer, nil
+}
+
+// LockFileCommands returns the commands which regenerate the lock files in the directory or nil if there are none
+func LockFileCommands(dir string) ([][]string, error) {
+	var answer [][]string
+	for _, l := range lockFileCommands {
+		exists, err := util.FileExists(filepath.Join(dir, l.fileName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking if %s exists in %s", l.fileName, dir)
+		}
+		if exists {
+			answer = append(answer, l.command)
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,41 @@
+package npm_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/npm"
+	"github.com/jenkins-x/jx/pkg/tests"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDependencyVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "npm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	srcDir := filepath.Join("test_data", "standard")
+	err = util.CopyDir(srcDir, dir, true)
+	require.NoError(t, err)
+
+	oldVersions, changedDirs, err := npm.UpdateDependencyVersion(dir, "@acme/widgets", "1.3.0")
+	require.NoError(t, err)
+
+	tests.AssertDirContentsEqual(t, fmt.Sprintf("%s.golden", srcDir), dir)
+	assert.Equal(t, []string{"1.1.3", "1.2.0"}, oldVersions)
+	assert.Equal(t, []string{dir, filepath.Join(dir, "packages", "web")}, changedDirs)
+}
+
+func TestLockFileCommands(t *testing.T) {
+	commands, err := npm.LockFileCommands(filepath.Join("test_data", "standard"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"yarn", "install", "--ignore-scripts"}}, commands)
+
+	commands, err = npm.LockFileCommands(filepath.Join("test_data", "standard", "packages", "web"))
+	require.NoError(t, err)
+	assert.Empty(t, commands)
+}
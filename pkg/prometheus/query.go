@@ -0,0 +1,153 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+var (
+	queryRegex = regexp.MustCompile(`^(.+)\s*(<=|>=|==|!=|<|>)\s*([-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?)$`)
+
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// Query a Prometheus query and the threshold its values must be within
+type Query struct {
+	Query     string
+	Operator  string
+	Threshold float64
+}
+
+// ParseQuery parses a query of the form '<promql> <operator> <number>' such as
+// 'sum(rate(http_requests_total{status=~"5.."}[5m])) < 1'
+func ParseQuery(text string) (*Query, error) {
+	m := queryRegex.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return nil, fmt.Errorf("query %q should be of the form '<promql> <operator> <number>'", text)
+	}
+	query := strings.TrimSpace(m[1])
+	if query == "" {
+		return nil, fmt.Errorf("query %q has no promql expression", text)
+	}
+	threshold, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing the threshold of query %q", text)
+	}
+	return &Query{
+		Query:     query,
+		Operator:  m[2],
+		Threshold: threshold,
+	}, nil
+}
+
+// Matches returns true if the value is within the threshold of the query
+func (q *Query) Matches(value float64) bool {
+	switch q.Operator {
+	case "<":
+		return value < q.Threshold
+	case "<=":
+		return value <= q.Threshold
+	case ">":
+		return value > q.Threshold
+	case ">=":
+		return value >= q.Threshold
+	case "==":
+		return value == q.Threshold
+	case "!=":
+		return value != q.Threshold
+	}
+	return false
+}
+
+// String returns the text of the query
+func (q *Query) String() string {
+	return fmt.Sprintf("%s %s %s", q.Query, q.Operator, strconv.FormatFloat(q.Threshold, 'f', -1, 64))
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// QueryValues evaluates the query using the Prometheus query API and returns the values of its result
+func QueryValues(prometheusURL string, query string) ([]float64, error) {
+	queryURL := util.UrlJoin(prometheusURL, "api/v1/query") + "?query=" + url.QueryEscape(query)
+	resp, err := httpClient.Get(queryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the response of %s", queryURL)
+	}
+	return ParseValues(data)
+}
+
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// ParseValues returns the values of a scalar or vector result of the Prometheus query API
+func ParseValues(data []byte) ([]float64, error) {
+	response := queryResponse{}
+	err := json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the Prometheus response")
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("the Prometheus query failed with status %s: %s", response.Status, response.Error)
+	}
+	samples := [][]interface{}{}
+	switch response.Data.ResultType {
+	case "scalar":
+		value := []interface{}{}
+		err = json.Unmarshal(response.Data.Result, &value)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing the Prometheus scalar result")
+		}
+		samples = append(samples, value)
+	case "vector":
+		vector := []sample{}
+		err = json.Unmarshal(response.Data.Result, &vector)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing the Prometheus vector result")
+		}
+		for _, sample := range vector {
+			samples = append(samples, sample.Value)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Prometheus result type %s", response.Data.ResultType)
+	}
+	answer := []float64{}
+	for _, sample := range samples {
+		if len(sample) != 2 {
+			return nil, fmt.Errorf("invalid Prometheus sample %v", sample)
+		}
+		text, ok := sample[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid Prometheus sample value %v", sample[1])
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing Prometheus sample value %s", text)
+		}
+		answer = append(answer, value)
+	}
+	return answer, nil
+}
@@ -0,0 +1,72 @@
+package prometheus_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		text      string
+		query     string
+		operator  string
+		threshold float64
+	}{
+		{`up{app="myapp"} == 1`, `up{app="myapp"}`, "==", 1},
+		{`sum(rate(http_requests_total{status=~"5.."}[5m])) < 0.5`, `sum(rate(http_requests_total{status=~"5.."}[5m]))`, "<", 0.5},
+		{`histogram_quantile(0.99, rate(latency_bucket[5m]))<=250`, `histogram_quantile(0.99, rate(latency_bucket[5m]))`, "<=", 250},
+		{`count(kube_pod_status_ready{condition="true"}) >= 2`, `count(kube_pod_status_ready{condition="true"})`, ">=", 2},
+		{`delta(errors[5m]) > -1e3`, `delta(errors[5m])`, ">", -1000},
+	}
+	for _, tc := range tests {
+		query, err := prometheus.ParseQuery(tc.text)
+		require.NoError(t, err, "parsing %s", tc.text)
+		assert.Equal(t, tc.query, query.Query, "query of %s", tc.text)
+		assert.Equal(t, tc.operator, query.Operator, "operator of %s", tc.text)
+		assert.Equal(t, tc.threshold, query.Threshold, "threshold of %s", tc.text)
+	}
+
+	for _, text := range []string{"", "up", "up == ", "== 1", "up = 1"} {
+		_, err := prometheus.ParseQuery(text)
+		assert.Error(t, err, "parsing %s", text)
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	t.Parallel()
+	query := &prometheus.Query{Query: "errors", Operator: "<", Threshold: 1}
+	assert.True(t, query.Matches(0.5))
+	assert.False(t, query.Matches(1))
+
+	query.Operator = ">="
+	assert.True(t, query.Matches(1))
+	assert.False(t, query.Matches(0.5))
+
+	query.Operator = "!="
+	assert.True(t, query.Matches(2))
+	assert.False(t, query.Matches(1))
+	assert.Equal(t, "errors != 1", query.String())
+}
+
+func TestParseValues(t *testing.T) {
+	t.Parallel()
+	values, err := prometheus.ParseValues([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"pod":"myapp-1"},"value":[1435781451.781,"0.25"]},
+		{"metric":{"pod":"myapp-2"},"value":[1435781451.781,"3"]}]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.25, 3}, values)
+
+	values, err = prometheus.ParseValues([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1435781451.781,"42"]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{42}, values)
+
+	_, err = prometheus.ParseValues([]byte(`{"status":"error","error":"parse error"}`))
+	assert.Error(t, err)
+
+	_, err = prometheus.ParseValues([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	assert.Error(t, err)
+}
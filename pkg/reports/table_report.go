@@ -25,6 +25,5 @@ func (t *TableBarReport) AddNumber(name string, value int) {
 }
 
 func (t *TableBarReport) Render() error {
-	t.Table.Render()
-	return nil
+	return t.Table.Render()
 }
@@ -30,6 +30,11 @@ func ReplaceURIs(s string, client Client, r *regexp.Regexp, schemePrefix string)
 				return ""
 			}
 			v, ok := secret[parts[1]]
+			if !ok && strings.Contains(parts[1], ".") {
+				// lets support nested keys of the form `foo.bar`
+				v = util.GetMapValueViaPath(secret, parts[1])
+				ok = v != nil
+			}
 			if !ok {
 				err = errors.Errorf("unable to find %s in secret at %s", parts[1], parts[0])
 				return ""
@@ -0,0 +1,243 @@
+package kubesecrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/secreturl"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Scheme the URI scheme used for secrets stored in Kubernetes Secrets
+	Scheme = "secret"
+
+	// LabelSecretURL the label added to all Secrets managed by this client
+	LabelSecretURL = "jenkins.io/secreturl"
+
+	// AnnotationSecretPath the annotation used to store the original secret path as Secret names are sanitized
+	AnnotationSecretPath = "jenkins.io/secreturl-path"
+
+	// AnnotationJSONKeys the annotation listing the keys whose values are not strings and so are stored as JSON
+	AnnotationJSONKeys = "jenkins.io/secreturl-json-keys"
+)
+
+var (
+	// secretURIRegex matches secret:path:key URIs which start a value, i.e. are at the start of a line or follow
+	// whitespace, a quote or an equals sign, so that YAML keys like client_secret: are not matched. Dots are only
+	// matched inside the path or key so a trailing period is not swallowed
+	secretURIRegex = regexp.MustCompile(`(?:^|[\s"'=])(secret:[-\w/]+(?:\.[-\w/]+)*:[-\w]+(?:\.[-\w]+)*)`)
+
+	secretValueRegex = regexp.MustCompile(`^secret:.*$`)
+)
+
+// KubeSecretsClient a client which loads/saves secrets as Kubernetes Secrets in a namespace.
+//
+// Nested keys are flattened into dotted keys in the Secret data so that `foo.bar` in the Secret
+// is read back as `map["foo"]["bar"]`
+type KubeSecretsClient struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+}
+
+// NewKubeSecretsClient creates a new client loading/saving secrets as Kubernetes Secrets in the given namespace
+func NewKubeSecretsClient(kubeClient kubernetes.Interface, namespace string) secreturl.Client {
+	return &KubeSecretsClient{
+		KubeClient: kubeClient,
+		Namespace:  namespace,
+	}
+}
+
+// Read reads a named secret from the namespace
+func (c *KubeSecretsClient) Read(secretName string) (map[string]interface{}, error) {
+	name := c.kubeSecretName(secretName)
+	secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find Secret %s in namespace %s for %s", name, c.Namespace, secretName)
+	}
+	err = c.checkSecretPath(secret, secretName)
+	if err != nil {
+		return nil, err
+	}
+	jsonKeys := map[string]bool{}
+	for _, k := range strings.Split(secret.Annotations[AnnotationJSONKeys], ",") {
+		if k != "" {
+			jsonKeys[k] = true
+		}
+	}
+	answer := map[string]interface{}{}
+	for k, v := range secret.Data {
+		var value interface{} = string(v)
+		if jsonKeys[k] {
+			err = json.Unmarshal(v, &value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal key %s of Secret %s", k, name)
+			}
+		}
+		util.SetMapValueViaPath(answer, k, value)
+	}
+	return answer, nil
+}
+
+// ReadObject reads a generic named object from the namespace.
+// The secret _must_ be serializable to JSON.
+func (c *KubeSecretsClient) ReadObject(secretName string, secret interface{}) error {
+	m, err := c.Read(secretName)
+	if err != nil {
+		return errors.Wrapf(err, "reading the secret %q from kubernetes", secretName)
+	}
+	err = util.ToStructFromMapStringInterface(m, &secret)
+	if err != nil {
+		return errors.Wrapf(err, "deserializing the secret %q from kubernetes", secretName)
+	}
+	return nil
+}
+
+// Write writes a named secret to the namespace with the data provided. Nested maps are stored using dotted keys
+// and any non string values are stored as JSON
+func (c *KubeSecretsClient) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
+	name := c.kubeSecretName(secretName)
+	secretData := map[string][]byte{}
+	jsonKeys := []string{}
+	err := flattenInto(secretData, &jsonKeys, "", data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert the data for secret %s", secretName)
+	}
+	sort.Strings(jsonKeys)
+
+	secrets := c.KubeClient.CoreV1().Secrets(c.Namespace)
+	secret, err := secrets.Get(name, metav1.GetOptions{})
+	create := false
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get Secret %s in namespace %s", name, c.Namespace)
+		}
+		create = true
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: c.Namespace,
+			},
+		}
+	} else {
+		err = c.checkSecretPath(secret, secretName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Labels[LabelSecretURL] = "true"
+	secret.Annotations[AnnotationSecretPath] = secretName
+	if len(jsonKeys) > 0 {
+		secret.Annotations[AnnotationJSONKeys] = strings.Join(jsonKeys, ",")
+	} else {
+		delete(secret.Annotations, AnnotationJSONKeys)
+	}
+	secret.Data = secretData
+
+	if create {
+		_, err = secrets.Create(secret)
+	} else {
+		_, err = secrets.Update(secret)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to save Secret %s in namespace %s", name, c.Namespace)
+	}
+	return c.Read(secretName)
+}
+
+// WriteObject writes a generic named object to the namespace.
+// The secret _must_ be serializable to JSON.
+func (c *KubeSecretsClient) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
+	m, err := util.ToMapStringInterfaceFromStruct(secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "serializing the secret %q", secretName)
+	}
+	return c.Write(secretName, m)
+}
+
+// ReplaceURIs will replace any secret: URIs in a string
+func (c *KubeSecretsClient) ReplaceURIs(s string) (string, error) {
+	var buffer strings.Builder
+	last := 0
+	for _, idx := range secretURIRegex.FindAllStringSubmatchIndex(s, -1) {
+		start, end := idx[2], idx[3]
+		value, err := secreturl.ReplaceURIs(s[start:end], c, secretValueRegex, Scheme+":")
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString(s[last:start])
+		buffer.WriteString(value)
+		last = end
+	}
+	buffer.WriteString(s[last:])
+	return buffer.String(), nil
+}
+
+// checkSecretPath returns an error if the Secret stores a different secret path as distinct paths can be sanitized
+// to the same Secret name
+func (c *KubeSecretsClient) checkSecretPath(secret *v1.Secret, secretName string) error {
+	path := secret.Annotations[AnnotationSecretPath]
+	if path != "" && strings.Trim(path, "/") != strings.Trim(secretName, "/") {
+		return fmt.Errorf("the secret path %s clashes with the secret path %s as both are stored in the Secret %s in namespace %s",
+			secretName, path, secret.Name, c.Namespace)
+	}
+	return nil
+}
+
+// kubeSecretName converts the secret path into a valid Kubernetes Secret name
+func (c *KubeSecretsClient) kubeSecretName(secretName string) string {
+	return naming.ToValidNameWithDots(strings.Trim(secretName, "/"))
+}
+
+// flattenInto converts the nested map into dotted keys in the secret data
+func flattenInto(secretData map[string][]byte, jsonKeys *[]string, prefix string, data map[string]interface{}) error {
+	for k, v := range data {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch value := v.(type) {
+		case string:
+			secretData[key] = []byte(value)
+		case []byte:
+			secretData[key] = value
+		case map[string]interface{}:
+			err := flattenInto(secretData, jsonKeys, key, value)
+			if err != nil {
+				return err
+			}
+		case map[interface{}]interface{}:
+			m, ok := util.ConvertAllMapKeysToString(value).(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("could not convert the map at key %s", key)
+			}
+			err := flattenInto(secretData, jsonKeys, key, m)
+			if err != nil {
+				return err
+			}
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal key %s to JSON", key)
+			}
+			secretData[key] = data
+			*jsonKeys = append(*jsonKeys, key)
+		}
+	}
+	return nil
+}
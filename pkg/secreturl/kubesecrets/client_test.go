@@ -0,0 +1,112 @@
+package kubesecrets_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/secreturl/kubesecrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const ns = "jx-secrets"
+
+type sampleObject struct {
+	Username string `json:"username"`
+	Enabled  bool   `json:"enabled"`
+	Replicas int    `json:"replicas"`
+}
+
+func TestKubeSecretsClientReadWriteNestedKeys(t *testing.T) {
+	t.Parallel()
+
+	kubeClient := fake.NewSimpleClientset()
+	client := kubesecrets.NewKubeSecretsClient(kubeClient, ns)
+
+	_, err := client.Write("cluster/adminUser", map[string]interface{}{
+		"username": "admin",
+		"github": map[string]interface{}{
+			"token": "mytoken",
+		},
+	})
+	require.NoError(t, err)
+
+	secret, err := kubeClient.CoreV1().Secrets(ns).Get("cluster-adminuser", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", string(secret.Data["username"]))
+	assert.Equal(t, "mytoken", string(secret.Data["github.token"]))
+	assert.Equal(t, "cluster/adminUser", secret.Annotations[kubesecrets.AnnotationSecretPath])
+
+	values, err := client.Read("cluster/adminUser")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", values["username"])
+	assert.Equal(t, map[string]interface{}{"token": "mytoken"}, values["github"])
+
+	text, err := client.ReplaceURIs("user: secret:cluster/adminUser:username token: secret:cluster/adminUser:github.token")
+	require.NoError(t, err)
+	assert.Equal(t, "user: admin token: mytoken", text)
+
+	text, err = client.ReplaceURIs("client_secret: secret:cluster/adminUser:username.\nuser: \"secret:cluster/adminUser:username\"")
+	require.NoError(t, err)
+	assert.Equal(t, "client_secret: admin.\nuser: \"admin\"", text)
+
+	text, err = client.ReplaceURIs("client_secret: foo\nmysecret:bar")
+	require.NoError(t, err)
+	assert.Equal(t, "client_secret: foo\nmysecret:bar", text)
+}
+
+func TestKubeSecretsClientRejectsClashingPaths(t *testing.T) {
+	t.Parallel()
+
+	client := kubesecrets.NewKubeSecretsClient(fake.NewSimpleClientset(), ns)
+
+	_, err := client.Write("cluster/adminUser", map[string]interface{}{"username": "admin"})
+	require.NoError(t, err)
+
+	_, err = client.Write("cluster/AdminUser", map[string]interface{}{"username": "other"})
+	assert.Error(t, err)
+
+	_, err = client.Read("cluster/adminuser")
+	assert.Error(t, err)
+
+	values, err := client.Read("cluster/adminUser")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", values["username"])
+}
+
+func TestKubeSecretsClientReadWriteObject(t *testing.T) {
+	t.Parallel()
+
+	client := kubesecrets.NewKubeSecretsClient(fake.NewSimpleClientset(), ns)
+
+	expected := sampleObject{
+		Username: "bob",
+		Enabled:  true,
+		Replicas: 3,
+	}
+	_, err := client.WriteObject("teams/bob", expected)
+	require.NoError(t, err)
+
+	actual := sampleObject{}
+	err = client.ReadObject("teams/bob", &actual)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// lets check we can update an existing secret
+	expected.Replicas = 5
+	_, err = client.WriteObject("teams/bob", expected)
+	require.NoError(t, err)
+	err = client.ReadObject("teams/bob", &actual)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestKubeSecretsClientReadMissingSecret(t *testing.T) {
+	t.Parallel()
+
+	client := kubesecrets.NewKubeSecretsClient(fake.NewSimpleClientset(), ns)
+
+	_, err := client.Read("does/not/exist")
+	assert.Error(t, err)
+}
@@ -0,0 +1,261 @@
+package table
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FormatText renders the table as aligned text columns
+	FormatText = "text"
+	// FormatJSON renders the rows as JSON objects in an items list
+	FormatJSON = "json"
+	// FormatYAML renders the rows as YAML objects in an items list
+	FormatYAML = "yaml"
+	// FormatCSV renders the rows as comma separated values
+	FormatCSV = "csv"
+	// FormatGoTemplatePrefix renders the rows using the go template following the prefix
+	FormatGoTemplatePrefix = "go-template="
+)
+
+var (
+	// Formats the supported output formats
+	Formats = []string{FormatText, FormatJSON, FormatYAML, FormatCSV, FormatGoTemplatePrefix + "..."}
+
+	ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// Renderer renders the rows of a table in a particular output format
+type Renderer interface {
+	// Render writes the rows of the table to the tables output
+	Render(t *Table) error
+}
+
+// NewRenderer creates a renderer for the given output format. An empty format renders aligned text
+func NewRenderer(format string) (Renderer, error) {
+	switch {
+	case format == "" || format == FormatText:
+		return &TextRenderer{}, nil
+	case format == FormatJSON:
+		return &JSONRenderer{}, nil
+	case format == FormatYAML:
+		return &YAMLRenderer{}, nil
+	case format == FormatCSV:
+		return &CSVRenderer{}, nil
+	case strings.HasPrefix(format, FormatGoTemplatePrefix):
+		text := strings.TrimPrefix(format, FormatGoTemplatePrefix)
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the go template %s", text)
+		}
+		return &TemplateRenderer{Template: tmpl}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %s. Supported formats are: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// IsObjectFormat returns true if the format renders objects rather than text columns
+func IsObjectFormat(format string) bool {
+	return format == FormatJSON || format == FormatYAML || strings.HasPrefix(format, FormatGoTemplatePrefix)
+}
+
+// TextRenderer renders the table as aligned text columns
+type TextRenderer struct {
+}
+
+// Render renders the table
+func (r *TextRenderer) Render(t *Table) error {
+	t.renderText()
+	return nil
+}
+
+// JSONRenderer renders the rows of the table as a JSON list of objects keyed by the column headers
+type JSONRenderer struct {
+}
+
+// Render renders the table
+func (r *JSONRenderer) Render(t *Table) error {
+	return RenderObject(t.Out, t.List(), FormatJSON)
+}
+
+// YAMLRenderer renders the rows of the table as a YAML list of objects keyed by the column headers
+type YAMLRenderer struct {
+}
+
+// Render renders the table
+func (r *YAMLRenderer) Render(t *Table) error {
+	return RenderObject(t.Out, t.List(), FormatYAML)
+}
+
+// CSVRenderer renders the rows of the table as comma separated values including the header row
+type CSVRenderer struct {
+}
+
+// Render renders the table
+func (r *CSVRenderer) Render(t *Table) error {
+	w := csv.NewWriter(t.Out)
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, col := range row {
+			record[i] = StripColors(col)
+		}
+		err := w.Write(record)
+		if err != nil {
+			return errors.Wrap(err, "failed to write CSV")
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// TemplateRenderer renders the rows of the table with a go template which is passed the list of row objects
+type TemplateRenderer struct {
+	Template *template.Template
+}
+
+// Render renders the table
+func (r *TemplateRenderer) Render(t *Table) error {
+	err := r.Template.Execute(t.Out, t.List())
+	if err != nil {
+		return errors.Wrap(err, "failed to execute the go template")
+	}
+	return nil
+}
+
+// List is the shape in which the rows of a table are rendered by the object formats so that they have the same shape
+// as a list of resources: as JSON or YAML with an 'items' field and as '.Items' in go templates
+type List struct {
+	Items interface{} `json:"items"`
+}
+
+// RenderObject renders the value as JSON, YAML or with a go template
+func RenderObject(out io.Writer, value interface{}, format string) error {
+	switch {
+	case format == FormatJSON:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal to JSON")
+		}
+		_, err = out.Write(data)
+		return err
+	case format == FormatYAML:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal to YAML")
+		}
+		_, err = out.Write(data)
+		return err
+	case strings.HasPrefix(format, FormatGoTemplatePrefix):
+		text := strings.TrimPrefix(format, FormatGoTemplatePrefix)
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the go template %s", text)
+		}
+		err = tmpl.Execute(out, value)
+		if err != nil {
+			return errors.Wrap(err, "failed to execute the go template")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported object output format %s. Supported formats are: %s, %s, %s...", format, FormatJSON, FormatYAML, FormatGoTemplatePrefix)
+	}
+}
+
+// List returns the rows after the header row as a List of objects keyed by the column headers
+func (t *Table) List() List {
+	return List{Items: t.Objects()}
+}
+
+// Objects returns the rows after the header row as objects keyed by the column headers
+func (t *Table) Objects() []map[string]interface{} {
+	answer := []map[string]interface{}{}
+	if len(t.Rows) == 0 {
+		return answer
+	}
+	headers := t.Rows[0]
+	keys := make([]string, len(headers))
+	for i, h := range headers {
+		keys[i] = ColumnKey(h)
+		if keys[i] == "" {
+			keys[i] = fmt.Sprintf("column%d", i+1)
+		}
+	}
+	for ri := 1; ri < len(t.Rows); ri++ {
+		object := map[string]interface{}{}
+		for ci, value := range t.rowValues(ri) {
+			if ci >= len(keys) {
+				break
+			}
+			if text, ok := value.(string); ok {
+				value = StripColors(text)
+			}
+			object[keys[ci]] = value
+		}
+		answer = append(answer, object)
+	}
+	return answer
+}
+
+// ColumnKey converts a column header such as 'LAST UPDATED' into the key 'lastUpdated' used for structured output
+func ColumnKey(header string) string {
+	words := strings.FieldsFunc(StripColors(header), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	buffer := strings.Builder{}
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if i > 0 {
+			w = strings.ToUpper(w[0:1]) + w[1:]
+		}
+		buffer.WriteString(w)
+	}
+	return buffer.String()
+}
+
+// StripColors removes any terminal color escape sequences from the text
+func StripColors(text string) string {
+	return ansiEscapeRegex.ReplaceAllString(text, "")
+}
+
+// ValidateFormat returns an error if the output format is not supported
+func ValidateFormat(format string) error {
+	_, err := NewRenderer(format)
+	return err
+}
+
+// FormatFlag is a pflag.Value for an output format which is validated when the flag is parsed
+type FormatFlag struct {
+	Format *string
+}
+
+// String returns the current format
+func (f *FormatFlag) String() string {
+	if f.Format == nil {
+		return ""
+	}
+	return *f.Format
+}
+
+// Set validates and sets the format
+func (f *FormatFlag) Set(value string) error {
+	err := ValidateFormat(value)
+	if err != nil {
+		return err
+	}
+	*f.Format = value
+	return nil
+}
+
+// Type returns the type of the flag
+func (f *FormatFlag) Type() string {
+	return "string"
+}
@@ -0,0 +1,127 @@
+package table_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/table"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestTable(format string) (*table.Table, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	t := table.CreateTable(out)
+	t.Format = format
+	t.AddRow("NAME", "LAST UPDATED", "BUILDS")
+	t.AddTypedRow("staging", "2019-10-01", 3)
+	t.AddTypedRow("production", "2019-10-02", 1)
+	return &t, out
+}
+
+func TestRenderText(t *testing.T) {
+	t.Parallel()
+	tbl, out := createTestTable("")
+	err := tbl.Render()
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "NAME       LAST UPDATED BUILDS\n")
+	assert.Contains(t, out.String(), "production 2019-10-02   1\n")
+}
+
+func TestRenderJSON(t *testing.T) {
+	t.Parallel()
+	tbl, out := createTestTable(table.FormatJSON)
+	err := tbl.Render()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items": [{"name": "staging", "lastUpdated": "2019-10-01", "builds": 3}, {"name": "production", "lastUpdated": "2019-10-02", "builds": 1}]}`, out.String())
+}
+
+func TestRenderYAML(t *testing.T) {
+	t.Parallel()
+	tbl, out := createTestTable(table.FormatYAML)
+	err := tbl.Render()
+	require.NoError(t, err)
+	assert.Equal(t, "items:\n- builds: 3\n  lastUpdated: \"2019-10-01\"\n  name: staging\n- builds: 1\n  lastUpdated: \"2019-10-02\"\n  name: production\n", out.String())
+}
+
+func TestRenderCSV(t *testing.T) {
+	t.Parallel()
+	tbl, out := createTestTable(table.FormatCSV)
+	err := tbl.Render()
+	require.NoError(t, err)
+	assert.Equal(t, "NAME,LAST UPDATED,BUILDS\nstaging,2019-10-01,3\nproduction,2019-10-02,1\n", out.String())
+}
+
+func TestRenderGoTemplate(t *testing.T) {
+	t.Parallel()
+	tbl, out := createTestTable(table.FormatGoTemplatePrefix + `{{range .Items}}{{.name}}={{.builds}};{{end}}`)
+	err := tbl.Render()
+	require.NoError(t, err)
+	assert.Equal(t, "staging=3;production=1;", out.String())
+}
+
+func TestRenderReturnsErrors(t *testing.T) {
+	t.Parallel()
+	tbl, _ := createTestTable(table.FormatGoTemplatePrefix + `{{.Missing.name}}`)
+	err := tbl.Render()
+	assert.Error(t, err)
+
+	tbl, _ = createTestTable("xml")
+	err = tbl.Render()
+	assert.Error(t, err)
+}
+
+func TestRenderObjectUsesTheShapeOfTables(t *testing.T) {
+	t.Parallel()
+	tbl, tableOut := createTestTable(table.FormatJSON)
+	err := tbl.Render()
+	require.NoError(t, err)
+
+	objectOut := &bytes.Buffer{}
+	err = table.RenderObject(objectOut, table.List{Items: tbl.Objects()}, table.FormatJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, tableOut.String(), objectOut.String())
+}
+
+func TestRenderObjectKeepsTheShapeOfValues(t *testing.T) {
+	t.Parallel()
+	out := &bytes.Buffer{}
+	err := table.RenderObject(out, []string{"jenkins-x/jx/master"}, table.FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, `["jenkins-x/jx/master"]`, out.String())
+
+	out.Reset()
+	err = table.RenderObject(out, []string{"jenkins-x/jx/master"}, table.FormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, "- jenkins-x/jx/master\n", out.String())
+}
+
+func TestFormatFlagValidates(t *testing.T) {
+	t.Parallel()
+	format := ""
+	flag := &table.FormatFlag{Format: &format}
+	require.NoError(t, flag.Set("csv"))
+	assert.Equal(t, "csv", format)
+	assert.Error(t, flag.Set("xml"))
+	assert.Error(t, flag.Set(table.FormatGoTemplatePrefix+"{{.broken"))
+	assert.Equal(t, "csv", format)
+}
+
+func TestStructuredOutputStripsColors(t *testing.T) {
+	t.Parallel()
+	out := &bytes.Buffer{}
+	tbl := table.CreateTable(out)
+	tbl.Format = table.FormatCSV
+	tbl.AddRow("NAME", "STATUS")
+	tbl.AddRow("staging", "\x1b[32mSucceeded\x1b[0m")
+	err := tbl.Render()
+	require.NoError(t, err)
+	assert.Equal(t, "NAME,STATUS\nstaging,Succeeded\n", out.String())
+}
+
+func TestColumnKey(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "lastUpdated", table.ColumnKey("LAST UPDATED"))
+	assert.Equal(t, "gitUrl", table.ColumnKey("GIT URL"))
+	assert.Equal(t, "name", table.ColumnKey("Name"))
+}
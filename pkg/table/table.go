@@ -8,12 +8,17 @@ import (
 	"github.com/jenkins-x/jx/pkg/util"
 )
 
+// Table renders rows of columns. The first row is the header row which is used to name the columns
+// when rendering in structured formats such as JSON, YAML or CSV
 type Table struct {
 	Out          io.Writer
 	Rows         [][]string
+	Values       [][]interface{}
 	ColumnWidths []int
 	ColumnAlign  []int
 	Separator    string
+	// Format is the output format such as json, yaml, csv or go-template=... Defaults to aligned text
+	Format string
 }
 
 func CreateTable(out io.Writer) Table {
@@ -26,14 +31,41 @@ func CreateTable(out io.Writer) Table {
 // Clear removes all rows while preserving the layout
 func (t *Table) Clear() {
 	t.Rows = [][]string{}
+	t.Values = [][]interface{}{}
 }
 
 // AddRow adds a new row to the table
 func (t *Table) AddRow(col ...string) {
+	values := make([]interface{}, len(col))
+	for i, c := range col {
+		values[i] = c
+	}
 	t.Rows = append(t.Rows, col)
+	t.Values = append(t.Values, values)
 }
 
-func (t *Table) Render() {
+// AddTypedRow adds a new row to the table keeping the types of the values for structured output formats
+func (t *Table) AddTypedRow(values ...interface{}) {
+	col := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			col[i] = fmt.Sprint(v)
+		}
+	}
+	t.Rows = append(t.Rows, col)
+	t.Values = append(t.Values, values)
+}
+
+// Render renders the table using the output format
+func (t *Table) Render() error {
+	renderer, err := NewRenderer(t.Format)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(t)
+}
+
+func (t *Table) renderText() {
 	// lets figure out the max widths of each column
 	for _, row := range t.Rows {
 		for ci, col := range row {
@@ -64,6 +96,19 @@ func (t *Table) Render() {
 	}
 }
 
+// rowValues returns the typed values of the row, falling back to the text of rows added directly to Rows
+func (t *Table) rowValues(i int) []interface{} {
+	if i < len(t.Values) && len(t.Values) == len(t.Rows) {
+		return t.Values[i]
+	}
+	row := t.Rows[i]
+	values := make([]interface{}, len(row))
+	for ci, col := range row {
+		values[ci] = col
+	}
+	return values
+}
+
 // SetColumnsAligns sets the alignment of the columns
 func (t *Table) SetColumnsAligns(colAligns []int) {
 	t.ColumnAlign = colAligns
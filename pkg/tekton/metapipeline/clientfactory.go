@@ -2,6 +2,7 @@ package metapipeline
 
 import (
 	"fmt"
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx/pkg/apps"
 	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
 	jxclient "github.com/jenkins-x/jx/pkg/client/clientset/versioned"
@@ -13,6 +14,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/prow"
 	"github.com/jenkins-x/jx/pkg/tekton"
 	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/versionstream"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
@@ -21,6 +23,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	kubeclient "k8s.io/client-go/kubernetes"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 )
@@ -41,9 +44,10 @@ type clientFactory struct {
 	kubeClient   kubernetes.Interface
 	ns           string
 
-	versionDir       string
-	versionStreamURL string
-	versionStreamRef string
+	versionResolver        *versionstream.VersionResolver
+	versionStreamURL       string
+	versionStreamRef       string
+	versionStreamOverrides []v1.VersionStreamOverride
 }
 
 // NewMetaPipelineClient creates a new client for the creation and application of meta pipelines.
@@ -65,19 +69,25 @@ func NewMetaPipelineClientWithClientsAndNamespace(jxClient versioned.Interface,
 		return nil, errors.Wrap(err, "unable to determine versions stream URL and ref")
 	}
 
-	versionDir, err := cloneVersionStream(url, ref)
+	overrides, err := versionStreamOverrides(jxClient, ns)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to determine version stream overrides")
+	}
+
+	versionResolver, err := cloneLayeredVersionStream(url, ref, overrides)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to clone version dir")
 	}
 
 	client := clientFactory{
-		jxClient:         jxClient,
-		tektonClient:     tektonClient,
-		kubeClient:       kubeClient,
-		ns:               ns,
-		versionDir:       versionDir,
-		versionStreamURL: url,
-		versionStreamRef: ref,
+		jxClient:               jxClient,
+		tektonClient:           tektonClient,
+		kubeClient:             kubeClient,
+		ns:                     ns,
+		versionResolver:        versionResolver,
+		versionStreamURL:       url,
+		versionStreamRef:       ref,
+		versionStreamOverrides: overrides,
 	}
 
 	return &client, nil
@@ -136,7 +146,7 @@ func (c *clientFactory) Create(param PipelineCreateParam) (kube.PromoteStepActiv
 		EnvVars:          param.EnvVariables,
 		DefaultImage:     param.DefaultImage,
 		Apps:             extendingApps,
-		VersionsDir:      c.versionDir,
+		VersionResolver:  c.versionResolver,
 		GitInfo:          *gitInfo,
 	}
 	tektonCRDs, err := createMetaPipelineCRDs(crdCreationParams)
@@ -161,7 +171,7 @@ func (c *clientFactory) Apply(pipelineActivity kube.PromoteStepActivityKey, crds
 
 // Close cleans up the resources use by this client.
 func (c *clientFactory) Close() error {
-	return os.RemoveAll(c.versionDir)
+	return removeVersionStreamDirs(c.versionResolver)
 }
 
 func (c *clientFactory) getPodTemplates(containerName string) (map[string]*corev1.Pod, error) {
@@ -219,18 +229,97 @@ func (c *clientFactory) cloneVersionStreamIfNeeded() error {
 		return err
 	}
 
-	if c.versionStreamURL != url || c.versionStreamRef != ref {
-		oldVersionStreamDir := c.versionDir
-		c.versionDir, err = cloneVersionStream(url, ref)
+	overrides, err := versionStreamOverrides(c.jxClient, c.ns)
+	if err != nil {
+		return err
+	}
+
+	if c.versionStreamURL != url || c.versionStreamRef != ref || !reflect.DeepEqual(c.versionStreamOverrides, overrides) {
+		oldVersionResolver := c.versionResolver
+		c.versionResolver, err = cloneLayeredVersionStream(url, ref, overrides)
 		if err != nil {
 			return err
 		}
-		_ = os.RemoveAll(oldVersionStreamDir)
+		c.versionStreamURL = url
+		c.versionStreamRef = ref
+		c.versionStreamOverrides = overrides
+		_ = removeVersionStreamDirs(oldVersionResolver)
 	}
 
 	return nil
 }
 
+func versionStreamOverrides(jxClient versioned.Interface, ns string) ([]v1.VersionStreamOverride, error) {
+	devEnv, err := kube.GetDevEnvironment(jxClient, ns)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve team environment")
+	}
+	if devEnv == nil {
+		return nil, nil
+	}
+	return devEnv.Spec.TeamSettings.VersionStreamOverrides, nil
+}
+
+// cloneLayeredVersionStream clones the version stream and its overrides returning a resolver which layers the
+// overrides on top of the version stream
+func cloneLayeredVersionStream(url string, ref string, overrides []v1.VersionStreamOverride) (*versionstream.VersionResolver, error) {
+	dir, err := cloneVersionStream(url, ref)
+	if err != nil {
+		return nil, err
+	}
+	upstream := &versionstream.Layer{
+		Dir: dir,
+		URL: url,
+		Ref: versionStreamCommit(dir, ref),
+	}
+	layers := []*versionstream.Layer{}
+	for _, override := range overrides {
+		overrideRef := override.Ref
+		if overrideRef == "" {
+			overrideRef = config.DefaultVersionsRef
+		}
+		overrideDir, err := cloneVersionStream(override.URL, overrideRef)
+		if err != nil {
+			for _, layer := range append(layers, upstream) {
+				_ = os.RemoveAll(layer.Dir)
+			}
+			return nil, errors.Wrapf(err, "unable to clone version stream override %s", override.Name)
+		}
+		layers = append(layers, &versionstream.Layer{
+			Name: override.Name,
+			Dir:  overrideDir,
+			URL:  override.URL,
+			Ref:  versionStreamCommit(overrideDir, overrideRef),
+		})
+	}
+	return versionstream.NewLayeredVersionResolver(upstream, layers...), nil
+}
+
+// versionStreamCommit returns the commit SHA checked out in the version stream directory or the given ref if it cannot
+// be determined
+func versionStreamCommit(dir string, ref string) string {
+	sha, err := gits.NewGitCLI().GetLatestCommitSha(dir)
+	if err != nil {
+		logger.Debugf("unable to determine the commit of version stream %s: %s", dir, err)
+		return ref
+	}
+	return sha
+}
+
+func removeVersionStreamDirs(resolver *versionstream.VersionResolver) error {
+	if resolver == nil {
+		return nil
+	}
+	var errs []error
+	for _, layer := range resolver.Layers() {
+		err := os.RemoveAll(layer.Dir)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return util.CombineErrors(errs...)
+}
+
 func cloneVersionStream(url string, ref string) (string, error) {
 	dir, err := ioutil.TempDir("", "jx-version-repo-")
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/jenkins-x/jx/pkg/gits"
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/tekton"
+	"github.com/jenkins-x/jx/pkg/versionstream"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo"
@@ -49,7 +50,7 @@ var _ = Describe("Meta pipeline", func() {
 				BuildNumber:      "1",
 				SourceDir:        "source",
 				ServiceAccount:   "tekton-bot",
-				VersionsDir:      filepath.Join("test_data", "stable_versions"),
+				VersionResolver:  &versionstream.VersionResolver{VersionsDir: filepath.Join("test_data", "stable_versions")},
 			}
 		})
 
@@ -281,7 +282,7 @@ var _ = Describe("Meta pipeline", func() {
 				BuildNumber:      "1",
 				SourceDir:        "source",
 				ServiceAccount:   "tekton-bot",
-				VersionsDir:      filepath.Join("test_data", "stable_versions"),
+				VersionResolver:  &versionstream.VersionResolver{VersionsDir: filepath.Join("test_data", "stable_versions")},
 			}
 		})
 
@@ -1352,7 +1352,7 @@ func TestParseJenkinsfileYaml(t *testing.T) {
 				}
 			}
 
-			pipeline, tasks, structure, err := parsed.GenerateCRDs("somepipeline", "1", "somepipeline", "jx", nil, testVersionsDir, nil, "source", nil, "")
+			pipeline, tasks, structure, err := parsed.GenerateCRDs("somepipeline", "1", "somepipeline", "jx", nil, &versionstream.VersionResolver{VersionsDir: testVersionsDir}, nil, "source", nil, "")
 
 			if err != nil {
 				if tt.expectedErrorMsg != "" {
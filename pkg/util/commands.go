@@ -107,6 +107,11 @@ func (c *Command) SetEnvVariable(name string, value string) {
 	c.Env[name] = value
 }
 
+// SetStdin Setter method for In to enable use of interface instead of Command struct
+func (c *Command) SetStdin(in io.Reader) {
+	c.In = in
+}
+
 // Attempts The number of times the command has been executed
 func (c *Command) Attempts() int {
 	return c.attempts
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"io"
 	"time"
 
 	"github.com/cenkalti/backoff"
@@ -25,4 +26,5 @@ type Commander interface {
 	SetEnv(map[string]string)
 	CurrentEnv() map[string]string
 	SetEnvVariable(string, string)
+	SetStdin(io.Reader)
 }
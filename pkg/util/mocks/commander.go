@@ -6,6 +6,7 @@ package util_test
 import (
 	backoff "github.com/cenkalti/backoff"
 	pegomock "github.com/petergtz/pegomock"
+	io "io"
 	"reflect"
 	"time"
 )
@@ -216,6 +217,14 @@ func (mock *MockCommander) SetName(_param0 string) {
 	pegomock.GetGenericMockFrom(mock).Invoke("SetName", params, []reflect.Type{})
 }
 
+func (mock *MockCommander) SetStdin(_param0 io.Reader) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommander().")
+	}
+	params := []pegomock.Param{_param0}
+	pegomock.GetGenericMockFrom(mock).Invoke("SetStdin", params, []reflect.Type{})
+}
+
 func (mock *MockCommander) SetTimeout(_param0 time.Duration) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockCommander().")
@@ -580,6 +589,33 @@ func (c *MockCommander_SetName_OngoingVerification) GetAllCapturedArguments() (_
 	return
 }
 
+func (verifier *VerifierMockCommander) SetStdin(_param0 io.Reader) *MockCommander_SetStdin_OngoingVerification {
+	params := []pegomock.Param{_param0}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "SetStdin", params, verifier.timeout)
+	return &MockCommander_SetStdin_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommander_SetStdin_OngoingVerification struct {
+	mock              *MockCommander
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommander_SetStdin_OngoingVerification) GetCapturedArguments() io.Reader {
+	_param0 := c.GetAllCapturedArguments()
+	return _param0[len(_param0)-1]
+}
+
+func (c *MockCommander_SetStdin_OngoingVerification) GetAllCapturedArguments() (_param0 []io.Reader) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]io.Reader, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(io.Reader)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockCommander) SetTimeout(_param0 time.Duration) *MockCommander_SetTimeout_OngoingVerification {
 	params := []pegomock.Param{_param0}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "SetTimeout", params, verifier.timeout)
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/jenkins-x/jx/pkg/secreturl"
 
 	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/jenkins-x/jx/pkg/vault"
 	"github.com/pkg/errors"
 )
 
@@ -20,13 +23,15 @@ var vaultURIRegex = regexp.MustCompile(`vault:[-_\w\/:]*`)
 
 // FakeVaultClient is an in memory implementation of vault, useful for testing
 type FakeVaultClient struct {
-	Data map[string]map[string]interface{}
+	Data     map[string]map[string]interface{}
+	Versions map[string]int
 }
 
 //NewFakeVaultClient creates a new FakeVaultClient
 func NewFakeVaultClient() FakeVaultClient {
 	return FakeVaultClient{
-		Data: make(map[string]map[string]interface{}),
+		Data:     make(map[string]map[string]interface{}),
+		Versions: make(map[string]int),
 	}
 }
 
@@ -34,9 +39,33 @@ func NewFakeVaultClient() FakeVaultClient {
 func (f FakeVaultClient) Write(secretName string, data map[string]interface{}) (map[string]interface{}, error) {
 	fmt.Printf("fakeClient: storing key at %s data: %#v\n", secretName, data)
 	f.Data[secretName] = data
+	if f.Versions != nil {
+		f.Versions[secretName]++
+	}
 	return data, nil
 }
 
+// ReadVersion reads a secret from vault along with its version
+func (f FakeVaultClient) ReadVersion(secretName string) (map[string]interface{}, int, error) {
+	return f.Data[secretName], f.Versions[secretName], nil
+}
+
+// WriteCAS writes a secret to vault if it is at the given version
+func (f FakeVaultClient) WriteCAS(secretName string, data map[string]interface{}, version int) (int, error) {
+	if f.Versions[secretName] != version {
+		return 0, &vault.CASError{SecretName: secretName, Version: version}
+	}
+	_, err := f.Write(secretName, data)
+	return f.Versions[secretName], err
+}
+
+// Delete deletes a secret from vault
+func (f FakeVaultClient) Delete(secretName string) error {
+	delete(f.Data, secretName)
+	delete(f.Versions, secretName)
+	return nil
+}
+
 // WriteObject a secret to vault
 func (f FakeVaultClient) WriteObject(secretName string, secret interface{}) (map[string]interface{}, error) {
 	payload, err := util.ToMapStringInterfaceFromStruct(secret)
@@ -55,14 +84,27 @@ func (f FakeVaultClient) WriteYaml(secretName string, y string) (map[string]inte
 	return f.Write(secretName, secretMap)
 }
 
-// List the secrets in vault
+// List the secrets in vault directly under the given path. Like vault any sub folders are returned with a trailing '/'
 func (f FakeVaultClient) List(path string) ([]string, error) {
 	secretNames := make([]string, 0)
-	for _, s := range f.Data[path] {
-		if orig, ok := s.(string); ok {
-			secretNames = append(secretNames, orig)
+	prefix := strings.TrimSuffix(path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	for key := range f.Data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, prefix)
+		idx := strings.Index(name, "/")
+		if idx >= 0 {
+			name = name[0 : idx+1]
+		}
+		if name != "" && util.StringArrayIndex(secretNames, name) < 0 {
+			secretNames = append(secretNames, name)
 		}
 	}
+	sort.Strings(secretNames)
 	return secretNames, nil
 }
 
@@ -2,9 +2,12 @@ package vault
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/jenkins-x/jx/pkg/secreturl"
@@ -51,6 +54,37 @@ type Client interface {
 	ReplaceURIs(text string) (string, error)
 }
 
+// VersionedClient is a Client for a KV version 2 secrets engine which supports check-and-set writes and deletes
+type VersionedClient interface {
+	Client
+
+	// ReadVersion reads a named secret along with its current version. A missing secret has no data and version 0
+	ReadVersion(secretName string) (map[string]interface{}, int, error)
+
+	// WriteCAS writes a named secret only if its current version is the given version, where 0 means that the secret
+	// must not exist, returning the new version. A CASError is returned if the version does not match
+	WriteCAS(secretName string, data map[string]interface{}, version int) (int, error)
+
+	// Delete permanently deletes a named secret along with all its versions
+	Delete(secretName string) error
+}
+
+// CASError is returned when a check-and-set write fails as the secret has been modified
+type CASError struct {
+	SecretName string
+	Version    int
+}
+
+func (e *CASError) Error() string {
+	return fmt.Sprintf("the secret %s is no longer at version %d", e.SecretName, e.Version)
+}
+
+// IsCASError returns true if the error is a CASError
+func IsCASError(err error) bool {
+	_, ok := errors.Cause(err).(*CASError)
+	return ok
+}
+
 // client is a hand wrapper around the official Vault API
 type client struct {
 	client *api.Client
@@ -74,6 +108,49 @@ func (v *client) Write(secretName string, data map[string]interface{}) (map[stri
 	return nil, err
 }
 
+// ReadVersion reads a named secret from the vault along with its current version
+func (v *client) ReadVersion(secretName string) (map[string]interface{}, int, error) {
+	secret, err := v.client.Logical().Read(secretPath(secretName))
+	if err != nil || secret == nil || secret.Data == nil {
+		return nil, 0, err
+	}
+	metadata, _ := secret.Data["metadata"].(map[string]interface{})
+	version, err := toVersion(metadata["version"])
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing the version of %s", secretName)
+	}
+	// the data of a deleted version is nil
+	data, _ := secret.Data["data"].(map[string]interface{})
+	return data, version, nil
+}
+
+// WriteCAS writes a named secret to the vault if it is still at the given version
+func (v *client) WriteCAS(secretName string, data map[string]interface{}, version int) (int, error) {
+	payload := map[string]interface{}{
+		"options": map[string]interface{}{
+			"cas": version,
+		},
+		"data": data,
+	}
+	secret, err := v.client.Logical().Write(secretPath(secretName), payload)
+	if err != nil {
+		if strings.Contains(err.Error(), "check-and-set parameter did not match") {
+			return 0, &CASError{SecretName: secretName, Version: version}
+		}
+		return 0, err
+	}
+	if secret == nil {
+		return 0, fmt.Errorf("no version returned when writing %s", secretName)
+	}
+	return toVersion(secret.Data["version"])
+}
+
+// Delete deletes the metadata and all versions of a named secret from the vault
+func (v *client) Delete(secretName string) error {
+	_, err := v.client.Logical().Delete(secretMetadataPath(secretName))
+	return err
+}
+
 // Read reads a named secret to the vault
 func (v *client) Read(secretName string) (map[string]interface{}, error) {
 	secret, err := v.client.Logical().Read(secretPath(secretName))
@@ -183,3 +260,22 @@ func (v *client) Config() (vaultURL url.URL, vaultToken string, err error) {
 func (v *client) ReplaceURIs(s string) (string, error) {
 	return secreturl.ReplaceURIs(s, v, vaultURIRegex, "vault:")
 }
+
+// toVersion converts the version in a KV version 2 response into an int
+func toVersion(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case json.Number:
+		i, err := v.Int64()
+		return int(i), err
+	case float64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("unexpected version %v", value)
+	}
+}
@@ -0,0 +1,230 @@
+package versionstream
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// VersionStatus the status of a version when checked against the version stream
+type VersionStatus string
+
+const (
+	// VersionStatusValid the version matches the version stream
+	VersionStatusValid VersionStatus = "valid"
+	// VersionStatusOutdated the version is allowed by the version stream but older than its stable version
+	VersionStatusOutdated VersionStatus = "outdated"
+	// VersionStatusDeprecated the version stream marks the chart, image or package as deprecated
+	VersionStatusDeprecated VersionStatus = "deprecated"
+	// VersionStatusDisallowed the version does not satisfy the constraints of the version stream
+	VersionStatusDisallowed VersionStatus = "disallowed"
+	// VersionStatusUnknown the version stream has no version for the chart, image or package
+	VersionStatusUnknown VersionStatus = "unknown"
+)
+
+// VersionCheck the result of checking the current version of a chart, image or package against the version stream
+type VersionCheck struct {
+	Kind           VersionKind
+	Name           string
+	CurrentVersion string
+	StableVersion  string
+	Status         VersionStatus
+	Message        string
+}
+
+// NeedsUpgrade returns true if the version should be upgraded to the stable version of the version stream
+func (c *VersionCheck) NeedsUpgrade() bool {
+	return c.StableVersion != "" && c.StableVersion != c.CurrentVersion &&
+		(c.Status == VersionStatusOutdated || c.Status == VersionStatusDisallowed)
+}
+
+// ParseConstraints parses semantic version constraints such as `>=1.2 <2` or `~1.4.0 || ^2.1.0` into a range.
+// Versions with missing minor or patch numbers are treated as zero. A tilde allows patch changes, or minor changes if
+// only the major version is given, and a caret allows changes which do not modify the left-most non-zero number
+func ParseConstraints(text string) (semver.Range, error) {
+	words := strings.Fields(text)
+	parts := []string{}
+	operator := ""
+	for _, word := range words {
+		if word == "||" {
+			parts = append(parts, word)
+			continue
+		}
+		idx := strings.IndexFunc(word, func(r rune) bool {
+			return !strings.ContainsRune("<>=!~^", r)
+		})
+		if idx < 0 {
+			// lets join an operator separated from its version by whitespace
+			operator += word
+			continue
+		}
+		operator += word[:idx]
+		version := strings.TrimPrefix(word[idx:], "v")
+		if operator == "~" || operator == "^" {
+			// the range parser does not support tilde and caret ranges so lets expand them into their bounds
+			bounds, err := expandRange(operator, version)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid version constraints %q", text)
+			}
+			parts = append(parts, bounds...)
+		} else {
+			parts = append(parts, operator+padVersion(version))
+		}
+		operator = ""
+	}
+	if operator != "" {
+		return nil, fmt.Errorf("invalid version constraints %q: operator %s has no version", text, operator)
+	}
+	answer, err := semver.ParseRange(strings.Join(parts, " "))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid version constraints %q", text)
+	}
+	return answer, nil
+}
+
+// expandRange expands a tilde or caret range into its lower and upper bounds
+func expandRange(operator string, version string) ([]string, error) {
+	suffix := ""
+	idx := strings.IndexAny(version, "-+")
+	if idx >= 0 {
+		suffix = version[idx:]
+		version = version[:idx]
+	}
+	paths := strings.Split(version, ".")
+	if len(paths) > 3 {
+		return nil, fmt.Errorf("invalid version %s%s", operator, version)
+	}
+	numbers := []uint64{}
+	for _, p := range paths {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %s%s", operator, version)
+		}
+		numbers = append(numbers, n)
+	}
+	given := len(numbers)
+	for len(numbers) < 3 {
+		numbers = append(numbers, 0)
+	}
+	var upper semver.Version
+	switch {
+	case operator == "~" && given == 1:
+		upper = semver.Version{Major: numbers[0] + 1}
+	case operator == "~":
+		upper = semver.Version{Major: numbers[0], Minor: numbers[1] + 1}
+	case numbers[0] > 0 || given == 1:
+		upper = semver.Version{Major: numbers[0] + 1}
+	case numbers[1] > 0 || given == 2:
+		upper = semver.Version{Minor: numbers[1] + 1}
+	default:
+		upper = semver.Version{Patch: numbers[2] + 1}
+	}
+	lower := fmt.Sprintf(">=%d.%d.%d%s", numbers[0], numbers[1], numbers[2], suffix)
+	return []string{lower, "<" + upper.String()}, nil
+}
+
+// padVersion adds any missing minor or patch numbers to a version
+func padVersion(version string) string {
+	suffix := ""
+	idx := strings.IndexAny(version, "-+")
+	if idx >= 0 {
+		suffix = version[idx:]
+		version = version[:idx]
+	}
+	numbers := strings.Split(version, ".")
+	for _, n := range numbers {
+		if n == "" || strings.Trim(n, "0123456789") != "" {
+			// lets leave wildcards and invalid versions to the range parser
+			return version + suffix
+		}
+	}
+	for len(numbers) < 3 {
+		numbers = append(numbers, "0")
+	}
+	return strings.Join(numbers, ".") + suffix
+}
+
+// VersionRange returns the range of versions allowed by the version stream. It uses the constraints if there are any
+// otherwise the version and upper limit. Returns nil if there are no constraints or upper limit
+func (data *StableVersion) VersionRange() (semver.Range, error) {
+	if data.Constraints != "" {
+		return ParseConstraints(data.Constraints)
+	}
+	if data.UpperLimit != "" && data.Version != "" {
+		return ParseConstraints(fmt.Sprintf(">=%s <%s", convertToVersion(data.Version), convertToVersion(data.UpperLimit)))
+	}
+	return nil, nil
+}
+
+// CheckVersion checks the current version of the chart, image or package against the version stream
+func (data *StableVersion) CheckVersion(kind VersionKind, name string, currentVersion string) (*VersionCheck, error) {
+	answer := &VersionCheck{
+		Kind:           kind,
+		Name:           name,
+		CurrentVersion: currentVersion,
+		StableVersion:  data.Version,
+		Status:         VersionStatusValid,
+	}
+	if data.Version == "" && data.Constraints == "" {
+		answer.Status = VersionStatusUnknown
+		answer.Message = "not in the version stream"
+		return answer, nil
+	}
+	versionRange, err := data.VersionRange()
+	if err != nil {
+		return answer, errors.Wrapf(err, "checking the version of %s %s", string(kind), name)
+	}
+
+	current, err := semver.ParseTolerant(currentVersion)
+	if err != nil {
+		// lets compare non semantic versions such as git SHAs as text
+		if versionRange != nil {
+			answer.Status = VersionStatusDisallowed
+			answer.Message = fmt.Sprintf("version %s is not a semantic version so cannot satisfy %s", currentVersion, data.constraintsText())
+		} else if currentVersion != data.Version {
+			answer.Status = VersionStatusOutdated
+			answer.Message = fmt.Sprintf("the version stream uses %s", data.Version)
+		}
+		return data.checkDeprecated(answer), nil
+	}
+
+	if versionRange != nil && !versionRange(current) {
+		answer.Status = VersionStatusDisallowed
+		answer.Message = fmt.Sprintf("version %s does not satisfy %s", currentVersion, data.constraintsText())
+		return answer, nil
+	}
+	if data.Version != "" {
+		stable, err := semver.ParseTolerant(data.Version)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to parse the stable version %s of %s %s", data.Version, string(kind), name)
+		}
+		if current.LT(stable) {
+			answer.Status = VersionStatusOutdated
+			answer.Message = fmt.Sprintf("the version stream uses %s", data.Version)
+		}
+	}
+	return data.checkDeprecated(answer), nil
+}
+
+// checkDeprecated marks an allowed version as deprecated if the version stream deprecates it
+func (data *StableVersion) checkDeprecated(check *VersionCheck) *VersionCheck {
+	if !data.Deprecated || check.Status == VersionStatusDisallowed {
+		return check
+	}
+	check.Status = VersionStatusDeprecated
+	check.Message = data.DeprecationMessage
+	if check.Message == "" {
+		check.Message = fmt.Sprintf("%s is deprecated", check.Name)
+	}
+	return check
+}
+
+func (data *StableVersion) constraintsText() string {
+	if data.Constraints != "" {
+		return data.Constraints
+	}
+	return fmt.Sprintf(">=%s <%s", data.Version, data.UpperLimit)
+}
@@ -0,0 +1,97 @@
+package versionstream_test
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConstraints(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		constraints string
+		valid       []string
+		invalid     []string
+	}{
+		{">=1.2 <2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"}},
+		{">= 1.2.3 < 1.3", []string{"1.2.3", "1.2.99"}, []string{"1.2.2", "1.3.0"}},
+		{"<1 || >=2.1", []string{"0.9.0", "2.1.0"}, []string{"1.0.0", "2.0.5"}},
+		{"v2", []string{"2.0.0"}, []string{"2.0.1"}},
+		{"~1.4.2", []string{"1.4.2", "1.4.9"}, []string{"1.4.1", "1.5.0"}},
+		{"~1.4", []string{"1.4.0", "1.4.9"}, []string{"1.3.9", "1.5.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"0.9.9", "2.0.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.2.2", "0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.2", "0.0.4"}},
+		{"^0", []string{"0.0.0", "0.9.9"}, []string{"1.0.0"}},
+		{"~ 1.4.0 || ^ 2.1", []string{"1.4.5", "2.9.0"}, []string{"1.5.0", "2.0.9", "3.0.0"}},
+	}
+	for _, tc := range testCases {
+		versionRange, err := versionstream.ParseConstraints(tc.constraints)
+		require.NoError(t, err, "parsing constraints %s", tc.constraints)
+		for _, v := range tc.valid {
+			assert.True(t, versionRange(semver.MustParse(v)), "%s should satisfy %s", v, tc.constraints)
+		}
+		for _, v := range tc.invalid {
+			assert.False(t, versionRange(semver.MustParse(v)), "%s should not satisfy %s", v, tc.constraints)
+		}
+	}
+
+	_, err := versionstream.ParseConstraints(">=")
+	assert.Error(t, err, "should reject an operator without a version")
+
+	_, err = versionstream.ParseConstraints("~1.x")
+	assert.Error(t, err, "should reject a tilde range with a wildcard")
+}
+
+func TestCheckVersion(t *testing.T) {
+	t.Parallel()
+
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: dataDir,
+	}
+	testCases := []struct {
+		kind     versionstream.VersionKind
+		name     string
+		version  string
+		expected versionstream.VersionStatus
+	}{
+		{versionstream.KindChart, "jenkins-x/knative-build", "0.1.13", versionstream.VersionStatusValid},
+		{versionstream.KindChart, "jenkins-x/knative-build", "0.1.12", versionstream.VersionStatusOutdated},
+		{versionstream.KindChart, "jenkins-x/exposecontroller", "2.3.50", versionstream.VersionStatusOutdated},
+		{versionstream.KindChart, "jenkins-x/exposecontroller", "2.4.0", versionstream.VersionStatusValid},
+		{versionstream.KindChart, "jenkins-x/exposecontroller", "3.0.1", versionstream.VersionStatusDisallowed},
+		{versionstream.KindChart, "jenkins-x/exposecontroller", "latest", versionstream.VersionStatusDisallowed},
+		{versionstream.KindChart, "jenkins-x/heapster", "0.3.2", versionstream.VersionStatusDeprecated},
+		{versionstream.KindChart, "doesNotExist", "1.0.0", versionstream.VersionStatusUnknown},
+		{versionstream.KindPackage, "kubectl", "1.13.4", versionstream.VersionStatusValid},
+		{versionstream.KindPackage, "kubectl", "2.0.0", versionstream.VersionStatusDisallowed},
+	}
+	for _, tc := range testCases {
+		check, err := resolver.CheckVersion(tc.kind, tc.name, tc.version)
+		require.NoError(t, err, "checking %s %s", tc.name, tc.version)
+		assert.Equal(t, tc.expected, check.Status, "status of %s %s: %s", tc.name, tc.version, check.Message)
+	}
+
+	check, err := resolver.CheckVersion(versionstream.KindChart, "jenkins-x/heapster", "0.3.2")
+	require.NoError(t, err)
+	assert.Equal(t, "heapster is replaced by metrics-server", check.Message)
+
+	check, err = resolver.CheckVersion(versionstream.KindChart, "jenkins-x/exposecontroller", "3.0.1")
+	require.NoError(t, err)
+	assert.True(t, check.NeedsUpgrade(), "a disallowed version should be upgraded")
+}
+
+func TestVerifyPackageWithOnlyConstraints(t *testing.T) {
+	t.Parallel()
+
+	data := &versionstream.StableVersion{
+		Constraints: ">=1.2 <2",
+	}
+	assert.NoError(t, data.VerifyPackage("kubectl", "1.5.0", ""))
+	assert.Error(t, data.VerifyPackage("kubectl", "2.0.0", ""), "should check the constraints when there is no version")
+}
@@ -0,0 +1,182 @@
+package versionstream
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// UpstreamLayerName the name of the layer of the version stream which the overrides are layered on top of
+const UpstreamLayerName = "upstream"
+
+// Layer a directory containing a version stream which is layered into a VersionResolver
+type Layer struct {
+	// Name the name of the layer such as team-overrides
+	Name string
+	// Dir the directory containing the version stream
+	Dir string
+	// URL the git URL the version stream was cloned from
+	URL string
+	// Ref the git commit SHA or ref of the version stream
+	Ref string
+}
+
+// String returns the name and ref of the layer such as team-overrides@abc123
+func (l *Layer) String() string {
+	ref := l.Ref
+	if len(ref) == 40 && isHex(ref) {
+		ref = ref[:7]
+	}
+	if ref == "" {
+		return l.Name
+	}
+	return l.Name + "@" + ref
+}
+
+// Resolution the version defined by a layer for a chart, image, package or git repository
+type Resolution struct {
+	Layer *Layer
+	// Version the version information defined in the layer
+	Version *StableVersion
+	// File the file in the layer defining the version information
+	File string
+	// Found true if the layer defines the version information
+	Found bool
+	// Selected true if the version number is resolved from this layer
+	Selected bool
+}
+
+// NewLayeredVersionResolver creates a VersionResolver which resolves versions from the overrides in order of
+// precedence before falling back to the upstream version stream
+func NewLayeredVersionResolver(upstream *Layer, overrides ...*Layer) *VersionResolver {
+	if upstream.Name == "" {
+		upstream.Name = UpstreamLayerName
+	}
+	return &VersionResolver{
+		VersionsDir: upstream.Dir,
+		Upstream:    upstream,
+		Overrides:   overrides,
+	}
+}
+
+// Layers returns the layers of the resolver in order of precedence ending with the upstream version stream
+func (v *VersionResolver) Layers() []*Layer {
+	upstream := v.Upstream
+	if upstream == nil || upstream.Dir != v.VersionsDir {
+		upstream = &Layer{
+			Name: UpstreamLayerName,
+			Dir:  v.VersionsDir,
+		}
+	}
+	return append(append([]*Layer{}, v.Overrides...), upstream)
+}
+
+// Explain returns how each layer contributes to the version of a chart, image, package or git repository in order
+// of precedence
+func (v *VersionResolver) Explain(kind VersionKind, name string) ([]*Resolution, error) {
+	answer := []*Resolution{}
+	selected := false
+	for _, layer := range v.Layers() {
+		file := stableVersionFile(layer.Dir, kind, name)
+		exists, err := util.FileExists(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check if file exists %s", file)
+		}
+		resolution := &Resolution{
+			Layer:   layer,
+			Version: &StableVersion{},
+			File:    file,
+			Found:   exists,
+		}
+		if exists {
+			resolution.Version, err = LoadStableVersionFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if !selected && resolution.Version.Version != "" {
+				resolution.Selected = true
+				selected = true
+			}
+		}
+		answer = append(answer, resolution)
+	}
+	return answer, nil
+}
+
+// ResolveStableVersion returns the version information of a chart, image, package or git repository along with the
+// layer which it is resolved from. The entry of the layer with the highest precedence which defines a version number
+// is used as a whole, falling back to the first layer defining the chart, image, package or git repository if none
+// defines a version number. The layer is nil if no layer defines a version number
+func (v *VersionResolver) ResolveStableVersion(kind VersionKind, name string) (*StableVersion, *Layer, error) {
+	resolutions, err := v.Explain(kind, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var found *Resolution
+	for _, r := range resolutions {
+		if r.Selected {
+			return r.Version, r.Layer, nil
+		}
+		if r.Found && found == nil {
+			found = r
+		}
+	}
+	if found != nil {
+		return found.Version, nil, nil
+	}
+	return &StableVersion{}, nil, nil
+}
+
+// GetLayeredRepositoryPrefixes loads the repository prefixes of all the layers. A repository in a layer replaces any
+// repository with the same prefix in the layers below it
+func GetLayeredRepositoryPrefixes(layers []*Layer) (*RepositoryPrefixes, error) {
+	answer := &RepositoryPrefixes{}
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		prefixes, err := GetRepositoryPrefixes(layer.Dir)
+		if err != nil {
+			return answer, errors.Wrapf(err, "failed to load the repository prefixes of version stream layer %s", layer.String())
+		}
+		for _, repo := range prefixes.Repositories {
+			if repo.Keyring != "" && !filepath.IsAbs(repo.Keyring) {
+				repo.Keyring = filepath.Join(layer.Dir, repo.Keyring)
+			}
+			existing := answer.RepositoryForPrefix(repo.Prefix)
+			if existing != nil {
+				*existing = repo
+			} else {
+				answer.Repositories = append(answer.Repositories, repo)
+			}
+		}
+	}
+	return answer, nil
+}
+
+func stableVersionFile(dir string, kind VersionKind, name string) string {
+	if kind == KindGit {
+		name = GitURLToName(name)
+	}
+	return filepath.Join(dir, string(kind), name+".yml")
+}
+
+func isHex(text string) bool {
+	for _, r := range text {
+		if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Description returns a description of where a version is resolved from such as 1.2.3 from team-overrides@abc123
+func (r *Resolution) Description() string {
+	if !r.Found {
+		return "not defined"
+	}
+	if r.Version.Version == "" {
+		return "no version"
+	}
+	return fmt.Sprintf("%s from %s", r.Version.Version, r.Layer.String())
+}
@@ -0,0 +1,92 @@
+package versionstream_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/jenkins-x/jx/pkg/versionstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createLayeredResolver() *versionstream.VersionResolver {
+	return versionstream.NewLayeredVersionResolver(&versionstream.Layer{
+		Dir: dataDir,
+		URL: "https://github.com/jenkins-x/jenkins-x-versions.git",
+		Ref: "master",
+	}, &versionstream.Layer{
+		Name: "team-overrides",
+		Dir:  path.Join("test_data", "team-overrides"),
+		Ref:  "abc123def456abc123def456abc123def456abc1",
+	})
+}
+
+func TestLayeredResolverOverridesVersions(t *testing.T) {
+	t.Parallel()
+
+	resolver := createLayeredResolver()
+
+	version, err := resolver.StableVersionNumber(versionstream.KindChart, "jenkins-x/knative-build")
+	require.NoError(t, err)
+	assert.Equal(t, "0.1.14", version, "the override should take precedence")
+
+	version, err = resolver.StableVersionNumber(versionstream.KindChart, "jenkins-x/prow")
+	require.NoError(t, err)
+	assert.NotEqual(t, "", version, "should fall back to the upstream version stream")
+
+	image, err := resolver.ResolveDockerImage("gcr.io/jenkinsxio/builder-jx")
+	require.NoError(t, err)
+	assert.Equal(t, "gcr.io/jenkinsxio/builder-jx:0.1.99", image)
+
+	data, layer, err := resolver.ResolveStableVersion(versionstream.KindPackage, "helm")
+	require.NoError(t, err)
+	assert.Equal(t, "2.12.2", data.Version, "the version should come from the upstream version stream")
+	assert.Equal(t, "", data.Constraints, "the constraints of the override should not be merged into the upstream entry")
+	assert.False(t, data.Deprecated, "the override should not be merged into the upstream entry")
+	assert.Equal(t, "upstream@master", layer.String())
+
+	data, layer, err = resolver.ResolveStableVersion(versionstream.KindChart, "jenkins-x/knative-build")
+	require.NoError(t, err)
+	assert.Equal(t, "0.1.14", data.Version, "the version should come from the override")
+	assert.Equal(t, "", data.GitURL, "the upstream entry should not be merged into the override")
+	assert.Equal(t, "team-overrides@abc123d", layer.String())
+}
+
+func TestLayeredResolverExplain(t *testing.T) {
+	t.Parallel()
+
+	resolver := createLayeredResolver()
+
+	resolutions, err := resolver.Explain(versionstream.KindChart, "jenkins-x/knative-build")
+	require.NoError(t, err)
+	require.Len(t, resolutions, 2)
+	assert.Equal(t, "team-overrides@abc123d", resolutions[0].Layer.String())
+	assert.True(t, resolutions[0].Selected)
+	assert.Equal(t, "0.1.14 from team-overrides@abc123d", resolutions[0].Description())
+	assert.True(t, resolutions[1].Found)
+	assert.False(t, resolutions[1].Selected)
+	assert.Equal(t, "0.1.13", resolutions[1].Version.Version)
+
+	resolutions, err = resolver.Explain(versionstream.KindChart, "jenkins-x/prow")
+	require.NoError(t, err)
+	assert.False(t, resolutions[0].Found)
+	assert.Equal(t, "not defined", resolutions[0].Description())
+	assert.True(t, resolutions[1].Selected)
+}
+
+func TestLayeredRepositoryPrefixes(t *testing.T) {
+	t.Parallel()
+
+	resolver := createLayeredResolver()
+
+	prefixes, err := resolver.GetRepositoryPrefixes()
+	require.NoError(t, err)
+	assert.Equal(t, "team", prefixes.PrefixForURL("https://charts.example.com/team"))
+	assert.Equal(t, []string{"https://charts.example.com/stable"}, prefixes.URLsForPrefix("stable"))
+	assert.Equal(t, "jenkins-x", prefixes.PrefixForURL("http://chartmuseum.jenkins-x.io"))
+
+	policy, keyring, err := resolver.ChartProvenance("stable/nginx-ingress", "")
+	require.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceVerify, policy)
+	assert.Equal(t, path.Join("test_data", "team-overrides", "keyrings", "stable.gpg"), keyring)
+}
@@ -2,29 +2,79 @@ package versionstream
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jenkins-x/jx/pkg/log"
 	"github.com/jenkins-x/jx/pkg/util"
 )
 
-// VersionResolver resolves versions of charts, packages or docker images
+// VersionResolver resolves versions of charts, packages or docker images from the version stream in VersionsDir
+// and any overrides layered on top of it
 type VersionResolver struct {
 	VersionsDir string
+
+	// Upstream describes the version stream in VersionsDir
+	Upstream *Layer
+	// Overrides the layers which take precedence over the VersionsDir with the first layer taking the highest precedence
+	Overrides []*Layer
 }
 
-// ResolveDockerImage ensures the given docker image has a valid version if there is one in the version stream
+// ResolveDockerImage ensures the given docker image has a valid version if there is one in the version stream.
+// If there is a version defined for the image 'image:<version>' is returned, otherwise the image is returned as is
 func (v *VersionResolver) ResolveDockerImage(image string) (string, error) {
-	return ResolveDockerImage(v.VersionsDir, image)
+	// lets check if we already have a version
+	path := strings.SplitN(image, ":", 2)
+	if len(path) == 2 && path[1] != "" {
+		return image, nil
+	}
+	info, layer, err := v.ResolveStableVersion(KindDocker, image)
+	if err != nil {
+		return image, err
+	}
+	if info.Version == "" {
+		// lets check if there is a docker.io prefix and if so lets try fetch without the docker prefix
+		prefix := "docker.io/"
+		if strings.HasPrefix(image, prefix) {
+			image = strings.TrimPrefix(image, prefix)
+			info, layer, err = v.ResolveStableVersion(KindDocker, image)
+			if err != nil {
+				return image, err
+			}
+		}
+	}
+	if info.Version == "" {
+		log.Logger().Warnf("could not find a stable version for Docker image: %s in %s", image, v.VersionsDir)
+		log.Logger().Warn("for background see: https://jenkins-x.io/architecture/version-stream/")
+		log.Logger().Infof("please lock this version down via the command: %s", util.ColorInfo(fmt.Sprintf("jx step create version pr -k docker -n %s -v 1.2.3", image)))
+		return image, nil
+	}
+	if len(v.Overrides) > 0 {
+		log.Logger().Debugf("resolved Docker image %s to version %s from %s", image, info.Version, layer.String())
+	}
+	prefix := strings.TrimSuffix(strings.TrimSpace(image), ":")
+	return prefix + ":" + info.Version, nil
 }
 
 // StableVersion returns the stable version of the given kind name
 func (v *VersionResolver) StableVersion(kind VersionKind, name string) (*StableVersion, error) {
-	return LoadStableVersion(v.VersionsDir, kind, name)
+	answer, _, err := v.ResolveStableVersion(kind, name)
+	return answer, err
 }
 
 // StableVersionNumber returns the stable version number of the given kind name
 func (v *VersionResolver) StableVersionNumber(kind VersionKind, name string) (string, error) {
-	return LoadStableVersionNumber(v.VersionsDir, kind, name)
+	data, layer, err := v.ResolveStableVersion(kind, name)
+	if err != nil {
+		return "", err
+	}
+	version := data.Version
+	if version != "" {
+		log.Logger().Debugf("using stable version %s from %s of %s from %s", util.ColorInfo(version), string(kind), util.ColorInfo(name), layer.String())
+	} else if kind != KindChart || name != "." {
+		// lets not warn if building current dir chart
+		logMissingStableVersion(kind, name, v.VersionsDir)
+	}
+	return version, nil
 }
 
 // ResolveGitVersion resolves the version to use for the given git repository using the version stream
@@ -61,14 +111,58 @@ func (v *VersionResolver) VerifyPackages(packages map[string]string) error {
 
 // VerifyPackage verifies the package is of a sufficient version
 func (v *VersionResolver) VerifyPackage(name string, currentVersion string) error {
-	data, err := LoadStableVersion(v.VersionsDir, KindPackage, name)
+	data, err := v.StableVersion(KindPackage, name)
 	if err != nil {
 		return err
 	}
 	return data.VerifyPackage(name, currentVersion, v.VersionsDir)
 }
 
-// GetRepositoryPrefixes loads the repository prefixes for the version stream
+// CheckVersion checks the current version of the chart, docker image or package against the version stream
+func (v *VersionResolver) CheckVersion(kind VersionKind, name string, currentVersion string) (*VersionCheck, error) {
+	data, err := v.StableVersion(kind, name)
+	if err != nil {
+		return nil, err
+	}
+	prefix := "docker.io/"
+	if kind == KindDocker && data.Version == "" && data.Constraints == "" && strings.HasPrefix(name, prefix) {
+		data, err = v.StableVersion(kind, strings.TrimPrefix(name, prefix))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data.CheckVersion(kind, name, currentVersion)
+}
+
+// GetRepositoryPrefixes loads the repository prefixes of all the layers of the version stream. The keyrings of the
+// repositories are resolved relative to the layer which defines them
 func (v *VersionResolver) GetRepositoryPrefixes() (*RepositoryPrefixes, error) {
-	return GetRepositoryPrefixes(v.VersionsDir)
+	return GetLayeredRepositoryPrefixes(v.Layers())
+}
+
+// ChartProvenance returns the provenance policy and the keyring of the repository of a chart using either the
+// repository URL or the repository prefix of the chart name. The keyring is blank if the repository has none
+func (v *VersionResolver) ChartProvenance(chart string, repoURL string) (ProvenancePolicy, string, error) {
+	prefixes, err := v.GetRepositoryPrefixes()
+	if err != nil {
+		return ProvenanceNone, "", err
+	}
+	var repo *RepositoryURLs
+	if repoURL != "" {
+		repo = prefixes.RepositoryForURL(repoURL)
+	} else {
+		paths := strings.SplitN(chart, "/", 2)
+		if len(paths) == 2 {
+			repo = prefixes.RepositoryForPrefix(paths[0])
+		}
+	}
+	if repo == nil || repo.Provenance == "" {
+		return ProvenanceNone, "", nil
+	}
+	switch repo.Provenance {
+	case ProvenanceNone, ProvenanceVerify, ProvenanceRequired:
+	default:
+		return ProvenanceNone, "", fmt.Errorf("unknown provenance policy %q for chart repository %s", repo.Provenance, repo.Prefix)
+	}
+	return repo.Provenance, repo.Keyring, nil
 }
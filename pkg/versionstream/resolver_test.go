@@ -31,3 +31,30 @@ func TestVersionGitRepository(t *testing.T) {
 		}
 	}
 }
+
+func TestChartProvenance(t *testing.T) {
+	t.Parallel()
+
+	versionsDir := path.Join("test_data", "jenkins-x-versions")
+	resolver := &versionstream.VersionResolver{
+		VersionsDir: versionsDir,
+	}
+
+	policy, keyring, err := resolver.ChartProvenance("jenkins-x/jenkins-x-platform", "")
+	assert.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceRequired, policy)
+	assert.Equal(t, path.Join(versionsDir, "charts", "keyrings", "jenkins-x.gpg"), keyring)
+
+	policy, keyring, err = resolver.ChartProvenance("flagger", "https://flagger.app/")
+	assert.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceVerify, policy)
+	assert.Equal(t, "", keyring)
+
+	policy, _, err = resolver.ChartProvenance("stable/nginx-ingress", "")
+	assert.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceNone, policy)
+
+	policy, _, err = resolver.ChartProvenance("nginx-ingress", "")
+	assert.NoError(t, err)
+	assert.Equal(t, versionstream.ProvenanceNone, policy)
+}
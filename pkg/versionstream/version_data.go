@@ -64,6 +64,13 @@ type StableVersion struct {
 	// e.g. for packages we could use: `{ version: "1.10.1", upperLimit: "1.14.0"}` which would mean these
 	// versions are all valid `["1.11.5", "1.13.1234"]` but these are invalid `["1.14.0", "1.14.1"]`
 	UpperLimit string `json:"upperLimit,omitempty"`
+	// Constraints the semantic version constraints of the allowed versions such as `>=1.2 <2` or `~1.4`. If specified they are
+	// used instead of the UpperLimit
+	Constraints string `json:"constraints,omitempty"`
+	// Deprecated marks the chart, image or package as deprecated so that it should no longer be used
+	Deprecated bool `json:"deprecated,omitempty"`
+	// DeprecationMessage explains why it is deprecated and what to use instead
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
 	// GitURL the URL to the source code
 	GitURL string `json:"gitUrl,omitempty"`
 	// Component is the component inside the git URL
@@ -78,6 +85,24 @@ func (data *StableVersion) VerifyPackage(name string, currentVersion string, wor
 	if currentVersion == "" {
 		return nil
 	}
+	if data.Deprecated {
+		log.Logger().Warnf("package %s is deprecated in the version stream: %s", name, data.DeprecationMessage)
+	}
+	if data.Constraints != "" {
+		currentSem, err := semver.Make(currentVersion)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse semantic version for current version %s for package %s", currentVersion, name)
+		}
+		versionRange, err := ParseConstraints(data.Constraints)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the version constraints for package %s", name)
+		}
+		if !versionRange(currentSem) {
+			return verifyError(name, fmt.Errorf("package %s is on version %s but the version stream requires a version matching %s", name, currentVersion, data.Constraints))
+		}
+		return nil
+	}
+
 	version := convertToVersion(data.Version)
 	if version == "" {
 		log.Logger().Warnf("could not find a stable package version for %s from %s\nFor background see: https://jenkins-x.io/architecture/version-stream/", name, workDir)
@@ -144,11 +169,7 @@ func convertToVersion(text string) string {
 // LoadStableVersion loads the stable version data from the version configuration directory returning an empty object if there is
 // no specific stable version configuration available
 func LoadStableVersion(wrkDir string, kind VersionKind, name string) (*StableVersion, error) {
-	if kind == KindGit {
-		name = GitURLToName(name)
-	}
-	path := filepath.Join(wrkDir, string(kind), name+".yml")
-	return LoadStableVersionFile(path)
+	return LoadStableVersionFile(stableVersionFile(wrkDir, kind, name))
 }
 
 // GitURLToName lets trim any URL scheme and trailing .git or / from a git URL
@@ -208,12 +229,16 @@ func LoadStableVersionNumber(wrkDir string, kind VersionKind, name string) (stri
 		if kind == KindChart && name == "." {
 			return version, err
 		}
-		log.Logger().Warnf("could not find a stable version from %s of %s from %s\nFor background see: https://jenkins-x.io/architecture/version-stream/", string(kind), name, wrkDir)
-		log.Logger().Infof("Please lock this version down via the command: %s", util.ColorInfo(fmt.Sprintf("jx step create version pr -k %s -n %s", string(kind), name)))
+		logMissingStableVersion(kind, name, wrkDir)
 	}
 	return version, err
 }
 
+func logMissingStableVersion(kind VersionKind, name string, wrkDir string) {
+	log.Logger().Warnf("could not find a stable version from %s of %s from %s\nFor background see: https://jenkins-x.io/architecture/version-stream/", string(kind), name, wrkDir)
+	log.Logger().Infof("Please lock this version down via the command: %s", util.ColorInfo(fmt.Sprintf("jx step create version pr -k %s -n %s", string(kind), name)))
+}
+
 // SaveStableVersion saves the version file
 func SaveStableVersion(wrkDir string, kind VersionKind, name string, stableVersion *StableVersion) error {
 	path := filepath.Join(wrkDir, string(kind), name+".yml")
@@ -243,34 +268,10 @@ func SaveStableVersionFile(path string, stableVersion *StableVersion) error {
 // If there is a version defined for the image in the version stream 'image:<version>' is returned, otherwise the
 // passed image name is returned as is.
 func ResolveDockerImage(versionsDir, image string) (string, error) {
-	// lets check if we already have a version
-	path := strings.SplitN(image, ":", 2)
-	if len(path) == 2 && path[1] != "" {
-		return image, nil
-	}
-	info, err := LoadStableVersion(versionsDir, KindDocker, image)
-	if err != nil {
-		return image, err
-	}
-	if info.Version == "" {
-		// lets check if there is a docker.io prefix and if so lets try fetch without the docker prefix
-		prefix := "docker.io/"
-		if strings.HasPrefix(image, prefix) {
-			image = strings.TrimPrefix(image, prefix)
-			info, err = LoadStableVersion(versionsDir, KindDocker, image)
-			if err != nil {
-				return image, err
-			}
-		}
+	resolver := &VersionResolver{
+		VersionsDir: versionsDir,
 	}
-	if info.Version == "" {
-		log.Logger().Warnf("could not find a stable version for Docker image: %s in %s", image, versionsDir)
-		log.Logger().Warn("for background see: https://jenkins-x.io/architecture/version-stream/")
-		log.Logger().Infof("please lock this version down via the command: %s", util.ColorInfo(fmt.Sprintf("jx step create version pr -k docker -n %s -v 1.2.3", image)))
-		return image, nil
-	}
-	prefix := strings.TrimSuffix(strings.TrimSpace(image), ":")
-	return prefix + ":" + info.Version, nil
+	return resolver.ResolveDockerImage(image)
 }
 
 // UpdateStableVersionFiles applies an update to the stable version files matched by globPattern, updating to version
@@ -393,8 +394,25 @@ type RepositoryPrefixes struct {
 type RepositoryURLs struct {
 	Prefix string   `json:"prefix"`
 	URLs   []string `json:"urls"`
+
+	// Provenance the policy for verifying the provenance files of the charts fetched from the repository
+	Provenance ProvenancePolicy `json:"provenance,omitempty"`
+	// Keyring the keyring file relative to the version stream with the public keys which sign the charts
+	Keyring string `json:"keyring,omitempty"`
 }
 
+// ProvenancePolicy the policy for verifying the provenance files of the charts of a repository
+type ProvenancePolicy string
+
+const (
+	// ProvenanceNone charts are not verified
+	ProvenanceNone ProvenancePolicy = "none"
+	// ProvenanceVerify signed charts are verified and unsigned charts are allowed
+	ProvenanceVerify ProvenancePolicy = "verify"
+	// ProvenanceRequired charts must be signed and are verified
+	ProvenanceRequired ProvenancePolicy = "required"
+)
+
 // QuickStart the configuration of a quickstart in the version stream
 type QuickStart struct {
 	ID             string   `json:"id,omitempty"`
@@ -477,6 +495,29 @@ func (p *RepositoryPrefixes) PrefixForURL(u string) string {
 	return p.urlToPrefix[u]
 }
 
+// RepositoryForURL returns the repository for the given URL or nil if there is none
+func (p *RepositoryPrefixes) RepositoryForURL(u string) *RepositoryURLs {
+	u = strings.TrimSuffix(u, "/")
+	for i := range p.Repositories {
+		for _, url := range p.Repositories[i].URLs {
+			if strings.TrimSuffix(url, "/") == u {
+				return &p.Repositories[i]
+			}
+		}
+	}
+	return nil
+}
+
+// RepositoryForPrefix returns the repository for the given prefix or nil if there is none
+func (p *RepositoryPrefixes) RepositoryForPrefix(prefix string) *RepositoryURLs {
+	for i := range p.Repositories {
+		if p.Repositories[i].Prefix == prefix {
+			return &p.Repositories[i]
+		}
+	}
+	return nil
+}
+
 // URLsForPrefix returns the repository URLs for the given prefix
 func (p *RepositoryPrefixes) URLsForPrefix(prefix string) []string {
 	if p.prefixToURLs == nil {
@@ -0,0 +1,269 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/pkg/kube/naming"
+	"github.com/jenkins-x/jx/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApprovalState is the state of a manual approval gate
+type ApprovalState string
+
+const (
+	// ApprovalStateNotRequired the step does not require approval
+	ApprovalStateNotRequired ApprovalState = "NotRequired"
+	// ApprovalStatePending the step is waiting for approval
+	ApprovalStatePending ApprovalState = "Pending"
+	// ApprovalStateApproved the step has been approved
+	ApprovalStateApproved ApprovalState = "Approved"
+	// ApprovalStateRejected the step has been rejected
+	ApprovalStateRejected ApprovalState = "Rejected"
+	// ApprovalStateExpired the step was not approved in time
+	ApprovalStateExpired ApprovalState = "Expired"
+
+	// LabelApprovalEnvironment the label on approval Facts for the environment being promoted to
+	LabelApprovalEnvironment = "environment"
+	// LabelSubjectKind the label on Facts for the kind of subject
+	LabelSubjectKind = "subjectkind"
+)
+
+// CanApprove returns true if the user or one of the teams the user belongs to can approve
+func CanApprove(approval *v1.WorkflowApproval, user string, teams []string) bool {
+	if approval == nil || (len(approval.Approvers) == 0 && len(approval.Teams) == 0) {
+		return true
+	}
+	if util.StringArrayIndex(approval.Approvers, user) >= 0 {
+		return true
+	}
+	for _, team := range teams {
+		if util.StringArrayIndex(approval.Teams, team) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserTeams returns the names of the teams each user is a member of indexed by the user name
+func GetUserTeams(jxClient versioned.Interface, ns string) (map[string][]string, error) {
+	list, err := jxClient.JenkinsV1().Teams(ns).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing Teams")
+	}
+	answer := map[string][]string{}
+	for _, team := range list.Items {
+		for _, member := range team.Spec.Members {
+			answer[member] = append(answer[member], team.Name)
+		}
+	}
+	return answer, nil
+}
+
+// GetApprovalState returns the state of the approval of the given promote step. Decisions of users who
+// are not allowed to approve are ignored. The teams recorded on a decision are not trusted so the current teams
+// of each approver are looked up in userTeams
+func GetApprovalState(approval *v1.WorkflowApproval, step *v1.PromoteApprovalStep, userTeams map[string][]string, now time.Time) ApprovalState {
+	if approval == nil {
+		return ApprovalStateNotRequired
+	}
+	if step == nil {
+		return ApprovalStatePending
+	}
+	minimum := approval.MinimumApprovals
+	if minimum < 1 {
+		minimum = 1
+	}
+	approvers := []string{}
+	for _, decision := range step.Decisions {
+		if !CanApprove(approval, decision.Approver, userTeams[decision.Approver]) {
+			continue
+		}
+		if !decision.Approved {
+			return ApprovalStateRejected
+		}
+		if util.StringArrayIndex(approvers, decision.Approver) < 0 {
+			approvers = append(approvers, decision.Approver)
+		}
+	}
+	if len(approvers) >= minimum {
+		return ApprovalStateApproved
+	}
+	if approval.Expiry != nil && approval.Expiry.Duration > 0 && step.StartedTimestamp != nil &&
+		now.After(step.StartedTimestamp.Add(approval.Expiry.Duration)) {
+		return ApprovalStateExpired
+	}
+	return ApprovalStatePending
+}
+
+// UpdateApproval evaluates the approval gate for promoting the activity to the environment, creating the promote
+// step waiting for approval if required. Returns the state of the approval and whether the activity was modified
+func UpdateApproval(activity *v1.PipelineActivity, envName string, approval *v1.WorkflowApproval, userTeams map[string][]string, now time.Time) (ApprovalState, bool) {
+	if approval == nil {
+		return ApprovalStateNotRequired, false
+	}
+	promote, modified := GetOrCreatePromoteStep(activity, envName, now)
+	if promote.Approval == nil {
+		promote.Approval = &v1.PromoteApprovalStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				Name:             "Approval",
+				Description:      fmt.Sprintf("Waiting for approval to promote to %s", envName),
+				StartedTimestamp: &metav1.Time{Time: now},
+				Status:           v1.ActivityStatusTypeWaitingForApproval,
+			},
+		}
+		promote.Status = v1.ActivityStatusTypeWaitingForApproval
+		modified = true
+	}
+	state := GetApprovalState(approval, promote.Approval, userTeams, now)
+	if promote.Approval.Status.IsTerminated() {
+		return state, modified
+	}
+	switch state {
+	case ApprovalStateApproved:
+		completeApproval(promote, v1.ActivityStatusTypeSucceeded, now)
+		// lets clear the waiting status so that the promotion can start
+		promote.Status = v1.ActivityStatusTypeNone
+		modified = true
+	case ApprovalStateRejected, ApprovalStateExpired:
+		completeApproval(promote, v1.ActivityStatusTypeFailed, now)
+		promote.Status = v1.ActivityStatusTypeAborted
+		if promote.CompletedTimestamp == nil {
+			promote.CompletedTimestamp = &metav1.Time{Time: now}
+		}
+		activity.Spec.Status = v1.ActivityStatusTypeAborted
+		activity.Spec.WorkflowStatus = v1.ActivityStatusTypeAborted
+		if state == ApprovalStateRejected {
+			activity.Spec.WorkflowMessage = fmt.Sprintf("Promotion to %s was rejected", envName)
+		} else {
+			activity.Spec.WorkflowMessage = fmt.Sprintf("Promotion to %s was not approved in time", envName)
+		}
+		modified = true
+	}
+	return state, modified
+}
+
+func completeApproval(promote *v1.PromoteActivityStep, status v1.ActivityStatusType, now time.Time) {
+	promote.Approval.Status = status
+	promote.Approval.CompletedTimestamp = &metav1.Time{Time: now}
+}
+
+// FindPromoteStep returns the promote step of the activity for the given environment or nil if there is none
+func FindPromoteStep(activity *v1.PipelineActivity, envName string) *v1.PromoteActivityStep {
+	for i := range activity.Spec.Steps {
+		promote := activity.Spec.Steps[i].Promote
+		if promote != nil && promote.Environment == envName {
+			return promote
+		}
+	}
+	return nil
+}
+
+// GetOrCreatePromoteStep returns the promote step of the activity for the given environment, adding a new one if there
+// is none. Returns true if the step was added
+func GetOrCreatePromoteStep(activity *v1.PipelineActivity, envName string, now time.Time) (*v1.PromoteActivityStep, bool) {
+	promote := FindPromoteStep(activity, envName)
+	if promote != nil {
+		return promote, false
+	}
+	activity.Spec.Steps = append(activity.Spec.Steps, v1.PipelineActivityStep{
+		Kind: v1.ActivityStepKindTypePromote,
+		Promote: &v1.PromoteActivityStep{
+			CoreActivityStep: v1.CoreActivityStep{
+				StartedTimestamp: &metav1.Time{Time: now},
+			},
+			Environment: envName,
+		},
+	})
+	return activity.Spec.Steps[len(activity.Spec.Steps)-1].Promote, true
+}
+
+// IsWaitingForApproval returns true if the promotion of the activity to the environment is waiting for approval
+func IsWaitingForApproval(activity *v1.PipelineActivity, envName string) bool {
+	promote := FindPromoteStep(activity, envName)
+	return promote != nil && promote.Approval != nil && promote.Approval.Status == v1.ActivityStatusTypeWaitingForApproval
+}
+
+// WaitingForApprovalEnvironments returns the names of the environments the activity is waiting for approval to promote to
+func WaitingForApprovalEnvironments(activity *v1.PipelineActivity) []string {
+	answer := []string{}
+	for _, step := range activity.Spec.Steps {
+		if step.Promote != nil && IsWaitingForApproval(activity, step.Promote.Environment) {
+			answer = append(answer, step.Promote.Environment)
+		}
+	}
+	return answer
+}
+
+// RecordApprovalDecision records the decision on the promote step of the activity which must be waiting for approval
+func RecordApprovalDecision(activity *v1.PipelineActivity, envName string, decision v1.ApprovalDecision) error {
+	if !IsWaitingForApproval(activity, envName) {
+		return fmt.Errorf("the PipelineActivity %s is not waiting for approval to promote to %s", activity.Name, envName)
+	}
+	promote := FindPromoteStep(activity, envName)
+	promote.Approval.Decisions = append(promote.Approval.Decisions, decision)
+	return nil
+}
+
+// ApprovalFactName returns the name of the Fact used to record the approval decisions of promoting the activity
+func ApprovalFactName(activity *v1.PipelineActivity, envName string) string {
+	return naming.ToValidNameTruncated(fmt.Sprintf("jx-approval-%s-%s", activity.Name, envName), 253)
+}
+
+// RecordApprovalFact records the decision as a Statement on the approval Fact of the activity, creating the Fact if required
+func RecordApprovalFact(jxClient versioned.Interface, ns string, activity *v1.PipelineActivity, envName string, decision v1.ApprovalDecision) (*v1.Fact, error) {
+	facts := jxClient.JenkinsV1().Facts(ns)
+	name := ApprovalFactName(activity, envName)
+	statement := v1.Statement{
+		Name:             decision.Approver,
+		StatementType:    v1.StatementTypeApproval,
+		MeasurementValue: decision.Approved,
+		Tags:             []string{envName},
+	}
+	fact, err := facts.Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "getting Fact %s", name)
+		}
+		fact = &v1.Fact{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					LabelSubjectKind:         "PipelineActivity",
+					v1.LabelOwner:            activity.RepositoryOwner(),
+					v1.LabelRepository:       activity.RepositoryName(),
+					v1.LabelBranch:           activity.BranchName(),
+					v1.LabelBuild:            activity.Spec.Build,
+					LabelApprovalEnvironment: envName,
+				},
+			},
+			Spec: v1.FactSpec{
+				Name:       name,
+				FactType:   v1.FactTypeApproval,
+				Statements: []v1.Statement{statement},
+				SubjectReference: v1.ResourceReference{
+					APIVersion: activity.APIVersion,
+					Kind:       "PipelineActivity",
+					Name:       activity.Name,
+					UID:        activity.UID,
+				},
+			},
+		}
+		fact, err = facts.Create(fact)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating Fact %s", name)
+		}
+		return fact, nil
+	}
+	fact.Spec.Statements = append(fact.Spec.Statements, statement)
+	fact, err = facts.Update(fact)
+	if err != nil {
+		return nil, errors.Wrapf(err, "updating Fact %s", name)
+	}
+	return fact, nil
+}
@@ -0,0 +1,165 @@
+package workflow_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/jenkins-x/jx/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetApprovalState(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	started := &metav1.Time{Time: now.Add(-2 * time.Hour)}
+	approval := &v1.WorkflowApproval{
+		Approvers:        []string{"alice", "bob"},
+		Teams:            []string{"release"},
+		MinimumApprovals: 2,
+	}
+	userTeams := map[string][]string{
+		"carol": {"release"},
+	}
+	step := func(decisions ...v1.ApprovalDecision) *v1.PromoteApprovalStep {
+		return &v1.PromoteApprovalStep{
+			CoreActivityStep: v1.CoreActivityStep{StartedTimestamp: started},
+			Decisions:        decisions,
+		}
+	}
+
+	assert.Equal(t, workflow.ApprovalStateNotRequired, workflow.GetApprovalState(nil, nil, userTeams, now))
+	assert.Equal(t, workflow.ApprovalStatePending, workflow.GetApprovalState(approval, nil, userTeams, now))
+	assert.Equal(t, workflow.ApprovalStatePending, workflow.GetApprovalState(approval, step(
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+	), userTeams, now), "the same approver should only count once")
+	assert.Equal(t, workflow.ApprovalStateApproved, workflow.GetApprovalState(approval, step(
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+		v1.ApprovalDecision{Approver: "carol", Approved: true},
+	), userTeams, now))
+	assert.Equal(t, workflow.ApprovalStatePending, workflow.GetApprovalState(approval, step(
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+		v1.ApprovalDecision{Approver: "mallory", Teams: []string{"release"}, Approved: true},
+	), userTeams, now), "the teams recorded on decisions should not be trusted")
+	assert.Equal(t, workflow.ApprovalStatePending, workflow.GetApprovalState(approval, step(
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+		v1.ApprovalDecision{Approver: "mallory", Approved: false},
+	), userTeams, now), "decisions of users who cannot approve should be ignored")
+	assert.Equal(t, workflow.ApprovalStateRejected, workflow.GetApprovalState(approval, step(
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+		v1.ApprovalDecision{Approver: "bob", Approved: false},
+	), userTeams, now))
+
+	approval.Expiry = &metav1.Duration{Duration: time.Hour}
+	assert.Equal(t, workflow.ApprovalStateExpired, workflow.GetApprovalState(approval, step(
+		v1.ApprovalDecision{Approver: "alice", Approved: true},
+	), userTeams, now))
+}
+
+func TestUpdateApproval(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	approval := &v1.WorkflowApproval{Approvers: []string{"alice"}}
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1"},
+	}
+
+	state, modified := workflow.UpdateApproval(activity, "production", approval, nil, now)
+	assert.Equal(t, workflow.ApprovalStatePending, state)
+	assert.True(t, modified)
+	assert.True(t, workflow.IsWaitingForApproval(activity, "production"))
+	assert.Equal(t, []string{"production"}, workflow.WaitingForApprovalEnvironments(activity))
+
+	state, modified = workflow.UpdateApproval(activity, "production", approval, nil, now)
+	assert.Equal(t, workflow.ApprovalStatePending, state)
+	assert.False(t, modified)
+
+	err := workflow.RecordApprovalDecision(activity, "staging", v1.ApprovalDecision{Approver: "alice", Approved: true})
+	assert.Error(t, err, "should not record decisions for environments which are not waiting for approval")
+
+	err = workflow.RecordApprovalDecision(activity, "production", v1.ApprovalDecision{Approver: "alice", Approved: true})
+	require.NoError(t, err)
+
+	state, modified = workflow.UpdateApproval(activity, "production", approval, nil, now)
+	assert.Equal(t, workflow.ApprovalStateApproved, state)
+	assert.True(t, modified)
+	promote := workflow.FindPromoteStep(activity, "production")
+	require.NotNil(t, promote)
+	assert.Equal(t, v1.ActivityStatusTypeSucceeded, promote.Approval.Status)
+	assert.Equal(t, v1.ActivityStatusTypeNone, promote.Status)
+	assert.False(t, workflow.IsWaitingForApproval(activity, "production"))
+}
+
+func TestUpdateApprovalRejected(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	approval := &v1.WorkflowApproval{}
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1"},
+	}
+
+	workflow.UpdateApproval(activity, "production", approval, nil, now)
+	err := workflow.RecordApprovalDecision(activity, "production", v1.ApprovalDecision{Approver: "bob", Approved: false})
+	require.NoError(t, err)
+
+	state, modified := workflow.UpdateApproval(activity, "production", approval, nil, now)
+	assert.Equal(t, workflow.ApprovalStateRejected, state)
+	assert.True(t, modified)
+	assert.Equal(t, v1.ActivityStatusTypeAborted, activity.Spec.Status)
+	assert.Equal(t, v1.ActivityStatusTypeAborted, activity.Spec.WorkflowStatus)
+	assert.Equal(t, v1.ActivityStatusTypeAborted, workflow.FindPromoteStep(activity, "production").Status)
+}
+
+func TestGetUserTeams(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	jxClient := fake.NewSimpleClientset(
+		&v1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "release", Namespace: ns},
+			Spec:       v1.TeamSpec{Members: []string{"alice", "carol"}},
+		},
+		&v1.Team{
+			ObjectMeta: metav1.ObjectMeta{Name: "qa", Namespace: ns},
+			Spec:       v1.TeamSpec{Members: []string{"carol"}},
+		},
+	)
+
+	userTeams, err := workflow.GetUserTeams(jxClient, ns)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"release"}, userTeams["alice"])
+	assert.ElementsMatch(t, []string{"release", "qa"}, userTeams["carol"])
+	assert.Empty(t, userTeams["mallory"])
+}
+
+func TestRecordApprovalFact(t *testing.T) {
+	t.Parallel()
+	ns := "jx"
+	jxClient := fake.NewSimpleClientset()
+	activity := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "myorg-myapp-master-1"},
+		Spec: v1.PipelineActivitySpec{
+			GitOwner:      "myorg",
+			GitRepository: "myapp",
+			GitBranch:     "master",
+			Build:         "1",
+		},
+	}
+
+	fact, err := workflow.RecordApprovalFact(jxClient, ns, activity, "production", v1.ApprovalDecision{Approver: "alice", Approved: true})
+	require.NoError(t, err)
+	assert.Equal(t, "jx-approval-myorg-myapp-master-1-production", fact.Name)
+	assert.Equal(t, v1.FactTypeApproval, fact.Spec.FactType)
+	assert.Equal(t, "production", fact.Labels[workflow.LabelApprovalEnvironment])
+
+	fact, err = workflow.RecordApprovalFact(jxClient, ns, activity, "production", v1.ApprovalDecision{Approver: "bob", Approved: false})
+	require.NoError(t, err)
+	require.Len(t, fact.Spec.Statements, 2)
+	assert.Equal(t, "alice", fact.Spec.Statements[0].Name)
+	assert.True(t, fact.Spec.Statements[0].MeasurementValue)
+	assert.Equal(t, "bob", fact.Spec.Statements[1].Name)
+	assert.False(t, fact.Spec.Statements[1].MeasurementValue)
+}